@@ -36,6 +36,12 @@ func main() {
 	if err := cmd.Execute(); err != nil {
 		switch err := err.(type) {
 		case cmd.ExitCode:
+			// an exit code without cause is no error, but the result of the
+			// comparison, e.g. when using --set-exit-code
+			if err.Cause() == nil {
+				os.Exit(err.Value())
+			}
+
 			var headline, content string
 
 			if unwrapped := errors.Unwrap(err.Cause()); unwrapped != nil {
@@ -0,0 +1,97 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/gonvenience/ytbx"
+	"github.com/spf13/cobra"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+type applyCmdOptions struct {
+	inPlace bool
+}
+
+var applyCmdSettings applyCmdOptions
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply [flags] <report> <file>",
+	Short: "Apply a previously saved report to a file",
+	Long: `
+Applies the differences of a report that was previously saved using the json or
+yaml output style of the between command to the given file. The resulting
+documents are written to standard output, or back into the file when --in-place
+is used. Changes that are already in place are skipped, so that applying the
+same report twice has no further effect.
+`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		report, err := loadReport(args[0])
+		if err != nil {
+			return err
+		}
+
+		target, err := dyff.LoadFile(args[1])
+		if err != nil {
+			return fmt.Errorf("failed to load input file from %s: %w", humanReadableFilename(args[1]), err)
+		}
+
+		documents, err := report.Apply(target.Documents)
+		if err != nil {
+			return fmt.Errorf("failed to apply report to %s: %w", humanReadableFilename(args[1]), err)
+		}
+
+		var buf bytes.Buffer
+		if err := writeYAMLDocuments(&buf, documents); err != nil {
+			return err
+		}
+
+		if !applyCmdSettings.inPlace {
+			_, err = buf.WriteTo(os.Stdout)
+			return err
+		}
+
+		if ytbx.IsStdin(args[1]) {
+			return fmt.Errorf("incompatible flags: cannot use in-place flag in combination with input from stdin")
+		}
+
+		info, err := os.Stat(args[1])
+		if err != nil {
+			return fmt.Errorf("failed to write changes to %s: %w", args[1], err)
+		}
+
+		return os.WriteFile(args[1], buf.Bytes(), info.Mode())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().SortFlags = false
+
+	applyCmd.Flags().BoolVarP(&applyCmdSettings.inPlace, "in-place", "i", false, "overwrite the file with the result instead of writing it to standard output")
+}
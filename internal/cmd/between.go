@@ -21,10 +21,20 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/gonvenience/text"
 	"github.com/gonvenience/ytbx"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/homeport/dyff/pkg/dyff"
 )
@@ -35,6 +45,33 @@ type betweenCmdOptions struct {
 	chroot                   string
 	chrootFrom               string
 	chrootTo                 string
+	attestKey                string
+	attestation              string
+	raw                      bool
+	project                  []string
+	allowMissingFile         bool
+	inventory                bool
+	plan                     bool
+	includeFiles             []string
+	excludeFiles             []string
+	each                     bool
+	fileType                 string
+	jobs                     int
+	fileMetadata             bool
+	fromLabel                string
+	toLabel                  string
+	sinceLastRun             string
+	git                      bool
+	fromStdin                bool
+	toStdin                  bool
+	stdinSeparator           string
+	headers                  []string
+	bearerTokenEnv           string
+	clientCert               string
+	clientKey                string
+	caCert                   string
+	retries                  int
+	timeout                  time.Duration
 }
 
 var betweenCmdSettings betweenCmdOptions
@@ -45,74 +82,155 @@ var betweenCmd = &cobra.Command{
 	Short: "Compare differences between input files from and to",
 	Long: `
 Compares differences between files and displays the delta. Supported input file
-types are: YAML (http://yaml.org/) and JSON (http://json.org/).
+types are: YAML (http://yaml.org/), JSON (http://json.org/), TOML, HCL (e.g.
+Terraform .tf and .tfvars files, blocks are matched by their type and labels),
+INI (sections are compared as maps), as well as Java properties and dotenv
+(.env) files, which are compared by key.
+
+Archives (tar, tgz, or zip) are extracted in memory and the files in them are
+compared by their path inside the archive, for example to compare two versions
+of a packaged Helm chart. Directories are compared the same way, the files in
+them are paired by their relative path. Use --include-files and --exclude-files
+with glob patterns to select the files of directories to be compared. Each
+difference refers to the file and the lines of both sides it belongs to. Use
+--jobs to compare the files of large directories concurrently, the report is
+the same regardless of the number of jobs. With --file-metadata, changes of the
+file mode, symbolic links that replace regular files (or vice versa), and file
+names that only differ in case are reported, too.
+
+Inputs can be loaded from HTTP(S) URLs. Use --http-header to add request
+headers, or --bearer-token-env to authenticate with the token of an environment
+variable. Otherwise, the user info of the URL, or the credentials of the host
+in the netrc file ($NETRC, or ~/.netrc) are used for basic authentication.
+Requests that fail due to network or server errors are retried.
+
+Helm charts in OCI registries can be referenced using oci://registry/chart:1.2.3,
+which renders the chart templates using the default values (requires helm).
+With --raw, the files of the chart package are compared instead.
+
+With --git, the files are loaded from Git revisions: use <from-revision>
+<to-revision> <path> to compare the file of two revisions, or <revision> <path>
+to compare the file of a revision with the one in the working tree, for
+example: dyff between --git HEAD~1 HEAD values.yaml (requires git).
+
+Use - as the location of an input to read it from standard input, or the
+--from-stdin and --to-stdin flags to omit its argument, for example to pass it
+as a here-doc. In case both inputs are read from standard input (- -), the
+from and the to input are separated by a line with the separator, which is
+---8<--- by default (see --stdin-separator).
+
+Inputs from process substitution, for example <(kubectl get ...), are named
+after the command writing into the pipe if it can be found (Linux only). Use
+--from-label and --to-label to name the inputs in the report explicitly.
+
+An empty input (an empty file, only empty documents, or /dev/null) is compared
+on the document level, that is all documents of the other input are reported
+as added or removed. With --allow-missing-file, an input file that does not
+exist is treated as empty, for example to bootstrap new files.
+
+With --inventory, only the documents are compared: the output lists which
+documents (Kubernetes resources by name) were added, removed, or retained.
+
+With --file-type auto, well-known files are reduced to their relevant part, for
+example only the services of a docker-compose.yml, or the versions and
+dependencies of a Helm Chart.yaml.
+
+With --follow-refs, references ($ref) of JSON schemas or OpenAPI documents are
+replaced with the referenced content before comparing, so that moving parts of
+a document into a reference is not reported as a change. Use
+--follow-external-refs to also resolve references to other files.
+
+With --each, from has to be a single document (template), which is compared
+against each document of to separately, for example to verify that generated
+resources conform to a golden template.
+
+With --plan, nothing is compared: the output lists which documents (or files)
+are paired, and which compare options and report filters are in effect.
+
+With --since-last-run, the differences of each run are stored in the provided
+state file, and only the differences that were not present in the previous run
+are reported, for example to only be notified about new drift in scheduled
+jobs. The exit code flags only consider these new differences.
 `,
-	Args:    cobra.ExactArgs(2),
+	Args: func(cmd *cobra.Command, args []string) error {
+		var stdin = betweenCmdSettings.fromStdin || betweenCmdSettings.toStdin
+		switch {
+		case betweenCmdSettings.git && stdin:
+			return fmt.Errorf("incompatible flags: --git cannot be used in combination with --from-stdin or --to-stdin")
+
+		case betweenCmdSettings.git:
+			return cobra.RangeArgs(2, 3)(cmd, args)
+
+		case betweenCmdSettings.fromStdin && betweenCmdSettings.toStdin:
+			return cobra.NoArgs(cmd, args)
+
+		case stdin:
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+
+		return cobra.ExactArgs(2)(cmd, args)
+	},
 	Aliases: []string{"bw"},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var fromLocation, toLocation string
-		if betweenCmdSettings.swap {
-			fromLocation = args[1]
-			toLocation = args[0]
-		} else {
-			fromLocation = args[0]
-			toLocation = args[1]
+		var fromLocation, toLocation = stdinLocations(args)
+		if betweenCmdSettings.git {
+			fromLocation, toLocation = gitLocations(args)
 		}
 
-		from, to, err := ytbx.LoadFiles(fromLocation, toLocation)
-		if err != nil {
-			return fmt.Errorf("failed to load input files: %w", err)
+		if betweenCmdSettings.swap {
+			fromLocation, toLocation = toLocation, fromLocation
 		}
 
-		// If the main change root flag is set, this (re-)sets the individual change roots of the two input files
-		if betweenCmdSettings.chroot != "" {
-			betweenCmdSettings.chrootFrom = betweenCmdSettings.chroot
-			betweenCmdSettings.chrootTo = betweenCmdSettings.chroot
+		if betweenCmdSettings.plan {
+			return writePlan(cmd, os.Stdout, fromLocation, toLocation)
 		}
 
-		// Change root of 'from' input file if change root flag for 'from' is set
-		if betweenCmdSettings.chrootFrom != "" {
-			if err = dyff.ChangeRoot(&from, betweenCmdSettings.chrootFrom, reportOptions.useGoPatchPaths, betweenCmdSettings.translateListToDocuments); err != nil {
-				return fmt.Errorf("failed to change root of %s to path %s: %w", from.Location, betweenCmdSettings.chrootFrom, err)
-			}
+		if betweenCmdSettings.inventory {
+			return writeInventory(fromLocation, toLocation)
 		}
 
-		// Change root of 'to' input file if change root flag for 'to' is set
-		if betweenCmdSettings.chrootTo != "" {
-			if err = dyff.ChangeRoot(&to, betweenCmdSettings.chrootTo, reportOptions.useGoPatchPaths, betweenCmdSettings.translateListToDocuments); err != nil {
-				return fmt.Errorf("failed to change root of %s to path %s: %w", to.Location, betweenCmdSettings.chrootTo, err)
-			}
-		}
+		// Labels have to be looked up before the input is read, since the
+		// command that writes into a process substitution pipe ends after that
+		fromLabel, hasFromLabel := inputLabel(fromLocation, betweenCmdSettings.fromLabel)
+		toLabel, hasToLabel := inputLabel(toLocation, betweenCmdSettings.toLabel)
 
-		report, err := dyff.CompareInputFiles(from, to,
-			dyff.IgnoreOrderChanges(reportOptions.ignoreOrderChanges),
-			dyff.IgnoreWhitespaceChanges(reportOptions.ignoreWhitespaceChanges),
-			dyff.KubernetesEntityDetection(reportOptions.kubernetesEntityDetection),
-			dyff.AdditionalIdentifiers(reportOptions.additionalIdentifiers...),
-		)
+		var report dyff.Report
+		var err error
+		var loadedAt = time.Now()
+		if isFileSet(fromLocation) || isFileSet(toLocation) {
+			report, err = compareArchives(fromLocation, toLocation)
+		} else {
+			report, err = compareFiles(fromLocation, toLocation)
+		}
 
 		if err != nil {
-			return fmt.Errorf("failed to compare input files: %w", err)
+			return err
 		}
 
-		if reportOptions.filters != nil {
-			report = report.Filter(reportOptions.filters...)
-		}
+		fromProvenance := dyff.NewInputProvenance(fromLocation, inputContent[fromLocation], loadedAt)
+		toProvenance := dyff.NewInputProvenance(toLocation, inputContent[toLocation], loadedAt)
+		inputProvenance.from, inputProvenance.to = &fromProvenance, &toProvenance
 
-		if reportOptions.filterRegexps != nil {
-			report = report.FilterRegexp(reportOptions.filterRegexps...)
+		if hasFromLabel {
+			report.From.Location = fromLabel
 		}
 
-		if reportOptions.excludes != nil {
-			report = report.Exclude(reportOptions.excludes...)
+		if hasToLabel {
+			report.To.Location = toLabel
 		}
 
-		if reportOptions.excludeRegexps != nil {
-			report = report.ExcludeRegexp(reportOptions.excludeRegexps...)
+		report = applyReportFilters(report)
+
+		if betweenCmdSettings.attestKey != "" {
+			if err := writeAttestation(cmd, report); err != nil {
+				return err
+			}
 		}
 
-		if reportOptions.ignoreValueChanges {
-			report = report.IgnoreValueChanges()
+		if betweenCmdSettings.sinceLastRun != "" {
+			if report, err = sinceLastRun(report, betweenCmdSettings.sinceLastRun); err != nil {
+				return err
+			}
 		}
 
 		return writeReport(cmd, report)
@@ -125,11 +243,533 @@ func init() {
 	betweenCmd.Flags().SortFlags = false
 
 	applyReportOptionsFlags(betweenCmd)
+	applyExpectationFlags(betweenCmd)
 
 	// Input documents modification flags
 	betweenCmd.Flags().BoolVar(&betweenCmdSettings.swap, "swap", false, "Swap 'from' and 'to' for comparison")
+	betweenCmd.Flags().BoolVar(&betweenCmdSettings.git, "git", false, "compare the file of two Git revisions (<from-revision> <to-revision> <path>), or of one revision and the working tree (<revision> <path>)")
+	betweenCmd.Flags().BoolVar(&betweenCmdSettings.fromStdin, "from-stdin", false, "read the from input from standard input, so that only the to input is provided as an argument")
+	betweenCmd.Flags().BoolVar(&betweenCmdSettings.toStdin, "to-stdin", false, "read the to input from standard input, so that only the from input is provided as an argument")
+	betweenCmd.Flags().StringVar(&betweenCmdSettings.stdinSeparator, "stdin-separator", defaultStdinSeparator, "line that separates the from and the to input in case both are read from standard input")
+	betweenCmd.Flags().StringVar(&betweenCmdSettings.fromLabel, "from-label", "", "name of the from input to be used in the report instead of its location")
+	betweenCmd.Flags().StringVar(&betweenCmdSettings.toLabel, "to-label", "", "name of the to input to be used in the report instead of its location")
 	betweenCmd.Flags().StringVar(&betweenCmdSettings.chroot, "chroot", "", "change the root level of the input file to another point in the document")
 	betweenCmd.Flags().StringVar(&betweenCmdSettings.chrootFrom, "chroot-of-from", "", "only change the root level of the from input file")
 	betweenCmd.Flags().StringVar(&betweenCmdSettings.chrootTo, "chroot-of-to", "", "only change the root level of the to input file")
 	betweenCmd.Flags().BoolVar(&betweenCmdSettings.translateListToDocuments, "chroot-list-to-documents", false, "in case the change root points to a list, treat this list as a set of documents and not as the list itself")
+	betweenCmd.Flags().StringVar(&betweenCmdSettings.fileType, "file-type", "", "only compare the relevant part of well-known files (e.g. the services of a compose file): auto (detect by file name), or one of "+strings.Join(dyff.FileTypeNames(), ", "))
+	betweenCmd.Flags().StringSliceVar(&betweenCmdSettings.project, "project", nil, "only compare the provided paths, for example /spec/template/spec/containers/*/image (use * to match all entries)")
+
+	betweenCmd.Flags().StringSliceVar(&betweenCmdSettings.includeFiles, "include-files", nil, "only compare the files of directories that match the provided glob patterns, for example *.yaml")
+	betweenCmd.Flags().StringSliceVar(&betweenCmdSettings.excludeFiles, "exclude-files", nil, "do not compare the files of directories that match the provided glob patterns, for example templates/*")
+	betweenCmd.Flags().IntVar(&betweenCmdSettings.jobs, "jobs", 1, "number of files of archives or directories that are compared concurrently, 0 uses one job per CPU")
+	betweenCmd.Flags().BoolVar(&betweenCmdSettings.fileMetadata, "file-metadata", false, "also compare the file mode, symbolic links, and the case of the file names of archives or directories")
+	betweenCmd.Flags().BoolVar(&betweenCmdSettings.allowMissingFile, "allow-missing-file", false, "treat an input file that does not exist as empty, so that all documents of the other input file are reported as added or removed")
+
+	betweenCmd.Flags().BoolVar(&betweenCmdSettings.each, "each", false, "compare the single document of from (template) against each document of to separately")
+	betweenCmd.Flags().BoolVar(&betweenCmdSettings.inventory, "inventory", false, "only report which documents were added, removed, or retained without comparing their content")
+	betweenCmd.Flags().BoolVar(&betweenCmdSettings.plan, "plan", false, "only print which documents are paired, and which options and filters apply, without comparing them")
+	betweenCmd.Flags().StringVar(&betweenCmdSettings.sinceLastRun, "since-last-run", "", "state file to store the differences in, only differences that were not present in the previous run are reported")
+	betweenCmd.Flags().BoolVar(&reportOptions.suggestIgnores, "suggest-ignores", defaults.suggestIgnores, "print a .dyff.yml exclusion section covering all reported differences after the report")
+
+	// HTTP input flags
+	betweenCmd.Flags().StringArrayVar(&betweenCmdSettings.headers, "http-header", nil, "header to be sent when loading inputs from URLs, for example \"Accept: application/yaml\" (can be specified multiple times)")
+	betweenCmd.Flags().StringVar(&betweenCmdSettings.bearerTokenEnv, "bearer-token-env", "", "name of the environment variable with the bearer token to authenticate when loading inputs from URLs")
+	betweenCmd.Flags().StringVar(&betweenCmdSettings.clientCert, "client-cert", "", "PEM encoded TLS client certificate to be used when loading inputs from URLs (requires --client-key)")
+	betweenCmd.Flags().StringVar(&betweenCmdSettings.clientKey, "client-key", "", "PEM encoded private key of the TLS client certificate")
+	betweenCmd.Flags().StringVar(&betweenCmdSettings.caCert, "ca-cert", "", "PEM encoded CA certificate to trust in addition to the system certificates when loading inputs from URLs")
+	betweenCmd.Flags().IntVar(&betweenCmdSettings.retries, "http-retries", defaultHTTPRetries, "number of retries of requests that fail due to network or server errors when loading inputs from URLs")
+	betweenCmd.Flags().DurationVar(&betweenCmdSettings.timeout, "http-timeout", defaultHTTPTimeout, "timeout of each request when loading inputs from URLs")
+
+	// Helm chart flags
+	betweenCmd.Flags().BoolVar(&betweenCmdSettings.raw, "raw", false, "compare the raw files of OCI chart references (oci://) instead of the rendered templates")
+
+	// Attestation flags
+	betweenCmd.Flags().StringVar(&betweenCmdSettings.attestKey, "attest", "", "sign an attestation of the comparison result (in-toto statement in a DSSE envelope) using the provided PEM encoded private key")
+	betweenCmd.Flags().StringVar(&betweenCmdSettings.attestation, "attestation", "", "file to write the signed attestation to (required when using --attest)")
+}
+
+// sinceLastRun stores the state of the report in the state file, and returns
+// a report that only contains the differences that were not present in the
+// previous run (all differences in case there was no previous run)
+func sinceLastRun(report dyff.Report, location string) (dyff.Report, error) {
+	previous, ok, err := dyff.LoadRunState(location)
+	if err != nil {
+		return dyff.Report{}, err
+	}
+
+	if err := dyff.NewRunState(report).Save(location); err != nil {
+		return dyff.Report{}, err
+	}
+
+	if !ok {
+		return report, nil
+	}
+
+	return report.Since(previous), nil
+}
+
+func writeInventory(fromLocation, toLocation string) error {
+	from, to, err := loadInputFiles(fromLocation, toLocation)
+	if err != nil {
+		return fmt.Errorf("failed to load input files: %w", err)
+	}
+
+	inventory := dyff.CompareInventory(from, to)
+	if err := inventory.WriteReport(os.Stdout); err != nil {
+		return fmt.Errorf("failed to print inventory: %w", err)
+	}
+
+	if reportOptions.exitWithCode {
+		if inventory.HasChanges() {
+			return errorWithExitCode{value: 1}
+		}
+
+		return errorWithExitCode{value: 0}
+	}
+
+	return nil
+}
+
+func writeAttestation(cmd *cobra.Command, report dyff.Report) error {
+	if betweenCmdSettings.attestation == "" {
+		return fmt.Errorf("incompatible flags: the --attest flag requires --attestation to specify the output file")
+	}
+
+	key, err := os.ReadFile(betweenCmdSettings.attestKey)
+	if err != nil {
+		return fmt.Errorf("failed to read attestation key: %w", err)
+	}
+
+	// The option set of the attestation are all explicitly set flags, except
+	// the ones that are about the attestation itself
+	var options []string
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		switch flag.Name {
+		case "attest", "attestation":
+			return
+		}
+
+		options = append(options, fmt.Sprintf("--%s=%s", flag.Name, flag.Value.String()))
+	})
+
+	statement, err := dyff.NewAttestationStatement(report, options...)
+	if err != nil {
+		return fmt.Errorf("failed to create attestation: %w", err)
+	}
+
+	attestation, err := statement.Sign(key)
+	if err != nil {
+		return fmt.Errorf("failed to create attestation: %w", err)
+	}
+
+	data, err := json.MarshalIndent(attestation, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to create attestation: %w", err)
+	}
+
+	if err := os.WriteFile(betweenCmdSettings.attestation, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write attestation to %s: %w", humanReadableFilename(betweenCmdSettings.attestation), err)
+	}
+
+	return nil
+}
+
+func compareOptions() ([]dyff.CompareOption, error) {
+	switch dyff.ListDiffMode(reportOptions.listDiffStrategy) {
+	case dyff.HashSet, dyff.LCS:
+	default:
+		return nil, fmt.Errorf("unsupported list diff strategy %q, supported strategies are %s and %s", reportOptions.listDiffStrategy, dyff.HashSet, dyff.LCS)
+	}
+
+	switch dyff.NodeHashMode(reportOptions.nodeHashing) {
+	case dyff.HashStructure, dyff.CanonicalHash:
+	default:
+		return nil, fmt.Errorf("unsupported node hashing %q, supported modes are %s and %s", reportOptions.nodeHashing, dyff.HashStructure, dyff.CanonicalHash)
+	}
+
+	var options = []dyff.CompareOption{
+		dyff.IgnoreOrderChanges(reportOptions.ignoreOrderChanges),
+		dyff.IgnoreOrderChangesAt(reportOptions.ignoreOrderChangesAt...),
+		dyff.ExcludePaths(reportOptions.excludeSubtrees...),
+		dyff.IgnoreWhitespaceChanges(reportOptions.ignoreWhitespaceChanges),
+		dyff.IgnoreNumberFormatChanges(reportOptions.ignoreNumberFormatChanges),
+		dyff.IgnoreBlockScalarStyleChanges(reportOptions.ignoreBlockStyleChanges),
+		dyff.KubernetesEntityDetection(reportOptions.kubernetesEntityDetection),
+		dyff.AdditionalIdentifiers(reportOptions.additionalIdentifiers...),
+		dyff.NullEquivalents(reportOptions.nullEquivalents...),
+		dyff.CustomTags(dyff.CustomTagMode(reportOptions.customTags)),
+		dyff.ListDiffStrategy(dyff.ListDiffMode(reportOptions.listDiffStrategy)),
+		dyff.NodeHashing(dyff.NodeHashMode(reportOptions.nodeHashing)),
+		dyff.DetectMoves(reportOptions.detectMoves),
+		dyff.NormalizeLineEndings(reportOptions.normalizeLineEndings),
+		dyff.NormalizeScripts(reportOptions.normalizeScripts),
+		dyff.CompareDirectives(reportOptions.compareDirectives),
+		dyff.SuppressionComments(reportOptions.suppressionComments),
+	}
+
+	switch {
+	case reportOptions.followExternalRefs:
+		options = append(options, dyff.FollowRefs(dyff.AllRefs))
+
+	case reportOptions.followRefs:
+		options = append(options, dyff.FollowRefs(dyff.InternalRefs))
+	}
+
+	for _, name := range reportOptions.presets {
+		preset, err := dyff.Preset(name)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, preset)
+	}
+
+	if reportOptions.chart != "" {
+		defaults, schema, err := loadChartValues(reportOptions.chart)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, dyff.ValuesDefaults(defaults))
+		if schema != nil {
+			options = append(options, dyff.ValuesSchema(schema))
+		}
+	}
+
+	if reportOptions.valuesSchema != "" {
+		schema, err := loadValuesSchema(reportOptions.valuesSchema)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, dyff.ValuesSchema(schema))
+	}
+
+	for _, compositeIdentifier := range reportOptions.compositeIdentifiers {
+		options = append(options, dyff.CompositeIdentifier(strings.Split(compositeIdentifier, "+")...))
+	}
+
+	for _, scope := range reportOptions.scopes {
+		scopedOption, err := dyff.ParseScopedOptions(scope)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, scopedOption)
+	}
+
+	return options, nil
+}
+
+func compareFiles(fromLocation, toLocation string) (dyff.Report, error) {
+	from, to, err := loadInputFiles(fromLocation, toLocation)
+	if err != nil {
+		return dyff.Report{}, fmt.Errorf("failed to load input files: %w", err)
+	}
+
+	// If the main change root flag is set, this (re-)sets the individual change roots of the two input files
+	if betweenCmdSettings.chroot != "" {
+		betweenCmdSettings.chrootFrom = betweenCmdSettings.chroot
+		betweenCmdSettings.chrootTo = betweenCmdSettings.chroot
+	}
+
+	// Change root of 'from' input file if change root flag for 'from' is set
+	if betweenCmdSettings.chrootFrom != "" {
+		if err = dyff.ChangeRoot(&from, betweenCmdSettings.chrootFrom, reportOptions.useGoPatchPaths, betweenCmdSettings.translateListToDocuments); err != nil {
+			return dyff.Report{}, fmt.Errorf("failed to change root of %s to path %s: %w", from.Location, betweenCmdSettings.chrootFrom, err)
+		}
+	}
+
+	// Change root of 'to' input file if change root flag for 'to' is set
+	if betweenCmdSettings.chrootTo != "" {
+		if err = dyff.ChangeRoot(&to, betweenCmdSettings.chrootTo, reportOptions.useGoPatchPaths, betweenCmdSettings.translateListToDocuments); err != nil {
+			return dyff.Report{}, fmt.Errorf("failed to change root of %s to path %s: %w", to.Location, betweenCmdSettings.chrootTo, err)
+		}
+	}
+
+	// Focus on the relevant part of well-known file types
+	if fileType, ok, err := lookupFileType(fromLocation, toLocation); err != nil {
+		return dyff.Report{}, err
+
+	} else if ok {
+		for _, inputFile := range []*ytbx.InputFile{&from, &to} {
+			if err = fileType.Apply(inputFile); err != nil {
+				return dyff.Report{}, fmt.Errorf("failed to apply %s file type to %s: %w", fileType.Name, inputFile.Location, err)
+			}
+		}
+	}
+
+	// Reduce both input files to the projected paths
+	for _, inputFile := range []*ytbx.InputFile{&from, &to} {
+		if err = dyff.Project(inputFile, betweenCmdSettings.project...); err != nil {
+			return dyff.Report{}, fmt.Errorf("failed to project %s: %w", inputFile.Location, err)
+		}
+	}
+
+	options, err := compareOptions()
+	if err != nil {
+		return dyff.Report{}, err
+	}
+
+	var report dyff.Report
+	if betweenCmdSettings.each {
+		report, err = dyff.CompareEach(from, to, options...)
+	} else {
+		report, err = dyff.CompareInputFiles(from, to, options...)
+	}
+
+	if err != nil {
+		return dyff.Report{}, fmt.Errorf("failed to compare input files: %w", err)
+	}
+
+	return report, nil
+}
+
+// compareArchives loads both archives in memory and compares the files in
+// them by their path inside the archive
+func compareArchives(fromLocation, toLocation string) (dyff.Report, error) {
+	if !isFileSet(fromLocation) || !isFileSet(toLocation) {
+		return dyff.Report{}, fmt.Errorf("failed to compare input files: an archive or directory can only be compared with another archive or directory")
+	}
+
+	if betweenCmdSettings.chroot != "" || betweenCmdSettings.chrootFrom != "" || betweenCmdSettings.chrootTo != "" {
+		return dyff.Report{}, fmt.Errorf("incompatible flags: change root cannot be used when comparing archives or directories")
+	}
+
+	if betweenCmdSettings.each {
+		return dyff.Report{}, fmt.Errorf("incompatible flags: each cannot be used when comparing archives or directories")
+	}
+
+	from, err := loadFileSet(fromLocation)
+	if err != nil {
+		return dyff.Report{}, fmt.Errorf("failed to load input files: %w", err)
+	}
+
+	to, err := loadFileSet(toLocation)
+	if err != nil {
+		return dyff.Report{}, fmt.Errorf("failed to load input files: %w", err)
+	}
+
+	switch betweenCmdSettings.fileType {
+	case "", "auto":
+	default:
+		return dyff.Report{}, fmt.Errorf("incompatible flags: only the automatic file type detection can be used when comparing archives or directories")
+	}
+
+	// Reduce all files of both archives to the relevant part of well-known
+	// file types, and to the projected paths
+	for _, fileSet := range []dyff.FileSet{from, to} {
+		for path, inputFile := range fileSet.Files {
+			if fileType, ok := dyff.DetectFileType(path); ok && betweenCmdSettings.fileType == "auto" {
+				if err = fileType.Apply(&inputFile); err != nil {
+					return dyff.Report{}, fmt.Errorf("failed to apply %s file type to %s: %w", fileType.Name, path, err)
+				}
+			}
+
+			if err = dyff.Project(&inputFile, betweenCmdSettings.project...); err != nil {
+				return dyff.Report{}, fmt.Errorf("failed to project %s: %w", path, err)
+			}
+
+			fileSet.Files[path] = inputFile
+		}
+	}
+
+	options, err := compareOptions()
+	if err != nil {
+		return dyff.Report{}, err
+	}
+
+	report, err := dyff.CompareFileSets(from, to, append(options, dyff.Jobs(betweenCmdSettings.jobs), dyff.CompareFileMetadata(betweenCmdSettings.fileMetadata))...)
+	if err != nil {
+		return dyff.Report{}, fmt.Errorf("failed to compare input files: %w", err)
+	}
+
+	// Summarize the files that only exist on one side, files that were only
+	// renamed in case are paired when comparing the file metadata
+	var renamed int
+	if betweenCmdSettings.fileMetadata {
+		renamed = len(from.CaseOnlyRenames(to))
+	}
+
+	if removed := len(from.Difference(to)) - renamed; removed > 0 {
+		dyff.AddNote(&report.From, fmt.Sprintf("%s removed", text.Plural(removed, "file")))
+	}
+
+	if added := len(to.Difference(from)) - renamed; added > 0 {
+		dyff.AddNote(&report.To, fmt.Sprintf("%s added", text.Plural(added, "file")))
+	}
+
+	return report, nil
+}
+
+// isFileSet returns whether the location refers to a set of files, which are
+// archives, directories, or the raw files of an OCI chart reference. The
+// directories of kubectl diff are loaded as one input file with the documents
+// of all files, since kubectl names the files after the resources without a
+// file extension, and the resources are matched by name anyway.
+func isFileSet(location string) bool {
+	return dyff.IsArchive(location) ||
+		(dyff.IsDirectory(location) && !isKubectlDiffDirectory(location)) ||
+		(isOCIReference(location) && betweenCmdSettings.raw)
+}
+
+// isKubectlDiffDirectory returns whether the location is one of the temporary
+// directories that kubectl diff creates for the live and merged resources,
+// which is only the case in diff driver mode
+func isKubectlDiffDirectory(location string) bool {
+	if !isDiffDriverMode() {
+		return false
+	}
+
+	base := filepath.Base(filepath.Clean(location))
+	return strings.HasPrefix(base, "LIVE-") || strings.HasPrefix(base, "MERGED-")
+}
+
+func loadFileSet(location string) (dyff.FileSet, error) {
+	switch {
+	case isOCIReference(location):
+		return loadOCIChartFiles(location)
+
+	case dyff.IsDirectory(location):
+		return dyff.LoadDirectory(location, betweenCmdSettings.includeFiles, betweenCmdSettings.excludeFiles)
+	}
+
+	data, err := readInputFile(location)
+	if err != nil {
+		return dyff.FileSet{}, fmt.Errorf("failed to read archive %s: %w", location, err)
+	}
+
+	return dyff.LoadArchiveContent(location, data)
+}
+
+// readInputFile reads the local input file and keeps its content for the
+// provenance of the input
+func readInputFile(location string) ([]byte, error) {
+	data, err := os.ReadFile(location)
+	if err != nil {
+		return nil, err
+	}
+
+	inputContent[location] = data
+	return data, nil
+}
+
+// loadInputFile loads the input file the same way as dyff.LoadFile does, local
+// files are read using readInputFile
+func loadInputFile(location string) (ytbx.InputFile, error) {
+	if info, err := os.Stat(location); err != nil || !info.Mode().IsRegular() {
+		return dyff.LoadFile(location)
+	}
+
+	data, err := readInputFile(location)
+	if err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("unable to load data from %s: %w", ytbx.HumanReadableLocation(location), err)
+	}
+
+	return dyff.LoadFileContent(location, data)
+}
+
+// lookupFileType returns the file type that is configured using the file type
+// flag, which in automatic mode is detected by the file names of the inputs
+// (if they do not contradict each other, and no root was changed explicitly)
+func lookupFileType(fromLocation, toLocation string) (dyff.FileType, bool, error) {
+	var chroot = betweenCmdSettings.chroot != "" || betweenCmdSettings.chrootFrom != "" || betweenCmdSettings.chrootTo != ""
+
+	switch {
+	case betweenCmdSettings.fileType == "", betweenCmdSettings.fileType == "auto" && chroot:
+		return dyff.FileType{}, false, nil
+
+	case chroot:
+		return dyff.FileType{}, false, fmt.Errorf("incompatible flags: a file type cannot be used in combination with change root")
+
+	case betweenCmdSettings.fileType == "auto":
+		fromType, fromOK := dyff.DetectFileType(fromLocation)
+		toType, toOK := dyff.DetectFileType(toLocation)
+
+		switch {
+		case fromOK && toOK && fromType.Name != toType.Name:
+			return dyff.FileType{}, false, nil
+
+		case fromOK:
+			return fromType, true, nil
+
+		case toOK:
+			return toType, true, nil
+		}
+
+		return dyff.FileType{}, false, nil
+	}
+
+	fileType, err := dyff.LookupFileType(betweenCmdSettings.fileType)
+	return fileType, err == nil, err
+}
+
+func loadInputFiles(fromLocation, toLocation string) (ytbx.InputFile, ytbx.InputFile, error) {
+	var isLocalFile = func(location string) bool {
+		return !ytbx.IsStdin(location) && !strings.Contains(location, "://") && !isGitRevision(location)
+	}
+
+	var isMissing = func(location string) bool {
+		if !betweenCmdSettings.allowMissingFile || !isLocalFile(location) {
+			return false
+		}
+
+		_, err := os.Stat(location)
+		return errors.Is(err, fs.ErrNotExist)
+	}
+
+	// An empty file (or /dev/null) is loaded as an input without documents,
+	// since it would otherwise be loaded as an empty map. Other special files,
+	// like pipes of process substitution, can only be read once.
+	var isEmpty = func(location string) bool {
+		if location == os.DevNull {
+			return true
+		}
+
+		if info, err := os.Stat(location); !isLocalFile(location) || err != nil || !info.Mode().IsRegular() {
+			return false
+		}
+
+		data, err := readInputFile(location)
+		return err == nil && len(bytes.TrimSpace(data)) == 0
+	}
+
+	// Both inputs are read from standard input, separated by a separator line
+	if ytbx.IsStdin(fromLocation) && ytbx.IsStdin(toLocation) {
+		return loadStdinInputs(betweenCmdSettings.stdinSeparator)
+	}
+
+	var load = func(location string) (ytbx.InputFile, error) {
+		switch {
+		case isMissing(location):
+			return ytbx.InputFile{Location: location, Note: "file does not exist"}, nil
+
+		case isEmpty(location):
+			return ytbx.InputFile{Location: location}, nil
+
+		case isOCIReference(location):
+			return loadOCIChart(location)
+
+		case isGitRevision(location):
+			return loadGitRevision(location)
+
+		case isURL(location):
+			return loadURL(location)
+		}
+
+		return loadInputFile(location)
+	}
+
+	from, err := load(fromLocation)
+	if err != nil {
+		return ytbx.InputFile{}, ytbx.InputFile{}, err
+	}
+
+	to, err := load(toLocation)
+	if err != nil {
+		return ytbx.InputFile{}, ytbx.InputFile{}, err
+	}
+
+	return from, to, nil
 }
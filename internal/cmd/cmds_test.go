@@ -21,8 +21,22 @@
 package cmd_test
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -49,6 +63,21 @@ var _ = Describe("command line tool flags", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(out).To(ContainSubstring("version (development)"))
 		})
+
+		It("should print the build details and capabilities as JSON", func() {
+			out, err := dyff("version", "--output", "json")
+			Expect(err).ToNot(HaveOccurred())
+
+			var info map[string]interface{}
+			Expect(json.Unmarshal([]byte(out), &info)).To(Succeed())
+			Expect(info).To(HaveKeyWithValue("version", "(development)"))
+			Expect(info).To(HaveKeyWithValue("goVersion", runtime.Version()))
+			Expect(info["inputFormats"]).To(ContainElement("yaml"))
+			Expect(info["outputStyles"]).To(ContainElements("human", "diff"))
+
+			_, err = dyff("version", "--output", "xml")
+			Expect(err).To(HaveOccurred())
+		})
 	})
 
 	Context("yaml command", func() {
@@ -131,6 +160,30 @@ list:
 				})
 			})
 
+			Context("documents with directives", func() {
+				It("should keep the directives of the documents", func() {
+					filename := createTestFile(`%YAML 1.2
+%TAG !e! tag:example.com,2000:app/
+---
+foo: !e!bar baz
+...
+---
+bar: 1
+`)
+					defer os.Remove(filename)
+
+					out, err := dyff("yaml", "--plain", filename)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(out).To(BeEquivalentTo(`%YAML 1.2
+%TAG !e! tag:example.com,2000:app/
+---
+foo: !<tag:example.com,2000:app/bar> baz
+---
+bar: 1
+`))
+				})
+			})
+
 			Context("incorrect usage", func() {
 				It("should fail to write a YAML when in place and STDIN are used at the same time", func() {
 					_, err := dyff("yaml", "--in-place", "-")
@@ -166,6 +219,16 @@ list:
 `))
 		})
 
+		It("should write a plain JSON file to STDOUT with alphabetically sorted keys", func() {
+			filename := createTestFile(`{"list":[{"name":"one","aaa":"bbb"}],"foo":"bar"}`)
+			defer os.Remove(filename)
+
+			out, err := dyff("json", "--sort-keys", "--plain", filename)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo(`{"foo": "bar", "list": [{"aaa": "bbb", "name": "one"}]}
+`))
+		})
+
 		It("should write a JSON file to STDOUT using restructure feature", func() {
 			filename := createTestFile(`{"list":[{"aaa":"bbb","name":"one"}]}`)
 			defer os.Remove(filename)
@@ -231,6 +294,135 @@ list:
   "DatestringOneHyphen": "9999-9999",
   "DatestringSlashes": "2022/01/01"
 }
+`))
+		})
+	})
+
+	Context("toml command", func() {
+		It("should convert input documents into TOML keeping the order of the keys", func() {
+			filename := createTestFile(`---
+name: app
+ports: [80, 443]
+database:
+  user: admin
+  enabled: true
+servers:
+- name: alpha
+- name: beta
+`)
+			defer os.Remove(filename)
+
+			out, err := dyff("toml", filename)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(`name = "app"
+ports = [80, 443]
+
+[database]
+user = "admin"
+enabled = true
+
+[[servers]]
+name = "alpha"
+
+[[servers]]
+name = "beta"
+`))
+		})
+
+		It("should convert TOML back into TOML without changes", func() {
+			out, err := dyff("toml", assets("issues", "issue-120", "buildpack.toml"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(`[[metadata.dependencies]]
+deprecation_date = "2021-08-21T00:00:00Z"
+
+[[metadata.dependency_deprecation_dates]]
+date = 2021-08-21T13:37:00Z
+`))
+		})
+
+		It("should fail for input that cannot be expressed in TOML", func() {
+			filename := createTestFile("---\nname: ~\n")
+			defer os.Remove(filename)
+
+			_, err := dyff("toml", filename)
+			Expect(err).To(MatchError(ContainSubstring("TOML does not support null values")))
+		})
+	})
+
+	Context("properties command", func() {
+		It("should convert input documents into properties in place", func() {
+			filename := createTestFile(`---
+server:
+  port: 8080
+  host: localhost
+database:
+  user: admin
+`)
+			defer os.Remove(filename)
+
+			out, err := dyff("properties", "--sort-keys", "--in-place", filename)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEmpty())
+
+			data, err := os.ReadFile(filename)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(`database.user=admin
+server.host=localhost
+server.port=8080
+`))
+		})
+
+		It("should fail for more than one document", func() {
+			filename := createTestFile("---\nfoo: bar\n---\nbar: foo\n")
+			defer os.Remove(filename)
+
+			_, err := dyff("properties", filename)
+			Expect(err).To(MatchError(ContainSubstring("properties only support one document")))
+		})
+	})
+
+	Context("ini command", func() {
+		It("should convert input documents into INI", func() {
+			filename := createTestFile(`---
+name: app
+database:
+  user: admin
+  port: 5432
+servers:
+  hosts: [alpha, beta]
+`)
+			defer os.Remove(filename)
+
+			out, err := dyff("ini", filename)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(`name = app
+
+[database]
+user = admin
+port = 5432
+
+[servers]
+hosts[] = alpha
+hosts[] = beta
+`))
+		})
+
+		It("should compare INI files by their sections", func() {
+			dir := createTestDirectory()
+			defer os.RemoveAll(dir)
+
+			from, to := filepath.Join(dir, "old.ini"), filepath.Join(dir, "new.ini")
+			Expect(os.WriteFile(from, []byte("[database]\nhost = db1\nport = 5432\n\n[cache]\nsize = 64\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(to, []byte("[cache]\nsize = 64\n\n[database]\nport = 5432\nhost = db2\n"), 0644)).To(Succeed())
+
+			out, err := dyff("between", "--omit-header", "--use-go-patch-style", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo(`
+/database/host
+  ± value change
+    - db1
+    + db2
+
 `))
 		})
 	})
@@ -495,6 +687,113 @@ example_two
 			Expect(exitCode.Value()).To(Equal(1))
 		})
 
+		It("should create an exit code with the bits of the kinds of changes", func() {
+			from := createTestFile(`{"foo": "bar", "list": [1, 2], "removed": true}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"foo": "bar", "list": [2, 1], "added": true}`)
+			defer os.Remove(to)
+
+			_, err := dyff("between", "--set-exit-code", "--exit-code-mode", "kinds", from, to)
+			Expect(err).To(HaveOccurred())
+
+			exitCode, ok := err.(ExitCode)
+			Expect(ok).To(BeTrue())
+			Expect(exitCode.Value()).To(Equal(2 | 4 | 8))
+
+			_, err = dyff("between", "--set-exit-code", "--exit-code-mode", "kinds", from, from)
+			Expect(err).To(HaveOccurred())
+
+			exitCode, ok = err.(ExitCode)
+			Expect(ok).To(BeTrue())
+			Expect(exitCode.Value()).To(Equal(0))
+
+			_, err = dyff("between", "--set-exit-code", "--exit-code-mode", "foo", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unsupported exit code mode"))
+		})
+
+		It("should only create exit code one for the configured kinds of changes and paths", func() {
+			from := createTestFile(`{"spec": {"image": "app:1", "replicas": 1}, "removed": true}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"spec": {"image": "app:2", "replicas": 1}}`)
+			defer os.Remove(to)
+
+			for args, expected := range map[string]int{
+				"--fail-on=addition":                     0,
+				"--fail-on=removal,addition":             1,
+				"--fail-on-path=/spec/replicas":          0,
+				"--fail-on-path=/spec/*":                 1,
+				"--fail-on=removal --fail-on-path=/spec": 0,
+				"--fail-on=± --fail-on-path=/spec/image": 1,
+			} {
+				_, err := dyff(append([]string{"between"}, append(strings.Fields(args), from, to)...)...)
+				Expect(err).To(HaveOccurred())
+
+				exitCode, ok := err.(ExitCode)
+				Expect(ok).To(BeTrue())
+				Expect(exitCode.Value()).To(Equal(expected), args)
+			}
+
+			_, err := dyff("between", "--fail-on=foo", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unsupported detail kind"))
+		})
+
+		It("should only fail for breaking changes of OpenAPI documents", func() {
+			from := createTestFile(`{"paths": {"/pets": {"get": {}, "post": {}}}}`)
+			defer os.Remove(from)
+
+			compatible := createTestFile(`{"paths": {"/pets": {"get": {}, "post": {}}, "/stores": {"get": {}}}}`)
+			defer os.Remove(compatible)
+
+			breaking := createTestFile(`{"paths": {"/pets": {"get": {}}}}`)
+			defer os.Remove(breaking)
+
+			for to, expected := range map[string]int{compatible: 0, breaking: 1} {
+				_, err := dyff("between", "--preset=openapi", "--fail-on=breaking", from, to)
+				Expect(err).To(HaveOccurred())
+
+				exitCode, ok := err.(ExitCode)
+				Expect(ok).To(BeTrue())
+				Expect(exitCode.Value()).To(Equal(expected))
+			}
+		})
+
+		It("should fail when differences are found, but no changes are expected", func() {
+			from := createTestFile(`{"foo": "bar"}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"foo": "BAR"}`)
+			defer os.Remove(to)
+
+			_, err := dyff("between", "--expect-no-changes", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("unexpected number of differences: expected no differences, but found one difference: /foo"))
+
+			_, err = dyff("between", "--expect-no-changes", from, from)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should verify the expected number of changes", func() {
+			from := createTestFile(`{"foo": "bar", "bar": "foo"}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"foo": "BAR", "bar": "FOO"}`)
+			defer os.Remove(to)
+
+			_, err := dyff("between", "--expect-changes", "2", from, to)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = dyff("between", "--expect-changes", "1", from, to)
+			Expect(err).To(HaveOccurred())
+
+			exitCode, ok := err.(ExitCode)
+			Expect(ok).To(BeTrue())
+			Expect(exitCode.Value()).To(Equal(1))
+		})
+
 		It("should fail with an exit code other than zero or one in case of an error", func() {
 			_, err := dyff("between", "--set-exit-code", "from", "to")
 			Expect(err).To(HaveOccurred())
@@ -612,6 +911,1848 @@ spec.replicas  (apps/v1/Deployment/test)
 		})
 	})
 
+	Context("between command with excluded paths", func() {
+		var from, to string
+
+		BeforeEach(func() {
+			from = createTestFile(`---
+spec:
+  a: 1
+  b: 1
+`)
+			to = createTestFile(`---
+spec:
+  a: 2
+  b: 2
+`)
+		})
+
+		AfterEach(func() {
+			Expect(os.Remove(from)).To(Succeed())
+			Expect(os.Remove(to)).To(Succeed())
+		})
+
+		It("should only exclude differences of exactly the provided path when --exclude is used", func() {
+			out, err := dyff("between", "--omit-header", "--output", "brief", "--exclude", "/spec", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("two changes detected"))
+
+			out, err = dyff("between", "--omit-header", "--exclude", "/spec/a", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).ToNot(ContainSubstring("spec.a"))
+			Expect(out).To(ContainSubstring("spec.b"))
+		})
+
+		It("should not compare the provided paths and everything below when --exclude-subtree is used", func() {
+			out, err := dyff("between", "--omit-header", "--exclude-subtree", "/spec", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo("\n"))
+		})
+
+		It("should fail for invalid paths", func() {
+			_, err := dyff("between", "--omit-header", "--exclude-subtree", "/a=b=c", from, to)
+			Expect(err).To(HaveOccurred())
+
+			_, err = dyff("between", "--omit-header", "--ignore-order-changes-at", "/a=b=c", from, to)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("between command with detailed brief output", func() {
+		It("should render the paths of the breakdown in the configured style", func() {
+			from := createTestFile("---\nspec:\n  replicas: 1\n")
+			defer os.Remove(from)
+
+			to := createTestFile("---\nspec:\n  replicas: 2\n")
+			defer os.Remove(to)
+
+			out, err := dyff("between", "--omit-header", "--output", "brief", "--detailed", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("  spec.replicas  one modification"))
+
+			out, err = dyff("between", "--omit-header", "--output", "brief", "--detailed", "-g", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("  /spec/replicas  one modification"))
+
+			out, err = dyff("between", "--omit-header", "--output", "brief", "--detailed", "--path-style", "dot", "-g", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("  spec.replicas  one modification"))
+		})
+	})
+
+	Context("between command with document additions and removals", func() {
+		var from, to string
+
+		BeforeEach(func() {
+			from = createTestFile(`---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: common
+data:
+  key: foo
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: removed
+`)
+
+			to = createTestFile(`---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: common
+data:
+  key: bar
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: added
+`)
+		})
+
+		AfterEach(func() {
+			Expect(os.Remove(from)).To(Succeed())
+			Expect(os.Remove(to)).To(Succeed())
+		})
+
+		It("should not report new documents when --ignore-new-documents is used", func() {
+			out, err := dyff("between", "--omit-header", "--ignore-new-documents", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("one document removed"))
+			Expect(out).ToNot(ContainSubstring("document added"))
+			Expect(out).To(ContainSubstring("data.key"))
+		})
+
+		It("should not report removed documents when --ignore-removed-documents is used", func() {
+			out, err := dyff("between", "--omit-header", "--ignore-removed-documents", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).ToNot(ContainSubstring("document removed"))
+			Expect(out).To(ContainSubstring("one document added"))
+			Expect(out).To(ContainSubstring("data.key"))
+		})
+
+		It("should exclude all differences of documents matching the selector", func() {
+			out, err := dyff("between", "--omit-header", "--exclude-document", "v1/ConfigMap/remov*", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).ToNot(ContainSubstring("document removed"))
+			Expect(out).To(ContainSubstring("one document added"))
+			Expect(out).To(ContainSubstring("data.key"))
+
+			out, err = dyff("between", "--omit-header", "--exclude-document", "v1/ConfigMap/*", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo("\n"))
+		})
+
+		It("should only report changes of matched documents when both flags are used", func() {
+			out, err := dyff("between", "--omit-header", "--ignore-new-documents", "--ignore-removed-documents", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo(`
+data.key  (v1/ConfigMap/common)
+  ± value change
+    - foo
+    + bar
+
+`))
+		})
+	})
+
+	Context("diff driver mode", func() {
+		var from, to string
+
+		BeforeEach(func() {
+			// Usually, the environment variable would be like `dyff between`,
+			// but the binary name during testing is `cmd.test`
+			GinkgoT().Setenv("KUBECTL_EXTERNAL_DIFF", "cmd.test between --omit-header")
+
+			// kubectl diff creates temporary directories with these prefixes
+			var err error
+			from, err = os.MkdirTemp("", "LIVE-")
+			Expect(err).ToNot(HaveOccurred())
+
+			to, err = os.MkdirTemp("", "MERGED-")
+			Expect(err).ToNot(HaveOccurred())
+
+			createTestFileInDir(from, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo\ndata:\n  key with spaces: one\n  other: one\n")
+			createTestFileInDir(to, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo\ndata:\n  key with spaces: two\n  other: two\n")
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(from)).To(Succeed())
+			Expect(os.RemoveAll(to)).To(Succeed())
+		})
+
+		It("should work with the arguments as kubectl diff provides them", func() {
+			out, err := dyff("between", "--omit-header", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("data.key with spaces"))
+			Expect(out).To(ContainSubstring("data.other"))
+		})
+
+		It("should work with the arguments as argocd app diff provides them", func() {
+			// argocd writes the live and the target state of each resource into
+			// a temporary directory, and calls the external diff with both files
+			dir := createTestDirectory()
+			defer os.RemoveAll(dir)
+
+			live, target := filepath.Join(dir, "foo-live.yaml"), filepath.Join(dir, "foo")
+			Expect(os.WriteFile(live, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo\ndata:\n  other: one\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(target, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo\ndata:\n  other: two\n"), 0644)).To(Succeed())
+
+			out, err := dyff("between", "--omit-header", live, target)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("data.other"))
+		})
+
+		It("should work with the input directories in front of the command", func() {
+			out, err := dyff(from, to, "between", "--omit-header")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("data.other"))
+		})
+
+		It("should not move flag values that are directories", func() {
+			out, err := dyff("between", "--omit-header", "--filter", from, from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).ToNot(ContainSubstring("data.other"))
+		})
+
+		It("should use the defaults from DYFF_DEFAULTS including values with spaces", func() {
+			GinkgoT().Setenv("DYFF_DEFAULTS", `--exclude "/data/key with spaces"`)
+
+			out, err := dyff("between", "--omit-header", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).ToNot(ContainSubstring("data.key with spaces"))
+			Expect(out).To(ContainSubstring("data.other"))
+		})
+
+		It("should give command line flags precedence over DYFF_DEFAULTS", func() {
+			GinkgoT().Setenv("DYFF_DEFAULTS", "--output brief")
+
+			out, err := dyff("between", "--omit-header", "--output", "human", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("data.other"))
+		})
+
+		It("should compare other directories file by file", func() {
+			fromDir, toDir := createTestDirectory(), createTestDirectory()
+			defer os.RemoveAll(fromDir)
+			defer os.RemoveAll(toDir)
+
+			Expect(os.WriteFile(filepath.Join(fromDir, "a.yml"), []byte("name: foo\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(toDir, "a.yml"), []byte("name: bar\n"), 0644)).To(Succeed())
+
+			out, err := dyff("between", "--omit-header", fromDir, toDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("a.yml"))
+			Expect(out).To(ContainSubstring("+ bar"))
+		})
+
+		It("should fail with a proper error for invalid DYFF_DEFAULTS", func() {
+			GinkgoT().Setenv("DYFF_DEFAULTS", `--exclude "/data/unterminated`)
+
+			_, err := dyff("between", "--omit-header", from, to)
+			Expect(err).To(MatchError(ContainSubstring("failed to parse DYFF_DEFAULTS")))
+		})
+	})
+
+	Context("default options from DYFF_DEFAULTS", func() {
+		It("should use the default flags and let command line flags override them", func() {
+			from := createTestFile("---\nlist:\n- one\n- two\n")
+			defer os.Remove(from)
+
+			to := createTestFile("---\nlist:\n- two\n- one\n")
+			defer os.Remove(to)
+
+			GinkgoT().Setenv("DYFF_DEFAULTS", "--omit-header -i --output brief")
+
+			out, err := dyff("between", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("no changes detected"))
+
+			out, err = dyff("between", "--output", "human", "--ignore-order-changes=false", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("order changed"))
+			Expect(out).ToNot(ContainSubstring("returned one difference"))
+		})
+
+		It("should skip default flags that are not supported by the command", func() {
+			filename := createTestFile(`{"foo": "bar"}`)
+			defer os.Remove(filename)
+
+			GinkgoT().Setenv("DYFF_DEFAULTS", "--omit-header --set-exit-code --plain")
+
+			out, err := dyff("json", filename)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("{\"foo\": \"bar\"}\n"))
+		})
+
+		It("should replace list flags with the ones on the command line instead of merging them", func() {
+			from := createTestFile(`{"foo": "bar", "bar": "foo"}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"foo": "BAR", "bar": "FOO"}`)
+			defer os.Remove(to)
+
+			GinkgoT().Setenv("DYFF_DEFAULTS", "--omit-header --output brief --exclude /foo")
+
+			out, err := dyff("between", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("one change detected"))
+
+			// merged lists would exclude both differences
+			out, err = dyff("between", "--exclude", "/bar", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("one change detected"))
+
+			GinkgoT().Setenv("DYFF_EXCLUDE", "/bar")
+			out, err = dyff("between", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("one change detected"))
+		})
+
+		It("should fail if it contains something else than flags", func() {
+			GinkgoT().Setenv("DYFF_DEFAULTS", "between")
+
+			_, err := dyff("version")
+			Expect(err).To(MatchError(ContainSubstring("failed to parse DYFF_DEFAULTS")))
+		})
+	})
+
+	Context("flag defaults from DYFF_* environment variables", func() {
+		It("should use the environment variables and let command line flags override them", func() {
+			from := createTestFile(`{"foo": "bar", "bar": "foo", "baz": 42}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"foo": "BAR", "bar": "FOO", "baz": 43}`)
+			defer os.Remove(to)
+
+			GinkgoT().Setenv("DYFF_OUTPUT", "brief")
+			GinkgoT().Setenv("DYFF_EXCLUDE", "/foo,/bar")
+
+			out, err := dyff("between", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("one change detected"))
+
+			out, err = dyff("between", "--omit-header", "--output", "human", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("baz"))
+			Expect(out).ToNot(ContainSubstring("foo"))
+		})
+
+		It("should take precedence over the flags of DYFF_DEFAULTS", func() {
+			from := createTestFile(`{"foo": "bar"}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"foo": "BAR"}`)
+			defer os.Remove(to)
+
+			GinkgoT().Setenv("DYFF_DEFAULTS", "--output human --omit-header")
+			GinkgoT().Setenv("DYFF_IGNORE_VALUE_CHANGES", "true")
+
+			out, err := dyff("between", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).ToNot(ContainSubstring("foo"))
+		})
+
+		It("should ignore variables of flags that the command does not support", func() {
+			filename := createTestFile(`{"foo": "bar"}`)
+			defer os.Remove(filename)
+
+			GinkgoT().Setenv("DYFF_SET_EXIT_CODE", "true")
+			GinkgoT().Setenv("DYFF_OUTPUT", "github")
+			GinkgoT().Setenv("DYFF_PLAIN", "true")
+
+			out, err := dyff("json", filename)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("{\"foo\": \"bar\"}\n"))
+		})
+	})
+
+	Context("between command fingerprint", func() {
+		It("should print the same fingerprint for the same differences", func() {
+			from, to := assets("examples", "from.yml"), assets("examples", "to.yml")
+
+			first, err := dyff("between", "--print-fingerprint", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(strings.TrimSpace(first)).To(MatchRegexp("^[0-9a-f]{64}$"))
+
+			second, err := dyff("between", "--print-fingerprint", "--output", "brief", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(second).To(Equal(first))
+
+			swapped, err := dyff("between", "--print-fingerprint", "--swap", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(swapped).ToNot(Equal(first))
+		})
+	})
+
+	Context("suggested ignores", func() {
+		It("should print an exclusion section for all reported differences", func() {
+			from := createTestFile(`---
+apiVersion: v1
+kind: Pod
+metadata: {name: app}
+spec: {replicas: 1, image: "app:1"}
+`)
+			defer os.Remove(from)
+
+			to := createTestFile(`---
+apiVersion: v1
+kind: Pod
+metadata: {name: app}
+spec: {replicas: 2, image: "app:2"}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: app}
+`)
+			defer os.Remove(to)
+
+			out, err := dyff("between", "--omit-header", "--output", "brief", "--suggest-ignores", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HaveSuffix(`
+# Suggested .dyff.yml section to ignore the reported differences
+exclude:
+    - v1/Pod/app:/spec/replicas
+    - v1/Pod/app:/spec/image
+ignore-new-documents: true
+`))
+
+			out, err = dyff("between", "--omit-header", "--output", "brief", "--exclude", "v1/Pod/app:/spec/replicas,v1/Pod/app:/spec/image", "--ignore-new-documents", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("no changes detected"))
+		})
+
+		It("should only exclude the suggested paths in the document they were found in", func() {
+			from := createTestFile(`---
+apiVersion: v1
+kind: Pod
+metadata: {name: one}
+spec: {replicas: 1}
+---
+apiVersion: v1
+kind: Pod
+metadata: {name: two}
+spec: {replicas: 1}
+`)
+			defer os.Remove(from)
+
+			to := createTestFile(`---
+apiVersion: v1
+kind: Pod
+metadata: {name: one}
+spec: {replicas: 2}
+---
+apiVersion: v1
+kind: Pod
+metadata: {name: two}
+spec: {replicas: 2}
+`)
+			defer os.Remove(to)
+
+			out, err := dyff("between", "--omit-header", "--output", "brief", "--exclude", "v1/Pod/one:/spec/replicas", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("one change detected"))
+
+			out, err = dyff("between", "--omit-header", "--output", "brief", "--exclude", "/spec/replicas", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("no changes detected"))
+		})
+	})
+
+	Context("merge command", func() {
+		It("should write the merged documents or fail with the conflicting paths", func() {
+			base := createTestFile("---\nname: app\nreplicas: 1\nimage: app:1\n")
+			defer os.Remove(base)
+
+			ours := createTestFile("---\nname: app\nreplicas: 3\nimage: app:1\n")
+			defer os.Remove(ours)
+
+			theirs := createTestFile("---\nname: app\nreplicas: 1\nimage: app:2\n")
+			defer os.Remove(theirs)
+
+			out, err := dyff("merge", base, ours, theirs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("name: app\nreplicas: 3\nimage: app:2\n"))
+
+			out, err = dyff("merge", base, ours, ours)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("name: app\nreplicas: 3\nimage: app:1\n"))
+
+			out, err = dyff("merge", base, ours, base)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("name: app\nreplicas: 3\nimage: app:1\n"))
+
+			conflicting := createTestFile("---\nname: app\nreplicas: 2\nimage: app:1\n")
+			defer os.Remove(conflicting)
+
+			_, err = dyff("merge", base, ours, conflicting)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("/replicas"))
+		})
+	})
+
+	Context("Helm values", func() {
+		It("should compare values files with the chart defaults and schema applied", func() {
+			chart, err := os.MkdirTemp("", "dyff-chart")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(chart)
+
+			Expect(os.WriteFile(filepath.Join(chart, "values.yaml"), []byte("replicaCount: 1\nimage:\n  tag: stable\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(chart, "values.schema.json"), []byte(`{"properties": {"replicaCount": {"type": "integer"}}}`), 0644)).To(Succeed())
+
+			from := createTestFile("---\nreplicaCount: \"1\"\n")
+			defer os.Remove(from)
+
+			to := createTestFile("---\nimage:\n  tag: latest\n")
+			defer os.Remove(to)
+
+			out, err := dyff("between", "--omit-header", "--output", "brief", "--chart", chart, from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("one change detected"))
+		})
+	})
+
+	Context("apply command", func() {
+		It("should apply a saved report to a file", func() {
+			from := createTestFile("---\nname: app\nreplicas: 1\nimage: app:1\n")
+			defer os.Remove(from)
+
+			to := createTestFile("---\nname: app\nreplicas: 3\nimage: app:2\n")
+			defer os.Remove(to)
+
+			out, err := dyff("between", "--output", "json", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring(`"kind": "modification"`))
+
+			report := createTestFile(out)
+			defer os.Remove(report)
+
+			out, err = dyff("apply", report, from)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("name: app\nreplicas: 3\nimage: app:2\n"))
+
+			_, err = dyff("apply", "--in-place", report, from)
+			Expect(err).ToNot(HaveOccurred())
+
+			data, err := os.ReadFile(from)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("name: app\nreplicas: 3\nimage: app:2\n"))
+
+			out, err = dyff("apply", report, from)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("name: app\nreplicas: 3\nimage: app:2\n"))
+		})
+	})
+
+	Context("HCL input files", func() {
+		It("should compare Terraform files by their blocks", func() {
+			dir := createTestDirectory()
+			defer os.RemoveAll(dir)
+
+			from, to := filepath.Join(dir, "old.tf"), filepath.Join(dir, "new.tf")
+			Expect(os.WriteFile(from, []byte(`
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "db" {
+  instance_type = "t3.large"
+}
+`), 0644)).To(Succeed())
+
+			Expect(os.WriteFile(to, []byte(`
+resource "aws_instance" "db" {
+  instance_type = "t3.large"
+}
+
+resource "aws_instance" "web" {
+  instance_type = "t3.small"
+}
+`), 0644)).To(Succeed())
+
+			out, err := dyff("between", "--omit-header", "--use-go-patch-style", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo(`
+/resource/aws_instance/web/instance_type
+  ± value change
+    - t3.micro
+    + t3.small
+
+`))
+		})
+	})
+
+	Context("properties and dotenv input files", func() {
+		It("should compare Java properties by key", func() {
+			dir := createTestDirectory()
+			defer os.RemoveAll(dir)
+
+			from, to := filepath.Join(dir, "app.properties"), filepath.Join(dir, "app.properties.new")
+			Expect(os.WriteFile(from, []byte("server.port=8080\nserver.host=localhost\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(to, []byte("server.host = localhost\nserver.port = 9090\n"), 0644)).To(Succeed())
+
+			out, err := dyff("between", "--omit-header", "--use-go-patch-style", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo(`
+/server.port
+  ± value change
+    - 8080
+    + 9090
+
+`))
+		})
+	})
+
+	Context("well-known file types", func() {
+		It("should only compare the services of compose files", func() {
+			from, to := createTestDirectory(), createTestDirectory()
+			defer os.RemoveAll(from)
+			defer os.RemoveAll(to)
+
+			fromFile, toFile := filepath.Join(from, "docker-compose.yml"), filepath.Join(to, "docker-compose.yml")
+			Expect(os.WriteFile(fromFile, []byte("version: '3'\nservices:\n  web:\n    image: nginx:1.25\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(toFile, []byte("version: '3.8'\nservices:\n  web:\n    image: nginx:1.27\n"), 0644)).To(Succeed())
+
+			out, err := dyff("between", "--omit-header", "--use-go-patch-style", "--file-type", "auto", fromFile, toFile)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo(`
+/web/image
+  ± value change
+    - nginx:1.25
+    + nginx:1.27
+
+`))
+
+			_, err = dyff("between", "--file-type", "unknown", fromFile, toFile)
+			Expect(err).To(MatchError(ContainSubstring(`unknown file type "unknown"`)))
+		})
+	})
+
+	Context("each mode", func() {
+		It("should compare a template against each document separately", func() {
+			template := createTestFile("---\nreplicas: 1\nimage: app:1\n")
+			defer os.Remove(template)
+
+			generated := createTestFile("---\nreplicas: 1\nimage: app:1\n---\nreplicas: 2\nimage: app:1\n")
+			defer os.Remove(generated)
+
+			out, err := dyff("between", "--omit-header", "--each", template, generated)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo(`
+replicas  (document #2)
+  ± value change
+    - 1
+    + 2
+
+`))
+
+			_, err = dyff("between", "--each", generated, template)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("inventory mode", func() {
+		It("should only list added, removed, and retained documents", func() {
+			from := createTestFile(`---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: app}
+data: {foo: bar}
+---
+apiVersion: v1
+kind: Secret
+metadata: {name: old}
+`)
+			defer os.Remove(from)
+
+			to := createTestFile(`---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: app}
+data: {foo: baz}
+---
+apiVersion: v1
+kind: Secret
+metadata: {name: new}
+`)
+			defer os.Remove(to)
+
+			out, err := dyff("between", "--inventory", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HaveSuffix(`: one document added, one document removed, one document retained
+
++ v1/Secret/new
+- v1/Secret/old
+  v1/ConfigMap/app
+`))
+
+			_, err = dyff("between", "--inventory", "--set-exit-code", from, from)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(interface{ Value() int }).Value()).To(Equal(0))
+		})
+	})
+
+	Context("directory comparison", func() {
+		It("should compare the files of two directories by their relative path", func() {
+			from := createTestDirectory()
+			defer os.RemoveAll(from)
+
+			to := createTestDirectory()
+			defer os.RemoveAll(to)
+
+			for dir, files := range map[string]map[string]string{
+				from: {"values.yaml": "replicas: 1\n", "old.yaml": "foo: bar\n"},
+				to:   {"values.yaml": "replicas: 2\n"},
+			} {
+				for name, content := range files {
+					Expect(os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)).To(Succeed())
+				}
+			}
+
+			out, err := dyff("between", "--output", "brief", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("two changes"))
+			Expect(out).To(ContainSubstring("one file removed"))
+
+			out, err = dyff("between", "--omit-header", "--exclude-files", "old.yaml", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(`
+replicas
+values.yaml:1
+  ± value change
+    - 1
+    + 2
+
+`))
+
+			out, err = dyff("between", "--output", "brief", "--jobs", "0", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("two changes"))
+
+			_, err = dyff("between", from, assets("examples", "from.yml"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should report the file metadata of directories only if requested", func() {
+			from := createTestDirectory()
+			defer os.RemoveAll(from)
+
+			to := createTestDirectory()
+			defer os.RemoveAll(to)
+
+			Expect(os.WriteFile(filepath.Join(from, "Values.yaml"), []byte("replicas: 1\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(to, "values.yaml"), []byte("replicas: 1\n"), 0644)).To(Succeed())
+
+			out, err := dyff("between", "--output", "brief", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("one file removed"))
+
+			out, err = dyff("between", "--omit-header", "--file-metadata", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("Values.yaml → values.yaml"))
+			Expect(out).To(ContainSubstring("- name Values.yaml"))
+			Expect(out).To(ContainSubstring("+ name values.yaml"))
+		})
+
+		It("should refer to the files and lines of the differences in all outputs", func() {
+			from := createTestDirectory()
+			defer os.RemoveAll(from)
+
+			to := createTestDirectory()
+			defer os.RemoveAll(to)
+
+			Expect(os.WriteFile(filepath.Join(from, "values.yaml"), []byte("name: app\nreplicas: 1\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(to, "values.yaml"), []byte("# scaled up\nname: app\nreplicas: 2\n"), 0644)).To(Succeed())
+
+			out, err := dyff("between", "--omit-header", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("replicas\nvalues.yaml:2 → values.yaml:3\n"))
+
+			out, err = dyff("between", "--output", "github", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("@@ replicas @@\n# values.yaml:2 → values.yaml:3\n"))
+
+			out, err = dyff("between", "--output", "yq", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("# values.yaml:2 → values.yaml:3\n"))
+
+			out, err = dyff("between", "--output", "json", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring(`"fromSource": {
+        "file": "values.yaml",
+        "line": 2
+      },
+      "toSource": {
+        "file": "values.yaml",
+        "line": 3
+      }`))
+		})
+	})
+
+	Context("comparison plan", func() {
+		It("should print the document pairing and the options without comparing", func() {
+			from := createTestFile("---\napiVersion: v1\nkind: ConfigMap\nmetadata: {name: foo}\n---\napiVersion: v1\nkind: ConfigMap\nmetadata: {name: bar}\n")
+			defer os.Remove(from)
+
+			to := createTestFile("---\napiVersion: v1\nkind: ConfigMap\nmetadata: {name: foo}\ndata: {key: value}\n")
+			defer os.Remove(to)
+
+			out, err := dyff("between", "--plan", "--exclude", "/data", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("  = v1/ConfigMap/foo (compared)"))
+			Expect(out).To(ContainSubstring("  - v1/ConfigMap/bar (only in from)"))
+			Expect(out).To(ContainSubstring("  ignore-order-changes: false"))
+			Expect(out).To(ContainSubstring("  exclude: [/data]"))
+			Expect(out).ToNot(ContainSubstring("key"))
+		})
+	})
+
+	Context("scoped compare options", func() {
+		It("should apply compare flags only below the scope path", func() {
+			from := createTestFile("---\nargs: [a, b]\nenv: [A, B]\n")
+			defer os.Remove(from)
+
+			to := createTestFile("---\nargs: [b, a]\nenv: [B, A]\n")
+			defer os.Remove(to)
+
+			out, err := dyff("between", "--omit-header", "--scope", "/env --ignore-order-changes", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("args"))
+			Expect(out).ToNot(ContainSubstring("env"))
+
+			_, err = dyff("between", "--scope", "/env --output=human", from, to)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("missing input files", func() {
+		It("should treat a missing file as empty if requested", func() {
+			to := createTestFile("---\nfoo: bar\n---\nbar: foo\n")
+			defer os.Remove(to)
+
+			_, err := dyff("between", "--omit-header", "/does/not/exist.yml", to)
+			Expect(err).To(HaveOccurred())
+
+			out, err := dyff("between", "--omit-header", "--allow-missing-file", "/does/not/exist.yml", to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("+ two documents added:"))
+		})
+
+		It("should report all documents as removed when comparing against an empty file", func() {
+			from := createTestFile("---\nfoo: bar\n---\nbar: foo\n")
+			defer os.Remove(from)
+
+			to := createTestFile("")
+			defer os.Remove(to)
+
+			expected := `
+(file level)
+  - two documents removed:
+    ---
+    foo: bar
+    ---
+    bar: foo
+
+`
+
+			for _, location := range []string{to, "/dev/null"} {
+				out, err := dyff("between", "--omit-header", from, location)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(BeEquivalentTo(expected))
+			}
+		})
+	})
+
+	Context("custom tags", func() {
+		It("should fail in strict mode if there are custom tags", func() {
+			from := createTestFile("---\nfoo: !vault bar\n")
+			defer os.Remove(from)
+
+			to := createTestFile("---\nfoo: bar\n")
+			defer os.Remove(to)
+
+			out, err := dyff("between", "--omit-header", "--output", "brief", "--custom-tags", "strip", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("no changes detected"))
+
+			_, err = dyff("between", "--omit-header", "--custom-tags", "strict", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unresolved custom tag !vault"))
+		})
+	})
+
+	Context("projection of input files", func() {
+		It("should only report differences in the projected paths", func() {
+			from := createTestFile(`---
+spec:
+  replicas: 1
+  paused: false
+  template:
+    spec:
+      containers:
+      - name: main
+        image: app:1
+`)
+			defer os.Remove(from)
+
+			to := createTestFile(`---
+spec:
+  replicas: 1
+  paused: true
+  template:
+    spec:
+      containers:
+      - name: main
+        image: app:2
+`)
+			defer os.Remove(to)
+
+			out, err := dyff("between", "--omit-header", "--output", "brief", "--project", "/spec/template/spec/containers/*/image,/spec/replicas", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("one change detected"))
+		})
+	})
+
+	withStdin := func(input string, f func()) {
+		r, w, err := os.Pipe()
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = w.WriteString(input)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(w.Close()).To(Succeed())
+
+		tmp := os.Stdin
+		defer func() { os.Stdin = tmp }()
+
+		os.Stdin = r
+		f()
+	}
+
+	Context("interactive section prompt", func() {
+		It("should only show the selected sections of the report", func() {
+			from := createTestFile("---\nfoo: 1\nbar: 1\nbaz: 1\n")
+			defer os.Remove(from)
+
+			to := createTestFile("---\nfoo: 2\nbar: 2\nbaz: 2\n")
+			defer os.Remove(to)
+
+			withStdin("1,3\n", func() {
+				out, err := dyff("between", "--omit-header", "--interactive", "always", from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(ContainSubstring("The report contains three differences in three sections"))
+				Expect(out).To(ContainSubstring("foo\n"))
+				Expect(out).To(ContainSubstring("baz\n"))
+				Expect(out).ToNot(ContainSubstring("bar\n"))
+			})
+		})
+
+		It("should not prompt in non-interactive environments", func() {
+			out, err := dyff("between", "--omit-header", "--interactive-threshold", "1", assets("examples", "from.yml"), assets("examples", "to.yml"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).ToNot(ContainSubstring("Select sections to show"))
+		})
+
+		It("should fail for unknown interactive modes", func() {
+			_, err := dyff("between", "--omit-header", "--interactive", "yes", assets("examples", "from.yml"), assets("examples", "to.yml"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`unsupported interactive mode "yes"`))
+		})
+	})
+
+	Context("profiling", func() {
+		It("should write Go profiles and an execution trace of the run", func() {
+			dir := createTestDirectory()
+			defer os.RemoveAll(dir)
+
+			cpuprofile, memprofile, trace := filepath.Join(dir, "cpu.pprof"), filepath.Join(dir, "mem.pprof"), filepath.Join(dir, "trace.out")
+
+			_, err := dyff("between", "--cpuprofile", cpuprofile, "--memprofile", memprofile, "--trace", trace, assets("examples", "from.yml"), assets("examples", "to.yml"))
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, file := range []string{cpuprofile, memprofile, trace} {
+				info, err := os.Stat(file)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.Size()).To(BeNumerically(">", 0))
+			}
+		})
+	})
+
+	Context("between command with inputs from standard input", func() {
+		It("should split standard input into both inputs at the separator", func() {
+			withStdin("---\nfoo: 1\nbar: 1\n---8<---\n---\nfoo: 2\nbar: 1\n", func() {
+				out, err := dyff("between", "--omit-header", "--output", "brief", "-", "-")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(HavePrefix("one change detected"))
+			})
+		})
+
+		It("should use the configured separator", func() {
+			withStdin("foo: 1\n===\nfoo: 2\n", func() {
+				out, err := dyff("between", "--omit-header", "--output", "brief", "--from-stdin", "--to-stdin", "--stdin-separator", "===")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(HavePrefix("one change detected"))
+			})
+		})
+
+		It("should fail if the separator is missing", func() {
+			withStdin("foo: 1\nfoo: 2\n", func() {
+				_, err := dyff("between", "-", "-")
+				Expect(err).To(MatchError(ContainSubstring(`standard input does not contain a "---8<---" line`)))
+			})
+		})
+
+		It("should read one input from standard input with only the other one as an argument", func() {
+			to := createTestFile("---\nfoo: 2\n")
+			defer os.Remove(to)
+
+			withStdin("---\nfoo: 1\n", func() {
+				out, err := dyff("between", "--omit-header", "--output", "brief", "--from-stdin", to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(HavePrefix("one change detected"))
+			})
+
+			withStdin("---\nfoo: 2\n", func() {
+				out, err := dyff("between", "--omit-header", "--output", "brief", "--to-stdin", to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(HavePrefix("no changes"))
+			})
+		})
+	})
+
+	Context("between command attestation", func() {
+		It("should write a signed attestation of the comparison result", func() {
+			_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+
+			der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+			Expect(err).ToNot(HaveOccurred())
+
+			key := createTestFile(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+			defer os.Remove(key)
+
+			attestationFile := createTestFile("")
+			defer os.Remove(attestationFile)
+
+			_, err = dyff("between", "--output", "brief", "--attest", key, "--attestation", attestationFile, assets("examples", "from.yml"), assets("examples", "to.yml"))
+			Expect(err).ToNot(HaveOccurred())
+
+			data, err := os.ReadFile(attestationFile)
+			Expect(err).ToNot(HaveOccurred())
+
+			var attestation struct {
+				PayloadType string `json:"payloadType"`
+				Payload     []byte `json:"payload"`
+				Signatures  []struct {
+					Sig string `json:"sig"`
+				} `json:"signatures"`
+			}
+
+			Expect(json.Unmarshal(data, &attestation)).To(Succeed())
+			Expect(attestation.PayloadType).To(Equal("application/vnd.in-toto+json"))
+			Expect(attestation.Signatures).To(HaveLen(1))
+
+			var statement struct {
+				Type      string `json:"_type"`
+				Predicate struct {
+					Options     []string `json:"options"`
+					Differences int      `json:"differences"`
+				} `json:"predicate"`
+			}
+
+			Expect(json.Unmarshal(attestation.Payload, &statement)).To(Succeed())
+			Expect(statement.Type).To(Equal("https://in-toto.io/Statement/v1"))
+			Expect(statement.Predicate.Options).To(ContainElement("--output=brief"))
+			Expect(statement.Predicate.Differences).To(BeNumerically(">", 0))
+		})
+
+		It("should fail when the attestation output file is not specified", func() {
+			_, err := dyff("between", "--attest", "key.pem", assets("examples", "from.yml"), assets("examples", "to.yml"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("between command with OCI chart references", func() {
+		var binDir string
+
+		BeforeEach(func() {
+			binDir = createTestDirectory()
+
+			// fake helm that renders a config map with the release name, chart
+			// reference, and chart version as data
+			script := `#!/bin/sh
+release=""
+chart=""
+version=""
+shift
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --version) version="$2"; shift ;;
+    *) if [ -z "$release" ]; then release="$1"; else chart="$1"; fi ;;
+  esac
+  shift
+done
+cat <<EOF
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: chart
+data:
+  release: "$release"
+  chart: "$chart"
+  version: "$version"
+EOF
+`
+			Expect(os.WriteFile(filepath.Join(binDir, "helm"), []byte(script), 0755)).To(Succeed())
+			GinkgoT().Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(binDir)).To(Succeed())
+		})
+
+		It("should compare the rendered templates of two chart versions", func() {
+			out, err := dyff("between", "--omit-header", "oci://registry/charts/chart:1.2.3", "oci://registry/charts/chart:1.3.0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("data.version"))
+			Expect(out).To(ContainSubstring("1.2.3"))
+			Expect(out).To(ContainSubstring("1.3.0"))
+		})
+
+		It("should keep the digest of a chart reference as part of the chart", func() {
+			out, err := dyff("between", "--omit-header", "--output", "brief", "--detailed",
+				"oci://registry:5000/charts/chart@sha256:1111",
+				"oci://registry:5000/charts/chart:1.3.0@sha256:2222",
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("two changes detected"))
+			Expect(out).To(ContainSubstring("data.chart"))
+			Expect(out).To(ContainSubstring("data.version"))
+			Expect(out).ToNot(ContainSubstring("data.release"))
+
+			out, err = dyff("between", "--omit-header", "--filter", "/data/chart",
+				"oci://registry:5000/charts/chart@sha256:1111",
+				"oci://registry:5000/charts/chart:1.3.0@sha256:2222",
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("- oci://registry:5000/charts/chart@sha256:1111"))
+			Expect(out).To(ContainSubstring("+ oci://registry:5000/charts/chart@sha256:2222"))
+		})
+	})
+
+	Context("live command", func() {
+		It("should fail with a clear error if kubectl is not available", func() {
+			GinkgoT().Setenv("PATH", GinkgoT().TempDir())
+
+			_, err := dyff("live", "deployment.yml")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("the live command requires kubectl"))
+		})
+	})
+
+	Context("kubectl command", func() {
+		var live, merged string
+
+		BeforeEach(func() {
+			live, merged = createTestDirectory(), createTestDirectory()
+
+			Expect(os.WriteFile(filepath.Join(live, "v1.ConfigMap.default.foo"), []byte(`---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+  namespace: default
+  generation: 1
+  resourceVersion: "100"
+data:
+  key: value
+`), 0644)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(merged, "merged-resource"), []byte(`---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+  namespace: default
+  generation: 2
+  resourceVersion: "101"
+data:
+  key: changed
+`), 0644)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(live)).To(Succeed())
+			Expect(os.RemoveAll(merged)).To(Succeed())
+		})
+
+		It("should pair the resources by identity and ignore server managed fields", func() {
+			out, err := dyff("kubectl", "--omit-header", live, merged)
+			Expect(err).To(HaveOccurred())
+
+			exitCode, ok := err.(ExitCode)
+			Expect(ok).To(BeTrue())
+			Expect(exitCode.Value()).To(Equal(1))
+
+			Expect(out).To(ContainSubstring("data.key"))
+			Expect(out).ToNot(ContainSubstring("resourceVersion"))
+			Expect(out).ToNot(ContainSubstring("generation"))
+		})
+
+		It("should compare the server managed fields if requested", func() {
+			out, err := dyff("kubectl", "--omit-header", "--set-exit-code=false", "--keep-server-fields", live, merged)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("metadata.resourceVersion"))
+			Expect(out).To(ContainSubstring("metadata.generation"))
+		})
+	})
+
+	Context("helm-diff command", func() {
+		var binDir string
+
+		BeforeEach(func() {
+			binDir = createTestDirectory()
+
+			// fake helm that renders a config map with the chart and the
+			// content of the last values file as data, and a static service
+			script := `#!/bin/sh
+release="$2"
+chart="$3"
+values=""
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --values) values="$(cat "$2")"; shift ;;
+  esac
+  shift
+done
+cat <<EOF
+---
+# Source: chart/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: $release-config
+data:
+  chart: "$chart"
+  values: "$values"
+---
+# Source: chart/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: $release-service
+EOF
+`
+			Expect(os.WriteFile(filepath.Join(binDir, "helm"), []byte(script), 0755)).To(Succeed())
+			GinkgoT().Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(binDir)).To(Succeed())
+		})
+
+		It("should compare the rendered manifests of two charts per resource", func() {
+			out, err := dyff("helm-diff", "--omit-header", "./old-chart", "./new-chart")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("data.chart  (v1/ConfigMap/release-config)"))
+			Expect(out).To(ContainSubstring("./old-chart"))
+			Expect(out).To(ContainSubstring("./new-chart"))
+			Expect(out).ToNot(ContainSubstring("v1/Service/release-service"))
+		})
+
+		It("should compare the rendered manifests of one chart using different values", func() {
+			from := createTestFile(`replicas=1`)
+			defer os.Remove(from)
+
+			to := createTestFile(`replicas=3`)
+			defer os.Remove(to)
+
+			out, err := dyff("helm-diff", "--omit-header", "--from-values", from, "--to-values", to, "./chart")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("data.values  (v1/ConfigMap/release-config)"))
+			Expect(out).To(ContainSubstring("replicas=1"))
+			Expect(out).To(ContainSubstring("replicas=3"))
+		})
+
+		It("should use the provided release name for both charts", func() {
+			out, err := dyff("helm-diff", "--omit-header", "--release", "prod", "./old-chart", "./new-chart")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("data.chart  (v1/ConfigMap/prod-config)"))
+		})
+	})
+
+	Context("configuration file", func() {
+		It("should use the flags of the configuration file unless overridden on the command line", func() {
+			from := createTestFile(`{"foo": "bar", "ignored": 1}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"foo": "BAR", "ignored": 2}`)
+			defer os.Remove(to)
+
+			config := createTestFile(`---
+output: brief
+exclude:
+- /ignored
+`)
+			defer os.Remove(config)
+
+			out, err := dyff("between", "--config", config, from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("one change"))
+
+			out, err = dyff("between", "--config", config, "--omit-header", "--output", "human", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("foo"))
+			Expect(out).ToNot(ContainSubstring("ignored"))
+
+			out, err = dyff("between", "--config=", "--output", "brief", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("two changes"))
+		})
+
+		It("should skip flags that are not supported by the command", func() {
+			config := createTestFile(`{output: brief, exclude: [/foo]}`)
+			defer os.Remove(config)
+
+			out, err := dyff("yaml", "--config", config, assets("examples", "from.yml"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).ToNot(BeEmpty())
+		})
+
+		It("should reject flags that are not supported in configuration files", func() {
+			dir := createTestDirectory()
+			defer os.RemoveAll(dir)
+
+			profile := filepath.Join(dir, "profile.out")
+			config := createTestFile(fmt.Sprintf("{output: brief, cpuprofile: %s}", profile))
+			defer os.Remove(config)
+
+			_, err := dyff("between", "--config", config, assets("examples", "from.yml"), assets("examples", "to.yml"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("flag cpuprofile is not supported in configuration files"))
+			Expect(profile).ToNot(BeAnExistingFile())
+		})
+	})
+
+	Context("between command with inputs from URLs", func() {
+		var to string
+		BeforeEach(func() {
+			to = createTestFile("---\nfoo: 2\n")
+		})
+
+		AfterEach(func() {
+			Expect(os.Remove(to)).To(Succeed())
+		})
+
+		It("should send the configured headers and bearer token", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Project") != "dyff" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				fmt.Fprint(w, "---\nfoo: 1\n")
+			}))
+			defer server.Close()
+
+			_, err := dyff("between", "--http-retries", "0", server.URL+"/from.yml", to)
+			Expect(err).To(MatchError(ContainSubstring("401 Unauthorized")))
+
+			GinkgoT().Setenv("TOKEN", "secret")
+			out, err := dyff("between", "--omit-header", "--output", "brief", "--http-header", "X-Project: dyff", "--bearer-token-env", "TOKEN", server.URL+"/from.yml", to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("one change detected"))
+		})
+
+		It("should use the credentials of the netrc file", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if login, password, ok := r.BasicAuth(); !ok || login != "alice" || password != "secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				fmt.Fprint(w, "---\nfoo: 1\n")
+			}))
+			defer server.Close()
+
+			netrc := createTestFile("machine 127.0.0.1\n  login alice\n  password secret\n")
+			defer os.Remove(netrc)
+			GinkgoT().Setenv("NETRC", netrc)
+
+			out, err := dyff("between", "--omit-header", "--output", "brief", server.URL+"/from.yml", to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("one change detected"))
+		})
+
+		It("should retry requests that failed due to server errors", func() {
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests++; requests == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+
+				fmt.Fprint(w, "---\nfoo: 1\n")
+			}))
+			defer server.Close()
+
+			out, err := dyff("between", "--omit-header", "--output", "brief", server.URL+"/from.yml", to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("one change detected"))
+			Expect(requests).To(Equal(2))
+		})
+	})
+
+	Context("between command with Git revisions", func() {
+		var repo string
+
+		var git = func(args ...string) {
+			cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=dyff", "-c", "user.email=dyff@example.com"}, args...)...)
+			out, err := cmd.CombinedOutput()
+			Expect(err).ToNot(HaveOccurred(), string(out))
+		}
+
+		BeforeEach(func() {
+			if _, err := exec.LookPath("git"); err != nil {
+				Skip("git is not available")
+			}
+
+			repo = createTestDirectory()
+			git("init", "--quiet")
+
+			Expect(os.WriteFile(filepath.Join(repo, "values.yaml"), []byte("replicas: 1\nname: foo\n"), 0644)).To(Succeed())
+			git("add", "values.yaml")
+			git("commit", "--quiet", "--message", "first")
+
+			Expect(os.WriteFile(filepath.Join(repo, "values.yaml"), []byte("replicas: 2\nname: foo\n"), 0644)).To(Succeed())
+			git("commit", "--quiet", "--all", "--message", "second")
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(repo)).To(Succeed())
+		})
+
+		It("should compare the file of two revisions", func() {
+			out, err := dyff("between", "--git", "--output", "brief", "HEAD~1", "HEAD", filepath.Join(repo, "values.yaml"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("one change detected between HEAD~1:"))
+
+			out, err = dyff("between", "--git", "--omit-header", "HEAD~1", "HEAD", filepath.Join(repo, "values.yaml"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("replicas"))
+			Expect(out).To(ContainSubstring("- 1"))
+			Expect(out).To(ContainSubstring("+ 2"))
+		})
+
+		It("should compare the file of a revision with the working tree", func() {
+			Expect(os.WriteFile(filepath.Join(repo, "values.yaml"), []byte("replicas: 2\nname: bar\n"), 0644)).To(Succeed())
+
+			out, err := dyff("between", "--git", "--omit-header", "HEAD", filepath.Join(repo, "values.yaml"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("name"))
+			Expect(out).ToNot(ContainSubstring("replicas"))
+		})
+
+		It("should treat a file that does not exist in a revision as missing if allowed", func() {
+			Expect(os.WriteFile(filepath.Join(repo, "new.yaml"), []byte("foo: bar\n"), 0644)).To(Succeed())
+
+			_, err := dyff("between", "--git", "HEAD", filepath.Join(repo, "new.yaml"))
+			Expect(err).To(MatchError(ContainSubstring("not in 'HEAD'")))
+
+			out, err := dyff("between", "--git", "--allow-missing-file", "--output", "brief", "HEAD", filepath.Join(repo, "new.yaml"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("one change detected"))
+		})
+
+		It("should only accept three arguments in Git mode", func() {
+			_, err := dyff("between", "--git", "HEAD~1", "HEAD", "values.yaml", "other.yaml")
+			Expect(err).To(MatchError(ContainSubstring("accepts between 2 and 3 arg(s)")))
+
+			_, err = dyff("between", "HEAD~1", "HEAD", "values.yaml")
+			Expect(err).To(MatchError(ContainSubstring("accepts 2 arg(s)")))
+		})
+	})
+
+	Context("between command input labels", func() {
+		It("should use the provided labels instead of the locations", func() {
+			from := createTestFile(`{"foo": "bar"}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"foo": "BAR"}`)
+			defer os.Remove(to)
+
+			out, err := dyff("between", "--output", "brief", "--from-label", "live", "--to-label", "desired", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("between live"))
+			Expect(out).To(ContainSubstring("and desired"))
+			Expect(out).ToNot(ContainSubstring(from))
+		})
+
+		It("should name inputs from process substitution after the command writing into the pipe", func() {
+			if runtime.GOOS != "linux" {
+				Skip("process substitution lookup is only supported on Linux")
+			}
+
+			reader, writer, err := os.Pipe()
+			Expect(err).ToNot(HaveOccurred())
+			defer reader.Close()
+
+			writerCmd := exec.Command("sh", "-c", `sleep 0.2; echo "foo: bar"`)
+			writerCmd.Stdout = writer
+			Expect(writerCmd.Start()).To(Succeed())
+			Expect(writer.Close()).To(Succeed())
+			defer func() { _ = writerCmd.Wait() }()
+
+			to := createTestFile(`{"foo": "BAR"}`)
+			defer os.Remove(to)
+
+			out, err := dyff("between", "--output", "brief", fmt.Sprintf("/dev/fd/%d", reader.Fd()), to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("one change detected"))
+			Expect(out).To(ContainSubstring(`between <(sh -c "sleep 0.2; echo \"foo: bar\"")`))
+		})
+	})
+
+	Context("between command since last run", func() {
+		It("should only report the differences that are new since the last run", func() {
+			dir := createTestDirectory()
+			defer os.RemoveAll(dir)
+
+			state := filepath.Join(dir, "state.json")
+
+			from := createTestFile(`{"foo": "bar", "bar": "foo"}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"foo": "BAR", "bar": "foo"}`)
+			defer os.Remove(to)
+
+			out, err := dyff("between", "--output", "brief", "--since-last-run", state, from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("one change detected"))
+
+			out, err = dyff("between", "--output", "brief", "--set-exit-code", "--since-last-run", state, from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value()).To(Equal(0))
+			Expect(out).To(HavePrefix("no changes detected"))
+
+			Expect(os.WriteFile(to, []byte(`{"foo": "BAR", "bar": "FOO"}`), 0644)).To(Succeed())
+
+			out, err = dyff("between", "--omit-header", "--since-last-run", state, from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("bar"))
+			Expect(out).To(ContainSubstring("+ FOO"))
+			Expect(out).ToNot(ContainSubstring("+ BAR"))
+		})
+	})
+
+	Context("between command structured output", func() {
+		It("should include the provenance of the input files", func() {
+			from, to := assets("examples", "from.yml"), assets("examples", "to.yml")
+
+			out, err := dyff("between", "--output", "json", from, to)
+			Expect(err).ToNot(HaveOccurred())
+
+			var report struct {
+				From struct {
+					Provenance struct {
+						ResolvedLocation string `json:"resolvedLocation"`
+						Size             int64  `json:"size"`
+						SHA256           string `json:"sha256"`
+						LoadedAt         string `json:"loadedAt"`
+					} `json:"provenance"`
+				} `json:"from"`
+			}
+
+			Expect(json.Unmarshal([]byte(out), &report)).To(Succeed())
+
+			data, err := os.ReadFile(from)
+			Expect(err).ToNot(HaveOccurred())
+
+			sum := sha256.Sum256(data)
+			Expect(report.From.Provenance.SHA256).To(Equal(hex.EncodeToString(sum[:])))
+			Expect(report.From.Provenance.Size).To(BeEquivalentTo(len(data)))
+			Expect(filepath.IsAbs(report.From.Provenance.ResolvedLocation)).To(BeTrue())
+			Expect(report.From.Provenance.LoadedAt).ToNot(BeEmpty())
+		})
+
+		It("should write the differences as SARIF results", func() {
+			out, err := dyff("between", "--output", "sarif", assets("examples", "from.yml"), assets("examples", "to.yml"))
+			Expect(err).ToNot(HaveOccurred())
+
+			var log struct {
+				Version string `json:"version"`
+				Runs    []struct {
+					Results []struct {
+						RuleID string `json:"ruleId"`
+					} `json:"results"`
+				} `json:"runs"`
+			}
+
+			Expect(json.Unmarshal([]byte(out), &log)).To(Succeed())
+			Expect(log.Version).To(Equal("2.1.0"))
+			Expect(log.Runs).To(HaveLen(1))
+			Expect(log.Runs[0].Results).ToNot(BeEmpty())
+			Expect(log.Runs[0].Results[0].RuleID).To(HavePrefix("dyff/"))
+		})
+	})
+
+	Context("live command", func() {
+		var binDir string
+
+		BeforeEach(func() {
+			binDir = createTestDirectory()
+
+			// fake kubectl that returns the live config map, including the
+			// fields maintained by the API server, and the provided arguments,
+			// or for apply the config map with a label of a mutating webhook
+			script := `#!/bin/sh
+cat >/dev/null
+if [ "$1" = "apply" ]; then
+cat <<EOF
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: default
+  uid: 0b4f2a5c-7d0e-4d6b-9a57-2c3b4e0c1a2f
+  labels:
+    injected-by: webhook
+  managedFields:
+  - manager: kubectl
+data:
+  foo: old
+  args: "$*"
+EOF
+exit 0
+fi
+cat <<EOF
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: config
+    namespace: default
+    uid: 0b4f2a5c-7d0e-4d6b-9a57-2c3b4e0c1a2f
+    resourceVersion: "4711"
+    creationTimestamp: "2024-01-01T00:00:00Z"
+    annotations:
+      kubectl.kubernetes.io/last-applied-configuration: '{}'
+    managedFields:
+    - manager: kubectl
+  data:
+    foo: old
+    args: "$*"
+EOF
+`
+			Expect(os.WriteFile(filepath.Join(binDir, "kubectl"), []byte(script), 0755)).To(Succeed())
+			GinkgoT().Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(binDir)).To(Succeed())
+		})
+
+		It("should compare the local resources with the live resources without server fields", func() {
+			filename := createTestFile(`---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  foo: new
+  args: "get --filename - --output yaml --ignore-not-found --context staging"
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: service
+`)
+			defer os.Remove(filename)
+
+			out, err := dyff("live", "--omit-header", "--context", "staging", filename)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("data.foo"))
+			Expect(out).To(ContainSubstring("one document added"))
+			Expect(out).To(ContainSubstring("name: service"))
+			Expect(out).ToNot(ContainSubstring("data.args"))
+			Expect(out).ToNot(ContainSubstring("namespace"))
+			Expect(out).ToNot(ContainSubstring("managedFields"))
+			Expect(out).ToNot(ContainSubstring("annotations"))
+		})
+
+		It("should compare the resources returned by a server-side dry-run apply", func() {
+			filename := createTestFile(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "config"}, "data": {"foo": "old"}}`)
+			defer os.Remove(filename)
+
+			out, err := dyff("live", "--omit-header", "--server-dry-run", filename)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("injected-by: webhook"))
+			Expect(out).To(ContainSubstring("+ apply --server-side --dry-run=server --filename - --output yaml"))
+			Expect(out).ToNot(ContainSubstring("managedFields"))
+			Expect(out).ToNot(ContainSubstring("namespace"))
+		})
+
+		It("should compare the server fields if requested", func() {
+			filename := createTestFile(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "config", "namespace": "default"}}`)
+			defer os.Remove(filename)
+
+			out, err := dyff("live", "--omit-header", "--keep-server-fields", filename)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("managedFields"))
+			Expect(out).To(ContainSubstring("kubectl.kubernetes.io/last-applied-configuration"))
+		})
+
+		It("should fail if the file does not contain Kubernetes resources", func() {
+			filename := createTestFile(`{"foo": "bar"}`)
+			defer os.Remove(filename)
+
+			_, err := dyff("live", filename)
+			Expect(err).To(MatchError(ContainSubstring("does not contain any Kubernetes resources")))
+		})
+	})
+
+	Context("watch command", func() {
+		var watch = func(args ...string) chan string {
+			var result = make(chan string, 1)
+			go func() {
+				defer GinkgoRecover()
+				out, err := dyff(append([]string{"watch", "--count", "1", "--omit-header"}, args...)...)
+				Expect(err).ToNot(HaveOccurred())
+				result <- out
+			}()
+
+			return result
+		}
+
+		It("should report the differences of a changed file", func() {
+			filename := createTestFile("---\nname: foo\nsize: 1\n")
+			defer os.Remove(filename)
+
+			result := watch(filename)
+
+			time.Sleep(300 * time.Millisecond)
+			Expect(os.WriteFile(filename, []byte("---\nname: bar\nsize: 1\n"), 0644)).To(Succeed())
+
+			var out string
+			Eventually(result, "5s").Should(Receive(&out))
+			Expect(out).To(ContainSubstring("name"))
+			Expect(out).To(ContainSubstring("- foo"))
+			Expect(out).To(ContainSubstring("+ bar"))
+			Expect(out).ToNot(ContainSubstring("size"))
+		})
+
+		It("should report the differences of files in a changed directory", func() {
+			dir := createTestDirectory()
+			defer os.RemoveAll(dir)
+
+			Expect(os.WriteFile(filepath.Join(dir, "a.yml"), []byte("name: foo\n"), 0644)).To(Succeed())
+
+			result := watch(dir)
+
+			time.Sleep(300 * time.Millisecond)
+			Expect(os.WriteFile(filepath.Join(dir, "b.yml"), []byte("name: bar\n"), 0644)).To(Succeed())
+
+			var out string
+			Eventually(result, "5s").Should(Receive(&out))
+			Expect(out).To(ContainSubstring("b.yml"))
+			Expect(out).ToNot(ContainSubstring("a.yml"))
+		})
+
+		It("should report the differences of files in the current directory", func() {
+			dir := createTestDirectory()
+			defer os.RemoveAll(dir)
+
+			Expect(os.WriteFile(filepath.Join(dir, "a.yml"), []byte("name: foo\n"), 0644)).To(Succeed())
+
+			pwd, err := os.Getwd()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.Chdir(dir)).To(Succeed())
+			defer func() { Expect(os.Chdir(pwd)).To(Succeed()) }()
+
+			result := watch(".")
+
+			time.Sleep(300 * time.Millisecond)
+			Expect(os.WriteFile(filepath.Join(dir, "a.yml"), []byte("name: bar\n"), 0644)).To(Succeed())
+
+			var out string
+			Eventually(result, "5s").Should(Receive(&out))
+			Expect(out).To(ContainSubstring("a.yml"))
+			Expect(out).To(ContainSubstring("+ bar"))
+		})
+	})
+
+	Context("render command", func() {
+		It("should render a saved report the same way as the between command", func() {
+			from, to := assets("examples", "from.yml"), assets("examples", "to.yml")
+
+			expected, err := dyff("between", "--omit-header", from, to)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, style := range []string{"json", "yaml"} {
+				saved, err := dyff("between", "--output", style, from, to)
+				Expect(err).ToNot(HaveOccurred())
+
+				report := createTestFile(saved)
+				defer os.Remove(report)
+
+				out, err := dyff("render", "--omit-header", report)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(BeEquivalentTo(expected))
+			}
+		})
+
+		It("should apply filters when rendering a saved report", func() {
+			saved, err := dyff("between", "--output", "json", assets("examples", "from.yml"), assets("examples", "to.yml"))
+			Expect(err).ToNot(HaveOccurred())
+
+			report := createTestFile(saved)
+			defer os.Remove(report)
+
+			out, err := dyff("render", "--output", "brief", "--filter", "/yaml/map/whitespaces", report)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("one change detected"))
+		})
+
+		It("should redact all values when rendering a saved report", func() {
+			from := createTestFile(`{"name": "secret-name", "replicas": 1, "enabled": true, "list": ["a"]}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"name": "other-name", "replicas": 2, "enabled": false, "list": ["a", "b"]}`)
+			defer os.Remove(to)
+
+			saved, err := dyff("between", "--output", "json", from, to)
+			Expect(err).ToNot(HaveOccurred())
+
+			report := createTestFile(saved)
+			defer os.Remove(report)
+
+			out, err := dyff("render", "--redact-values", "--output", "json", report)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).ToNot(ContainSubstring("secret-name"))
+			Expect(out).ToNot(ContainSubstring("other-name"))
+
+			redacted := createTestFile(out)
+			defer os.Remove(redacted)
+
+			out, err = dyff("render", "--omit-header", "--output", "human", redacted)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("name"))
+			Expect(out).To(ContainSubstring("replicas"))
+			Expect(out).To(ContainSubstring("…"))
+			Expect(out).ToNot(ContainSubstring("secret-name"))
+			Expect(out).ToNot(ContainSubstring("other-name"))
+		})
+
+		It("should fail when the report cannot be read", func() {
+			_, err := dyff("render", "/does/not/exist/report.json")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Context("last-applied command", func() {
 		It("should create the default report when there are no flags specified", func() {
 			kubeYAML := createTestFile(`---
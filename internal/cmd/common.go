@@ -23,6 +23,7 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -30,6 +31,7 @@ import (
 
 	"github.com/gonvenience/bunt"
 	"github.com/gonvenience/neat"
+	"github.com/gonvenience/text"
 	"github.com/gonvenience/ytbx"
 	"github.com/spf13/cobra"
 	yamlv3 "gopkg.in/yaml.v3"
@@ -41,63 +43,217 @@ type reportConfig struct {
 	style                     string
 	ignoreOrderChanges        bool
 	ignoreWhitespaceChanges   bool
+	ignoreNumberFormatChanges bool
+	ignoreBlockStyleChanges   bool
 	kubernetesEntityDetection bool
 	noTableStyle              bool
 	doNotInspectCerts         bool
+	omitBinaryHexDump         bool
 	exitWithCode              bool
+	exitCodeMode              string
+	failOn                    []string
+	failOnPaths               []string
 	omitHeader                bool
+	header                    string
 	useGoPatchPaths           bool
+	pathStyle                 string
 	ignoreValueChanges        bool
+	ignoreNewDocuments        bool
+	ignoreRemovedDocuments    bool
 	minorChangeThreshold      float64
 	multilineContextLines     int
+	contextLines              int
+	contextKeys               int
+	groupByKind               bool
+	versionSummary            bool
+	summary                   bool
+	bidirectional             bool
+	detailed                  bool
+	relativeTo                string
+	documentValues            string
+	expectChanges             int
+	expectNoChanges           bool
+	sortKeys                  bool
+	printFingerprint          bool
+	suggestIgnores            bool
+	interactive               string
+	interactiveThreshold      int
+	ignoreOrderChangesAt      []string
+	excludeSubtrees           []string
+	scopes                    []string
 	additionalIdentifiers     []string
+	compositeIdentifiers      []string
+	nullEquivalents           []string
+	customTags                string
+	listDiffStrategy          string
+	nodeHashing               string
+	detectMoves               bool
+	normalizeLineEndings      bool
+	normalizeScripts          bool
+	compareDirectives         bool
+	presets                   []string
+	chart                     string
+	valuesSchema              string
+	suppressionComments       bool
+	followRefs                bool
+	followExternalRefs        bool
 	filters                   []string
 	excludes                  []string
 	filterRegexps             []string
 	excludeRegexps            []string
+	excludeDocuments          []string
 }
 
+// outputStyles are the supported styles of the output flag
+var outputStyles = []string{"human", "brief", "github", "gitlab", "gitea", "json", "yaml", "yq", "gopatch", "jsonpatch", "diff", "sarif"}
+
 var defaults = reportConfig{
 	style:                     "human",
 	ignoreOrderChanges:        false,
 	ignoreWhitespaceChanges:   false,
+	ignoreNumberFormatChanges: false,
+	ignoreBlockStyleChanges:   false,
 	kubernetesEntityDetection: true,
 	noTableStyle:              false,
 	doNotInspectCerts:         false,
+	omitBinaryHexDump:         false,
 	exitWithCode:              false,
+	exitCodeMode:              "any",
+	failOn:                    nil,
+	failOnPaths:               nil,
 	omitHeader:                false,
+	header:                    string(dyff.HeaderBanner),
 	useGoPatchPaths:           false,
+	pathStyle:                 "",
+	ignoreNewDocuments:        false,
+	ignoreRemovedDocuments:    false,
 	minorChangeThreshold:      0.1,
 	multilineContextLines:     4,
+	contextLines:              3,
+	contextKeys:               0,
+	groupByKind:               false,
+	versionSummary:            false,
+	summary:                   false,
+	bidirectional:             false,
+	detailed:                  false,
+	relativeTo:                "",
+	documentValues:            string(dyff.DocumentValuesFull),
+	expectChanges:             -1,
+	expectNoChanges:           false,
+	sortKeys:                  false,
+	printFingerprint:          false,
+	suggestIgnores:            false,
+	interactive:               "auto",
+	interactiveThreshold:      500,
+	ignoreOrderChangesAt:      nil,
+	excludeSubtrees:           nil,
+	scopes:                    nil,
 	additionalIdentifiers:     nil,
+	compositeIdentifiers:      nil,
+	nullEquivalents:           nil,
+	customTags:                string(dyff.CustomTagsOpaque),
+	listDiffStrategy:          string(dyff.HashSet),
+	nodeHashing:               string(dyff.HashStructure),
+	detectMoves:               false,
+	normalizeLineEndings:      false,
+	normalizeScripts:          false,
+	compareDirectives:         false,
+	presets:                   nil,
+	chart:                     "",
+	valuesSchema:              "",
+	suppressionComments:       true,
+	followRefs:                false,
+	followExternalRefs:        false,
 	filters:                   nil,
 	excludes:                  nil,
 	filterRegexps:             nil,
 	excludeRegexps:            nil,
+	excludeDocuments:          nil,
 }
 
 var reportOptions reportConfig
 
+// inputProvenance holds the provenance of the input files of the comparison,
+// which is included in structured reports (not available for saved reports)
+var inputProvenance struct {
+	from, to *dyff.InputProvenance
+}
+
+// inputContent holds the content of the local input files as it was read for
+// the comparison, so that the provenance describes the compared data
+var inputContent = map[string][]byte{}
+
 func applyReportOptionsFlags(cmd *cobra.Command) {
+	applyCompareOptionsFlags(cmd)
+	applyRenderOptionsFlags(cmd)
+}
+
+func applyCompareOptionsFlags(cmd *cobra.Command) {
 	// Compare options
 	cmd.Flags().BoolVarP(&reportOptions.ignoreOrderChanges, "ignore-order-changes", "i", defaults.ignoreOrderChanges, "ignore order changes in lists")
+	cmd.Flags().StringSliceVar(&reportOptions.ignoreOrderChangesAt, "ignore-order-changes-at", defaults.ignoreOrderChangesAt, "ignore order changes in lists at or below the provided paths")
+	cmd.Flags().StringSliceVar(&reportOptions.excludeSubtrees, "exclude-subtree", defaults.excludeSubtrees, "do not compare the provided paths and everything below them at all, unlike --exclude, which removes differences of exactly these paths from the report")
+	cmd.Flags().StringArrayVar(&reportOptions.scopes, "scope", defaults.scopes, "apply compare flags only at and below a path, for example \"/spec/containers/*/env --ignore-order-changes\"")
 	cmd.Flags().BoolVar(&reportOptions.ignoreWhitespaceChanges, "ignore-whitespace-changes", defaults.ignoreWhitespaceChanges, "ignore leading or trailing whitespace changes")
+	cmd.Flags().BoolVar(&reportOptions.ignoreNumberFormatChanges, "ignore-number-format-changes", defaults.ignoreNumberFormatChanges, "ignore changes between numbers that are equal, but written differently, for example 100 and 1e2")
+	cmd.Flags().BoolVar(&reportOptions.ignoreBlockStyleChanges, "ignore-block-scalar-style-changes", defaults.ignoreBlockStyleChanges, "ignore block scalars that only differ in their chomping indicator (trailing line breaks)")
 	cmd.Flags().BoolVarP(&reportOptions.kubernetesEntityDetection, "detect-kubernetes", "", defaults.kubernetesEntityDetection, "detect kubernetes entities")
 	cmd.Flags().StringArrayVar(&reportOptions.additionalIdentifiers, "additional-identifier", defaults.additionalIdentifiers, "use additional identifier candidates in named entry lists")
+	cmd.Flags().StringArrayVar(&reportOptions.compositeIdentifiers, "composite-identifier", defaults.compositeIdentifiers, "use a combination of fields to identify entries in named entry lists, for example host+port")
+	cmd.Flags().StringArrayVar(&reportOptions.nullEquivalents, "null-equivalent", defaults.nullEquivalents, "treat the provided value as equal to null (can be specified multiple times)")
+	cmd.Flags().StringVar(&reportOptions.customTags, "custom-tags", defaults.customTags, "how to handle custom tags like !vault: opaque (compare as tagged values), strict (fail), or strip (ignore the tags)")
+	cmd.Flags().StringVar(&reportOptions.listDiffStrategy, "list-diff-strategy", defaults.listDiffStrategy, "how to compare lists without identifiers: hashset (report added and removed entries as sets) or lcs (report insertions and deletions at their index)")
+	cmd.Flags().StringVar(&reportOptions.nodeHashing, "node-hashing", defaults.nodeHashing, "how to hash list entries for matching: hashstructure or canonical (no conversion into basic types)")
+	cmd.Flags().BoolVar(&reportOptions.detectMoves, "detect-moves", defaults.detectMoves, "report identical map entries or documents that were removed at one location and added at another as moved")
+	cmd.Flags().BoolVar(&reportOptions.normalizeLineEndings, "normalize-line-endings", defaults.normalizeLineEndings, "normalize the line endings of multi-line strings as configured in .gitattributes or .editorconfig files")
+	cmd.Flags().BoolVar(&reportOptions.normalizeScripts, "normalize-scripts", defaults.normalizeScripts, "ignore blank lines and comment-only lines of shell scripts, i.e. CI script blocks (script, run) and keys ending in .sh")
+	cmd.Flags().StringSliceVar(&reportOptions.presets, "preset", defaults.presets, "apply the compare options of a preset for well-known file types, supported presets: "+strings.Join(dyff.PresetNames(), ", "))
+	cmd.Flags().BoolVar(&reportOptions.compareDirectives, "compare-directives", defaults.compareDirectives, "report changes of the %YAML and %TAG directives of documents")
+	cmd.Flags().StringVar(&reportOptions.chart, "chart", defaults.chart, "compare values files with the default values and values schema of the provided Helm chart (directory or packaged chart) applied")
+	cmd.Flags().StringVar(&reportOptions.valuesSchema, "values-schema", defaults.valuesSchema, "compare values files with the defaults and type coercions of the provided JSON schema (e.g. values.schema.json) applied")
+	cmd.Flags().BoolVar(&reportOptions.suppressionComments, "suppression-comments", defaults.suppressionComments, "skip map entries that are annotated with a '# dyff:ignore' comment in either input file")
+	cmd.Flags().BoolVar(&reportOptions.followRefs, "follow-refs", defaults.followRefs, "resolve references ($ref) within the same document before comparing, for example of JSON schemas or OpenAPI documents")
+	cmd.Flags().BoolVar(&reportOptions.followExternalRefs, "follow-external-refs", defaults.followExternalRefs, "like --follow-refs, but also resolve references to other files (relative to the input file)")
+}
+
+func applyRenderOptionsFlags(cmd *cobra.Command) {
+	// Filter options
 	cmd.Flags().StringSliceVar(&reportOptions.filters, "filter", defaults.filters, "filter reports to a subset of differences based on supplied arguments")
-	cmd.Flags().StringSliceVar(&reportOptions.excludes, "exclude", defaults.excludes, "exclude reports from a set of differences based on supplied arguments")
+	cmd.Flags().StringSliceVar(&reportOptions.excludes, "exclude", defaults.excludes, "exclude reports from a set of differences based on supplied arguments, a path can be restricted to one document with a document name prefix (e.g. v1/ConfigMap/app:/data/key)")
 	cmd.Flags().StringSliceVar(&reportOptions.filterRegexps, "filter-regexp", defaults.filterRegexps, "filter reports to a subset of differences based on supplied regular expressions")
 	cmd.Flags().StringSliceVar(&reportOptions.excludeRegexps, "exclude-regexp", defaults.excludeRegexps, "exclude reports from a set of differences based on supplied regular expressions")
+	cmd.Flags().StringSliceVar(&reportOptions.excludeDocuments, "exclude-document", defaults.excludeDocuments, "exclude all differences of documents with matching names, for example v1/Secret/*")
 	cmd.Flags().BoolVarP(&reportOptions.ignoreValueChanges, "ignore-value-changes", "v", false, "exclude changes in values")
+	cmd.Flags().BoolVar(&reportOptions.ignoreNewDocuments, "ignore-new-documents", defaults.ignoreNewDocuments, "exclude documents that only exist in the to input file")
+	cmd.Flags().BoolVar(&reportOptions.ignoreRemovedDocuments, "ignore-removed-documents", defaults.ignoreRemovedDocuments, "exclude documents that only exist in the from input file")
 	// Main output preferences
-	cmd.Flags().StringVarP(&reportOptions.style, "output", "o", defaults.style, "specify the output style, supported styles: human, brief, github, gitlab, gitea")
+	cmd.Flags().StringVarP(&reportOptions.style, "output", "o", defaults.style, "specify the output style, supported styles: "+strings.Join(outputStyles, ", "))
+	cmd.Flags().BoolVar(&reportOptions.sortKeys, "sort-keys", defaults.sortKeys, "sort map keys alphabetically in structured (json, yaml) output instead of using the original order")
+	cmd.Flags().BoolVar(&reportOptions.printFingerprint, "print-fingerprint", defaults.printFingerprint, "print a stable hash of the differences instead of the report to detect whether the set of differences changed")
+	cmd.Flags().BoolVar(&reportOptions.detailed, "detailed", defaults.detailed, "list the changed documents and paths with their number of changes in the brief output")
+	cmd.Flags().IntVar(&reportOptions.contextLines, "context-lines", defaults.contextLines, "number of unchanged lines to show around changes in the unified diff (diff) output")
 	cmd.Flags().BoolVarP(&reportOptions.omitHeader, "omit-header", "b", defaults.omitHeader, "omit the dyff summary header")
+	cmd.Flags().StringVar(&reportOptions.header, "header", defaults.header, "style of the dyff summary header: banner, compact (single line), or none")
 	cmd.Flags().BoolVarP(&reportOptions.exitWithCode, "set-exit-code", "s", defaults.exitWithCode, "set program exit code, with 0 meaning no difference, 1 for differences detected, and 255 for program error")
+	cmd.Flags().StringSliceVar(&reportOptions.failOn, "fail-on", defaults.failOn, "only set exit code 1 for the provided kinds of changes (addition, removal, modification, order-change, move), or for breaking changes of OpenAPI documents (breaking), implies --set-exit-code")
+	cmd.Flags().StringSliceVar(&reportOptions.failOnPaths, "fail-on-path", defaults.failOnPaths, "only set exit code 1 for changes at or below the provided paths, for example /spec/*/image (use * to match any path element), implies --set-exit-code")
+	cmd.Flags().StringVar(&reportOptions.exitCodeMode, "exit-code-mode", defaults.exitCodeMode, "exit code to use with --set-exit-code: any (1 for differences detected), or kinds (bitmask of 1 for modifications, 2 for additions, 4 for removals, 8 for order changes)")
 
 	// Human/BOSH output related flags
+	cmd.Flags().IntVar(&reportOptions.contextKeys, "show-context-keys", defaults.contextKeys, "show up to the given number of unchanged sibling keys of modified map entries")
+	cmd.Flags().BoolVar(&reportOptions.groupByKind, "group-by-kind", defaults.groupByKind, "group the differences of Kubernetes resources by their kind, with a heading and count per kind")
+	cmd.Flags().BoolVar(&reportOptions.versionSummary, "version-summary", defaults.versionSummary, "show a table of all image tag and version changes at the top of the report")
+	cmd.Flags().BoolVar(&reportOptions.summary, "summary", defaults.summary, "show the number of changes per change kind, per document, and per top-level path at the top of the report")
+	cmd.Flags().BoolVar(&reportOptions.bidirectional, "bidirectional", defaults.bidirectional, "show each difference from the from to the to input, and the other way around, for example to review both the upgrade and the rollback")
+	cmd.Flags().StringVar(&reportOptions.relativeTo, "relative-to", defaults.relativeTo, "show the paths below the provided path (for example /spec/template) relative to it, structured outputs keep the full paths")
+	cmd.Flags().StringVar(&reportOptions.documentValues, "show-values-of-added-documents", defaults.documentValues, "how to show the content of added or removed documents: full, summary (one line with the name and size per document), or keys (summary and top-level keys)")
 	cmd.Flags().BoolVarP(&reportOptions.noTableStyle, "no-table-style", "l", defaults.noTableStyle, "do not place blocks next to each other, always use one row per text block")
 	cmd.Flags().BoolVarP(&reportOptions.doNotInspectCerts, "no-cert-inspection", "x", defaults.doNotInspectCerts, "disable x509 certificate inspection, compare as raw text")
+	cmd.Flags().BoolVar(&reportOptions.omitBinaryHexDump, "no-binary-hexdump", defaults.omitBinaryHexDump, "only show the size and hash of changed binary data, but no hex dump")
 	cmd.Flags().BoolVarP(&reportOptions.useGoPatchPaths, "use-go-patch-style", "g", defaults.useGoPatchPaths, "use Go-Patch style paths in outputs")
+	cmd.Flags().StringVar(&reportOptions.pathStyle, "path-style", defaults.pathStyle, "style of the paths in outputs, supported styles: "+strings.Join(dyff.PathStyleNames(), ", "))
+	cmd.Flags().StringVar(&reportOptions.interactive, "interactive", defaults.interactive, "ask which sections of a huge report to show: auto (only in a terminal), always, or never")
+	cmd.Flags().IntVar(&reportOptions.interactiveThreshold, "interactive-threshold", defaults.interactiveThreshold, "number of differences above which a report is considered huge for the interactive prompt (0 to disable)")
 
 	// Deprecated
 	cmd.Flags().BoolVar(&reportOptions.exitWithCode, "set-exit-status", defaults.exitWithCode, "set program exit code, with 0 meaning no difference, 1 for differences detected, and 255 for program error")
@@ -109,6 +265,7 @@ func applyReportOptionsFlags(cmd *cobra.Command) {
 type OutputWriter struct {
 	PlainMode        bool
 	Restructure      bool
+	SortKeys         bool
 	OmitIndentHelper bool
 	OutputStyle      string
 }
@@ -153,19 +310,39 @@ func (w *OutputWriter) WriteInplace(filename string) error {
 }
 
 func (w *OutputWriter) write(writer io.Writer, filename string) error {
-	inputFile, err := ytbx.LoadFile(filename)
+	inputFile, err := dyff.LoadFile(filename)
 	if err != nil {
 		return fmt.Errorf("failed to load input from %s: %w", humanReadableFilename(filename), err)
 	}
 
-	for _, document := range inputFile.Documents {
-		if w.Restructure {
+	if w.OutputStyle == "toml" && len(inputFile.Documents) > 1 {
+		return fmt.Errorf("TOML only supports one document, but %s has %d", humanReadableFilename(filename), len(inputFile.Documents))
+	}
+
+	if w.OutputStyle == "properties" && len(inputFile.Documents) > 1 {
+		return fmt.Errorf("properties only support one document, but %s has %d", humanReadableFilename(filename), len(inputFile.Documents))
+	}
+
+	if w.OutputStyle == "ini" && len(inputFile.Documents) > 1 {
+		return fmt.Errorf("INI only supports one document, but %s has %d", humanReadableFilename(filename), len(inputFile.Documents))
+	}
+
+	for i, document := range inputFile.Documents {
+		if directives := dyff.Directives(document); len(directives) > 0 && w.OutputStyle == "yaml" {
+			writeDirectives(writer, i > 0, directives)
+		}
+
+		switch {
+		case w.SortKeys:
+			dyff.SortMapKeys(document)
+
+		case w.Restructure:
 			ytbx.RestructureObject(document)
 		}
 
 		switch {
 		case w.PlainMode && w.OutputStyle == "json":
-			output, err := neat.NewOutputProcessor(false, false, &neat.DefaultColorSchema).ToCompactJSON(document)
+			output, err := dyff.CompactJSON(document)
 			if err != nil {
 				return err
 			}
@@ -187,7 +364,19 @@ func (w *OutputWriter) write(writer io.Writer, filename string) error {
 		case w.OutputStyle == "json":
 			output, err := neat.NewOutputProcessor(!w.OmitIndentHelper, true, &neat.DefaultColorSchema).ToJSON(document)
 			if err != nil {
-				return err
+				// numbers beyond the range of int and float64 cannot be
+				// rendered by neat, use the plain JSON output instead
+				compact, compactErr := dyff.CompactJSON(document)
+				if compactErr != nil {
+					return err
+				}
+
+				var buf bytes.Buffer
+				if indentErr := json.Indent(&buf, []byte(compact), "", "  "); indentErr != nil {
+					return err
+				}
+
+				output = buf.String()
 			}
 			fmt.Fprintf(writer, "%s\n", output)
 
@@ -197,26 +386,164 @@ func (w *OutputWriter) write(writer io.Writer, filename string) error {
 				return err
 			}
 			fmt.Fprintf(writer, "%s\n", output)
+
+		case w.OutputStyle == "toml":
+			output, err := dyff.TOML(document)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(writer, output)
+
+		case w.OutputStyle == "properties":
+			output, err := dyff.Properties(document)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(writer, output)
+
+		case w.OutputStyle == "ini":
+			output, err := dyff.INI(document)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(writer, output)
+		}
+	}
+
+	return nil
+}
+
+// writeDirectives writes the directives of a document, directives of all but
+// the first document need to be preceded by a document end marker
+func writeDirectives(writer io.Writer, endPrevious bool, directives []string) {
+	if endPrevious {
+		fmt.Fprintln(writer, "...")
+	}
+
+	for _, directive := range directives {
+		fmt.Fprintln(writer, directive)
+	}
+}
+
+// writeYAMLDocuments writes the documents as a YAML stream including their
+// directives (see dyff.Directives)
+func writeYAMLDocuments(writer io.Writer, documents []*yamlv3.Node) error {
+	for i, document := range documents {
+		directives := dyff.Directives(document)
+		switch {
+		case len(directives) > 0:
+			writeDirectives(writer, i > 0, directives)
+			fmt.Fprintln(writer, "---")
+
+		case i > 0:
+			fmt.Fprintln(writer, "---")
+		}
+
+		encoder := yamlv3.NewEncoder(writer)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(document); err != nil {
+			return fmt.Errorf("failed to write document: %w", err)
+		}
+
+		if err := encoder.Close(); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+func applyReportFilters(report dyff.Report) dyff.Report {
+	if reportOptions.filters != nil {
+		report = report.Filter(reportOptions.filters...)
+	}
+
+	if reportOptions.filterRegexps != nil {
+		report = report.FilterRegexp(reportOptions.filterRegexps...)
+	}
+
+	if reportOptions.excludes != nil {
+		report = report.Exclude(reportOptions.excludes...)
+	}
+
+	if reportOptions.excludeRegexps != nil {
+		report = report.ExcludeRegexp(reportOptions.excludeRegexps...)
+	}
+
+	if reportOptions.excludeDocuments != nil {
+		report = report.ExcludeDocuments(reportOptions.excludeDocuments...)
+	}
+
+	if reportOptions.ignoreValueChanges {
+		report = report.IgnoreValueChanges()
+	}
+
+	if reportOptions.ignoreNewDocuments {
+		report = report.IgnoreDocumentAdditions()
+	}
+
+	if reportOptions.ignoreRemovedDocuments {
+		report = report.IgnoreDocumentRemovals()
+	}
+
+	return report
+}
+
 func writeReport(cmd *cobra.Command, report dyff.Report) error {
+	switch reportOptions.exitCodeMode {
+	case "any", "kinds":
+	default:
+		return fmt.Errorf("unsupported exit code mode %q, supported modes are any and kinds", reportOptions.exitCodeMode)
+	}
+
+	switch strings.ToLower(reportOptions.interactive) {
+	case "auto", "always", "never":
+	default:
+		return fmt.Errorf("unsupported interactive mode %q, supported modes are auto, always, and never", reportOptions.interactive)
+	}
+
+	failOnKinds, err := parseFailOnKinds()
+	if err != nil {
+		return err
+	}
+
+	if reportOptions.relativeTo != "" && !strings.HasPrefix(reportOptions.relativeTo, "/") {
+		return fmt.Errorf("invalid relative-to path %q, it has to start with a slash", reportOptions.relativeTo)
+	}
+
 	var reportWriter dyff.ReportWriter
 	switch strings.ToLower(reportOptions.style) {
 	case "human", "bosh":
+		// For huge reports, ask the user which sections should be shown, the
+		// checks for expected changes and exit codes use the full report
+		var shown = report
+		if useInteractivePrompt(report) {
+			var err error
+			if shown, err = promptForSections(report, os.Stdin, os.Stdout); err != nil {
+				return err
+			}
+		}
+
 		reportWriter = &dyff.HumanReport{
-			Report:                report,
+			Report:                shown,
 			Indent:                2,
 			DoNotInspectCerts:     reportOptions.doNotInspectCerts,
+			OmitBinaryHexDump:     reportOptions.omitBinaryHexDump,
 			NoTableStyle:          reportOptions.noTableStyle,
 			OmitHeader:            reportOptions.omitHeader,
+			HeaderStyle:           dyff.HeaderStyle(reportOptions.header),
 			UseGoPatchPaths:       reportOptions.useGoPatchPaths,
+			PathStyle:             reportOptions.pathStyle,
 			MinorChangeThreshold:  reportOptions.minorChangeThreshold,
 			MultilineContextLines: reportOptions.multilineContextLines,
 			PrefixMultiline:       false,
+			ContextKeys:           reportOptions.contextKeys,
+			GroupByKind:           reportOptions.groupByKind,
+			VersionSummary:        reportOptions.versionSummary,
+			Summary:               reportOptions.summary,
+			Bidirectional:         reportOptions.bidirectional,
+			RelativeTo:            reportOptions.relativeTo,
+			DocumentValues:        dyff.DocumentValuesStyle(reportOptions.documentValues),
 		}
 
 	case "github", "linguist":
@@ -228,12 +555,15 @@ func writeReport(cmd *cobra.Command, report dyff.Report) error {
 				Report:                report,
 				Indent:                0,
 				DoNotInspectCerts:     reportOptions.doNotInspectCerts,
+				OmitBinaryHexDump:     reportOptions.omitBinaryHexDump,
 				NoTableStyle:          true,
 				OmitHeader:            true,
 				UseGoPatchPaths:       reportOptions.useGoPatchPaths,
+				PathStyle:             reportOptions.pathStyle,
 				MinorChangeThreshold:  reportOptions.minorChangeThreshold,
 				MultilineContextLines: reportOptions.multilineContextLines,
 				PrefixMultiline:       true,
+				DocumentValues:        dyff.DocumentValuesStyle(reportOptions.documentValues),
 			},
 		}
 
@@ -246,12 +576,15 @@ func writeReport(cmd *cobra.Command, report dyff.Report) error {
 				Report:                report,
 				Indent:                0,
 				DoNotInspectCerts:     reportOptions.doNotInspectCerts,
+				OmitBinaryHexDump:     reportOptions.omitBinaryHexDump,
 				NoTableStyle:          true,
 				OmitHeader:            true,
 				UseGoPatchPaths:       reportOptions.useGoPatchPaths,
+				PathStyle:             reportOptions.pathStyle,
 				MinorChangeThreshold:  reportOptions.minorChangeThreshold,
 				MultilineContextLines: reportOptions.multilineContextLines,
 				PrefixMultiline:       true,
+				DocumentValues:        dyff.DocumentValuesStyle(reportOptions.documentValues),
 			},
 		}
 
@@ -264,32 +597,106 @@ func writeReport(cmd *cobra.Command, report dyff.Report) error {
 				Report:                report,
 				Indent:                0,
 				DoNotInspectCerts:     reportOptions.doNotInspectCerts,
+				OmitBinaryHexDump:     reportOptions.omitBinaryHexDump,
 				NoTableStyle:          true,
 				OmitHeader:            true,
 				UseGoPatchPaths:       reportOptions.useGoPatchPaths,
+				PathStyle:             reportOptions.pathStyle,
 				MinorChangeThreshold:  reportOptions.minorChangeThreshold,
 				MultilineContextLines: reportOptions.multilineContextLines,
 				PrefixMultiline:       true,
+				DocumentValues:        dyff.DocumentValuesStyle(reportOptions.documentValues),
 			},
 		}
 
 	case "brief", "short", "summary":
 		reportWriter = &dyff.BriefReport{
+			Report:          report,
+			Detailed:        reportOptions.detailed,
+			UseGoPatchPaths: reportOptions.useGoPatchPaths,
+			PathStyle:       reportOptions.pathStyle,
+		}
+
+	case "yq":
+		reportWriter = &dyff.YQReport{
+			Report: report,
+		}
+
+	case "gopatch", "go-patch", "ops":
+		reportWriter = &dyff.GoPatchReport{
 			Report: report,
 		}
 
+	case "jsonpatch", "json-patch":
+		reportWriter = &dyff.JSONPatchReport{
+			Report: report,
+		}
+
+	case "diff", "unified":
+		reportWriter = &dyff.UnifiedDiffReport{
+			Report:       report,
+			ContextLines: reportOptions.contextLines,
+		}
+
+	case "sarif":
+		reportWriter = &dyff.SARIFReport{
+			Report:      report,
+			ToolVersion: currentVersionInfo().Version,
+		}
+
+	case "json", "yaml":
+		reportWriter = &dyff.StructuredReport{
+			Report:         report,
+			Format:         strings.ToLower(reportOptions.style),
+			SortKeys:       reportOptions.sortKeys,
+			FromProvenance: inputProvenance.from,
+			ToProvenance:   inputProvenance.to,
+		}
+
 	default:
 		return fmt.Errorf("unknown output style %s: %w", reportOptions.style, fmt.Errorf(cmd.UsageString()))
 	}
 
-	if err := reportWriter.WriteReport(os.Stdout); err != nil {
+	if reportOptions.printFingerprint {
+		fmt.Fprintln(os.Stdout, report.Fingerprint())
+
+	} else if err := reportWriter.WriteReport(os.Stdout); err != nil {
 		return fmt.Errorf("failed to print report: %w", err)
 	}
 
+	// If configured, print an exclusion section that covers all differences
+	if reportOptions.suggestIgnores {
+		if err := writeSuggestedIgnores(os.Stdout, report); err != nil {
+			return err
+		}
+	}
+
+	// If configured, verify that the number of differences is as expected
+	if err := checkExpectedChanges(report); err != nil {
+		return errorWithExitCode{value: 1, cause: err}
+	}
+
+	// If configured, list the breaking changes, which are the reason to fail
+	if failOnBreaking() {
+		writeBreakingChanges(os.Stderr, report)
+	}
+
+	// If configured, only fail for the configured kinds of changes or paths
+	if len(failOnKinds) > 0 || failOnBreaking() || len(reportOptions.failOnPaths) > 0 {
+		if failOnCount(report, failOnKinds) > 0 {
+			return errorWithExitCode{value: 1}
+		}
+
+		return errorWithExitCode{value: 0}
+	}
+
 	// If configured, make sure `dyff` exists with an exit status
 	if reportOptions.exitWithCode {
-		switch len(report.Diffs) {
-		case 0:
+		switch {
+		case reportOptions.exitCodeMode == "kinds":
+			return errorWithExitCode{value: int(report.ChangeKinds())}
+
+		case len(report.Diffs) == 0:
 			return errorWithExitCode{value: 0}
 
 		default:
@@ -299,3 +706,136 @@ func writeReport(cmd *cobra.Command, report dyff.Report) error {
 
 	return nil
 }
+
+func parseFailOnKinds() ([]dyff.DetailKind, error) {
+	if len(reportOptions.failOn) > 0 || len(reportOptions.failOnPaths) > 0 {
+		if reportOptions.exitCodeMode == "kinds" {
+			return nil, fmt.Errorf("incompatible flags: cannot use --fail-on or --fail-on-path in combination with the kinds exit code mode")
+		}
+	}
+
+	for _, path := range reportOptions.failOnPaths {
+		if !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid fail-on path %q, expected a path starting with a slash", path)
+		}
+	}
+
+	var result = make([]dyff.DetailKind, 0, len(reportOptions.failOn))
+	for _, name := range reportOptions.failOn {
+		if name = strings.TrimSpace(name); name == failOnBreakingChanges {
+			continue
+		}
+
+		kind, err := dyff.ParseDetailKind(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fail-on value: %w", err)
+		}
+
+		result = append(result, kind)
+	}
+
+	return result, nil
+}
+
+// failOnBreakingChanges is the fail-on value for breaking changes of OpenAPI
+// documents, see dyff.Report.OpenAPIBreakingChanges
+const failOnBreakingChanges = "breaking"
+
+func failOnBreaking() bool {
+	for _, name := range reportOptions.failOn {
+		if strings.TrimSpace(name) == failOnBreakingChanges {
+			return true
+		}
+	}
+
+	return false
+}
+
+// failOnCount returns the number of changes that match the configured kinds
+// and paths, no kinds match all kinds, and no paths match all paths
+func failOnCount(report dyff.Report, kinds []dyff.DetailKind) int {
+	var paths = reportOptions.failOnPaths
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+
+	var count int
+	if failOnBreaking() {
+		for _, path := range paths {
+			count += report.CountOpenAPIBreakingChanges(path)
+		}
+
+		if len(kinds) == 0 {
+			return count
+		}
+	}
+
+	if len(kinds) == 0 {
+		kinds = []dyff.DetailKind{0}
+	}
+
+	for _, kind := range kinds {
+		for _, path := range paths {
+			count += report.CountBy(kind, path)
+		}
+	}
+
+	return count
+}
+
+// writeBreakingChanges lists the breaking changes with the reason why each of
+// them breaks existing clients of the API
+func writeBreakingChanges(out io.Writer, report dyff.Report) {
+	var changes = report.OpenAPIBreakingChanges()
+	if len(changes) == 0 {
+		return
+	}
+
+	fmt.Fprintf(out, "%s:\n", text.Plural(len(changes), "breaking change"))
+	for _, change := range changes {
+		var path = change.Path.ToDotStyle()
+		if reportOptions.useGoPatchPaths {
+			path = change.Path.ToGoPatchStyle()
+		}
+
+		fmt.Fprintf(out, "  %s: %s\n", path, change.Reason)
+	}
+}
+
+func applyExpectationFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&reportOptions.expectNoChanges, "expect-no-changes", defaults.expectNoChanges, "fail if any differences are detected")
+	cmd.Flags().IntVar(&reportOptions.expectChanges, "expect-changes", defaults.expectChanges, "fail if the number of detected differences does not match the provided number")
+}
+
+func checkExpectedChanges(report dyff.Report) error {
+	var expected = reportOptions.expectChanges
+	if reportOptions.expectNoChanges {
+		if expected > 0 {
+			return fmt.Errorf("incompatible flags: cannot use --expect-no-changes in combination with --expect-changes %d", expected)
+		}
+
+		expected = 0
+	}
+
+	if expected < 0 || len(report.Diffs) == expected {
+		return nil
+	}
+
+	var paths []string
+	for _, diff := range report.Diffs {
+		if diff.Path == nil {
+			paths = append(paths, "(file level)")
+			continue
+		}
+
+		paths = append(paths, diff.Path.String())
+	}
+
+	return fmt.Errorf("unexpected number of differences: %w",
+		fmt.Errorf("expected %s, but found %s: %s",
+			text.Plural(expected, "difference"),
+			text.Plural(len(report.Diffs), "difference"),
+			strings.Join(paths, ", "),
+		),
+	)
+}
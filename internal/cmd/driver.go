@@ -0,0 +1,197 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// Diff driver mode
+//
+// The diff driver mode is used when dyff is called by another tool as its
+// external diff program, which is the case when the KUBECTL_EXTERNAL_DIFF
+// environment variable refers to dyff. This covers `kubectl diff` itself
+// (two directories), tools that run `kubectl diff` under the hood, and
+// `argocd app diff` (two files per resource). In this mode:
+//
+//   - Positional directory arguments (the from and to input) are moved to the
+//     end of the arguments, since some callers put them in front of the flags.
+//     Values of flags are never moved, even if they refer to a directory.
+//   - Since kubectl splits KUBECTL_EXTERNAL_DIFF by whitespace, flags whose
+//     values contain spaces or other special characters have to be configured
+//     using the DYFF_DEFAULTS environment variable (see defaultOptionsEnv).
+//   - Kubernetes entity detection is enabled and `metadata.managedFields` is
+//     excluded from the report.
+//   - The temporary directories of kubectl diff (named LIVE-* and MERGED-*)
+//     are each compared as one input with the documents of all their files,
+//     other directories are still compared file by file.
+
+func isDiffDriverMode() bool {
+	return strings.Contains(os.Getenv("KUBECTL_EXTERNAL_DIFF"), name)
+}
+
+// rearrange will rearrange the args to match `dyff between --flags from to`
+// to mitigate an issue in `kubectl`, which puts the `from` and `to` at the
+// second and third position in the command arguments.
+func rearrange(args []string) []string {
+	var paths, result []string
+	for i, entry := range args {
+		if i > 0 && !isFlagValue(args[i-1]) {
+			if info, err := os.Stat(entry); err == nil && info.IsDir() {
+				paths = append(paths, entry)
+				continue
+			}
+		}
+
+		result = append(result, entry)
+	}
+
+	return append(result, paths...)
+}
+
+// insertAfterSubCommand inserts the additional arguments right after the name
+// of the sub-command, or after the program name if there is no sub-command
+func insertAfterSubCommand(args []string, additional []string) []string {
+	if len(additional) == 0 || len(args) == 0 {
+		return args
+	}
+
+	idx := 1
+	for i := 1; i < len(args); i++ {
+		if isSubCommand(args[i]) {
+			idx = i + 1
+			break
+		}
+	}
+
+	result := make([]string, 0, len(args)+len(additional))
+	result = append(result, args[:idx]...)
+	result = append(result, additional...)
+	return append(result, args[idx:]...)
+}
+
+func isSubCommand(arg string) bool {
+	for _, cmd := range rootCmd.Commands() {
+		if cmd.Name() == arg || cmd.HasAlias(arg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isFlagValue returns whether the argument following the provided argument is
+// the value of a flag, i.e. the argument is a flag that requires a value and
+// the value is not provided in the same argument (--flag=value)
+func isFlagValue(arg string) bool {
+	var lookup func(*pflag.FlagSet) *pflag.Flag
+
+	switch {
+	case arg == "--" || !strings.HasPrefix(arg, "-") || strings.Contains(arg, "="):
+		return false
+
+	case strings.HasPrefix(arg, "--"):
+		lookup = func(flags *pflag.FlagSet) *pflag.Flag { return flags.Lookup(arg[2:]) }
+
+	default:
+		lookup = func(flags *pflag.FlagSet) *pflag.Flag { return flags.ShorthandLookup(arg[len(arg)-1:]) }
+	}
+
+	for _, cmd := range append(rootCmd.Commands(), rootCmd) {
+		for _, flags := range []*pflag.FlagSet{cmd.Flags(), cmd.PersistentFlags()} {
+			if flag := lookup(flags); flag != nil {
+				return flag.NoOptDefVal == ""
+			}
+		}
+	}
+
+	return false
+}
+
+// splitArguments splits the input into arguments like a shell would do it,
+// arguments are separated by whitespace, single quotes preserve everything,
+// double quotes and backslashes can be used to escape characters
+func splitArguments(input string) ([]string, error) {
+	var (
+		result  []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range input {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				result = append(result, current.String())
+				current.Reset()
+				inArg = false
+			}
+
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	switch {
+	case quote != 0:
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+
+	case escaped:
+		return nil, fmt.Errorf("unterminated escape sequence at the end")
+	}
+
+	if inArg {
+		result = append(result, current.String())
+	}
+
+	return result, nil
+}
@@ -0,0 +1,199 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// defaultOptionsEnv is the environment variable that contains default flags,
+// which are used for every invocation (similar to LESS for less), including
+// the diff driver mode. It is parsed using shell-like quoting rules. Flags that
+// are not supported by the sub-command are skipped, so that for example
+// `--omit-header` can be set without breaking `dyff yaml`. Flags on the command
+// line take precedence over the ones from the environment variable, lists
+// (e.g. `--exclude`) on the command line replace the ones of the variable.
+const defaultOptionsEnv = "DYFF_DEFAULTS"
+
+// flagEnvPrefix is the prefix of the environment variables that set the
+// default of a single flag, e.g. DYFF_OUTPUT=github for --output=github
+const flagEnvPrefix = "DYFF_"
+
+// withDefaultOptions returns the program arguments with the default flags of
+// the DYFF_DEFAULTS environment variable inserted after the sub-command
+func withDefaultOptions(args []string) ([]string, error) {
+	value, ok := os.LookupEnv(defaultOptionsEnv)
+	if !ok || strings.TrimSpace(value) == "" || len(args) == 0 {
+		return args, nil
+	}
+
+	options, err := splitArguments(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", defaultOptionsEnv, err)
+	}
+
+	cmd, _, err := rootCmd.Find(args[1:])
+	if err != nil {
+		cmd = rootCmd
+	}
+
+	supported, err := supportedFlags(cmd, options, setListFlags(cmd, args[1:]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", defaultOptionsEnv, err)
+	}
+
+	return insertAfterSubCommand(args, supported), nil
+}
+
+// withFlagEnvironment returns the program arguments with the flags of the
+// sub-command that are set using an environment variable (DYFF_<FLAG>) inserted
+// after the sub-command. They take precedence over DYFF_DEFAULTS, but not over
+// the flags on the command line, lists on the command line replace the ones of
+// the variable. Empty variables are ignored.
+func withFlagEnvironment(args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+
+	cmd, _, err := rootCmd.Find(args[1:])
+	if err != nil {
+		cmd = rootCmd
+	}
+
+	var set = setListFlags(cmd, args[1:])
+
+	var result []string
+	var visit = func(flag *pflag.Flag) {
+		if _, ok := set[flag.Name]; ok || flag.Name == "help" {
+			return
+		}
+
+		if value, ok := os.LookupEnv(flagEnvName(flag.Name)); ok && value != "" {
+			result = append(result, fmt.Sprintf("--%s=%s", flag.Name, value))
+		}
+	}
+
+	cmd.InheritedFlags().VisitAll(visit)
+	cmd.LocalFlags().VisitAll(visit)
+
+	return insertAfterSubCommand(args, result)
+}
+
+// flagEnvName returns the name of the environment variable for the provided
+// flag name, e.g. DYFF_IGNORE_ORDER_CHANGES for ignore-order-changes
+func flagEnvName(name string) string {
+	return flagEnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// lookupFlag returns the flag of the provided argument (e.g. `--output=brief`)
+// that is supported by the given command, combined short flags (e.g. -bi) are
+// only supported if all of them are, and the last one is returned
+func lookupFlag(cmd *cobra.Command, arg string) *pflag.Flag {
+	var flagSets = []*pflag.FlagSet{cmd.Flags(), cmd.InheritedFlags()}
+
+	var find = func(lookup func(*pflag.FlagSet) *pflag.Flag) *pflag.Flag {
+		for _, flags := range flagSets {
+			if flag := lookup(flags); flag != nil {
+				return flag
+			}
+		}
+
+		return nil
+	}
+
+	if strings.HasPrefix(arg, "--") {
+		name, _, _ := strings.Cut(arg[2:], "=")
+		return find(func(flags *pflag.FlagSet) *pflag.Flag { return flags.Lookup(name) })
+	}
+
+	shorthands, _, _ := strings.Cut(arg[1:], "=")
+	var flag *pflag.Flag
+	for _, shorthand := range shorthands {
+		if flag = find(func(flags *pflag.FlagSet) *pflag.Flag { return flags.ShorthandLookup(string(shorthand)) }); flag == nil {
+			return nil
+		}
+	}
+
+	return flag
+}
+
+// setListFlags returns the names of the list flags (e.g. `--exclude`) that
+// are set in the provided arguments of the command. Since the values of list
+// flags accumulate, defaults have to be skipped for them explicitly to let the
+// command line take precedence.
+func setListFlags(cmd *cobra.Command, args []string) map[string]struct{} {
+	var result = map[string]struct{}{}
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			continue
+		}
+
+		if flag := lookupFlag(cmd, arg); flag != nil {
+			if _, ok := flag.Value.(pflag.SliceValue); ok {
+				result[flag.Name] = struct{}{}
+			}
+		}
+	}
+
+	return result
+}
+
+// supportedFlags returns the flags (and their values) of the provided list of
+// options that are supported by the given command, except for the ones in
+// the skip list
+func supportedFlags(cmd *cobra.Command, options []string, skip map[string]struct{}) ([]string, error) {
+	var result []string
+	for i := 0; i < len(options); i++ {
+		option := options[i]
+		if !strings.HasPrefix(option, "-") || option == "-" || option == "--" {
+			return nil, fmt.Errorf("only flags are supported, but found %q", option)
+		}
+
+		flag := lookupFlag(cmd, option)
+
+		// the value of a flag that is not provided in the same argument is the
+		// next argument
+		var unit = []string{option}
+		if !strings.Contains(option, "=") && (flag == nil || flag.NoOptDefVal == "") && i+1 < len(options) && !strings.HasPrefix(options[i+1], "-") {
+			unit = append(unit, options[i+1])
+			i++
+		}
+
+		if flag == nil {
+			continue
+		}
+
+		if _, ok := skip[flag.Name]; !ok {
+			result = append(result, unit...)
+		}
+	}
+
+	return result, nil
+}
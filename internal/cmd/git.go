@@ -0,0 +1,104 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+// gitLocations returns the from and to locations of the arguments of the
+// between command in Git mode, which are either two revisions and a path, or
+// one revision and a path to compare the revision with the working tree. The
+// locations of revisions use the notation of git show, e.g. HEAD~1:values.yaml
+func gitLocations(args []string) (string, string) {
+	if len(args) == 2 {
+		return args[0] + ":" + args[1], args[1]
+	}
+
+	return args[0] + ":" + args[2], args[1] + ":" + args[2]
+}
+
+// isGitRevision returns whether the location refers to a file of a Git
+// revision, which is only the case in Git mode of the between command
+func isGitRevision(location string) bool {
+	if !betweenCmdSettings.git {
+		return false
+	}
+
+	if _, err := os.Stat(location); err == nil {
+		return false
+	}
+
+	return strings.Contains(location, ":")
+}
+
+func git(dir string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("the git command is required to load files of Git revisions: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run git %s: %w\n%s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// loadGitRevision loads the file of the Git revision location (revision:path),
+// the path is relative to the current working directory (not the root of the
+// repository), so that it is the same as the one of the file in the working
+// tree
+func loadGitRevision(location string) (ytbx.InputFile, error) {
+	revision, path, _ := strings.Cut(location, ":")
+
+	// the git show notation for paths relative to the working directory
+	var dir, object = filepath.Dir(path), revision + ":./" + filepath.Base(path)
+
+	if betweenCmdSettings.allowMissingFile {
+		if _, err := git(dir, "cat-file", "-e", object); err != nil {
+			return ytbx.InputFile{Location: location, Note: "file does not exist"}, nil
+		}
+	}
+
+	data, err := git(dir, "show", object)
+	if err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("failed to load %s: %w", location, err)
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return ytbx.InputFile{Location: location}, nil
+	}
+
+	return dyff.LoadFileContent(location, data)
+}
@@ -0,0 +1,223 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+const ociPrefix = "oci://"
+
+// isOCIReference returns whether the location refers to a Helm chart in an
+// OCI registry, for example oci://registry/charts/name:1.2.3
+func isOCIReference(location string) bool {
+	return strings.HasPrefix(location, ociPrefix)
+}
+
+// splitOCIReference splits an OCI reference into the chart reference and the
+// optional chart version, which is the tag of the reference. A digest (e.g.
+// `@sha256:...`) stays part of the chart reference.
+func splitOCIReference(location string) (chart string, version string) {
+	name, digest, hasDigest := strings.Cut(location, "@")
+
+	chart = name
+	if idx := strings.LastIndex(name, ":"); idx > strings.LastIndex(name, "/") {
+		chart, version = name[:idx], name[idx+1:]
+	}
+
+	if hasDigest {
+		chart += "@" + digest
+	}
+
+	return chart, version
+}
+
+// ociChartName returns the name of the chart of an OCI chart reference
+// without tag and digest, e.g. `app` for `oci://registry/charts/app@sha256:...`
+func ociChartName(chart string) string {
+	name, _, _ := strings.Cut(chart, "@")
+	return path.Base(name)
+}
+
+func helm(args ...string) ([]byte, error) {
+	if _, err := exec.LookPath("helm"); err != nil {
+		return nil, fmt.Errorf("the helm command is required to render or pull Helm charts: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("helm", args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run helm %s: %w\n%s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// loadOCIChart renders the templates of the referenced chart using its default
+// values and returns the rendered documents as an input file
+func loadOCIChart(location string) (ytbx.InputFile, error) {
+	chart, version := splitOCIReference(location)
+	return renderChart(location, helmTemplateArgs(ociChartName(chart), chart, version, nil))
+}
+
+// helmTemplateArgs returns the arguments of the helm template command to
+// render the chart using the provided release name, chart version, and any
+// additional arguments like values files
+func helmTemplateArgs(release string, chart string, version string, additional []string) []string {
+	args := []string{"template", release, chart}
+	if version != "" {
+		args = append(args, "--version", version)
+	}
+
+	return append(args, additional...)
+}
+
+// renderChart runs helm template with the provided arguments and returns the
+// rendered documents as an input file
+func renderChart(location string, args []string) (ytbx.InputFile, error) {
+	output, err := helm(args...)
+	if err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("failed to render chart %s: %w", location, err)
+	}
+
+	documents, err := ytbx.LoadYAMLDocuments(output)
+	if err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("failed to load rendered chart %s: %w", location, err)
+	}
+
+	return ytbx.InputFile{
+		Location:  location,
+		Note:      "rendered Helm chart",
+		Documents: documents,
+	}, nil
+}
+
+// loadOCIChartFiles pulls the referenced chart and returns the raw files of
+// the chart package as a file set
+func loadOCIChartFiles(location string) (dyff.FileSet, error) {
+	chart, version := splitOCIReference(location)
+
+	tmpDir, err := os.MkdirTemp("", "dyff-helm")
+	if err != nil {
+		return dyff.FileSet{}, err
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	args := []string{"pull", chart, "--destination", tmpDir}
+	if version != "" {
+		args = append(args, "--version", version)
+	}
+
+	if _, err := helm(args...); err != nil {
+		return dyff.FileSet{}, fmt.Errorf("failed to pull chart %s: %w", location, err)
+	}
+
+	packages, err := filepath.Glob(filepath.Join(tmpDir, "*.tgz"))
+	if err != nil || len(packages) != 1 {
+		return dyff.FileSet{}, fmt.Errorf("failed to pull chart %s: expected exactly one chart package", location)
+	}
+
+	fileSet, err := dyff.LoadArchive(packages[0])
+	if err != nil {
+		return dyff.FileSet{}, err
+	}
+
+	fileSet.Location = location
+	return fileSet, nil
+}
+
+// loadChartValues returns the default values (values.yaml) and the optional
+// values schema (values.schema.json) of a chart, which can either be a chart
+// directory, or a packaged chart
+func loadChartValues(location string) (defaults *yamlv3.Node, schema *yamlv3.Node, err error) {
+	var files = map[string]ytbx.InputFile{}
+	switch {
+	case dyff.IsDirectory(location):
+		for _, name := range []string{"values.yaml", "values.schema.json"} {
+			filename := filepath.Join(location, name)
+			if _, err := os.Stat(filename); err != nil {
+				continue
+			}
+
+			inputFile, err := dyff.LoadFile(filename)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to load %s of chart %s: %w", name, location, err)
+			}
+
+			files[name] = inputFile
+		}
+
+	case dyff.IsArchive(location):
+		fileSet, err := dyff.LoadArchive(location)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// only the files of the chart itself, not the ones of its subcharts
+		for name, inputFile := range fileSet.Files {
+			if parts := strings.Split(name, "/"); len(parts) == 2 {
+				files[parts[1]] = inputFile
+			}
+		}
+
+	default:
+		return nil, nil, fmt.Errorf("failed to load chart %s: expected a chart directory or a packaged chart", location)
+	}
+
+	valuesFile, ok := files["values.yaml"]
+	if !ok || len(valuesFile.Documents) == 0 {
+		return nil, nil, fmt.Errorf("failed to load chart %s: there is no values.yaml", location)
+	}
+
+	if schemaFile, ok := files["values.schema.json"]; ok && len(schemaFile.Documents) > 0 {
+		schema = schemaFile.Documents[0]
+	}
+
+	return valuesFile.Documents[0], schema, nil
+}
+
+// loadValuesSchema returns the JSON schema that is stored in the file
+func loadValuesSchema(location string) (*yamlv3.Node, error) {
+	inputFile, err := dyff.LoadFile(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load values schema from %s: %w", humanReadableFilename(location), err)
+	}
+
+	if len(inputFile.Documents) == 0 {
+		return nil, fmt.Errorf("failed to load values schema from %s: no schema found", humanReadableFilename(location))
+	}
+
+	return inputFile.Documents[0], nil
+}
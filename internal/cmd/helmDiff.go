@@ -0,0 +1,138 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+type helmDiffCmdOptions struct {
+	release     string
+	namespace   string
+	values      []string
+	fromValues  []string
+	toValues    []string
+	set         []string
+	fromVersion string
+	toVersion   string
+}
+
+const defaultReleaseName = "release"
+
+var helmDiffCmdSettings = helmDiffCmdOptions{release: defaultReleaseName}
+
+// helmDiffCmd represents the helm-diff command
+var helmDiffCmd = &cobra.Command{
+	Use:   "helm-diff [flags] <from-chart> [<to-chart>]",
+	Short: "Compare the rendered manifests of two Helm charts, or chart values",
+	Long: `
+Renders both charts using helm template (requires helm) and compares the
+rendered manifests. A chart can be anything helm template accepts: a chart
+directory, a packaged chart, a repository chart (repo/name), or an OCI reference
+(oci://registry/chart:1.2.3). The rendered resources are paired by their
+Kubernetes API version, kind, and name, so that the differences are grouped per
+rendered resource.
+
+With only one chart, the same chart is rendered twice, for example to compare
+the effect of two values files using --from-values and --to-values.
+`,
+	Args:    cobra.RangeArgs(1, 2),
+	Aliases: []string{"hd"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fromChart, toChart := args[0], args[0]
+		if len(args) == 2 {
+			toChart = args[1]
+		}
+
+		from, err := renderChart(fromChart, helmDiffArgs(fromChart, helmDiffCmdSettings.fromVersion, helmDiffCmdSettings.fromValues))
+		if err != nil {
+			return err
+		}
+
+		to, err := renderChart(toChart, helmDiffArgs(toChart, helmDiffCmdSettings.toVersion, helmDiffCmdSettings.toValues))
+		if err != nil {
+			return err
+		}
+
+		options, err := compareOptions()
+		if err != nil {
+			return err
+		}
+
+		report, err := dyff.CompareInputFiles(from, to, options...)
+		if err != nil {
+			return fmt.Errorf("failed to compare rendered charts: %w", err)
+		}
+
+		return writeReport(cmd, applyReportFilters(report))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(helmDiffCmd)
+
+	helmDiffCmd.Flags().SortFlags = false
+
+	applyReportOptionsFlags(helmDiffCmd)
+	applyExpectationFlags(helmDiffCmd)
+
+	// Helm template flags
+	helmDiffCmd.Flags().StringVar(&helmDiffCmdSettings.release, "release", defaultReleaseName, "release name used to render the charts")
+	helmDiffCmd.Flags().StringVar(&helmDiffCmdSettings.namespace, "namespace", "", "namespace used to render the charts")
+	helmDiffCmd.Flags().StringSliceVar(&helmDiffCmdSettings.values, "values", nil, "values files used to render both charts")
+	helmDiffCmd.Flags().StringSliceVar(&helmDiffCmdSettings.fromValues, "from-values", nil, "values files only used to render the from chart")
+	helmDiffCmd.Flags().StringSliceVar(&helmDiffCmdSettings.toValues, "to-values", nil, "values files only used to render the to chart")
+	helmDiffCmd.Flags().StringSliceVar(&helmDiffCmdSettings.set, "set", nil, "values used to render both charts, for example key=value")
+	helmDiffCmd.Flags().StringVar(&helmDiffCmdSettings.fromVersion, "from-version", "", "chart version of the from chart (defaults to the tag of an OCI reference)")
+	helmDiffCmd.Flags().StringVar(&helmDiffCmdSettings.toVersion, "to-version", "", "chart version of the to chart (defaults to the tag of an OCI reference)")
+}
+
+// helmDiffArgs returns the helm template arguments to render one side of the
+// comparison, the values files specific to that side take precedence over the
+// shared ones
+func helmDiffArgs(chart string, version string, values []string) []string {
+	if isOCIReference(chart) {
+		var tag string
+		if chart, tag = splitOCIReference(chart); version == "" {
+			version = tag
+		}
+	}
+
+	var additional []string
+	if helmDiffCmdSettings.namespace != "" {
+		additional = append(additional, "--namespace", helmDiffCmdSettings.namespace)
+	}
+
+	for _, filename := range append(append([]string{}, helmDiffCmdSettings.values...), values...) {
+		additional = append(additional, "--values", filename)
+	}
+
+	if len(helmDiffCmdSettings.set) > 0 {
+		additional = append(additional, "--set", strings.Join(helmDiffCmdSettings.set, ","))
+	}
+
+	return helmTemplateArgs(helmDiffCmdSettings.release, chart, version, additional)
+}
@@ -0,0 +1,258 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gonvenience/ytbx"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+// Defaults of the HTTP input flags
+const (
+	defaultHTTPTimeout = 30 * time.Second
+	defaultHTTPRetries = 2
+)
+
+// httpRetryDelay is the delay before the first retry of a failed request,
+// which is doubled for every subsequent retry
+var httpRetryDelay = 500 * time.Millisecond
+
+// isURL returns whether the location is an HTTP or HTTPS URL
+func isURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// loadURL loads the input file from the URL location using the headers,
+// credentials, and TLS settings of the between command flags. Credentials are
+// looked up in this order: the Authorization header, the bearer token from
+// the environment, the user info of the URL, and the netrc file.
+func loadURL(location string) (ytbx.InputFile, error) {
+	client, err := httpClient()
+	if err != nil {
+		return ytbx.InputFile{}, err
+	}
+
+	request, err := httpRequest(location)
+	if err != nil {
+		return ytbx.InputFile{}, err
+	}
+
+	var data []byte
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		data, retry, err = fetch(client, request)
+		if err == nil || !retry || attempt >= betweenCmdSettings.retries {
+			break
+		}
+
+		time.Sleep(httpRetryDelay << attempt)
+	}
+
+	if err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("failed to retrieve data from location %s: %w", location, err)
+	}
+
+	return dyff.LoadFileContent(location, data)
+}
+
+// fetch sends the request and returns the response body, or an error and
+// whether the request can be retried (network errors, or server errors)
+func fetch(client *http.Client, request *http.Request) ([]byte, bool, error) {
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, true, err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, true, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		retry := response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests
+		return nil, retry, fmt.Errorf("%s: %s", response.Status, strings.TrimSpace(string(data)))
+	}
+
+	return data, false, nil
+}
+
+func httpClient() (*http.Client, error) {
+	var tlsConfig tls.Config
+
+	switch {
+	case betweenCmdSettings.clientCert != "" && betweenCmdSettings.clientKey != "":
+		certificate, err := tls.LoadX509KeyPair(betweenCmdSettings.clientCert, betweenCmdSettings.clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+
+	case betweenCmdSettings.clientCert != "" || betweenCmdSettings.clientKey != "":
+		return nil, fmt.Errorf("incompatible flags: --client-cert and --client-key have to be used together")
+	}
+
+	if betweenCmdSettings.caCert != "" {
+		data, err := os.ReadFile(betweenCmdSettings.caCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CA certificate: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("failed to load CA certificate: %s does not contain a PEM encoded certificate", betweenCmdSettings.caCert)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tlsConfig
+
+	return &http.Client{Transport: transport, Timeout: betweenCmdSettings.timeout}, nil
+}
+
+func httpRequest(location string) (*http.Request, error) {
+	request, err := http.NewRequest(http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, header := range betweenCmdSettings.headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header %q, expected the format 'Name: value'", header)
+		}
+
+		request.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	if request.Header.Get("Authorization") != "" {
+		return request, nil
+	}
+
+	if name := betweenCmdSettings.bearerTokenEnv; name != "" {
+		token := os.Getenv(name)
+		if token == "" {
+			return nil, fmt.Errorf("environment variable %s with the bearer token is not set", name)
+		}
+
+		request.Header.Set("Authorization", "Bearer "+token)
+		return request, nil
+	}
+
+	if request.URL.User != nil {
+		return request, nil
+	}
+
+	if login, password, ok := netrcCredentials(request.URL); ok {
+		request.SetBasicAuth(login, password)
+	}
+
+	return request, nil
+}
+
+// netrcCredentials looks up the login and password for the host of the URL in
+// the netrc file, which is the file of the NETRC environment variable, or the
+// .netrc file in the home directory
+func netrcCredentials(location *url.URL) (string, string, bool) {
+	filename := os.Getenv("NETRC")
+	if filename == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", false
+		}
+
+		filename = filepath.Join(home, ".netrc")
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", "", false
+	}
+	defer file.Close()
+
+	var tokens []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); !strings.HasPrefix(line, "#") {
+			tokens = append(tokens, strings.Fields(line)...)
+		}
+	}
+
+	type entry struct{ login, password string }
+	var (
+		entries = map[string]*entry{}
+		current *entry
+	)
+
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine":
+			if i+1 < len(tokens) {
+				i++
+				current = &entry{}
+				entries[tokens[i]] = current
+			}
+
+		case "default":
+			current = &entry{}
+			entries[""] = current
+
+		case "login", "password":
+			if current != nil && i+1 < len(tokens) {
+				if tokens[i] == "login" {
+					current.login = tokens[i+1]
+				} else {
+					current.password = tokens[i+1]
+				}
+			}
+
+			i++
+		}
+	}
+
+	for _, machine := range []string{location.Hostname(), ""} {
+		if entry, ok := entries[machine]; ok {
+			return entry.login, entry.password, true
+		}
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,177 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gonvenience/bunt"
+	"github.com/gonvenience/term"
+	"github.com/gonvenience/text"
+	"github.com/mattn/go-isatty"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+// section is a group of differences that share the same top-level path
+type section struct {
+	name  string
+	diffs []dyff.Diff
+}
+
+// useInteractivePrompt returns whether the user should be asked which parts
+// of the report to show, which is only the case for huge reports in case both
+// standard input and output are connected to a terminal (or if forced)
+func useInteractivePrompt(report dyff.Report) bool {
+	switch strings.ToLower(reportOptions.interactive) {
+	case "always":
+		return true
+
+	case "never":
+		return false
+	}
+
+	return reportOptions.interactiveThreshold > 0 &&
+		len(report.Diffs) > reportOptions.interactiveThreshold &&
+		term.IsTerminal() &&
+		isatty.IsTerminal(os.Stdin.Fd())
+}
+
+// sectionsOf groups the differences of the report by their top-level path,
+// which is the document and the first path element
+func sectionsOf(report dyff.Report) []section {
+	var result []section
+	var lookup = map[string]int{}
+
+	for _, diff := range report.Diffs {
+		var name string
+		switch {
+		case diff.Path == nil:
+			name = "(file level)"
+
+		case len(diff.Path.PathElements) == 0:
+			name = fmt.Sprintf("(root level) (%s)", diff.Path.RootDescription())
+
+		default:
+			name = fmt.Sprintf("%s (%s)", diff.Path.PathElements[0].Name, diff.Path.RootDescription())
+			if diff.Path.PathElements[0].Name == "" {
+				name = fmt.Sprintf("%d (%s)", diff.Path.PathElements[0].Idx, diff.Path.RootDescription())
+			}
+		}
+
+		idx, ok := lookup[name]
+		if !ok {
+			idx = len(result)
+			lookup[name] = idx
+			result = append(result, section{name: name})
+		}
+
+		result[idx].diffs = append(result[idx].diffs, diff)
+	}
+
+	return result
+}
+
+// promptForSections prints a summary of the top-level sections of the report
+// and asks the user which sections to show, the returned report only contains
+// the differences of the selected sections
+func promptForSections(report dyff.Report, in io.Reader, out io.Writer) (dyff.Report, error) {
+	sections := sectionsOf(report)
+
+	fmt.Fprintln(out, bunt.Sprintf("The report contains *%s* in %s:",
+		text.Plural(len(report.Diffs), "difference"),
+		text.Plural(len(sections), "section"),
+	))
+
+	for i, section := range sections {
+		fmt.Fprintf(out, "  %3d) %s  %s\n", i+1, section.name, bunt.Sprintf("DimGray{(%s)}", text.Plural(len(section.diffs), "difference")))
+	}
+
+	fmt.Fprint(out, "Select sections to show (e.g. 1,3-5), or press enter to show all: ")
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return dyff.Report{}, fmt.Errorf("failed to read selection: %w", err)
+	}
+
+	selected, err := parseSelection(strings.TrimSpace(line), len(sections))
+	if err != nil {
+		return dyff.Report{}, err
+	}
+
+	if selected == nil {
+		return report, nil
+	}
+
+	result := dyff.Report{From: report.From, To: report.To}
+	for i, section := range sections {
+		if selected[i] {
+			result.Diffs = append(result.Diffs, section.diffs...)
+		}
+	}
+
+	return result, nil
+}
+
+// parseSelection parses a selection like `1,3-5` into the set of selected
+// (zero based) indices, an empty selection or `all` selects everything
+func parseSelection(input string, count int) (map[int]bool, error) {
+	if input == "" || strings.EqualFold(input, "all") {
+		return nil, nil
+	}
+
+	var parse = func(str string) (int, error) {
+		number, err := strconv.Atoi(strings.TrimSpace(str))
+		if err != nil || number < 1 || number > count {
+			return 0, fmt.Errorf("invalid selection %q, expected a number between 1 and %d", str, count)
+		}
+
+		return number - 1, nil
+	}
+
+	var result = map[int]bool{}
+	for _, part := range strings.Split(input, ",") {
+		start, end, isRange := strings.Cut(part, "-")
+
+		first, err := parse(start)
+		if err != nil {
+			return nil, err
+		}
+
+		last := first
+		if isRange {
+			if last, err = parse(end); err != nil {
+				return nil, err
+			}
+		}
+
+		for i := first; i <= last; i++ {
+			result[i] = true
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,159 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gonvenience/ytbx"
+	"github.com/spf13/cobra"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+type kubectlCmdOptions struct {
+	keepServerFields bool
+}
+
+var kubectlCmdSettings kubectlCmdOptions
+
+// serverManagedFields are the fields that are maintained by the API server and
+// which are not part of the configuration of a resource
+var serverManagedFields = []string{
+	"/metadata/managedFields",
+	"/metadata/generation",
+	"/metadata/resourceVersion",
+}
+
+// kubectlCmd represents the kubectl command
+var kubectlCmd = &cobra.Command{
+	Use:   "kubectl [flags] <live-directory> <merged-directory>",
+	Short: "Compare the live and merged resources of kubectl diff",
+	Long: `
+Compares the two directories that kubectl diff passes to the program configured
+in KUBECTL_EXTERNAL_DIFF, for example:
+
+  export KUBECTL_EXTERNAL_DIFF="dyff kubectl --omit-header"
+  kubectl diff [...]
+
+All files of both directories are loaded, regardless of their names, and the
+resources are paired by their API version, kind, and name. The fields that are
+maintained by the API server (metadata.managedFields, metadata.generation, and
+metadata.resourceVersion) are not compared, unless --keep-server-fields is used.
+
+The exit code is set the way kubectl expects it, unless --set-exit-code is
+provided explicitly: 0 means no differences, 1 differences detected, and 255 a
+program error.
+`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		live, err := loadKubectlDirectory(args[0], "live")
+		if err != nil {
+			return err
+		}
+
+		merged, err := loadKubectlDirectory(args[1], "merged")
+		if err != nil {
+			return err
+		}
+
+		if !cmd.Flags().Changed("set-exit-code") && !cmd.Flags().Changed("set-exit-status") {
+			reportOptions.exitWithCode = true
+		}
+
+		reportOptions.kubernetesEntityDetection = true
+		options, err := compareOptions()
+		if err != nil {
+			return err
+		}
+
+		report, err := dyff.CompareInputFiles(live, merged, options...)
+		if err != nil {
+			return fmt.Errorf("failed to compare input files: %w", err)
+		}
+
+		return writeReport(cmd, applyReportFilters(report))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(kubectlCmd)
+
+	kubectlCmd.Flags().SortFlags = false
+
+	applyReportOptionsFlags(kubectlCmd)
+
+	kubectlCmd.Flags().BoolVar(&kubectlCmdSettings.keepServerFields, "keep-server-fields", false, "compare the fields that are maintained by the API server, e.g. metadata.managedFields")
+}
+
+// loadKubectlDirectory loads the documents of all files in the directory that
+// kubectl created, which are named after the resources without a file
+// extension, as one input file
+func loadKubectlDirectory(location string, note string) (ytbx.InputFile, error) {
+	entries, err := os.ReadDir(location)
+	if err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("failed to read %s resources from %s: %w", note, location, err)
+	}
+
+	var result = ytbx.InputFile{Location: location, Note: note}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(location, entry.Name()))
+		if err != nil {
+			return ytbx.InputFile{}, fmt.Errorf("failed to read %s resources from %s: %w", note, location, err)
+		}
+
+		documents, err := dyff.LoadDocuments(data)
+		if err != nil {
+			return ytbx.InputFile{}, fmt.Errorf("failed to load %s resource %s: %w", note, entry.Name(), err)
+		}
+
+		for _, document := range documents {
+			if !kubectlCmdSettings.keepServerFields {
+				purgeServerManagedFields(document)
+			}
+
+			result.Documents = append(result.Documents, document)
+		}
+	}
+
+	return result, nil
+}
+
+func purgeServerManagedFields(document *yamlv3.Node) {
+	for _, path := range serverManagedFields {
+		deleteIfExists(document, path)
+	}
+}
+
+// deleteIfExists removes the path from the document, if it exists, since
+// ytbx.Delete removes the first entry of the parent map for unknown keys
+func deleteIfExists(document *yamlv3.Node, path string) {
+	if _, err := ytbx.Grab(document, path); err == nil {
+		_, _ = ytbx.Delete(document, path)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// processSubstitution matches the locations that shells use for process
+// substitution, e.g. `dyff between <(kubectl get ...) <(cat file.yaml)`
+var processSubstitution = regexp.MustCompile(`^/(dev|proc/self)/fd/[0-9]+$`)
+
+// inputLabel returns the label to be used for the input location in the report
+// instead of the location itself: the label provided on the command line, or
+// for process substitution, the command that writes into the pipe (if it can
+// be found using /proc)
+func inputLabel(location string, label string) (string, bool) {
+	if label != "" {
+		return label, true
+	}
+
+	if !processSubstitution.MatchString(location) {
+		return "", false
+	}
+
+	if command, ok := pipeWriterCommand(location); ok {
+		return "<(" + command + ")", true
+	}
+
+	return "", false
+}
+
+// pipeWriterCommand looks up the process that has the pipe of the provided
+// location as its standard output and returns its command line. Shells fork
+// themselves for compound commands, these forks are skipped, as well as the
+// child processes of the command (which inherit the pipe). In case there is
+// not exactly one such process, the command is unknown.
+func pipeWriterCommand(location string) (string, bool) {
+	target, err := os.Readlink(location)
+	if err != nil || !strings.HasPrefix(target, "pipe:") {
+		return "", false
+	}
+
+	stdouts, err := filepath.Glob("/proc/[0-9]*/fd/1")
+	if err != nil {
+		return "", false
+	}
+
+	var writers = map[string][]string{}
+	for _, stdout := range stdouts {
+		pid := filepath.Base(filepath.Dir(filepath.Dir(stdout)))
+		if pid == strconv.Itoa(os.Getpid()) {
+			continue
+		}
+
+		if link, err := os.Readlink(stdout); err != nil || link != target {
+			continue
+		}
+
+		args, ok := processCommandLine(pid)
+		if !ok {
+			continue
+		}
+
+		if parentArgs, ok := processCommandLine(parentPID(pid)); ok && slices.Equal(args, parentArgs) {
+			continue
+		}
+
+		writers[pid] = args
+	}
+
+	var candidates [][]string
+	for pid, args := range writers {
+		if _, ok := writers[parentPID(pid)]; !ok {
+			candidates = append(candidates, args)
+		}
+	}
+
+	if len(candidates) != 1 {
+		return "", false
+	}
+
+	args := candidates[0]
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'$;|&<>()") {
+			args[i] = strconv.Quote(arg)
+		}
+	}
+
+	return strings.Join(args, " "), true
+}
+
+func processCommandLine(pid string) ([]string, bool) {
+	data, err := os.ReadFile(filepath.Join("/proc", pid, "cmdline"))
+	if err != nil || len(data) == 0 {
+		return nil, false
+	}
+
+	return strings.Split(strings.TrimRight(string(data), "\x00"), "\x00"), true
+}
+
+// parentPID returns the parent process ID, which is the second field after
+// the command name (in parentheses) in /proc/<pid>/stat
+func parentPID(pid string) string {
+	data, err := os.ReadFile(filepath.Join("/proc", pid, "stat"))
+	if err != nil {
+		return ""
+	}
+
+	stat := string(data)
+	if idx := strings.LastIndex(stat, ")"); idx >= 0 {
+		if fields := strings.Fields(stat[idx+1:]); len(fields) > 1 {
+			return fields[1]
+		}
+	}
+
+	return ""
+}
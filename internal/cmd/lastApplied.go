@@ -42,7 +42,7 @@ to compare it against the current configuration.
 	Args:    cobra.ExactArgs(1),
 	Aliases: []string{"la"},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		inputFile, err := ytbx.LoadFile(args[0])
+		inputFile, err := dyff.LoadFile(args[0])
 		if err != nil {
 			return err
 		}
@@ -81,7 +81,7 @@ func lookUpLastAppliedConfiguration(inputFile ytbx.InputFile) (ytbx.InputFile, e
 		return ytbx.InputFile{}, fmt.Errorf("provided input file does not contain the last applied configuration metadata")
 	}
 
-	documents, err := ytbx.LoadDocuments([]byte(kubectlLastApplied.Value))
+	documents, err := dyff.LoadDocuments([]byte(kubectlLastApplied.Value))
 	if err != nil {
 		return ytbx.InputFile{}, err
 	}
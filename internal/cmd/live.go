@@ -0,0 +1,326 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	"github.com/spf13/cobra"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+type liveCmdOptions struct {
+	kubeconfig       string
+	context          string
+	namespace        string
+	keepServerFields bool
+	serverDryRun     bool
+}
+
+var liveCmdSettings liveCmdOptions
+
+// lastAppliedAnnotationPath is the path of the annotation that kubectl apply
+// uses to store the previously applied configuration of a resource
+const lastAppliedAnnotationPath = "/metadata/annotations/kubectl.kubernetes.io\\/last-applied-configuration"
+
+// liveServerFields are the fields of live resources that are maintained by
+// the API server, in addition to the ones of the kubectl command
+var liveServerFields = append([]string{
+	"/metadata/uid",
+	"/metadata/creationTimestamp",
+	"/metadata/selfLink",
+	"/status",
+}, serverManagedFields...)
+
+// liveCmd represents the live command
+var liveCmd = &cobra.Command{
+	Use:   "live [flags] <file>",
+	Short: "Compare local Kubernetes manifests with the live resources of a cluster",
+	Long: `
+Compares the Kubernetes resources of a local file with the live resources of
+the cluster, which are fetched using kubectl (kubectl get), for example:
+
+  dyff live --context staging deployment.yml
+
+The kubectl command needs to be installed and available in the PATH, since it
+is used to access the cluster. The kubeconfig and credentials of kubectl are
+used, unless --kubeconfig or --context are provided.
+
+The resources are paired by their API version, kind, namespace, and name. The
+namespace of live resources is not compared, if the local resource does not
+specify it. Resources that do not exist in the cluster are reported as added.
+The fields that are maintained by the API server (e.g. metadata.managedFields,
+metadata.uid, or status) and the last applied configuration annotation are not
+compared, unless --keep-server-fields is used.
+
+With --server-dry-run, the local resources are submitted to the cluster using
+a server-side apply in dry-run mode (kubectl apply --server-side
+--dry-run=server), and the returned resources are compared instead of the local
+ones. They include the defaults and the changes of mutating admission webhooks,
+which plain file comparison misses. Invalid resources are reported as errors.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := lookupKubectl(); err != nil {
+			return err
+		}
+
+		local, err := dyff.LoadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to load input file: %w", err)
+		}
+
+		resources, err := kubernetesResources(local)
+		if err != nil {
+			return err
+		}
+
+		live, err := loadLiveResources(local, resources)
+		if err != nil {
+			return err
+		}
+
+		if liveCmdSettings.serverDryRun {
+			if local, err = loadDryRunResources(local, resources); err != nil {
+				return err
+			}
+		}
+
+		reportOptions.kubernetesEntityDetection = true
+		options, err := compareOptions()
+		if err != nil {
+			return err
+		}
+
+		report, err := dyff.CompareInputFiles(live, local, options...)
+		if err != nil {
+			return fmt.Errorf("failed to compare input files: %w", err)
+		}
+
+		return writeReport(cmd, applyReportFilters(report))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(liveCmd)
+
+	liveCmd.Flags().SortFlags = false
+
+	applyReportOptionsFlags(liveCmd)
+
+	liveCmd.Flags().StringVar(&liveCmdSettings.kubeconfig, "kubeconfig", "", "kubeconfig file to use instead of the default one of kubectl")
+	liveCmd.Flags().StringVar(&liveCmdSettings.context, "context", "", "kubeconfig context to use instead of the current one")
+	liveCmd.Flags().StringVar(&liveCmdSettings.namespace, "namespace", "", "namespace of the resources that do not specify one, instead of the one of the context")
+	liveCmd.Flags().BoolVar(&liveCmdSettings.serverDryRun, "server-dry-run", false, "compare the resources that a server-side dry-run apply returns, including defaults and changes of admission webhooks")
+	liveCmd.Flags().BoolVar(&liveCmdSettings.keepServerFields, "keep-server-fields", false, "compare the fields that are maintained by the API server, e.g. metadata.managedFields or status")
+}
+
+// lookupKubectl checks that the kubectl command, which is used to access the
+// cluster, is available
+func lookupKubectl() error {
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		return fmt.Errorf("the live command requires kubectl to access the cluster, but it could not be found in the PATH: %w", err)
+	}
+
+	return nil
+}
+
+func kubectl(stdin []byte, args ...string) ([]byte, error) {
+	if err := lookupKubectl(); err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("kubectl", args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(stdin), &stdout, &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run kubectl %s: %w\n%s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// kubernetesResources returns the documents of the input file that are
+// Kubernetes resources as a YAML stream
+func kubernetesResources(local ytbx.InputFile) ([]byte, error) {
+	var resources []*yamlv3.Node
+	for _, document := range local.Documents {
+		if _, ok := resourceName(document); ok {
+			resources = append(resources, document)
+		}
+	}
+
+	if len(resources) == 0 {
+		return nil, fmt.Errorf("%s does not contain any Kubernetes resources", local.Location)
+	}
+
+	var buf bytes.Buffer
+	encoder := yamlv3.NewEncoder(&buf)
+	for _, resource := range resources {
+		if err := encoder.Encode(resource); err != nil {
+			return nil, fmt.Errorf("failed to prepare resources of %s: %w", local.Location, err)
+		}
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to prepare resources of %s: %w", local.Location, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// loadLiveResources fetches the live version of the resources of the local
+// input file, resources that do not exist in the cluster are skipped
+func loadLiveResources(local ytbx.InputFile, resources []byte) (ytbx.InputFile, error) {
+	documents, err := kubectlResources(resources, "get", "--filename", "-", "--output", "yaml", "--ignore-not-found")
+	if err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("failed to fetch live resources: %w", err)
+	}
+
+	return ytbx.InputFile{
+		Location:  "cluster",
+		Note:      "live",
+		Documents: normalizeResources(documents, local),
+	}, nil
+}
+
+// loadDryRunResources applies the resources of the local input file using a
+// server-side dry-run and returns the resources the way the cluster would
+// store them
+func loadDryRunResources(local ytbx.InputFile, resources []byte) (ytbx.InputFile, error) {
+	documents, err := kubectlResources(resources, "apply", "--server-side", "--dry-run=server", "--filename", "-", "--output", "yaml")
+	if err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("failed to apply resources using server-side dry-run: %w", err)
+	}
+
+	return ytbx.InputFile{
+		Location:  local.Location,
+		Note:      "server-side dry-run",
+		Documents: normalizeResources(documents, local),
+	}, nil
+}
+
+// kubectlResources runs kubectl with the provided resources as input and
+// returns the resources of its output, more than one resource is returned
+// by kubectl as a list of items
+func kubectlResources(resources []byte, args ...string) ([]*yamlv3.Node, error) {
+	for _, flag := range []struct{ name, value string }{
+		{"--kubeconfig", liveCmdSettings.kubeconfig},
+		{"--context", liveCmdSettings.context},
+		{"--namespace", liveCmdSettings.namespace},
+	} {
+		if flag.value != "" {
+			args = append(args, flag.name, flag.value)
+		}
+	}
+
+	output, err := kubectl(resources, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := ytbx.LoadYAMLDocuments(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load resources: %w", err)
+	}
+
+	var result []*yamlv3.Node
+	for _, document := range documents {
+		if kind, err := ytbx.Grab(document, "/kind"); err == nil && kind.Value == "List" {
+			if items, err := ytbx.Grab(document, "/items"); err == nil {
+				for _, item := range items.Content {
+					result = append(result, &yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{item}})
+				}
+			}
+
+			continue
+		}
+
+		if _, ok := resourceName(document); ok {
+			result = append(result, document)
+		}
+	}
+
+	return result, nil
+}
+
+// normalizeResources removes the fields of the resources returned by the
+// cluster that are not part of the local resources: the namespace (if the
+// local resource does not specify it) and the fields maintained by the API
+// server (unless they should be kept)
+func normalizeResources(documents []*yamlv3.Node, local ytbx.InputFile) []*yamlv3.Node {
+	var withoutNamespace = map[string]bool{}
+	for _, document := range local.Documents {
+		if name, ok := resourceName(document); ok {
+			if _, err := ytbx.Grab(document, "/metadata/namespace"); err != nil {
+				withoutNamespace[name] = true
+			}
+		}
+	}
+
+	for _, document := range documents {
+		if name, _ := resourceName(document); withoutNamespace[name] {
+			deleteIfExists(document, "/metadata/namespace")
+		}
+
+		if !liveCmdSettings.keepServerFields {
+			purgeLiveServerFields(document)
+		}
+	}
+
+	return documents
+}
+
+// resourceName returns the API version, kind, and name of the resource, and
+// whether the document is a Kubernetes resource at all
+func resourceName(document *yamlv3.Node) (string, bool) {
+	var elements []string
+	for _, path := range []string{"/apiVersion", "/kind", "/metadata/name"} {
+		node, err := ytbx.Grab(document, path)
+		if err != nil || node.Kind != yamlv3.ScalarNode {
+			return "", false
+		}
+
+		elements = append(elements, node.Value)
+	}
+
+	return strings.Join(elements, "/"), true
+}
+
+func purgeLiveServerFields(document *yamlv3.Node) {
+	for _, path := range liveServerFields {
+		deleteIfExists(document, path)
+	}
+
+	// The annotations are removed as a whole, if the last applied configuration
+	// was the only one, since local resources usually do not have any
+	deleteIfExists(document, lastAppliedAnnotationPath)
+	if annotations, err := ytbx.Grab(document, "/metadata/annotations"); err == nil && len(annotations.Content) == 0 {
+		deleteIfExists(document, "/metadata/annotations")
+	}
+}
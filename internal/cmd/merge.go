@@ -0,0 +1,126 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gonvenience/bunt"
+	"github.com/gonvenience/ytbx"
+	"github.com/spf13/cobra"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+type mergeCmdOptions struct {
+	listChanges bool
+}
+
+var mergeCmdSettings mergeCmdOptions
+
+// mergeCmd represents the merge command
+var mergeCmd = &cobra.Command{
+	Use:   "merge [flags] <base> <ours> <theirs>",
+	Short: "Merge two modified versions of a file using their common base",
+	Long: `
+Compares two modified versions (ours and theirs) with their common base and
+classifies each change as made in ours, in theirs, in both, or as conflicting.
+Unless there are conflicts, the merged documents are written to standard output.
+In case of conflicts, the conflicting paths are listed and the command fails.
+
+With --changes, the list of classified changes is shown instead.
+`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var inputFiles = make([]ytbx.InputFile, len(args))
+		for i, location := range args {
+			inputFile, err := dyff.LoadFile(location)
+			if err != nil {
+				return fmt.Errorf("failed to load input file from %s: %w", humanReadableFilename(location), err)
+			}
+
+			inputFiles[i] = inputFile
+		}
+
+		options, err := compareOptions()
+		if err != nil {
+			return err
+		}
+
+		report, err := dyff.CompareThreeWay(inputFiles[0], inputFiles[1], inputFiles[2], options...)
+		if err != nil {
+			return fmt.Errorf("failed to compare input files: %w", err)
+		}
+
+		if mergeCmdSettings.listChanges {
+			for _, change := range report.Changes {
+				fmt.Println(threeWayChangeLine(change))
+			}
+
+			return nil
+		}
+
+		documents, err := report.Merge()
+		if err != nil {
+			var conflictErr *dyff.MergeConflictError
+			if errors.As(err, &conflictErr) {
+				for _, change := range conflictErr.Conflicts {
+					fmt.Println(threeWayChangeLine(change))
+				}
+
+				return errorWithExitCode{value: 1, cause: err}
+			}
+
+			return err
+		}
+
+		return writeYAMLDocuments(os.Stdout, documents)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+
+	mergeCmd.Flags().SortFlags = false
+
+	applyCompareOptionsFlags(mergeCmd)
+	mergeCmd.Flags().BoolVar(&mergeCmdSettings.listChanges, "changes", false, "list the classified changes instead of writing the merged documents")
+}
+
+func threeWayChangeLine(change dyff.ThreeWayChange) string {
+	var path = "(file level)"
+	if p := change.Path(); p != nil {
+		path = fmt.Sprintf("%s  (%s)", p.String(), p.RootDescription())
+	}
+
+	var origin = string(change.Origin)
+	if change.Origin == dyff.ChangeConflict {
+		origin = bunt.Sprintf("Red{%s}", origin)
+	}
+
+	return fmt.Sprintf("%s%s  %s", origin, spaces(len(dyff.ChangeConflict)-len(change.Origin)), path)
+}
+
+func spaces(n int) string {
+	return fmt.Sprintf("%*s", n, "")
+}
@@ -0,0 +1,160 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/gonvenience/bunt"
+	"github.com/gonvenience/ytbx"
+	"github.com/spf13/cobra"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+// planFlags are the flags that are listed in the comparison plan, grouped by
+// the stage of the comparison in which they are used
+var planFlags = []struct {
+	title string
+	names []string
+	all   bool
+}{
+	{
+		title: "input modifications",
+		names: []string{"swap", "chroot", "chroot-of-from", "chroot-of-to", "chroot-list-to-documents", "project", "include-files", "exclude-files", "allow-missing-file"},
+	},
+	{
+		title: "compare options",
+		names: []string{"ignore-order-changes", "ignore-order-changes-at", "exclude-subtree", "scope", "ignore-whitespace-changes", "ignore-number-format-changes", "ignore-block-scalar-style-changes", "detect-kubernetes", "additional-identifier", "composite-identifier", "null-equivalent", "custom-tags", "list-diff-strategy", "node-hashing", "detect-moves", "normalize-line-endings", "normalize-scripts", "compare-directives", "preset", "chart", "values-schema", "suppression-comments", "follow-refs", "follow-external-refs"},
+		all:   true,
+	},
+	{
+		title: "report filters",
+		names: []string{"filter", "exclude", "filter-regexp", "exclude-regexp", "exclude-document", "ignore-value-changes", "ignore-new-documents", "ignore-removed-documents"},
+	},
+}
+
+// writePlan prints which documents (or files) are paired, and which options
+// and filters apply, without performing the comparison
+func writePlan(cmd *cobra.Command, out io.Writer, fromLocation, toLocation string) error {
+	writer := bufio.NewWriter(out)
+	defer writer.Flush()
+
+	_, _ = fmt.Fprintf(writer, "comparison plan for %s and %s\n",
+		humanReadableFilename(fromLocation),
+		humanReadableFilename(toLocation),
+	)
+
+	var retained, removed, added []string
+	var title string
+	if isFileSet(fromLocation) || isFileSet(toLocation) {
+		from, err := loadFileSet(fromLocation)
+		if err != nil {
+			return fmt.Errorf("failed to load input from %s: %w", humanReadableFilename(fromLocation), err)
+		}
+
+		to, err := loadFileSet(toLocation)
+		if err != nil {
+			return fmt.Errorf("failed to load input from %s: %w", humanReadableFilename(toLocation), err)
+		}
+
+		title = "files"
+		removed, added = from.Difference(to), to.Difference(from)
+		for _, path := range from.Paths() {
+			if _, ok := to.Files[path]; ok {
+				retained = append(retained, path)
+			}
+		}
+
+	} else {
+		from, to, err := loadInputFiles(fromLocation, toLocation)
+		if err != nil {
+			return fmt.Errorf("failed to load input files: %w", err)
+		}
+
+		title = "documents"
+		inventory := planInventory(from, to)
+		retained, removed, added = inventory.Retained, inventory.Removed, inventory.Added
+	}
+
+	_, _ = fmt.Fprintf(writer, "\n%s:\n", title)
+	for _, entry := range []struct {
+		names  []string
+		prefix string
+		note   string
+	}{
+		{retained, "=", "compared"},
+		{removed, "-", "only in from"},
+		{added, "+", "only in to"},
+	} {
+		for _, name := range entry.names {
+			_, _ = fmt.Fprintf(writer, "  %s %s %s\n", entry.prefix, name, bunt.Sprintf("DimGray{(%s)}", entry.note))
+		}
+	}
+
+	for _, group := range planFlags {
+		_, _ = fmt.Fprintf(writer, "\n%s:\n", group.title)
+
+		var listed bool
+		for _, name := range group.names {
+			flag := cmd.Flags().Lookup(name)
+			if flag == nil || (!group.all && !flag.Changed) {
+				continue
+			}
+
+			listed = true
+			_, _ = fmt.Fprintf(writer, "  %s: %s\n", flag.Name, flag.Value.String())
+		}
+
+		if !listed {
+			_, _ = fmt.Fprintf(writer, "  none\n")
+		}
+	}
+
+	return nil
+}
+
+// planInventory pairs the documents the same way the comparison does, that
+// is by name for Kubernetes resources, otherwise by position
+func planInventory(from, to ytbx.InputFile) dyff.DocumentInventory {
+	if reportOptions.kubernetesEntityDetection {
+		return dyff.CompareInventory(from, to)
+	}
+
+	var result = dyff.DocumentInventory{From: from, To: to}
+	for i := 0; i < max(len(from.Documents), len(to.Documents)); i++ {
+		name := fmt.Sprintf("document #%d", i+1)
+		switch {
+		case i >= len(to.Documents):
+			result.Removed = append(result.Removed, name)
+
+		case i >= len(from.Documents):
+			result.Added = append(result.Added, name)
+
+		default:
+			result.Retained = append(result.Retained, name)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,133 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+
+	"github.com/spf13/cobra"
+)
+
+type profileOptions struct {
+	cpuprofile string
+	memprofile string
+	trace      string
+}
+
+var profileSettings profileOptions
+
+// stopProfiling finishes the profiles started by startProfiling, it is a no-op
+// in case no profiling is active
+var stopProfiling = func() error { return nil }
+
+// startProfiling starts the CPU profile and execution trace if configured,
+// the memory profile is written when profiling is stopped
+func startProfiling() error {
+	var closers []func() error
+
+	stop := func() error {
+		var result error
+		for i := len(closers) - 1; i >= 0; i-- {
+			if err := closers[i](); err != nil && result == nil {
+				result = err
+			}
+		}
+
+		closers = nil
+		return result
+	}
+
+	if profileSettings.cpuprofile != "" {
+		file, err := os.Create(profileSettings.cpuprofile)
+		if err != nil {
+			return fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+
+		if err := pprof.StartCPUProfile(file); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+
+		closers = append(closers, func() error {
+			pprof.StopCPUProfile()
+			return file.Close()
+		})
+	}
+
+	if profileSettings.trace != "" {
+		file, err := os.Create(profileSettings.trace)
+		if err != nil {
+			_ = stop()
+			return fmt.Errorf("failed to create execution trace: %w", err)
+		}
+
+		if err := trace.Start(file); err != nil {
+			file.Close()
+			_ = stop()
+			return fmt.Errorf("failed to start execution trace: %w", err)
+		}
+
+		closers = append(closers, func() error {
+			trace.Stop()
+			return file.Close()
+		})
+	}
+
+	if profileSettings.memprofile != "" {
+		path := profileSettings.memprofile
+		closers = append(closers, func() error {
+			file, err := os.Create(path)
+			if err != nil {
+				return fmt.Errorf("failed to create memory profile: %w", err)
+			}
+			defer file.Close()
+
+			// get up-to-date statistics of the allocations of this run
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(file); err != nil {
+				return fmt.Errorf("failed to write memory profile: %w", err)
+			}
+
+			return nil
+		})
+	}
+
+	stopProfiling = stop
+	return nil
+}
+
+func init() {
+	rootCmd.PersistentPreRunE = func(_ *cobra.Command, _ []string) error {
+		return startProfiling()
+	}
+
+	rootCmd.PersistentFlags().StringVar(&profileSettings.cpuprofile, "cpuprofile", "", "write a Go CPU profile of the run to the provided file")
+	rootCmd.PersistentFlags().StringVar(&profileSettings.memprofile, "memprofile", "", "write a Go memory profile of the run to the provided file")
+	rootCmd.PersistentFlags().StringVar(&profileSettings.trace, "trace", "", "write a Go execution trace of the run to the provided file")
+
+	for _, flag := range []string{"cpuprofile", "memprofile", "trace"} {
+		_ = rootCmd.PersistentFlags().MarkHidden(flag)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gonvenience/ytbx"
+	"github.com/spf13/cobra"
+)
+
+type propertiesCmdOptions struct {
+	restructure bool
+	sortKeys    bool
+	inplace     bool
+}
+
+var propertiesCmdSettings propertiesCmdOptions
+
+// propertiesCmd represents the properties command
+var propertiesCmd = &cobra.Command{
+	Use:   "properties [flags] <file-location> ...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "Converts input documents into Java properties format",
+	Long: `
+Converts input document into Java properties format while preserving the order
+of all keys. Nested maps and lists are flattened into keys like server.port or
+hosts[0], null values are written as empty values. Since properties files only
+contain one document, multiple documents cannot be converted.
+`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		writer := &OutputWriter{
+			OutputStyle: "properties",
+			Restructure: propertiesCmdSettings.restructure,
+			SortKeys:    propertiesCmdSettings.sortKeys,
+		}
+
+		var errs []error
+		for _, filename := range args {
+			if ytbx.IsStdin(filename) && propertiesCmdSettings.inplace {
+				return fmt.Errorf("incompatible flags: %w", fmt.Errorf("cannot use in-place flag in combination with input from STDIN"))
+			}
+
+			if propertiesCmdSettings.inplace {
+				if err := writer.WriteInplace(filename); err != nil {
+					errs = append(errs, err)
+				}
+			} else {
+				if err := writer.WriteToStdout(filename); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+
+		if len(errs) > 0 {
+			return fmt.Errorf("failed to process input files: %w", errors.Join(errs...))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(propertiesCmd)
+
+	propertiesCmd.Flags().SortFlags = false
+
+	propertiesCmd.Flags().BoolVarP(&propertiesCmdSettings.restructure, "restructure", "r", false, "restructure map keys in reasonable order")
+	propertiesCmd.Flags().BoolVar(&propertiesCmdSettings.sortKeys, "sort-keys", false, "sort map keys alphabetically instead of keeping the original order")
+	propertiesCmd.Flags().BoolVarP(&propertiesCmdSettings.inplace, "in-place", "i", false, "overwrite input file with output of this command")
+}
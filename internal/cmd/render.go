@@ -0,0 +1,99 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gonvenience/ytbx"
+	"github.com/spf13/cobra"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+type renderCmdOptions struct {
+	redactValues bool
+}
+
+var renderCmdSettings renderCmdOptions
+
+// renderCmd represents the render command
+var renderCmd = &cobra.Command{
+	Use:   "render [flags] <report>",
+	Short: "Render a previously saved report",
+	Long: `
+Renders a report that was previously saved using the json or yaml output style
+of the between command. This decouples the comparison of the input files from
+the presentation of the differences, the report can be rendered using any of
+the supported output styles and filters.
+
+With --redact-values, all values are replaced with placeholders of the same type
+(strings become "…", numbers 0), while the paths and the structure of the
+changes are kept. Use it with the json or yaml output style to share a report
+without leaking its data.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		report, err := loadReport(args[0])
+		if err != nil {
+			return err
+		}
+
+		report = applyReportFilters(report)
+		if renderCmdSettings.redactValues {
+			report = report.RedactValues()
+		}
+
+		return writeReport(cmd, report)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(renderCmd)
+
+	renderCmd.Flags().SortFlags = false
+
+	applyRenderOptionsFlags(renderCmd)
+
+	renderCmd.Flags().BoolVar(&renderCmdSettings.redactValues, "redact-values", false, "replace all values with placeholders of the same type, only the structure of the changes is kept")
+}
+
+func loadReport(location string) (dyff.Report, error) {
+	var in io.Reader = os.Stdin
+	if !ytbx.IsStdin(location) {
+		file, err := os.Open(location)
+		if err != nil {
+			return dyff.Report{}, fmt.Errorf("failed to open report %s: %w", humanReadableFilename(location), err)
+		}
+		defer file.Close()
+
+		in = file
+	}
+
+	report, err := dyff.LoadReport(in)
+	if err != nil {
+		return dyff.Report{}, fmt.Errorf("failed to load report from %s: %w", humanReadableFilename(location), err)
+	}
+
+	return report, nil
+}
@@ -23,7 +23,6 @@ package cmd
 import (
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/gonvenience/bunt"
 	"github.com/gonvenience/term"
@@ -87,26 +86,28 @@ is preserved during the conversion.
 // the test suite to make sure that the flag parsing works correctly.
 func ResetSettings() {
 	reportOptions = defaults
-	betweenCmdSettings = betweenCmdOptions{}
+	betweenCmdSettings = betweenCmdOptions{
+		jobs:           1,
+		stdinSeparator: defaultStdinSeparator,
+		retries:        defaultHTTPRetries,
+		timeout:        defaultHTTPTimeout,
+	}
 	yamlCmdSettings = yamlCmdOptions{}
 	jsonCmdSettings = jsonCmdOptions{}
-}
-
-// rearrange will rearrange the OS args to match `dyff between --flags from to`
-// to mitigate an issue in `kubectl`, which puts the `from` and `to` at the
-// second and third position in the command arguments.
-func rearrange() []string {
-	var paths, args []string
-	for _, entry := range os.Args {
-		if info, err := os.Stat(entry); err == nil && info.IsDir() {
-			paths = append(paths, entry)
-
-		} else {
-			args = append(args, entry)
-		}
-	}
-
-	return append(args, paths...)
+	tomlCmdSettings = tomlCmdOptions{}
+	iniCmdSettings = iniCmdOptions{}
+	propertiesCmdSettings = propertiesCmdOptions{}
+	mergeCmdSettings = mergeCmdOptions{}
+	applyCmdSettings = applyCmdOptions{}
+	helmDiffCmdSettings = helmDiffCmdOptions{release: defaultReleaseName}
+	kubectlCmdSettings = kubectlCmdOptions{}
+	liveCmdSettings = liveCmdOptions{}
+	renderCmdSettings = renderCmdOptions{}
+	watchCmdSettings = watchCmdOptions{delay: defaultWatchDelay}
+	versionCmdSettings = versionCmdOptions{}
+	inputProvenance.from, inputProvenance.to = nil, nil
+	inputContent = map[string][]byte{}
+	profileSettings = profileOptions{}
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -114,10 +115,10 @@ func rearrange() []string {
 func Execute() error {
 	// In case `KUBECTL_EXTERNAL_DIFF` is set with `dyff`, it is very likely
 	// that `kubectl` intends to use `dyff` for its `diff` command. Therefore,
-	// enable Kubernetes specific entity detection and fix the order issue.
-	if strings.Contains(os.Getenv("KUBECTL_EXTERNAL_DIFF"), name) {
+	// run in diff driver mode, see driver.go for details.
+	if isDiffDriverMode() {
 		// Make sure the OS args are in a supported order
-		os.Args = rearrange()
+		os.Args = rearrange(os.Args)
 
 		// Enable Kubernetes specific entity detection implicitly
 		reportOptions.kubernetesEntityDetection = true
@@ -129,7 +130,33 @@ func Execute() error {
 		reportOptions.excludeRegexps = append(reportOptions.excludeRegexps, "^/metadata/managedFields")
 	}
 
-	if err := rootCmd.Execute(); err != nil {
+	// Add the flags set using environment variables (e.g. DYFF_OUTPUT)
+	os.Args = withFlagEnvironment(os.Args)
+
+	// Add the default flags of the DYFF_DEFAULTS environment variable
+	args, err := withDefaultOptions(os.Args)
+	if err != nil {
+		return errorWithExitCode{value: 255, cause: err}
+	}
+
+	os.Args = args
+
+	// Add the default flags of the configuration file (.dyff.yml)
+	args, err = withSettingsFile(os.Args)
+	if err != nil {
+		return errorWithExitCode{value: 255, cause: err}
+	}
+
+	os.Args = args
+
+	err = rootCmd.Execute()
+
+	// Finish the profiles of the run (if any), even if the command failed
+	if stopErr := stopProfiling(); err == nil {
+		err = stopErr
+	}
+
+	if err != nil {
 		// Special case ExitCode, which means that we will exit immediately
 		// with the given exit code
 		if _, ok := err.(errorWithExitCode); ok {
@@ -153,5 +180,6 @@ func init() {
 	rootCmd.PersistentFlags().VarP(&bunt.ColorSetting, "color", "c", "specify color usage: on, off, or auto")
 	rootCmd.PersistentFlags().VarP(&bunt.TrueColorSetting, "truecolor", "t", "specify true color usage: on, off, or auto")
 	rootCmd.PersistentFlags().IntVarP(&term.FixedTerminalWidth, "fixed-width", "w", -1, "disable terminal width detection and use provided fixed value")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "configuration file with default flags, by default .dyff.yml in the current directory or its parents is used (use --config= to not use any)")
 	rootCmd.PersistentFlags().BoolVarP(&ytbx.PreserveKeyOrderInJSON, "preserve-key-order-in-json", "k", false, "use ordered keys during JSON decoding (non standard behavior)")
 }
@@ -0,0 +1,127 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+// configFile is only used to document the config flag, the flag is looked up
+// before the arguments are parsed, since the configuration file contains flags
+var configFile string
+
+// settingsFileFlags are the flags that can be set in a configuration file,
+// which is looked up in the parent directories and therefore might come from
+// an untrusted checkout: only compare, filter, and output style flags are
+// supported, but no flags that read or write other files, access the network,
+// or change the exit code
+var settingsFileFlags = map[string]struct{}{
+	// compare options
+	"ignore-order-changes": {}, "ignore-order-changes-at": {}, "exclude-subtree": {}, "scope": {},
+	"ignore-whitespace-changes": {}, "ignore-number-format-changes": {},
+	"ignore-block-scalar-style-changes": {}, "detect-kubernetes": {},
+	"additional-identifier": {}, "composite-identifier": {}, "null-equivalent": {},
+	"custom-tags": {}, "list-diff-strategy": {}, "node-hashing": {}, "detect-moves": {},
+	"normalize-line-endings": {}, "normalize-scripts": {}, "preset": {},
+	"compare-directives": {}, "suppression-comments": {},
+
+	// report filters
+	"filter": {}, "exclude": {}, "filter-regexp": {}, "exclude-regexp": {},
+	"exclude-document": {}, "ignore-value-changes": {}, "ignore-new-documents": {},
+	"ignore-removed-documents": {},
+
+	// output style
+	"output": {}, "sort-keys": {}, "print-fingerprint": {}, "detailed": {},
+	"context-lines": {}, "omit-header": {}, "header": {}, "show-context-keys": {},
+	"group-by-kind": {}, "version-summary": {}, "summary": {}, "bidirectional": {},
+	"relative-to": {}, "show-values-of-added-documents": {}, "no-table-style": {},
+	"no-cert-inspection": {}, "no-binary-hexdump": {}, "use-go-patch-style": {},
+	"path-style": {}, "color": {}, "truecolor": {}, "fixed-width": {},
+}
+
+// checkSettingsFileFlags returns an error if the configuration file contains
+// a flag that is not supported in configuration files, see settingsFileFlags
+func checkSettingsFileFlags(settings dyff.Settings) error {
+	for _, flag := range settings.Flags {
+		name, _, _ := strings.Cut(strings.TrimPrefix(flag, "--"), "=")
+		if _, ok := settingsFileFlags[name]; !ok {
+			return fmt.Errorf("failed to use settings of %s: flag %s is not supported in configuration files, only compare, filter, and output style flags are", settings.Location, name)
+		}
+	}
+
+	return nil
+}
+
+// withSettingsFile returns the program arguments with the flags of the
+// configuration file (.dyff.yml) inserted after the sub-command, so that the
+// flags of environment variables and the command line take precedence. Flags that are not
+// supported by the sub-command are skipped, lists (e.g. excludes) are merged.
+func withSettingsFile(args []string) ([]string, error) {
+	location, ok := settingsFileLocation(args)
+	if !ok || len(args) == 0 {
+		return args, nil
+	}
+
+	settings, err := dyff.LoadSettings(location)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkSettingsFileFlags(settings); err != nil {
+		return nil, err
+	}
+
+	cmd, _, err := rootCmd.Find(args[1:])
+	if err != nil {
+		cmd = rootCmd
+	}
+
+	supported, err := supportedFlags(cmd, settings.Flags, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use settings of %s: %w", location, err)
+	}
+
+	return insertAfterSubCommand(args, supported), nil
+}
+
+// settingsFileLocation returns the location of the configuration file, which
+// is either provided using the config flag, or looked up in the current
+// working directory and its parents
+func settingsFileLocation(args []string) (string, bool) {
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "--":
+			return dyff.FindSettingsFile(".")
+
+		case args[i] == "--config" && i+1 < len(args):
+			return args[i+1], args[i+1] != ""
+
+		case strings.HasPrefix(args[i], "--config="):
+			location := strings.TrimPrefix(args[i], "--config=")
+			return location, location != ""
+		}
+	}
+
+	return dyff.FindSettingsFile(".")
+}
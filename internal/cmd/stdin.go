@@ -0,0 +1,98 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+// defaultStdinSeparator is the line that separates the from and the to input
+// in case both are read from standard input
+const defaultStdinSeparator = "---8<---"
+
+// stdinLocations returns the from and to location of the arguments, where an
+// input that is read from standard input (--from-stdin, or --to-stdin) is not
+// part of the arguments
+func stdinLocations(args []string) (string, string) {
+	switch {
+	case betweenCmdSettings.fromStdin && betweenCmdSettings.toStdin:
+		return "-", "-"
+
+	case betweenCmdSettings.fromStdin:
+		return "-", args[0]
+
+	case betweenCmdSettings.toStdin:
+		return args[0], "-"
+	}
+
+	return args[0], args[1]
+}
+
+// loadStdinInputs reads standard input and splits it into the from and the to
+// input at the first line that only consists of the separator
+func loadStdinInputs(separator string) (ytbx.InputFile, ytbx.InputFile, error) {
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return ytbx.InputFile{}, ytbx.InputFile{}, fmt.Errorf("failed to read standard input: %w", err)
+	}
+
+	fromData, toData, found := splitAtSeparator(data, separator)
+	if !found {
+		return ytbx.InputFile{}, ytbx.InputFile{}, fmt.Errorf("standard input does not contain a %q line to separate the from and to input", separator)
+	}
+
+	var inputFiles [2]ytbx.InputFile
+	for i, part := range [][]byte{fromData, toData} {
+		if len(bytes.TrimSpace(part)) == 0 {
+			inputFiles[i] = ytbx.InputFile{Location: "-"}
+		} else if inputFiles[i], err = dyff.LoadFileContent("-", part); err != nil {
+			return ytbx.InputFile{}, ytbx.InputFile{}, err
+		}
+	}
+
+	inputFiles[0].Note = "before " + separator
+	inputFiles[1].Note = "after " + separator
+
+	return inputFiles[0], inputFiles[1], nil
+}
+
+// splitAtSeparator returns the data before and after the first line that only
+// consists of the separator (surrounding whitespace is ignored)
+func splitAtSeparator(data []byte, separator string) ([]byte, []byte, bool) {
+	var offset int
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if strings.TrimSpace(string(line)) == separator {
+			return data[:offset], data[offset+len(line):], true
+		}
+
+		offset += len(line)
+	}
+
+	return nil, nil, false
+}
@@ -0,0 +1,83 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+// suggestedIgnores is the exclusion section of a .dyff.yml configuration,
+// the keys are the same as the respective flag names
+type suggestedIgnores struct {
+	Exclude                []string `yaml:"exclude,omitempty"`
+	IgnoreNewDocuments     bool     `yaml:"ignore-new-documents,omitempty"`
+	IgnoreRemovedDocuments bool     `yaml:"ignore-removed-documents,omitempty"`
+}
+
+// writeSuggestedIgnores writes a .dyff.yml exclusion section that covers all
+// differences of the report, which can be used as a baseline for drift checks,
+// paths are qualified with their document so that they only exclude the
+// difference in the document it was found in
+func writeSuggestedIgnores(out io.Writer, report dyff.Report) error {
+	if len(report.Diffs) == 0 {
+		return nil
+	}
+
+	var (
+		result = suggestedIgnores{}
+		known  = map[string]struct{}{}
+	)
+
+	for _, diff := range report.Diffs {
+		if diff.Path == nil {
+			for _, detail := range diff.Details {
+				switch detail.Kind {
+				case dyff.ADDITION:
+					result.IgnoreNewDocuments = true
+
+				case dyff.REMOVAL:
+					result.IgnoreRemovedDocuments = true
+				}
+			}
+
+			continue
+		}
+
+		path := dyff.DocumentQualifiedPath(diff.Path)
+		if _, ok := known[path]; !ok {
+			known[path] = struct{}{}
+			result.Exclude = append(result.Exclude, path)
+		}
+	}
+
+	data, err := yamlv3.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to create suggested ignores: %w", err)
+	}
+
+	_, err = fmt.Fprintf(out, "\n# Suggested .dyff.yml section to ignore the reported differences\n%s", data)
+	return err
+}
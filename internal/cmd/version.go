@@ -21,7 +21,10 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"runtime"
+	"runtime/debug"
 
 	"github.com/spf13/cobra"
 )
@@ -29,21 +32,99 @@ import (
 // version string will be injected by automation
 var version string
 
+// commit and build date will be injected by automation, if not, the details
+// of the Go build information are used
+var (
+	commit    string
+	buildDate string
+)
+
+// inputFormats are the formats that can be used as input files
+var inputFormats = []string{"yaml", "json", "toml", "hcl", "ini", "properties", "dotenv"}
+
+type versionCmdOptions struct {
+	output string
+}
+
+var versionCmdSettings versionCmdOptions
+
+// versionInfo contains the version and build details of the tool
+type versionInfo struct {
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	Commit       string   `json:"commit"`
+	BuildDate    string   `json:"buildDate"`
+	GoVersion    string   `json:"goVersion"`
+	Platform     string   `json:"platform"`
+	InputFormats []string `json:"inputFormats"`
+	OutputStyles []string `json:"outputStyles"`
+}
+
 // versionCmd represents the version command
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Args:  cobra.MaximumNArgs(0),
 	Short: "Shows the version of this tool",
-	Long:  `Shows the version of this tool`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if len(version) == 0 {
-			version = "(development)"
+	Long:  `Shows the version of this tool, optionally with the build details and supported formats as JSON`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info := currentVersionInfo()
+
+		switch versionCmdSettings.output {
+		case "", "text":
+			fmt.Printf("%s version %s\n", info.Name, info.Version)
+
+		case "json":
+			data, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to render version information: %w", err)
+			}
+
+			fmt.Println(string(data))
+
+		default:
+			return fmt.Errorf("unsupported output style %q, supported styles: text, json", versionCmdSettings.output)
 		}
 
-		fmt.Printf("%s version %s\n", name, version)
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(versionCmd)
+
+	versionCmd.Flags().SortFlags = false
+	versionCmd.Flags().StringVarP(&versionCmdSettings.output, "output", "o", "text", "specify the output style, supported styles: text, json")
+}
+
+// currentVersionInfo returns the version and build details, where details
+// that were not injected at build time are taken from the Go build info
+func currentVersionInfo() versionInfo {
+	var info = versionInfo{
+		Name:         name,
+		Version:      version,
+		Commit:       commit,
+		BuildDate:    buildDate,
+		GoVersion:    runtime.Version(),
+		Platform:     runtime.GOOS + "/" + runtime.GOARCH,
+		InputFormats: inputFormats,
+		OutputStyles: outputStyles,
+	}
+
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range buildInfo.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+
+			case setting.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = setting.Value
+			}
+		}
+	}
+
+	if len(info.Version) == 0 {
+		info.Version = "(development)"
+	}
+
+	return info
 }
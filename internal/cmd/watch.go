@@ -0,0 +1,270 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gonvenience/ytbx"
+	"github.com/spf13/cobra"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+type watchCmdOptions struct {
+	delay time.Duration
+	count int
+}
+
+var watchCmdSettings watchCmdOptions
+
+// watchSnapshot is the loaded content of the watched file or directory
+type watchSnapshot struct {
+	file    ytbx.InputFile
+	fileSet *dyff.FileSet
+}
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch [flags] <file-or-directory>",
+	Short: "Watch a file or directory and report the differences of each change",
+	Long: `
+Watches a file or directory and prints a report of the differences whenever
+its content changes, compared to the content before the change. This is useful
+to follow rendered manifests or templates during development, for example:
+
+  dyff watch --omit-header rendered.yml
+
+Changes within the delay are combined into one report, so that editors which
+write a file in multiple steps do not cause multiple reports. In case the file
+cannot be loaded, for example because it is not valid YAML while it is edited,
+an error is shown and the next change is compared against the last content
+that could be loaded.
+
+Use --count to stop after the provided number of reports.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var location = args[0]
+
+		options, err := compareOptions()
+		if err != nil {
+			return err
+		}
+
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("failed to watch %s: %w", location, err)
+		}
+		defer watcher.Close()
+
+		isRelevant, err := addWatchLocation(watcher, location)
+		if err != nil {
+			return err
+		}
+
+		previous, err := loadWatchSnapshot(location)
+		if err != nil {
+			return err
+		}
+
+		// the comparator reuses the state of the previous content, which is
+		// unchanged in each comparison
+		comparator := dyff.NewComparator(options...)
+
+		fmt.Fprintf(os.Stderr, "watching %s for changes\n", location)
+
+		var timer = time.NewTimer(watchCmdSettings.delay)
+		timer.Stop()
+
+		var reports int
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return nil
+				}
+
+				if !isRelevant(event) {
+					continue
+				}
+
+				// Watch new sub-directories of a watched directory as well
+				if event.Has(fsnotify.Create) && dyff.IsDirectory(location) && dyff.IsDirectory(event.Name) {
+					if err := addWatchDirectories(watcher, event.Name); err != nil {
+						return err
+					}
+				}
+
+				timer.Reset(watchCmdSettings.delay)
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return nil
+				}
+
+				return fmt.Errorf("failed to watch %s: %w", location, err)
+
+			case <-timer.C:
+				current, err := loadWatchSnapshot(location)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%v\n", err)
+					continue
+				}
+
+				report, err := compareWatchSnapshots(previous, current, comparator, options)
+				if err != nil {
+					return err
+				}
+
+				previous = current
+
+				report = applyReportFilters(report)
+				if len(report.Diffs) == 0 {
+					continue
+				}
+
+				// exit codes do not apply, since the command keeps running
+				if err := writeReport(cmd, report); err != nil {
+					if _, ok := err.(errorWithExitCode); !ok {
+						return err
+					}
+				}
+
+				if reports++; watchCmdSettings.count > 0 && reports >= watchCmdSettings.count {
+					return nil
+				}
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().SortFlags = false
+
+	applyReportOptionsFlags(watchCmd)
+
+	watchCmd.Flags().DurationVar(&watchCmdSettings.delay, "delay", defaultWatchDelay, "time to wait for further changes before the content is compared")
+	watchCmd.Flags().IntVar(&watchCmdSettings.count, "count", 0, "stop after the provided number of reports, 0 means to watch until interrupted")
+}
+
+// defaultWatchDelay is the default time to wait for further changes
+const defaultWatchDelay = 100 * time.Millisecond
+
+// addWatchLocation adds the file or directory to the watcher and returns a
+// function to check whether an event concerns the location. Files are watched
+// using their directory, since editors often replace a file instead of writing
+// into it, which would end the watch of the file itself.
+func addWatchLocation(watcher *fsnotify.Watcher, location string) (func(fsnotify.Event) bool, error) {
+	location = filepath.Clean(location)
+
+	if dyff.IsDirectory(location) {
+		// the relative path is used, since a prefix check does not work for
+		// the current directory (event names do not start with ./)
+		return func(event fsnotify.Event) bool {
+			rel, err := filepath.Rel(location, filepath.Clean(event.Name))
+			return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+		}, addWatchDirectories(watcher, location)
+	}
+
+	if err := watcher.Add(filepath.Dir(location)); err != nil {
+		return nil, fmt.Errorf("failed to watch %s: %w", location, err)
+	}
+
+	return func(event fsnotify.Event) bool {
+		return filepath.Clean(event.Name) == location
+	}, nil
+}
+
+// addWatchDirectories adds the directory and all its sub-directories to the
+// watcher, since changes are only reported for the direct entries
+func addWatchDirectories(watcher *fsnotify.Watcher, location string) error {
+	return filepath.WalkDir(location, func(path string, entry fs.DirEntry, err error) error {
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return nil
+
+		case err != nil:
+			return err
+
+		case !entry.IsDir():
+			return nil
+		}
+
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+
+		return nil
+	})
+}
+
+func loadWatchSnapshot(location string) (watchSnapshot, error) {
+	if dyff.IsDirectory(location) {
+		fileSet, err := dyff.LoadDirectory(location, nil, nil)
+		if err != nil {
+			return watchSnapshot{}, fmt.Errorf("failed to load %s: %w", location, err)
+		}
+
+		return watchSnapshot{fileSet: &fileSet}, nil
+	}
+
+	file, err := dyff.LoadFile(location)
+	if err != nil {
+		return watchSnapshot{}, fmt.Errorf("failed to load %s: %w", location, err)
+	}
+
+	return watchSnapshot{file: file}, nil
+}
+
+// compareWatchSnapshots compares the content before and after a change, the
+// input files are named after the time of the change
+func compareWatchSnapshots(previous, current watchSnapshot, comparator *dyff.Comparator, options []dyff.CompareOption) (dyff.Report, error) {
+	var report dyff.Report
+	var err error
+	switch {
+	case previous.fileSet != nil && current.fileSet != nil:
+		report, err = dyff.CompareFileSets(*previous.fileSet, *current.fileSet, options...)
+
+	case previous.fileSet == nil && current.fileSet == nil:
+		report, err = comparator.Compare(previous.file, current.file)
+
+	default:
+		return dyff.Report{}, fmt.Errorf("failed to compare changes: a file can only be compared with a file, and a directory with a directory")
+	}
+
+	if err != nil {
+		return dyff.Report{}, fmt.Errorf("failed to compare changes: %w", err)
+	}
+
+	report.From.Note = "previous"
+	report.To.Note = time.Now().Format(time.TimeOnly)
+	return report, nil
+}
@@ -32,6 +32,7 @@ import (
 type yamlCmdOptions struct {
 	plainMode        bool
 	restructure      bool
+	sortKeys         bool
 	omitIndentHelper bool
 	inplace          bool
 }
@@ -53,6 +54,7 @@ Converts input document into YAML format while preserving the order of all keys.
 			OutputStyle:      "yaml",
 			PlainMode:        yamlCmdSettings.plainMode,
 			Restructure:      yamlCmdSettings.restructure,
+			SortKeys:         yamlCmdSettings.sortKeys,
 			OmitIndentHelper: yamlCmdSettings.omitIndentHelper,
 		}
 
@@ -88,6 +90,7 @@ func init() {
 
 	yamlCmd.Flags().BoolVarP(&yamlCmdSettings.plainMode, "plain", "p", false, "output in plain style without any highlighting")
 	yamlCmd.Flags().BoolVarP(&yamlCmdSettings.restructure, "restructure", "r", false, "restructure map keys in reasonable order")
+	yamlCmd.Flags().BoolVar(&yamlCmdSettings.sortKeys, "sort-keys", false, "sort map keys alphabetically instead of keeping the original order")
 	yamlCmd.Flags().BoolVarP(&yamlCmdSettings.omitIndentHelper, "omit-indent-helper", "O", false, "omit indent helper lines in highlighted output")
 	yamlCmd.Flags().BoolVarP(&yamlCmdSettings.inplace, "in-place", "i", false, "overwrite input file with output of this command")
 }
@@ -0,0 +1,239 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ansibleTaskKeys are the fields that identify a task, handler, or play
+// without a name, for example `include_tasks: setup.yml`
+var ansibleTaskKeys = []string{
+	"import_playbook", "import_tasks", "include_tasks", "import_role",
+	"include_role", "include_vars", "include", "hosts",
+}
+
+// ansibleConditionKeys are the fields with conditional expressions
+var ansibleConditionKeys = map[string]struct{}{
+	"when": {}, "changed_when": {}, "failed_when": {}, "until": {},
+}
+
+// AnsibleTasks enables the identification of tasks, handlers, and plays of
+// Ansible playbooks and roles by their name, or by the file or role they
+// include or import (e.g. `include_tasks: setup.yml`) in case they have no
+// name. Lists with entries that cannot be identified this way, or with
+// ambiguous names are compared as usual. Also, conditions (e.g. `when`) are
+// compared regardless of their formatting: surrounding `{{ }}` and
+// additional whitespace are ignored, and a list of conditions is the same as
+// the conditions combined with `and`.
+func AnsibleTasks(value bool) CompareOption {
+	return func(settings *compareSettings) {
+		settings.AnsibleTasks = value
+	}
+}
+
+// ansibleTask is a list item identifier for tasks, handlers, and plays, the
+// name of a task is its field and value, for example `name:Install nginx`
+type ansibleTask struct{}
+
+var _ listItemIdentifier = &ansibleTask{}
+
+func (*ansibleTask) Name(node *yamlv3.Node) (string, error) {
+	node = followAlias(node)
+	if node.Kind != yamlv3.MappingNode {
+		return "", fmt.Errorf("provided node is not a mapping node")
+	}
+
+	for _, key := range append([]string{"name"}, ansibleTaskKeys...) {
+		value, ok := findValueByKey(node, key)
+		if !ok {
+			continue
+		}
+
+		// roles are included with the role name as a field
+		if value = followAlias(value); value.Kind == yamlv3.MappingNode {
+			if value, ok = findValueByKey(value, "name"); !ok {
+				continue
+			}
+		}
+
+		if value.Kind == yamlv3.ScalarNode && value.Value != "" {
+			return key + ":" + value.Value, nil
+		}
+	}
+
+	return "", fmt.Errorf("provided node is not a named Ansible task")
+}
+
+func (at *ansibleTask) FindNodeByName(sequenceNode *yamlv3.Node, name string) (*yamlv3.Node, error) {
+	for _, entry := range sequenceNode.Content {
+		if nameOfNode, err := at.Name(entry); err == nil && nameOfNode == name {
+			return entry, nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to find task with name %q", name)
+}
+
+func (*ansibleTask) String() string {
+	return "task"
+}
+
+// PathElement returns the path element of the task, which refers to the
+// identifying field, for example `name=Install nginx`
+func (*ansibleTask) PathElement(name string) ytbx.PathElement {
+	key, value, _ := strings.Cut(name, ":")
+	return ytbx.PathElement{Idx: -1, Key: key, Name: value}
+}
+
+// getAnsibleTaskIdentifier returns the task identifier, if all entries of
+// both lists are tasks with a unique name
+func (compare *compare) getAnsibleTaskIdentifier(listA, listB *yamlv3.Node) listItemIdentifier {
+	if !compare.settings.AnsibleTasks {
+		return nil
+	}
+
+	identifier := &ansibleTask{}
+	if !hasUniqueNames(identifier, listA, listB) {
+		return nil
+	}
+
+	return identifier
+}
+
+// normalizeAnsibleConditions normalizes all conditions of the documents, the
+// documents are copied so that the input files keep their original content
+func (compare *compare) normalizeAnsibleConditions(inputFiles ...*ytbx.InputFile) {
+	if !compare.settings.AnsibleTasks {
+		return
+	}
+
+	for _, inputFile := range inputFiles {
+		documents := make([]*yamlv3.Node, len(inputFile.Documents))
+		for i, document := range inputFile.Documents {
+			documents[i] = copyNode(document)
+			normalizeConditionsIn(documents[i])
+		}
+
+		inputFile.Documents = documents
+	}
+}
+
+func normalizeConditionsIn(node *yamlv3.Node) {
+	switch node.Kind {
+	case yamlv3.DocumentNode, yamlv3.SequenceNode:
+		for _, entry := range node.Content {
+			normalizeConditionsIn(entry)
+		}
+
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if _, ok := ansibleConditionKeys[node.Content[i].Value]; ok {
+				node.Content[i+1] = ansibleCondition(node.Content[i+1])
+				continue
+			}
+
+			normalizeConditionsIn(node.Content[i+1])
+		}
+	}
+}
+
+// ansibleCondition returns the condition as a string without surrounding
+// `{{ }}` and additional whitespace, where a list of conditions is combined
+// with `and`. Conditions that are no strings (e.g. booleans) are kept.
+func ansibleCondition(node *yamlv3.Node) *yamlv3.Node {
+	var conditions []string
+	switch node.Kind {
+	case yamlv3.ScalarNode:
+		if node.Tag != "!!str" {
+			return node
+		}
+
+		conditions = []string{normalizeCondition(node.Value)}
+
+	case yamlv3.SequenceNode:
+		for _, entry := range node.Content {
+			if entry = followAlias(entry); entry.Kind != yamlv3.ScalarNode {
+				return node
+			}
+
+			condition := normalizeCondition(entry.Value)
+			if len(node.Content) > 1 && strings.Contains(condition, " or ") {
+				condition = "(" + condition + ")"
+			}
+
+			conditions = append(conditions, condition)
+		}
+
+	default:
+		return node
+	}
+
+	return &yamlv3.Node{
+		Kind:  yamlv3.ScalarNode,
+		Tag:   "!!str",
+		Value: strings.Join(conditions, " and "),
+	}
+}
+
+// normalizeCondition removes surrounding `{{ }}` and collapses whitespace
+// that is not part of a quoted string
+func normalizeCondition(condition string) string {
+	condition = strings.TrimSpace(condition)
+	if strings.HasPrefix(condition, "{{") && strings.HasSuffix(condition, "}}") {
+		condition = strings.TrimSpace(condition[2 : len(condition)-2])
+	}
+
+	var (
+		result strings.Builder
+		quote  rune
+		space  bool
+	)
+
+	for _, r := range condition {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+
+		case r == '"' || r == '\'':
+			quote = r
+
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			space = true
+			continue
+		}
+
+		if space {
+			result.WriteRune(' ')
+			space = false
+		}
+
+		result.WriteRune(r)
+	}
+
+	return result.String()
+}
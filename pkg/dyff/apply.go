@@ -0,0 +1,491 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Apply applies the differences of the report to the provided documents, which
+// is typically the content of the from input file, and returns the resulting
+// documents. The provided documents are not modified. This turns a report,
+// for example one that was saved using the json output style, into a patch.
+func (r Report) Apply(documents []*yamlv3.Node) ([]*yamlv3.Node, error) {
+	return applyDiffs(documents, r.Diffs)
+}
+
+// applyDiffs applies the differences to copies of the provided documents and
+// returns the resulting documents. Changes that are already in place, for
+// example a key that was already removed, are skipped so that the changes of
+// two reports with the same base can be applied one after another. Document
+// level changes (additions, removals, and order changes of documents) are
+// applied last, because the paths of the differences refer to the document
+// index in the original list of documents. Positional removals and additions
+// of simple list entries (see LCS) are applied after all other changes,
+// removals from the end of the list first, so that the indices stay valid.
+func applyDiffs(documents []*yamlv3.Node, diffs []Diff) ([]*yamlv3.Node, error) {
+	var result = make([]*yamlv3.Node, len(documents))
+	for i, document := range documents {
+		result[i] = copyNode(document)
+	}
+
+	var fileLevel []Diff
+	var positional []positionalChange
+	for _, diff := range diffs {
+		if diff.Path == nil {
+			fileLevel = append(fileLevel, diff)
+			continue
+		}
+
+		if diff.Path.DocumentIdx >= len(result) {
+			return nil, fmt.Errorf("failed to apply change to %s, there is no document #%d", diff.Path.String(), diff.Path.DocumentIdx)
+		}
+
+		if len(diff.Details) == 1 && diff.Details[0].Kind == DIRECTIVECHANGE {
+			SetDirectives(result[diff.Path.DocumentIdx], directiveLines(diff.Details[0].To))
+			continue
+		}
+
+		if len(diff.Details) == 1 && diff.Details[0].Kind == MOVED {
+			if err := applyMove(documentRoot(result[diff.Path.DocumentIdx]), diff, diff.Details[0]); err != nil {
+				return nil, fmt.Errorf("failed to apply change to %s: %w", diff.Path.String(), err)
+			}
+
+			continue
+		}
+
+		if change, ok := positionalTarget(documentRoot(result[diff.Path.DocumentIdx]), diff); ok {
+			positional = append(positional, change)
+			continue
+		}
+
+		target, err := lookupNode(documentRoot(result[diff.Path.DocumentIdx]), diff.Path.PathElements)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply change to %s: %w", diff.Path.String(), err)
+		}
+
+		for _, detail := range diff.Details {
+			if err := applyDetail(target, detail); err != nil {
+				return nil, fmt.Errorf("failed to apply change to %s: %w", diff.Path.String(), err)
+			}
+		}
+	}
+
+	sort.SliceStable(positional, func(i, j int) bool {
+		a, b := positional[i], positional[j]
+		if a.detail.Kind != b.detail.Kind {
+			return a.detail.Kind == REMOVAL
+		}
+
+		if a.detail.Kind == REMOVAL {
+			return a.idx > b.idx
+		}
+
+		return a.idx < b.idx
+	})
+
+	for _, change := range positional {
+		change.apply()
+	}
+
+	for _, diff := range fileLevel {
+		for _, detail := range diff.Details {
+			result = applyDocumentDetail(result, detail)
+		}
+	}
+
+	return result, nil
+}
+
+// positionalChange is a removal or addition of a run of entries at a specific
+// index of a simple list
+type positionalChange struct {
+	list   *yamlv3.Node
+	idx    int
+	detail Detail
+}
+
+// positionalTarget returns the positional change, if the difference removes
+// or adds list entries at the index in its path. A removal or addition of
+// entries of a nested list (a list entry that is a list itself) has the same
+// shape, it is told apart by the entries at the index.
+func positionalTarget(root *yamlv3.Node, diff Diff) (positionalChange, bool) {
+	if len(diff.Path.PathElements) == 0 || len(diff.Details) != 1 {
+		return positionalChange{}, false
+	}
+
+	var last = diff.Path.PathElements[len(diff.Path.PathElements)-1]
+	if last.Key != "" || last.Name != "" {
+		return positionalChange{}, false
+	}
+
+	list, err := lookupNode(root, diff.Path.PathElements[:len(diff.Path.PathElements)-1])
+	if err != nil || list.Kind != yamlv3.SequenceNode {
+		return positionalChange{}, false
+	}
+
+	var isNestedList = last.Idx >= 0 && last.Idx < len(list.Content) && followAlias(list.Content[last.Idx]).Kind == yamlv3.SequenceNode
+
+	var detail = diff.Details[0]
+	switch {
+	case detail.Kind == REMOVAL && detail.From.Kind == yamlv3.SequenceNode:
+		if !isRunAt(list, last.Idx, detail.From) && isNestedList {
+			return positionalChange{}, false
+		}
+
+	case detail.Kind == ADDITION && detail.To.Kind == yamlv3.SequenceNode && len(detail.To.Content) > 0:
+		if isNestedList && followAlias(detail.To.Content[0]).Kind != yamlv3.SequenceNode {
+			return positionalChange{}, false
+		}
+
+	default:
+		return positionalChange{}, false
+	}
+
+	return positionalChange{list: list, idx: last.Idx, detail: detail}, true
+}
+
+// apply removes or inserts the entries, a removal of entries that are no
+// longer at the index is skipped
+func (change positionalChange) apply() {
+	var list = change.list
+	switch change.detail.Kind {
+	case REMOVAL:
+		if isRunAt(list, change.idx, change.detail.From) {
+			list.Content = append(list.Content[:change.idx], list.Content[change.idx+len(change.detail.From.Content):]...)
+		}
+
+	case ADDITION:
+		var idx = change.idx
+		if idx > len(list.Content) {
+			idx = len(list.Content)
+		}
+
+		var entries = make([]*yamlv3.Node, 0, len(list.Content)+len(change.detail.To.Content))
+		entries = append(entries, list.Content[:idx]...)
+		for _, entry := range change.detail.To.Content {
+			entries = append(entries, copyNode(entry))
+		}
+
+		list.Content = append(entries, list.Content[idx:]...)
+	}
+}
+
+// documentRoot returns the root node of the document, which is the content of
+// a document node, or the node itself otherwise
+func documentRoot(document *yamlv3.Node) *yamlv3.Node {
+	if document.Kind == yamlv3.DocumentNode && len(document.Content) > 0 {
+		return document.Content[0]
+	}
+
+	return document
+}
+
+// lookupNode returns the node at the path in the tree of the provided node
+func lookupNode(node *yamlv3.Node, elements []ytbx.PathElement) (*yamlv3.Node, error) {
+	for _, element := range elements {
+		node = followAlias(node)
+
+		switch {
+		case element.Key != "":
+			var next *yamlv3.Node
+			if node.Kind == yamlv3.SequenceNode {
+				for _, entry := range node.Content {
+					if listEntryName(followAlias(entry), element.Key) == element.Name {
+						next = entry
+						break
+					}
+				}
+			}
+
+			if next == nil {
+				return nil, fmt.Errorf("there is no list entry with %s=%s", element.Key, element.Name)
+			}
+
+			node = next
+
+		case element.Name != "":
+			value, ok := (*yamlv3.Node)(nil), false
+			if node.Kind == yamlv3.MappingNode {
+				value, ok = findValueByKey(node, element.Name)
+			}
+
+			if !ok {
+				return nil, fmt.Errorf("there is no key %s", element.Name)
+			}
+
+			node = value
+
+		default:
+			if node.Kind != yamlv3.SequenceNode || element.Idx < 0 || element.Idx >= len(node.Content) {
+				return nil, fmt.Errorf("there is no list entry #%d", element.Idx)
+			}
+
+			node = node.Content[element.Idx]
+		}
+	}
+
+	return followAlias(node), nil
+}
+
+func applyDetail(target *yamlv3.Node, detail Detail) error {
+	switch detail.Kind {
+	case MODIFICATION:
+		*target = *copyNode(detail.To)
+
+	case ADDITION:
+		switch {
+		case detail.To.Kind == yamlv3.MappingNode && target.Kind == yamlv3.MappingNode:
+			for i := 0; i+1 < len(detail.To.Content); i += 2 {
+				key, value := detail.To.Content[i], copyNode(detail.To.Content[i+1])
+				if existing, ok := findValueByKey(target, key.Value); ok {
+					*existing = *value
+					continue
+				}
+
+				target.Content = append(target.Content, copyNode(key), value)
+			}
+
+		case detail.To.Kind == yamlv3.SequenceNode && target.Kind == yamlv3.SequenceNode:
+			for _, entry := range detail.To.Content {
+				if indexOfEqualNode(target.Content, entry) < 0 {
+					target.Content = append(target.Content, copyNode(entry))
+				}
+			}
+
+		default:
+			return fmt.Errorf("cannot add %s entries to %s", kindName(detail.To), kindName(target))
+		}
+
+	case REMOVAL:
+		switch {
+		case detail.From.Kind == yamlv3.MappingNode && target.Kind == yamlv3.MappingNode:
+			for i := 0; i+1 < len(detail.From.Content); i += 2 {
+				for j := 0; j+1 < len(target.Content); j += 2 {
+					if target.Content[j].Value == detail.From.Content[i].Value {
+						target.Content = append(target.Content[:j], target.Content[j+2:]...)
+						break
+					}
+				}
+			}
+
+		case detail.From.Kind == yamlv3.SequenceNode && target.Kind == yamlv3.SequenceNode:
+			for _, entry := range detail.From.Content {
+				if idx := indexOfEqualNode(target.Content, entry); idx >= 0 {
+					target.Content = append(target.Content[:idx], target.Content[idx+1:]...)
+				}
+			}
+
+		default:
+			return fmt.Errorf("cannot remove %s entries from %s", kindName(detail.From), kindName(target))
+		}
+
+	case ORDERCHANGE:
+		if target.Kind != yamlv3.SequenceNode {
+			return fmt.Errorf("cannot change the order of %s", kindName(target))
+		}
+
+		target.Content = reorder(target.Content, detail.To.Content)
+	}
+
+	return nil
+}
+
+func applyDocumentDetail(documents []*yamlv3.Node, detail Detail) []*yamlv3.Node {
+	var indexOf = func(node *yamlv3.Node) int {
+		if name, err := k8sItem.Name(node); err == nil {
+			for i, document := range documents {
+				if other, err := k8sItem.Name(documentRoot(document)); err == nil && other == name {
+					return i
+				}
+			}
+
+			return -1
+		}
+
+		for i, document := range documents {
+			if canonicalString(documentRoot(document)) == canonicalString(node) {
+				return i
+			}
+		}
+
+		return -1
+	}
+
+	switch detail.Kind {
+	case ADDITION:
+		for _, node := range detail.To.Content {
+			if indexOf(node) < 0 {
+				documents = append(documents, &yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{copyNode(node)}})
+			}
+		}
+
+	case REMOVAL:
+		for _, node := range detail.From.Content {
+			if idx := indexOf(node); idx >= 0 {
+				documents = append(documents[:idx], documents[idx+1:]...)
+			}
+		}
+
+	case ORDERCHANGE:
+		roots := make([]*yamlv3.Node, len(documents))
+		for i, document := range documents {
+			roots[i] = documentRoot(document)
+		}
+
+		var byRoot = map[*yamlv3.Node]*yamlv3.Node{}
+		for i, root := range roots {
+			byRoot[root] = documents[i]
+		}
+
+		var result []*yamlv3.Node
+		for _, root := range reorder(roots, detail.To.Content) {
+			result = append(result, byRoot[root])
+		}
+
+		documents = result
+	}
+
+	return documents
+}
+
+// reorder returns the list entries in the order of the provided names, which
+// are either the entries themselves (simple lists), or the identifying names
+// of the entries (named-entry lists, or Kubernetes resources). Entries that
+// are not in the list of names keep their position at the end of the list.
+func reorder(entries []*yamlv3.Node, order []*yamlv3.Node) []*yamlv3.Node {
+	var positions = map[string]int{}
+	for i, node := range order {
+		positions[canonicalString(node)] = i
+		if node.Kind == yamlv3.ScalarNode {
+			positions[node.Value] = i
+		}
+	}
+
+	var position = func(entry *yamlv3.Node) (int, bool) {
+		entry = followAlias(entry)
+		if pos, ok := positions[canonicalString(entry)]; ok {
+			return pos, true
+		}
+
+		if name, err := k8sItem.Name(entry); err == nil {
+			if pos, ok := positions[name]; ok {
+				return pos, true
+			}
+		}
+
+		if entry.Kind == yamlv3.MappingNode {
+			for i := 0; i+1 < len(entry.Content); i += 2 {
+				if value := followAlias(entry.Content[i+1]); value.Kind == yamlv3.ScalarNode {
+					if pos, ok := positions[value.Value]; ok {
+						return pos, true
+					}
+				}
+			}
+		}
+
+		return 0, false
+	}
+
+	var sorted = make([]*yamlv3.Node, len(order))
+	var rest []*yamlv3.Node
+	for _, entry := range entries {
+		if pos, ok := position(entry); ok && sorted[pos] == nil {
+			sorted[pos] = entry
+			continue
+		}
+
+		rest = append(rest, entry)
+	}
+
+	var result []*yamlv3.Node
+	for _, entry := range sorted {
+		if entry != nil {
+			result = append(result, entry)
+		}
+	}
+
+	return append(result, rest...)
+}
+
+func indexOfEqualNode(list []*yamlv3.Node, node *yamlv3.Node) int {
+	var search = canonicalString(node)
+	for i, entry := range list {
+		if canonicalString(entry) == search {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// canonicalString returns a representation of the node that only depends on
+// its semantic content, see writeCanonicalNode for details
+func canonicalString(node *yamlv3.Node) string {
+	var buf strings.Builder
+	writeCanonicalNode(&buf, node)
+	return buf.String()
+}
+
+// copyNode returns a deep copy of the node, aliases are resolved and the
+// directives of documents are kept
+func copyNode(node *yamlv3.Node) *yamlv3.Node {
+	if node == nil {
+		return nil
+	}
+
+	node = followAlias(node)
+	result := *node
+	result.Anchor = ""
+	if node.Content != nil {
+		result.Content = make([]*yamlv3.Node, len(node.Content))
+		for i, content := range node.Content {
+			result.Content[i] = copyNode(content)
+		}
+	}
+
+	if node.Kind == yamlv3.DocumentNode {
+		SetDirectives(&result, Directives(node))
+	}
+
+	return &result
+}
+
+func kindName(node *yamlv3.Node) string {
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		return "map"
+
+	case yamlv3.SequenceNode:
+		return "list"
+
+	case yamlv3.DocumentNode:
+		return "document"
+
+	default:
+		return "scalar"
+	}
+}
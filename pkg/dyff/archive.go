@@ -0,0 +1,197 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+)
+
+// IsArchive returns whether the provided location refers to a supported
+// archive file (tar, gzip compressed tar, or zip) based on its file extension
+func IsArchive(location string) bool {
+	lower := strings.ToLower(location)
+	for _, suffix := range []string{".tar", ".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LoadArchive reads the archive at the provided location in memory and returns
+// all files in it that contain structured data (YAML, JSON, TOML, HCL, INI,
+// Java properties, or dotenv) as a file set, so that two archives can be
+// compared using CompareFileSets
+func LoadArchive(location string) (FileSet, error) {
+	data, err := os.ReadFile(location)
+	if err != nil {
+		return FileSet{}, fmt.Errorf("failed to read archive %s: %w", location, err)
+	}
+
+	return LoadArchiveContent(location, data)
+}
+
+// LoadArchiveContent loads the provided data as the content of the archive at
+// the location the same way as LoadArchive does
+func LoadArchiveContent(location string, data []byte) (FileSet, error) {
+	var (
+		entries map[string]archiveEntry
+		err     error
+	)
+
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		entries, err = readZipArchive(data)
+
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		var reader *gzip.Reader
+		if reader, err = gzip.NewReader(bytes.NewReader(data)); err == nil {
+			entries, err = readTarArchive(reader)
+		}
+
+	default:
+		entries, err = readTarArchive(bytes.NewReader(data))
+	}
+
+	if err != nil {
+		return FileSet{}, fmt.Errorf("failed to extract archive %s: %w", location, err)
+	}
+
+	var result = FileSet{
+		Location: location,
+		Files:    make(map[string]ytbx.InputFile, len(entries)),
+		Metadata: make(map[string]FileMetadata, len(entries)),
+	}
+
+	for name, entry := range entries {
+		// symbolic links are loaded with the content of the file they point
+		// to, in case it is part of the archive
+		if entry.metadata.Symlink != "" {
+			target, ok := entries[path.Join(path.Dir(name), entry.metadata.Symlink)]
+			if !ok || target.metadata.Symlink != "" {
+				continue
+			}
+
+			entry.data = target.data
+		}
+
+		result.Files[name] = loadFileSetEntry(location+":"+name, entry.data)
+		result.Metadata[name] = entry.metadata
+	}
+
+	return result, nil
+}
+
+// archiveEntry is a file in an archive, the content of a symbolic link is
+// the content of the file it points to, which is resolved separately
+type archiveEntry struct {
+	data     []byte
+	metadata FileMetadata
+}
+
+func readTarArchive(in io.Reader) (map[string]archiveEntry, error) {
+	var (
+		result = map[string]archiveEntry{}
+		reader = tar.NewReader(in)
+	)
+
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		name := path.Clean(header.Name)
+		if !isSupportedFileSetEntry(name) {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg:
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				return nil, err
+			}
+
+			result[name] = archiveEntry{data: data, metadata: FileMetadata{Mode: header.FileInfo().Mode().Perm()}}
+
+		case tar.TypeSymlink:
+			result[name] = archiveEntry{metadata: FileMetadata{Mode: header.FileInfo().Mode().Perm(), Symlink: header.Linkname}}
+		}
+	}
+}
+
+func readZipArchive(data []byte) (map[string]archiveEntry, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var result = map[string]archiveEntry{}
+	for _, file := range reader.File {
+		name := path.Clean(file.Name)
+		if file.FileInfo().IsDir() || !isSupportedFileSetEntry(name) {
+			continue
+		}
+
+		content, err := readZipEntry(file)
+		if err != nil {
+			return nil, err
+		}
+
+		// the content of a symbolic link in a zip archive is its target
+		if file.Mode()&fs.ModeSymlink != 0 {
+			result[name] = archiveEntry{metadata: FileMetadata{Mode: file.Mode().Perm(), Symlink: string(content)}}
+			continue
+		}
+
+		result[name] = archiveEntry{data: content, metadata: FileMetadata{Mode: file.Mode().Perm()}}
+	}
+
+	return result, nil
+}
+
+func readZipEntry(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,346 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("Archive inputs", func() {
+	var tmpDir string
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "dyff-archive")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	createTarGz := func(name string, files map[string]string) string {
+		location := filepath.Join(tmpDir, name)
+		file, err := os.Create(location)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		gz := gzip.NewWriter(file)
+		tw := tar.NewWriter(gz)
+		for path, content := range files {
+			Expect(tw.WriteHeader(&tar.Header{Name: path, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})).To(Succeed())
+			_, err := tw.Write([]byte(content))
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(tw.Close()).To(Succeed())
+		Expect(gz.Close()).To(Succeed())
+		return location
+	}
+
+	createZip := func(name string, files map[string]string) string {
+		location := filepath.Join(tmpDir, name)
+		file, err := os.Create(location)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		zw := zip.NewWriter(file)
+		for path, content := range files {
+			w, err := zw.Create(path)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = w.Write([]byte(content))
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(zw.Close()).To(Succeed())
+		return location
+	}
+
+	Context("detecting archives", func() {
+		It("should detect supported archives by their file extension", func() {
+			Expect(dyff.IsArchive("chart-1.0.0.tgz")).To(BeTrue())
+			Expect(dyff.IsArchive("backup.tar.gz")).To(BeTrue())
+			Expect(dyff.IsArchive("backup.ZIP")).To(BeTrue())
+			Expect(dyff.IsArchive("values.yaml")).To(BeFalse())
+		})
+	})
+
+	Context("comparing archives", func() {
+		It("should match the files in the archives by their path", func() {
+			from := createTarGz("from.tgz", map[string]string{
+				"chart/Chart.yaml":  "name: chart\nversion: 1.0.0\n",
+				"chart/values.yaml": "replicas: 1\n",
+				"chart/old.yaml":    "foo: bar\n",
+				"chart/README.md":   "ignored",
+			})
+
+			to := createZip("to.zip", map[string]string{
+				"chart/Chart.yaml":  "name: chart\nversion: 1.1.0\n",
+				"chart/values.yaml": "replicas: 1\n",
+				"chart/new.yaml":    "foo: bar\n",
+			})
+
+			fromSet, err := dyff.LoadArchive(from)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fromSet.Paths()).To(Equal([]string{"chart/Chart.yaml", "chart/old.yaml", "chart/values.yaml"}))
+
+			toSet, err := dyff.LoadArchive(to)
+			Expect(err).ToNot(HaveOccurred())
+
+			report, err := dyff.CompareFileSets(fromSet, toSet)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Diffs).To(HaveLen(3))
+
+			Expect(report.Diffs[0].Path.RootDescription()).To(Equal("chart/Chart.yaml"))
+			Expect(report.Diffs[0].Path.ToDotStyle()).To(Equal("version"))
+			Expect(report.Diffs[0].Details[0].Kind).To(Equal(dyff.MODIFICATION))
+
+			Expect(report.Diffs[1].Path.RootDescription()).To(Equal("chart/new.yaml"))
+			Expect(report.Diffs[1].Details[0].Kind).To(Equal(dyff.ADDITION))
+
+			Expect(report.Diffs[2].Path.RootDescription()).To(Equal("chart/old.yaml"))
+			Expect(report.Diffs[2].Details[0].Kind).To(Equal(dyff.REMOVAL))
+
+			Expect(report.Diffs[0].FromSource).To(Equal(&dyff.Source{File: "chart/Chart.yaml", Line: 2}))
+			Expect(report.Diffs[0].ToSource).To(Equal(&dyff.Source{File: "chart/Chart.yaml", Line: 2}))
+			Expect(report.Diffs[1].FromSource).To(BeNil())
+			Expect(report.Diffs[1].ToSource).To(Equal(&dyff.Source{File: "chart/new.yaml", Line: 1}))
+			Expect(report.Diffs[2].FromSource).To(Equal(&dyff.Source{File: "chart/old.yaml", Line: 1}))
+			Expect(report.Diffs[2].ToSource).To(BeNil())
+
+			data, err := report.MarshalJSON()
+			Expect(err).ToNot(HaveOccurred())
+
+			var loaded dyff.Report
+			Expect(loaded.UnmarshalJSON(data)).To(Succeed())
+			Expect(loaded.Diffs[0].FromSource).To(Equal(report.Diffs[0].FromSource))
+		})
+
+		It("should report documents that moved to another file if moves are detected", func() {
+			from := createTarGz("from.tgz", map[string]string{
+				"chart/old.yaml": "foo: bar\n",
+			})
+
+			to := createTarGz("to.tgz", map[string]string{
+				"chart/new.yaml": "foo: bar\n",
+			})
+
+			fromSet, err := dyff.LoadArchive(from)
+			Expect(err).ToNot(HaveOccurred())
+
+			toSet, err := dyff.LoadArchive(to)
+			Expect(err).ToNot(HaveOccurred())
+
+			report, err := dyff.CompareFileSets(fromSet, toSet, dyff.DetectMoves(true))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Diffs).To(HaveLen(1))
+			Expect(report.Diffs[0].Path.RootDescription()).To(Equal("chart/new.yaml"))
+			Expect(report.Diffs[0].Details[0].Kind).To(Equal(dyff.MOVED))
+			Expect(report.Diffs[0].Details[0].From.Value).To(Equal("chart/old.yaml"))
+		})
+
+		It("should fall back to a text comparison for files that cannot be parsed", func() {
+			from := createTarGz("from.tgz", map[string]string{"templates/cm.yaml": "{{- if .Values.foo }}\nfoo: bar\n{{- end }}\n"})
+			to := createTarGz("to.tgz", map[string]string{"templates/cm.yaml": "{{- if .Values.bar }}\nfoo: bar\n{{- end }}\n"})
+
+			fromSet, err := dyff.LoadArchive(from)
+			Expect(err).ToNot(HaveOccurred())
+
+			toSet, err := dyff.LoadArchive(to)
+			Expect(err).ToNot(HaveOccurred())
+
+			report, err := dyff.CompareFileSets(fromSet, toSet)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Diffs).To(HaveLen(1))
+			Expect(report.Diffs[0].Details[0].To.Value).To(Equal("{{- if .Values.bar }}\nfoo: bar\n{{- end }}\n"))
+		})
+	})
+
+	Context("comparing directories", func() {
+		createDirectory := func(name string, files map[string]string) string {
+			location := filepath.Join(tmpDir, name)
+			for path, content := range files {
+				Expect(os.MkdirAll(filepath.Dir(filepath.Join(location, path)), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(location, path), []byte(content), 0644)).To(Succeed())
+			}
+
+			return location
+		}
+
+		It("should pair the files by their relative path and respect the glob patterns", func() {
+			from := createDirectory("from", map[string]string{
+				"values.yaml":        "replicas: 1\n",
+				"templates/old.yaml": "foo: bar\n",
+				"templates/cm.json":  `{"foo": "bar"}`,
+				"README.md":          "ignored",
+			})
+
+			to := createDirectory("to", map[string]string{
+				"values.yaml":        "replicas: 2\n",
+				"templates/new.yaml": "foo: bar\n",
+			})
+
+			Expect(dyff.IsDirectory(from)).To(BeTrue())
+			Expect(dyff.IsDirectory(filepath.Join(from, "values.yaml"))).To(BeFalse())
+
+			fromSet, err := dyff.LoadDirectory(from, nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fromSet.Paths()).To(Equal([]string{"templates/cm.json", "templates/old.yaml", "values.yaml"}))
+
+			toSet, err := dyff.LoadDirectory(to, nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fromSet.Difference(toSet)).To(Equal([]string{"templates/cm.json", "templates/old.yaml"}))
+			Expect(toSet.Difference(fromSet)).To(Equal([]string{"templates/new.yaml"}))
+
+			report, err := dyff.CompareFileSets(fromSet, toSet)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Diffs).To(HaveLen(4))
+
+			fromSet, err = dyff.LoadDirectory(from, []string{"*.yaml"}, []string{"templates/*"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fromSet.Paths()).To(Equal([]string{"values.yaml"}))
+
+			_, err = dyff.LoadDirectory(from, []string{"["}, nil)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should refer to the to file for documents that were added to a file", func() {
+			from := createDirectory("from", map[string]string{
+				"app.yaml": "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: one\ndata:\n  key: value\n",
+				"db.yaml":  "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: db\n",
+			})
+
+			to := createDirectory("to", map[string]string{
+				"app.yaml": "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: one\ndata:\n  key: value\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: two\n",
+				"db.yaml":  "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: db\n",
+			})
+
+			fromSet, err := dyff.LoadDirectory(from, nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			toSet, err := dyff.LoadDirectory(to, nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			report, err := dyff.CompareFileSets(fromSet, toSet, dyff.KubernetesEntityDetection(true))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Diffs).To(HaveLen(1))
+			Expect(report.Diffs[0].Path.Root.Location).To(Equal(report.To.Location))
+			Expect(report.Diffs[0].Path.RootDescription()).To(Equal("app.yaml: v1/ConfigMap/two"))
+
+			var buf bytes.Buffer
+			Expect((&dyff.HumanReport{Report: report, OmitHeader: true}).WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring("(app.yaml: v1/ConfigMap/two)"))
+			Expect(buf.String()).ToNot(ContainSubstring("v1/ConfigMap/one"))
+		})
+
+		It("should create the same report regardless of the number of jobs", func() {
+			var fromFiles, toFiles = map[string]string{}, map[string]string{}
+			for i := 0; i < 50; i++ {
+				name := fmt.Sprintf("files/%02d.yaml", i)
+				fromFiles[name] = fmt.Sprintf("key: value\nidx: %d\n", i)
+				toFiles[name] = fmt.Sprintf("key: value\nidx: %d\n", i*2)
+			}
+
+			fromSet, err := dyff.LoadDirectory(createDirectory("from", fromFiles), nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			toSet, err := dyff.LoadDirectory(createDirectory("to", toFiles), nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			var render = func(report dyff.Report) string {
+				var buf bytes.Buffer
+				Expect((&dyff.HumanReport{Report: report, OmitHeader: true}).WriteReport(&buf)).To(Succeed())
+				return buf.String()
+			}
+
+			sequential, err := dyff.CompareFileSets(fromSet, toSet, dyff.Jobs(1))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sequential.Diffs).To(HaveLen(49))
+
+			var streamed []string
+			concurrent, err := dyff.CompareFileSets(fromSet, toSet, dyff.Jobs(8), dyff.FileCompared(func(path string, report dyff.Report) {
+				streamed = append(streamed, path)
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(render(concurrent)).To(Equal(render(sequential)))
+			Expect(streamed).To(HaveLen(50))
+			Expect(streamed).To(BeEquivalentTo(fromSet.Paths()))
+		})
+
+		It("should compare the file metadata only if enabled", func() {
+			from := createDirectory("from", map[string]string{
+				"values.yaml": "replicas: 1\n",
+				"Config.yaml": "foo: bar\n",
+				"base.yaml":   "foo: bar\n",
+				"link.yaml":   "foo: bar\n",
+			})
+
+			to := createDirectory("to", map[string]string{
+				"values.yaml": "replicas: 1\n",
+				"config.yaml": "foo: bar\n",
+				"base.yaml":   "foo: bar\n",
+			})
+
+			Expect(os.Chmod(filepath.Join(from, "values.yaml"), 0644)).To(Succeed())
+			Expect(os.Chmod(filepath.Join(to, "values.yaml"), 0755)).To(Succeed())
+			Expect(os.Symlink("base.yaml", filepath.Join(to, "link.yaml"))).To(Succeed())
+
+			fromSet, err := dyff.LoadDirectory(from, nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			toSet, err := dyff.LoadDirectory(to, nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(toSet.Metadata["link.yaml"].Symlink).To(Equal("base.yaml"))
+			Expect(fromSet.CaseOnlyRenames(toSet)).To(Equal(map[string]string{"Config.yaml": "config.yaml"}))
+
+			report, err := dyff.CompareFileSets(fromSet, toSet)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Diffs).To(HaveLen(2))
+
+			report, err = dyff.CompareFileSets(fromSet, toSet, dyff.CompareFileMetadata(true))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Diffs).To(HaveLen(3))
+
+			Expect(report.Diffs[0].Path.RootDescription()).To(Equal("Config.yaml"))
+			Expect(report.Diffs[0].Details[0].From.Value).To(Equal("name Config.yaml"))
+			Expect(report.Diffs[0].Details[0].To.Value).To(Equal("name config.yaml"))
+
+			Expect(report.Diffs[1].Path.RootDescription()).To(Equal("link.yaml"))
+			Expect(report.Diffs[1].Details[0].From.Value).To(Equal("regular file (-rw-r--r--)"))
+			Expect(report.Diffs[1].Details[0].To.Value).To(Equal("symlink to base.yaml (-rw-r--r--)"))
+
+			Expect(report.Diffs[2].Path.RootDescription()).To(Equal("values.yaml"))
+			Expect(report.Diffs[2].Details[0].From.Value).To(Equal("regular file (-rw-r--r--)"))
+			Expect(report.Diffs[2].Details[0].To.Value).To(Equal("regular file (-rwxr-xr-x)"))
+		})
+	})
+})
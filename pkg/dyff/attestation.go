@@ -0,0 +1,310 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/gonvenience/ytbx"
+)
+
+// Types of the attestation, which is an in-toto statement
+// (https://in-toto.io/Statement/v1) in a DSSE envelope
+// (https://github.com/secure-systems-lab/dsse)
+const (
+	AttestationPayloadType   = "application/vnd.in-toto+json"
+	AttestationStatementType = "https://in-toto.io/Statement/v1"
+	AttestationPredicateType = "https://github.com/homeport/dyff/comparison/v1"
+)
+
+// AttestationStatement is the in-toto statement about a comparison result,
+// the subjects are the compared inputs
+type AttestationStatement struct {
+	Type          string               `json:"_type"`
+	Subject       []AttestationSubject `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     AttestationPredicate `json:"predicate"`
+}
+
+// AttestationSubject is one of the compared inputs with the digest of its
+// documents
+type AttestationSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// AttestationPredicate contains the facts about a comparison result
+type AttestationPredicate struct {
+	Timestamp    time.Time        `json:"timestamp"`
+	From         AttestationInput `json:"from"`
+	To           AttestationInput `json:"to"`
+	Options      []string         `json:"options"`
+	Differences  int              `json:"differences"`
+	ReportSHA256 string           `json:"reportSHA256"`
+}
+
+// AttestationInput describes one of the compared inputs
+type AttestationInput struct {
+	Location        string `json:"location"`
+	Documents       int    `json:"documents"`
+	DocumentsSHA256 string `json:"documentsSHA256"`
+}
+
+// Attestation is a DSSE envelope with the signed statement as payload
+type Attestation struct {
+	PayloadType string                 `json:"payloadType"`
+	Payload     string                 `json:"payload"`
+	Signatures  []AttestationSignature `json:"signatures"`
+}
+
+// AttestationSignature is a signature of a DSSE envelope, the key ID is the
+// SHA-256 of the DER encoded public key
+type AttestationSignature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// NewAttestationStatement creates the statement for the provided report, the
+// options are the comparison settings in use (for example command-line flags)
+func NewAttestationStatement(report Report, options ...string) (AttestationStatement, error) {
+	from, err := attestationInput(report.From)
+	if err != nil {
+		return AttestationStatement{}, err
+	}
+
+	to, err := attestationInput(report.To)
+	if err != nil {
+		return AttestationStatement{}, err
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return AttestationStatement{}, err
+	}
+
+	if options == nil {
+		options = []string{}
+	}
+
+	return AttestationStatement{
+		Type: AttestationStatementType,
+		Subject: []AttestationSubject{
+			{Name: from.Location, Digest: map[string]string{"sha256": from.DocumentsSHA256}},
+			{Name: to.Location, Digest: map[string]string{"sha256": to.DocumentsSHA256}},
+		},
+		PredicateType: AttestationPredicateType,
+		Predicate: AttestationPredicate{
+			Timestamp:    time.Now().UTC(),
+			From:         from,
+			To:           to,
+			Options:      options,
+			Differences:  len(report.Diffs),
+			ReportSHA256: sha256Hex(data),
+		},
+	}, nil
+}
+
+// Sign creates a signed attestation of the statement using the provided PEM
+// encoded private key, supported are ECDSA, Ed25519, and RSA keys in PKCS #8,
+// SEC 1 (EC), or PKCS #1 (RSA) format
+func (statement AttestationStatement) Sign(privateKeyPEM []byte) (Attestation, error) {
+	signer, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return Attestation{}, err
+	}
+
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return Attestation{}, err
+	}
+
+	message := preAuthEncoding(AttestationPayloadType, payload)
+
+	var signature []byte
+	switch key := signer.(type) {
+	case ed25519.PrivateKey:
+		signature, err = key.Sign(rand.Reader, message, crypto.Hash(0))
+
+	case *ecdsa.PrivateKey, *rsa.PrivateKey:
+		digest := sha256.Sum256(message)
+		signature, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+
+	default:
+		return Attestation{}, fmt.Errorf("unsupported private key type %T", signer)
+	}
+
+	if err != nil {
+		return Attestation{}, fmt.Errorf("failed to sign attestation: %w", err)
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return Attestation{}, err
+	}
+
+	return Attestation{
+		PayloadType: AttestationPayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures: []AttestationSignature{{
+			KeyID: sha256Hex(publicKey),
+			Sig:   base64.StdEncoding.EncodeToString(signature),
+		}},
+	}, nil
+}
+
+// Verify checks that the attestation is signed with the provided PEM encoded
+// public key and returns the signed statement
+func (attestation Attestation) Verify(publicKeyPEM []byte) (AttestationStatement, error) {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return AttestationStatement{}, fmt.Errorf("failed to decode public key, no PEM data found")
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return AttestationStatement{}, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	if attestation.PayloadType != AttestationPayloadType {
+		return AttestationStatement{}, fmt.Errorf("unsupported attestation payload type %q", attestation.PayloadType)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(attestation.Payload)
+	if err != nil {
+		return AttestationStatement{}, fmt.Errorf("failed to decode attestation payload: %w", err)
+	}
+
+	message := preAuthEncoding(attestation.PayloadType, payload)
+
+	var verified bool
+	for _, entry := range attestation.Signatures {
+		signature, err := base64.StdEncoding.DecodeString(entry.Sig)
+		if err != nil {
+			continue
+		}
+
+		if verified, err = verifySignature(publicKey, message, signature); err != nil {
+			return AttestationStatement{}, err
+		}
+
+		if verified {
+			break
+		}
+	}
+
+	if !verified {
+		return AttestationStatement{}, fmt.Errorf("attestation is not signed with the provided public key")
+	}
+
+	var statement AttestationStatement
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return AttestationStatement{}, fmt.Errorf("failed to parse attestation statement: %w", err)
+	}
+
+	if statement.Type != AttestationStatementType || statement.PredicateType != AttestationPredicateType {
+		return AttestationStatement{}, fmt.Errorf("unsupported attestation statement %s with predicate %s", statement.Type, statement.PredicateType)
+	}
+
+	return statement, nil
+}
+
+// verifySignature returns whether the signature of the message matches the
+// public key
+func verifySignature(publicKey crypto.PublicKey, message []byte, signature []byte) (bool, error) {
+	digest := sha256.Sum256(message)
+	switch key := publicKey.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, message, signature), nil
+
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(key, digest[:], signature), nil
+
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil, nil
+
+	default:
+		return false, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+// preAuthEncoding returns the DSSE pre-authentication encoding of the payload,
+// which is the message that is actually signed
+func preAuthEncoding(payloadType string, payload []byte) []byte {
+	return fmt.Appendf(nil, "DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)
+}
+
+func attestationInput(inputFile ytbx.InputFile) (AttestationInput, error) {
+	hash := sha256.New()
+	for _, document := range inputFile.Documents {
+		str, err := encodeNode(document)
+		if err != nil {
+			return AttestationInput{}, err
+		}
+
+		_, _ = hash.Write([]byte(str))
+	}
+
+	return AttestationInput{
+		Location:        inputFile.Location,
+		Documents:       len(inputFile.Documents),
+		DocumentsSHA256: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode private key, no PEM data found")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+	}
+
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("failed to parse private key of PEM type %q", block.Type)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
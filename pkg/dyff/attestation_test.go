@@ -0,0 +1,111 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("Report attestation", func() {
+	var statement dyff.AttestationStatement
+
+	var publicKeyPEM = func(key crypto.PublicKey) []byte {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		Expect(err).ToNot(HaveOccurred())
+		return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	}
+
+	BeforeEach(func() {
+		from, to := loadFiles(assets("examples", "from.yml"), assets("examples", "to.yml"))
+		report, err := dyff.CompareInputFiles(from, to)
+		Expect(err).ToNot(HaveOccurred())
+
+		statement, err = dyff.NewAttestationStatement(report, "--ignore-order-changes=true")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statement.Type).To(Equal(dyff.AttestationStatementType))
+		Expect(statement.Subject).To(HaveLen(2))
+		Expect(statement.Predicate.Differences).To(Equal(len(report.Diffs)))
+		Expect(statement.Predicate.From.DocumentsSHA256).ToNot(Equal(statement.Predicate.To.DocumentsSHA256))
+		Expect(statement.Subject[0].Digest["sha256"]).To(Equal(statement.Predicate.From.DocumentsSHA256))
+	})
+
+	It("should sign and verify using an Ed25519 key", func() {
+		public, key, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		Expect(err).ToNot(HaveOccurred())
+
+		attestation, err := statement.Sign(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(attestation.PayloadType).To(Equal(dyff.AttestationPayloadType))
+
+		verified, err := attestation.Verify(publicKeyPEM(public))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(verified.Predicate.Options).To(Equal([]string{"--ignore-order-changes=true"}))
+
+		other, _, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = attestation.Verify(publicKeyPEM(other))
+		Expect(err).To(HaveOccurred())
+
+		statement.Predicate.Differences++
+		tampered, err := json.Marshal(statement)
+		Expect(err).ToNot(HaveOccurred())
+
+		attestation.Payload = base64.StdEncoding.EncodeToString(tampered)
+		_, err = attestation.Verify(publicKeyPEM(public))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should sign and verify using an ECDSA key", func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		der, err := x509.MarshalECPrivateKey(key)
+		Expect(err).ToNot(HaveOccurred())
+
+		attestation, err := statement.Sign(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = attestation.Verify(publicKeyPEM(key.Public()))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should fail to sign with something that is not a key", func() {
+		_, err := statement.Sign([]byte("not a key"))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,85 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// decodeBinary decodes the base64 content of a `!!binary` scalar, which can
+// be spread over multiple lines
+func decodeBinary(value string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+}
+
+// binarySummary returns a short description of binary data, which is the size
+// and the beginning of the SHA-256 hash of the data
+func binarySummary(data []byte) string {
+	unit := "bytes"
+	if len(data) == 1 {
+		unit = "byte"
+	}
+
+	return fmt.Sprintf("%d %s, sha256:%s", len(data), unit, sha256Hex(data)[:12])
+}
+
+// renderBinary is the default renderer for `!!binary` scalars, which are shown
+// as a summary instead of the base64 encoded data, content that cannot be
+// decoded is shown as it is
+func renderBinary(node *yamlv3.Node) (string, error) {
+	data, err := decodeBinary(node.Value)
+	if err != nil {
+		return node.Value, nil
+	}
+
+	return fmt.Sprintf("<binary data, %s>", binarySummary(data)), nil
+}
+
+// binaryValues compares the decoded content of two `!!binary` scalars, so that
+// differences in the formatting of the base64 text are ignored
+func (compare *compare) binaryValues(path ytbx.Path, from *yamlv3.Node, to *yamlv3.Node) ([]Diff, error) {
+	fromData, fromErr := decodeBinary(from.Value)
+	toData, toErr := decodeBinary(to.Value)
+
+	// fall back to a comparison of the text if the content cannot be decoded
+	if fromErr != nil || toErr != nil {
+		return compare.nodeValues(path, from, to)
+	}
+
+	if bytes.Equal(fromData, toData) {
+		return nil, nil
+	}
+
+	return []Diff{{
+		Path: &path,
+		Details: []Detail{{
+			Kind: MODIFICATION,
+			From: from,
+			To:   to,
+		}},
+	}}, nil
+}
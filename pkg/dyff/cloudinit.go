@@ -0,0 +1,118 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// cloudInitNamedLists are the top-level lists of a cloud-config, where entries
+// are either a name, or a map with the details of the named entry
+var cloudInitNamedLists = map[string]struct{}{"users": {}, "groups": {}}
+
+// cloudInitOrderInsensitive are the top-level lists of a cloud-config, where
+// the order of the entries has no meaning, unlike for example runcmd
+var cloudInitOrderInsensitive = []string{
+	"packages", "users", "groups", "write_files", "ssh_authorized_keys",
+	"snap/assertions", "apt/sources",
+}
+
+// CloudInitModules enables the identification of users and groups of a
+// cloud-config, which are either a name (e.g. `default`), or a map with a
+// name (users), or a map with the group name as the key and its members as
+// the value (groups).
+func CloudInitModules(value bool) CompareOption {
+	return func(settings *compareSettings) {
+		settings.CloudInitModules = value
+	}
+}
+
+func cloudInitPresetOptions() []CompareOption {
+	options := []CompareOption{
+		CloudInitModules(true),
+		AdditionalIdentifiers("path"),
+	}
+
+	for _, path := range cloudInitOrderInsensitive {
+		options = append(options, ScopedOptions("/"+path, IgnoreOrderChanges(true)))
+	}
+
+	return options
+}
+
+// cloudInitEntry is a list item identifier for users and groups
+type cloudInitEntry struct{}
+
+var _ listItemIdentifier = &cloudInitEntry{}
+
+func (*cloudInitEntry) Name(node *yamlv3.Node) (string, error) {
+	switch node = followAlias(node); node.Kind {
+	case yamlv3.ScalarNode:
+		return node.Value, nil
+
+	case yamlv3.MappingNode:
+		if name, ok := findValueByKey(node, "name"); ok && name.Kind == yamlv3.ScalarNode {
+			return name.Value, nil
+		}
+
+		if len(node.Content) == 2 && followAlias(node.Content[1]).Kind == yamlv3.SequenceNode {
+			return node.Content[0].Value, nil
+		}
+	}
+
+	return "", fmt.Errorf("provided node is not a named cloud-config entry")
+}
+
+func (ce *cloudInitEntry) FindNodeByName(sequenceNode *yamlv3.Node, name string) (*yamlv3.Node, error) {
+	for _, entry := range sequenceNode.Content {
+		if nameOfNode, err := ce.Name(entry); err == nil && nameOfNode == name {
+			return entry, nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to find entry with name %q", name)
+}
+
+func (*cloudInitEntry) String() string {
+	return "name"
+}
+
+// getCloudInitIdentifier returns the entry identifier for the users and
+// groups of a cloud-config, if all entries of both lists have a unique name
+func (compare *compare) getCloudInitIdentifier(path ytbx.Path, listA, listB *yamlv3.Node) listItemIdentifier {
+	if !compare.settings.CloudInitModules || len(path.PathElements) != 1 {
+		return nil
+	}
+
+	if _, ok := cloudInitNamedLists[path.PathElements[0].Name]; !ok {
+		return nil
+	}
+
+	identifier := &cloudInitEntry{}
+	if !hasUniqueNames(identifier, listA, listB) {
+		return nil
+	}
+
+	return identifier
+}
@@ -0,0 +1,233 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Comparator compares input files repeatedly using the same compare options,
+// for example in watch mode or in server sessions. It retains the state of the
+// previous comparison, i.e. the prepared documents (references resolved,
+// defaults and normalizations applied), the node hashes, and the field counts
+// of lists that are used to decide on the identifier of list entries. When
+// only one input changes, the re-comparison reuses the state of the other one.
+//
+// Inputs are recognized by their document nodes: an unchanged input has to be
+// provided with the same, unmodified nodes (it does not matter whether it was
+// the from or to input before), a changed input has to be loaded again. A
+// Comparator is not safe for concurrent use.
+type Comparator struct {
+	compare  *compare
+	prepared []preparedInput
+}
+
+// preparedInput is an input file of a comparison with its documents as they
+// were provided, and as they were compared
+type preparedInput struct {
+	location  string
+	documents []*yamlv3.Node
+	prepared  []*yamlv3.Node
+}
+
+// compareCaches are the results of calculations that only depend on a node
+// (and the settings), which are therefore cached by node pointer
+type compareCaches struct {
+	// hashes of mapping and sequence nodes, since lists are hashed repeatedly
+	// (lookup, entry and order checks)
+	hashes map[*yamlv3.Node]uint64
+
+	// number of distinct values per identifier candidate of list entries
+	candidates map[*yamlv3.Node]map[string]int
+
+	// number of distinct string values per string field of list entries
+	fields map[*yamlv3.Node]map[string]int
+}
+
+// NewComparator returns a comparator that uses the provided compare options
+// for all of its comparisons
+func NewComparator(compareOptions ...CompareOption) *Comparator {
+	return &Comparator{compare: newCompare(compareOptions...)}
+}
+
+// Compare compares the input files the same way as CompareInputFiles, but
+// reuses the state of the previous comparison for unchanged inputs
+func (comparator *Comparator) Compare(from ytbx.InputFile, to ytbx.InputFile) (Report, error) {
+	var prepared = make([]preparedInput, 0, 2)
+	for _, inputFile := range []*ytbx.InputFile{&from, &to} {
+		input, err := comparator.prepare(*inputFile)
+		if err != nil {
+			return Report{}, err
+		}
+
+		inputFile.Documents = input.prepared
+		prepared = append(prepared, input)
+	}
+
+	// only keep the state of the inputs of this comparison, entries that are
+	// not used again are dropped with the next comparison
+	comparator.prepared = prepared
+	comparator.compare.retained = comparator.compare.caches
+	comparator.compare.caches = compareCaches{}
+
+	return comparator.compare.inputFiles(from, to)
+}
+
+// prepare returns the prepared input of the previous comparison if the input
+// file is unchanged, or prepares it otherwise
+func (comparator *Comparator) prepare(inputFile ytbx.InputFile) (preparedInput, error) {
+	for _, input := range comparator.prepared {
+		if input.location == inputFile.Location && sameNodes(input.documents, inputFile.Documents) {
+			return input, nil
+		}
+	}
+
+	var input = preparedInput{location: inputFile.Location, documents: inputFile.Documents}
+	if err := comparator.compare.prepare(&inputFile); err != nil {
+		return preparedInput{}, err
+	}
+
+	input.prepared = inputFile.Documents
+	return input, nil
+}
+
+func sameNodes(a, b []*yamlv3.Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (compare *compare) cachedHash(node *yamlv3.Node) (uint64, bool) {
+	if hash, ok := compare.caches.hashes[node]; ok {
+		return hash, true
+	}
+
+	if hash, ok := compare.retained.hashes[node]; ok {
+		compare.cacheHash(node, hash)
+		return hash, true
+	}
+
+	return 0, false
+}
+
+func (compare *compare) cacheHash(node *yamlv3.Node, hash uint64) {
+	if compare.caches.hashes == nil {
+		compare.caches.hashes = map[*yamlv3.Node]uint64{}
+	}
+
+	compare.caches.hashes[node] = hash
+}
+
+// candidateCounts returns the number of distinct values per identifier
+// candidate of the mapping entries of the list
+func (compare *compare) candidateCounts(sequenceNode *yamlv3.Node, isCandidate func(*yamlv3.Node) bool) map[string]int {
+	if counts, ok := cachedCounts(&compare.caches.candidates, compare.retained.candidates, sequenceNode); ok {
+		return counts
+	}
+
+	values := map[string]map[string]struct{}{}
+	for _, entry := range sequenceNode.Content {
+		switch entry.Kind {
+		case yamlv3.MappingNode:
+			for i := 0; i < len(entry.Content); i += 2 {
+				k, v := followAlias(entry.Content[i]), followAlias(entry.Content[i+1])
+				if isCandidate(k) {
+					if _, found := values[k.Value]; !found {
+						values[k.Value] = map[string]struct{}{}
+					}
+
+					values[k.Value][v.Value] = struct{}{}
+				}
+			}
+		}
+	}
+
+	return cacheCounts(&compare.caches.candidates, sequenceNode, values)
+}
+
+// fieldCounts returns the number of distinct string values per string field
+// of the entries of the list, or no counts if not all entries are mappings
+func (compare *compare) fieldCounts(sequenceNode *yamlv3.Node) map[string]int {
+	if counts, ok := cachedCounts(&compare.caches.fields, compare.retained.fields, sequenceNode); ok {
+		return counts
+	}
+
+	values := map[string]map[string]struct{}{}
+	for _, entry := range sequenceNode.Content {
+		if entry.Kind != yamlv3.MappingNode {
+			return cacheCounts(&compare.caches.fields, sequenceNode, nil)
+		}
+
+		for i := 0; i < len(entry.Content); i += 2 {
+			k, v := followAlias(entry.Content[i]), followAlias(entry.Content[i+1])
+			if k.Kind == yamlv3.ScalarNode && k.Tag == "!!str" &&
+				v.Kind == yamlv3.ScalarNode && v.Tag == "!!str" {
+				if _, ok := values[k.Value]; !ok {
+					values[k.Value] = map[string]struct{}{}
+				}
+
+				values[k.Value][v.Value] = struct{}{}
+			}
+		}
+	}
+
+	return cacheCounts(&compare.caches.fields, sequenceNode, values)
+}
+
+func cachedCounts(cache *map[*yamlv3.Node]map[string]int, retained map[*yamlv3.Node]map[string]int, node *yamlv3.Node) (map[string]int, bool) {
+	if counts, ok := (*cache)[node]; ok {
+		return counts, true
+	}
+
+	if counts, ok := retained[node]; ok {
+		if *cache == nil {
+			*cache = map[*yamlv3.Node]map[string]int{}
+		}
+
+		(*cache)[node] = counts
+		return counts, true
+	}
+
+	return nil, false
+}
+
+func cacheCounts(cache *map[*yamlv3.Node]map[string]int, node *yamlv3.Node, values map[string]map[string]struct{}) map[string]int {
+	counts := make(map[string]int, len(values))
+	for key, value := range values {
+		counts[key] = len(value)
+	}
+
+	if *cache == nil {
+		*cache = map[*yamlv3.Node]map[string]int{}
+	}
+
+	(*cache)[node] = counts
+	return counts
+}
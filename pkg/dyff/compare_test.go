@@ -21,6 +21,9 @@
 package dyff_test
 
 import (
+	"fmt"
+	"strings"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -226,6 +229,61 @@ some:
 				Expect(err).To(BeNil())
 				Expect(diffs).To(BeNil())
 			})
+			It("should treat configured values as equivalent to null", func() {
+				from := yml(`---
+some:
+  a: ~
+  b: ""
+  c: None
+  d: foobar
+`)
+
+				to := yml(`---
+some:
+  a: None
+  b: null
+  c: ""
+  d: None
+`)
+
+				result, err := compare(from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(HaveLen(4))
+
+				result, err = compare(from, to, dyff.NullEquivalents("", "None"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(HaveLen(1))
+				Expect(result[0]).To(BeSameDiffAs(singleDiff("/some/d", dyff.MODIFICATION, "foobar", "None")))
+			})
+
+			It("should handle custom tags according to the configured mode", func() {
+				from := yml(`---
+password: !vault secret
+bucket: !Ref Bucket
+`)
+
+				to := yml(`---
+password: secret
+bucket: !Ref OtherBucket
+`)
+
+				result, err := compare(from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(HaveLen(2))
+
+				_, err = compare(from, to, dyff.CustomTags(dyff.CustomTagsStrict))
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(BeAssignableToTypeOf(&dyff.UnresolvedCustomTagError{}))
+				Expect(err.Error()).To(ContainSubstring("!vault"))
+
+				result, err = compare(from, to, dyff.CustomTags(dyff.CustomTagsStrip))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(HaveLen(1))
+				Expect(result[0]).To(BeSameDiffAs(singleDiff("/bucket", dyff.MODIFICATION, "Bucket", "OtherBucket")))
+
+				_, err = compare(from, to, dyff.CustomTags("unknown"))
+				Expect(err).To(HaveOccurred())
+			})
 		})
 
 		Context("Given two YAML structures with simple lists", func() {
@@ -356,6 +414,94 @@ list:
 				Expect(err).To(BeNil())
 				Expect(result).To(HaveLen(0))
 			})
+
+			It("should detect changes in large lists of complex entries", func() {
+				var fromEntries, toEntries []string
+				for i := 0; i < 500; i++ {
+					fromEntries = append(fromEntries, fmt.Sprintf("- [%d, %d]", i, i+1))
+				}
+
+				for i := 499; i >= 0; i-- {
+					if i != 250 {
+						toEntries = append(toEntries, fmt.Sprintf("- [%d, %d]", i, i+1))
+					}
+				}
+
+				from := yml("list:\n" + strings.Join(fromEntries, "\n"))
+				to := yml("list:\n" + strings.Join(toEntries, "\n"))
+
+				result, err := compare(from, to)
+				Expect(err).To(BeNil())
+				Expect(result).To(HaveLen(1))
+				Expect(result[0].Details).To(HaveLen(2))
+				Expect(result[0].Details[0].Kind).To(Equal(dyff.ORDERCHANGE))
+				Expect(result[0].Details[1].Kind).To(Equal(dyff.REMOVAL))
+
+				result, err = compare(from, to, dyff.IgnoreOrderChanges(true))
+				Expect(err).To(BeNil())
+				Expect(result).To(HaveLen(1))
+				Expect(result[0].Details).To(HaveLen(1))
+				Expect(result[0].Details[0].Kind).To(Equal(dyff.REMOVAL))
+
+				canonical, err := compare(from, to, dyff.NodeHashing(dyff.CanonicalHash))
+				Expect(err).To(BeNil())
+				expected, err := compare(from, to)
+				Expect(err).To(BeNil())
+				Expect(canonical).To(HaveLen(1))
+				Expect(canonical[0]).To(BeSameDiffAs(expected[0]))
+			})
+
+			It("should calculate the same node hashes when comparing repeatedly", func() {
+				from := yml(`---
+list:
+- {name: a, nested: [1, 2, {x: y}]}
+- [a, b, [c]]
+- {foo: bar, version: 1}
+`)
+
+				to := yml(`---
+list:
+- [a, b, [c]]
+- {nested: [1, 2, {x: y}], name: a}
+- {foo: bar, version: 2}
+`)
+
+				expected, err := compare(from, to)
+				Expect(err).To(BeNil())
+				Expect(expected).To(HaveLen(1))
+
+				for i := 0; i < 3; i++ {
+					result, err := compare(from, to)
+					Expect(err).To(BeNil())
+					Expect(result).To(HaveLen(1))
+					Expect(result[0]).To(BeSameDiffAs(expected[0]))
+				}
+			})
+
+			It("should match list entries using canonical node hashing", func() {
+				from := yml(`---
+list:
+- [a]
+- a
+- {foo: bar, version: 1}
+- {foo: bar, version: 2}
+`)
+
+				to := yml(`---
+list:
+- a
+- [a]
+- {version: 1, foo: bar}
+- {foo: bar, version: 3}
+`)
+
+				result, err := compare(from, to, dyff.NodeHashing(dyff.CanonicalHash), dyff.IgnoreOrderChanges(true))
+				Expect(err).To(BeNil())
+				Expect(result).To(HaveLen(1))
+				Expect(result[0]).To(BeSameDiffAs(doubleDiff("/list",
+					dyff.REMOVAL, list(`[ {foo: bar, version: 2} ]`), nil,
+					dyff.ADDITION, nil, list(`[ {foo: bar, version: 3} ]`))))
+			})
 		})
 
 		Context("Given two YAML structures with complex content", func() {
@@ -710,6 +856,13 @@ listY: [ Yo, Yo, Yo ]
 
 				_, err := dyff.CompareInputFiles(from, to)
 				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal(`comparing YAMLs with a different number of documents is currently not supported, /ginkgo/compare/test/from contains two documents and /ginkgo/compare/test/to contains one document
+  - document #2 of /ginkgo/compare/test/from is unmatched (map with keys dead)`))
+
+				mismatch, ok := err.(*dyff.DocumentCountMismatchError)
+				Expect(ok).To(BeTrue())
+				Expect(mismatch.Unmatched).To(HaveLen(1))
+				Expect(mismatch.Unmatched[0].Index).To(Equal(1))
 			})
 
 			It("should return differences in named lists even if no standard identifier is used", func() {
@@ -816,6 +969,21 @@ listY: [ Yo, Yo, Yo ]
 				Expect(report.FilterRegexp("/does/not/exist")).To(BeEquivalentTo(dyff.Report{}))
 			})
 
+			It("should exclude paths only in the document they are qualified with", func() {
+				report, err := dyff.CompareInputFiles(
+					ytbx.InputFile{Location: "from", Documents: multiDoc("{name: one, foo: bar}", "{name: two, foo: bar}")},
+					ytbx.InputFile{Location: "to", Documents: multiDoc("{name: one, foo: baz}", "{name: two, foo: baz}")},
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.Diffs).To(HaveLen(2))
+
+				Expect(dyff.DocumentQualifiedPath(report.Diffs[0].Path)).To(Equal("document #1:/foo"))
+				Expect(report.Exclude("document #1:/foo").Diffs).To(HaveLen(1))
+				Expect(report.Exclude("document #1:/foo").Diffs[0].Path.DocumentIdx).To(Equal(1))
+				Expect(report.Exclude("document #3:/foo").Diffs).To(HaveLen(2))
+				Expect(report.Exclude("/foo").Diffs).To(BeEmpty())
+			})
+
 			It("should exclude my report based on regular expressions", func() {
 				pathString := "/yaml/map/foobar"
 
@@ -832,6 +1000,141 @@ listY: [ Yo, Yo, Yo ]
 				Expect(report.ExcludeRegexp("/does/not/exist")).To(BeEquivalentTo(report))
 			})
 
+			It("should count the changes by kind and path", func() {
+				report := dyff.Report{Diffs: []dyff.Diff{
+					singleDiff("/yaml/map/added", dyff.ADDITION, nil, "added"),
+					singleDiff("/yaml/map/removed", dyff.REMOVAL, "removed", nil),
+					singleDiff("/yaml/list/name=app/image", dyff.MODIFICATION, "app:1", "app:2"),
+				}}
+
+				Expect(report.CountBy(0, "")).To(Equal(3))
+				Expect(report.CountBy(dyff.REMOVAL, "")).To(Equal(1))
+				Expect(report.CountBy(dyff.ORDERCHANGE, "")).To(Equal(0))
+				Expect(report.CountBy(0, "/yaml/map")).To(Equal(2))
+				Expect(report.CountBy(dyff.ADDITION, "/yaml/map")).To(Equal(1))
+				Expect(report.CountBy(0, "/yaml/*/name=app")).To(Equal(1))
+				Expect(report.CountBy(0, "/yaml/map/added/deeper")).To(Equal(0))
+			})
+
+			It("should group the differences by the provided key", func() {
+				report := dyff.Report{Diffs: []dyff.Diff{
+					singleDiff("/yaml/map/added", dyff.ADDITION, nil, "added"),
+					singleDiff("/yaml/list/name=app/image", dyff.MODIFICATION, "app:1", "app:2"),
+					singleDiff("/yaml/map/removed", dyff.REMOVAL, "removed", nil),
+				}}
+
+				groups := report.GroupBy(func(diff dyff.Diff) string {
+					return diff.Path.PathElements[1].Name
+				})
+
+				Expect(groups).To(HaveLen(2))
+				Expect(groups["map"].Diffs).To(Equal([]dyff.Diff{report.Diffs[0], report.Diffs[2]}))
+				Expect(groups["list"].Diffs).To(Equal([]dyff.Diff{report.Diffs[1]}))
+				Expect(dyff.Report{}.GroupBy(func(dyff.Diff) string { return "" })).To(BeEmpty())
+			})
+
+			It("should report the differences in document and path traversal order", func() {
+				from := ytbx.InputFile{Documents: multiDoc(`---
+name: one
+spec:
+  list:
+  - name: a
+    value: 1
+  - name: b
+    value: 1
+  replicas: 1
+---
+name: two
+old: true
+`)}
+
+				to := ytbx.InputFile{Documents: multiDoc(`---
+spec:
+  replicas: 2
+  list:
+  - name: b
+    value: 2
+  - name: a
+    value: 2
+name: uno
+---
+name: two
+new: true
+`)}
+
+				report, err := dyff.CompareInputFiles(from, to, dyff.IgnoreOrderChanges(true))
+				Expect(err).ToNot(HaveOccurred())
+
+				var paths = func(report dyff.Report) []string {
+					var result []string
+					for _, diff := range report.Diffs {
+						result = append(result, fmt.Sprintf("#%d%s", diff.Path.DocumentIdx, diff.Path.String()))
+					}
+
+					return result
+				}
+
+				expected := []string{
+					"#0/name",
+					"#0/spec/list/name=a/value",
+					"#0/spec/list/name=b/value",
+					"#0/spec/replicas",
+					"#1/",
+				}
+
+				Expect(paths(report)).To(Equal(expected))
+
+				var merged dyff.Report
+				for _, group := range report.GroupBy(func(diff dyff.Diff) string { return diff.Path.String() }) {
+					merged.Diffs = append(merged.Diffs, group.Diffs...)
+				}
+
+				merged.From, merged.To = report.From, report.To
+				merged.Sort()
+				Expect(paths(merged)).To(Equal(expected))
+			})
+
+			It("should count the changes per kind, document, and top-level path", func() {
+				from := ytbx.InputFile{Documents: multiDoc(`---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: one
+data:
+  a: 1
+  b: 1
+`)}
+
+				to := ytbx.InputFile{Documents: multiDoc(`---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: one
+  labels:
+    app: one
+data:
+  a: 2
+  b: 2
+`, `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: two
+`)}
+
+				report, err := dyff.CompareInputFiles(from, to)
+				Expect(err).ToNot(HaveOccurred())
+
+				statistics := report.Statistics()
+				Expect(statistics.Kinds).To(Equal(map[dyff.DetailKind]int{dyff.MODIFICATION: 2, dyff.ADDITION: 2}))
+				Expect(statistics.Documents).To(Equal(map[string]int{"v1/ConfigMap/one": 3, "v1/ConfigMap/two": 1}))
+				Expect(statistics.Paths).To(Equal(map[string]int{"/data": 2, "/metadata": 1, "/": 1}))
+				Expect(statistics.Total()).To(Equal(4))
+				Expect(statistics.SortedPaths()).To(Equal([]dyff.StatisticsEntry{{Name: "/data", Count: 2}, {Name: "/", Count: 1}, {Name: "/metadata", Count: 1}}))
+				Expect(statistics.String()).To(Equal("two modifications, two additions across two documents"))
+				Expect(dyff.Report{}.Statistics().String()).To(Equal("no changes"))
+			})
+
 			It("should ignore changes in values", func() {
 				report := dyff.Report{Diffs: []dyff.Diff{
 					singleDiff("/yaml/map/add", dyff.ADDITION, nil, "added"),
@@ -881,6 +1184,890 @@ b: bar
 			})
 		})
 
+		Context("comparing against an empty input", func() {
+			It("should report all documents as added or removed", func() {
+				for _, empty := range []ytbx.InputFile{
+					{Location: "/dev/null"},
+					{Location: "/ginkgo/compare/test/empty", Documents: multiDoc(`---`)},
+				} {
+					other := ytbx.InputFile{Location: "/ginkgo/compare/test/other", Documents: multiDoc("foo: bar\n", "baz: 1\n")}
+
+					report, err := dyff.CompareInputFiles(empty, other)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(report.Diffs).To(HaveLen(1))
+					Expect(report.Diffs[0].Path).To(BeNil())
+					Expect(report.Diffs[0].Details).To(HaveLen(1))
+					Expect(report.Diffs[0].Details[0].Kind).To(Equal(dyff.ADDITION))
+					Expect(report.Diffs[0].Details[0].To.Kind).To(Equal(yamlv3.DocumentNode))
+					Expect(report.Diffs[0].Details[0].To.Content).To(HaveLen(2))
+
+					report, err = dyff.CompareInputFiles(other, empty)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(report.Diffs).To(HaveLen(1))
+					Expect(report.Diffs[0].Details[0].Kind).To(Equal(dyff.REMOVAL))
+					Expect(report.Diffs[0].Details[0].From.Content).To(HaveLen(2))
+
+					report, err = dyff.CompareInputFiles(empty, empty)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(report.Diffs).To(BeEmpty())
+				}
+			})
+		})
+
+		Context("document inventory", func() {
+			It("should match documents by position if they are not Kubernetes resources", func() {
+				inventory := dyff.CompareInventory(
+					ytbx.InputFile{Documents: multiDoc("foo: bar", "bar: foo")},
+					ytbx.InputFile{Documents: multiDoc("foo: baz")},
+				)
+
+				Expect(inventory.HasChanges()).To(BeTrue())
+				Expect(inventory.Added).To(BeEmpty())
+				Expect(inventory.Removed).To(Equal([]string{"document #2"}))
+				Expect(inventory.Retained).To(Equal([]string{"document #1"}))
+			})
+		})
+
+		Context("projection for comparison", func() {
+			It("should only compare the projected paths", func() {
+				from := ytbx.InputFile{Location: "/ginkgo/compare/test/from", Documents: multiDoc(`---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels: {version: "1"}
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: main
+        image: app:1
+        args: [--foo]
+      - name: sidecar
+        image: proxy:1
+`)}
+
+				to := ytbx.InputFile{Location: "/ginkgo/compare/test/to", Documents: multiDoc(`---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels: {version: "2"}
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: sidecar
+        image: proxy:1
+      - name: main
+        image: app:2
+        args: [--bar]
+`)}
+
+				for _, inputFile := range []*ytbx.InputFile{&from, &to} {
+					Expect(dyff.Project(inputFile, "/spec/template/spec/containers/*/image", "/spec/replicas")).To(Succeed())
+				}
+
+				results, err := dyff.CompareInputFiles(from, to, dyff.IgnoreOrderChanges(true))
+				Expect(err).To(BeNil())
+
+				expected := []dyff.Diff{
+					singleDiff("/spec/replicas", dyff.MODIFICATION, 1, 3),
+					singleDiff("/spec/template/spec/containers/name=main/image", dyff.MODIFICATION, "app:1", "app:2"),
+				}
+
+				Expect(results.Diffs).To(HaveLen(len(expected)))
+				for i, result := range results.Diffs {
+					Expect(result).To(BeSameDiffAs(expected[i]))
+				}
+			})
+
+			It("should fail for invalid projection paths", func() {
+				inputFile := ytbx.InputFile{Documents: multiDoc(`{"foo": "bar"}`)}
+				Expect(dyff.Project(&inputFile, "foo")).ToNot(Succeed())
+				Expect(dyff.Project(&inputFile, "/")).ToNot(Succeed())
+			})
+		})
+
+		Context("scoped order change detection", func() {
+			It("should only ignore order changes at or below the provided paths", func() {
+				from := yml(`---
+spec:
+  args: [--foo, --bar]
+  containers:
+  - name: app
+    env:
+    - name: A
+    - name: B
+`)
+
+				to := yml(`---
+spec:
+  args: [--bar, --foo]
+  containers:
+  - name: app
+    env:
+    - name: B
+    - name: A
+`)
+
+				results, err := compare(from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(2))
+
+				results, err = compare(from, to, dyff.IgnoreOrderChangesAt("/spec/containers"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Path.String()).To(Equal("/spec/args"))
+
+				results, err = compare(from, to, dyff.IgnoreOrderChangesAt("spec.args"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Path.String()).To(Equal("/spec/containers/name=app/env"))
+			})
+		})
+
+		Context("scoped compare options", func() {
+			from := yml(`---
+spec:
+  args: [--foo, --bar]
+  containers:
+  - name: app
+    command: "run app "
+    env:
+    - name: A
+    - name: B
+`)
+
+			to := yml(`---
+spec:
+  args: [--bar, --foo]
+  containers:
+  - name: app
+    command: "run app"
+    env:
+    - name: B
+    - name: A
+`)
+
+			It("should only apply the options at and below the scope path", func() {
+				results, err := compare(from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(3))
+
+				results, err = compare(from, to, dyff.ScopedOptions("/spec/containers/*/env", dyff.IgnoreOrderChanges(true)))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(2))
+
+				results, err = compare(from, to, dyff.ScopedOptions("spec.containers",
+					dyff.IgnoreOrderChanges(true),
+					dyff.IgnoreWhitespaceChanges(true),
+				))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Path.String()).To(Equal("/spec/args"))
+			})
+
+			It("should let nested scopes override the settings of outer scopes", func() {
+				results, err := compare(from, to,
+					dyff.IgnoreOrderChanges(true),
+					dyff.ScopedOptions("/spec/containers", dyff.IgnoreOrderChanges(false)),
+					dyff.ScopedOptions("/spec/containers/name=app/env", dyff.IgnoreOrderChanges(true)),
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Path.String()).To(Equal("/spec/containers/name=app/command"))
+			})
+
+			It("should fail if an option of a scope is invalid", func() {
+				_, err := compare(from, to, dyff.ScopedOptions("/x", dyff.IgnoreOrderChangesAt("/a=b=c")))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid options for scope /x"))
+				Expect(err.Error()).To(ContainSubstring(`invalid path "/a=b=c"`))
+			})
+		})
+
+		Context("block scalar style changes", func() {
+			from := yml("script: |\n  echo foo\n  echo bar\nother: |\n  foo\n")
+			to := yml("script: |-\n    echo foo\n    echo bar\nother: |\n  bar\n")
+
+			It("should report chomping indicator changes unless they are ignored", func() {
+				results, err := compare(from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(2))
+				Expect(humanDiff(results[0])).To(ContainSubstring("block scalar style change"))
+				Expect(humanDiff(results[0])).To(ContainSubstring("- block scalar |\n"))
+				Expect(humanDiff(results[0])).To(ContainSubstring("+ block scalar |-\n"))
+
+				results, err = compare(from, to, dyff.IgnoreBlockScalarStyleChanges(true))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Path.String()).To(Equal("/other"))
+			})
+		})
+
+		Context("script normalization", func() {
+			from := yml(`---
+build:
+  script:
+  - make build
+  - make test
+data:
+  setup.sh: |
+    #!/bin/sh
+    set -e
+    echo "setting up"
+    ./configure --prefix=/usr
+`)
+
+			It("should ignore blank lines and comments of scripts", func() {
+				to := yml(`---
+build:
+  script:
+  - "# build everything"
+  - make build
+  - make test
+data:
+  setup.sh: |
+    #!/bin/sh
+    set -e
+
+    # announce what is going on
+    echo "setting up"   
+    ./configure --prefix=/usr
+`)
+
+				results, err := compare(from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(2))
+
+				results, err = compare(from, to, dyff.NormalizeScripts(true))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(BeEmpty())
+			})
+
+			It("should still report changed commands", func() {
+				to := yml(`---
+build:
+  script:
+  - make build
+  - make test
+data:
+  setup.sh: |
+    #!/bin/sh
+    set -e
+    # announce what is going on
+    echo "setting up"
+    ./configure --prefix=/usr/local
+`)
+
+				results, err := compare(from, to, dyff.NormalizeScripts(true))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Path.String()).To(Equal("/data/setup.sh"))
+				Expect(humanDiff(results[0])).To(ContainSubstring("./configure --prefix=/usr/local"))
+				Expect(humanDiff(results[0])).ToNot(ContainSubstring("announce"))
+			})
+		})
+
+		Context("Concourse preset", func() {
+			var from = yml(`---
+jobs:
+- name: build
+  plan:
+  - get: source
+    trigger: true
+  - get: image
+  - task: unit
+    file: source/ci/unit.yml
+  - put: image
+    params: {build: source}
+`)
+
+			It("should report changed steps individually", func() {
+				to := yml(`---
+jobs:
+- name: build
+  plan:
+  - get: source
+    trigger: false
+  - get: image
+  - task: unit
+    file: source/ci/unit.yml
+  - put: image
+    params: {build: source}
+`)
+
+				preset, err := dyff.Preset(dyff.ConcoursePreset)
+				Expect(err).ToNot(HaveOccurred())
+
+				results, err := compare(from, to, preset)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Path.String()).To(Equal("/jobs/name=build/plan/get=source/trigger"))
+				Expect(results[0].Details[0].Kind).To(Equal(dyff.MODIFICATION))
+			})
+
+			It("should report reordered, added, and removed steps", func() {
+				to := yml(`---
+jobs:
+- name: build
+  plan:
+  - get: image
+  - get: source
+    trigger: true
+  - task: lint
+    file: source/ci/lint.yml
+  - put: image
+    params: {build: source}
+`)
+
+				results, err := compare(from, to, dyff.ConcourseSteps(true))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Path.String()).To(Equal("/jobs/name=build/plan"))
+
+				var kinds []dyff.DetailKind
+				for _, detail := range results[0].Details {
+					kinds = append(kinds, detail.Kind)
+				}
+
+				Expect(kinds).To(ConsistOf(dyff.ORDERCHANGE, dyff.ADDITION, dyff.REMOVAL))
+			})
+
+			It("should fail for unknown presets", func() {
+				_, err := dyff.Preset("unknown")
+				Expect(err).To(MatchError(ContainSubstring(`unknown preset "unknown"`)))
+			})
+		})
+
+		Context("Ansible preset", func() {
+			from := yml(`---
+- name: web servers
+  hosts: webservers
+  tasks:
+  - name: Install nginx
+    apt: {name: nginx}
+    when: ansible_os_family == "Debian" and nginx_enabled
+  - include_tasks: setup.yml
+  - name: Start nginx
+    service: {name: nginx, state: started}
+  handlers:
+  - name: restart nginx
+    service: {name: nginx, state: restarted}
+`)
+
+			var preset dyff.CompareOption
+			BeforeEach(func() {
+				var err error
+				preset, err = dyff.Preset(dyff.AnsiblePreset)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should identify tasks by their name or included file", func() {
+				to := yml(`---
+- name: web servers
+  hosts: webservers
+  tasks:
+  - name: Install nginx
+    apt: {name: nginx}
+    when:
+    - ansible_os_family   ==   "Debian"
+    - "{{ nginx_enabled }}"
+  - name: Configure nginx
+    template: {src: nginx.conf.j2, dest: /etc/nginx/nginx.conf}
+  - include_tasks: setup.yml
+    tags: [setup]
+  - name: Start nginx
+    service: {name: nginx, state: reloaded}
+  handlers:
+  - name: restart nginx
+    service: {name: nginx, state: restarted}
+`)
+
+				results, err := compare(from, to, preset)
+				Expect(err).ToNot(HaveOccurred())
+
+				var paths []string
+				for _, result := range results {
+					paths = append(paths, result.Path.String())
+				}
+
+				Expect(paths).To(ConsistOf(
+					"/name=web servers/tasks",
+					"/name=web servers/tasks/include_tasks=setup.yml",
+					"/name=web servers/tasks/name=Start nginx/service/state",
+				))
+			})
+
+			It("should still report changed conditions", func() {
+				to := yml(`---
+- name: web servers
+  hosts: webservers
+  tasks:
+  - name: Install nginx
+    apt: {name: nginx}
+    when: ansible_os_family == "RedHat" and nginx_enabled
+  - include_tasks: setup.yml
+  - name: Start nginx
+    service: {name: nginx, state: started}
+  handlers:
+  - name: restart nginx
+    service: {name: nginx, state: restarted}
+`)
+
+				results, err := compare(from, to, preset)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Path.String()).To(Equal("/name=web servers/tasks/name=Install nginx/when"))
+			})
+		})
+
+		Context("cloud-init preset", func() {
+			It("should identify users, groups, and files by their name or path", func() {
+				from := yml(`---
+users:
+- default
+- name: alice
+  groups: sudo
+groups:
+- admins
+- developers: [alice]
+packages: [nginx, git]
+write_files:
+- path: /etc/motd
+  content: hello
+- path: /etc/issue
+  content: welcome
+runcmd:
+- systemctl restart nginx
+`)
+
+				to := yml(`---
+users:
+- name: alice
+  groups: sudo, docker
+- default
+groups:
+- developers: [alice, bob]
+- admins
+packages: [git, nginx]
+write_files:
+- path: /etc/issue
+  content: welcome
+- path: /etc/motd
+  content: hello world
+runcmd:
+- systemctl restart nginx
+`)
+
+				preset, err := dyff.Preset(dyff.CloudInitPreset)
+				Expect(err).ToNot(HaveOccurred())
+
+				results, err := compare(from, to, preset)
+				Expect(err).ToNot(HaveOccurred())
+
+				var paths []string
+				for _, result := range results {
+					paths = append(paths, result.Path.String())
+				}
+
+				Expect(paths).To(ConsistOf(
+					"/users/name=alice/groups",
+					"/groups/name=developers/developers",
+					"/write_files/path=/etc/motd/content",
+				))
+			})
+		})
+
+		Context("Prometheus preset", func() {
+			from := yml(`---
+groups:
+- name: api
+  rules:
+  - record: job:http_requests:rate5m
+    expr: sum by (job) (rate(http_requests_total[5m]))
+  - alert: HighErrorRate
+    expr: |
+      sum by (job) (rate(http_requests_total{code=~"5.."}[5m]))
+        / sum by (job) (rate(http_requests_total[5m])) > 0.05
+    for: 10m
+    labels: {severity: page}
+  - alert: InstanceDown
+    expr: up == 0
+    for: 5m
+`)
+
+			var preset dyff.CompareOption
+			BeforeEach(func() {
+				var err error
+				preset, err = dyff.Preset(dyff.PrometheusPreset)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should identify rules by their alert or record field", func() {
+				to := yml(`---
+groups:
+- name: api
+  rules:
+  - alert: InstanceDown
+    expr: up == 0
+    for: 2m
+  - alert: HighLatency
+    expr: histogram_quantile(0.99, sum by (le) (rate(http_request_duration_seconds_bucket[5m]))) > 1
+  - record: job:http_requests:rate5m
+    expr: sum by(job)( rate(http_requests_total[5m]) )
+  - alert: HighErrorRate
+    expr: sum by (job) (rate(http_requests_total{code=~"5.."}[5m])) / sum by (job) (rate(http_requests_total[5m])) > 0.05
+    for: 10m
+    labels: {severity: ticket}
+`)
+
+				results, err := compare(from, to, preset)
+				Expect(err).ToNot(HaveOccurred())
+
+				var paths []string
+				for _, result := range results {
+					paths = append(paths, result.Path.String())
+				}
+
+				Expect(paths).To(ConsistOf(
+					"/groups/name=api/rules",
+					"/groups/name=api/rules/alert=InstanceDown/for",
+					"/groups/name=api/rules/alert=HighErrorRate/labels/severity",
+				))
+			})
+
+			It("should still report changed expressions", func() {
+				to := yml(`---
+groups:
+- name: api
+  rules:
+  - record: job:http_requests:rate5m
+    expr: sum by (job) (rate(http_requests_total[5m]))
+  - alert: HighErrorRate
+    expr: |
+      sum by (job) (rate(http_requests_total{code=~"5.."}[5m]))
+        / sum by (job) (rate(http_requests_total[5m])) > 0.05
+    for: 10m
+    labels: {severity: page}
+  - alert: InstanceDown
+    expr: up{job="api"} == 0
+    for: 5m
+`)
+
+				results, err := compare(from, to, preset)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Path.String()).To(Equal("/groups/name=api/rules/alert=InstanceDown/expr"))
+			})
+		})
+
+		Context("Compose preset", func() {
+			from := yml(`---
+services:
+  web:
+    image: nginx:1.27
+    ports:
+    - "8080:80"
+    - "127.0.0.1:8443:443/tcp"
+    - 9090
+    environment:
+    - MODE=production
+    - DEBUG
+    depends_on:
+    - db
+    - cache
+`)
+
+			var preset dyff.CompareOption
+			BeforeEach(func() {
+				var err error
+				preset, err = dyff.Preset(dyff.ComposePreset)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should not report different syntaxes of the same services", func() {
+				to := yml(`---
+services:
+  web:
+    image: nginx:1.27
+    ports:
+    - target: 443
+      published: "8443"
+      host_ip: 127.0.0.1
+    - target: 9090
+      protocol: tcp
+    - target: 80
+      published: 8080
+    environment:
+      DEBUG:
+      MODE: production
+    depends_on:
+      cache:
+        condition: service_started
+      db: {}
+`)
+
+				results, err := compare(from, to, preset)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(BeEmpty())
+			})
+
+			It("should still report actual changes of services", func() {
+				to := yml(`---
+services:
+  web:
+    image: nginx:1.27
+    ports:
+    - "8080:80"
+    - "127.0.0.1:8443:443/udp"
+    - 9090
+    environment:
+    - MODE=staging
+    - DEBUG
+    depends_on:
+    - db
+    - cache
+`)
+
+				results, err := compare(from, to, preset)
+				Expect(err).ToNot(HaveOccurred())
+
+				var paths []string
+				for _, result := range results {
+					paths = append(paths, result.Path.String())
+				}
+
+				Expect(paths).To(ConsistOf("/services/web/ports", "/services/web/environment/MODE"))
+			})
+
+			It("should not change the input documents", func() {
+				to := yml(`---
+services:
+  web:
+    ports:
+    - "8080:80"
+`)
+
+				_, err := compare(from, to, preset)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(to.Content[1].Content[1].Content[1].Content[0].Value).To(Equal("8080:80"))
+			})
+		})
+
+		Context("LCS list diff strategy", func() {
+			from := yml(`---
+args:
+- --verbose
+- --port=8080
+- --log=debug
+- --once
+`)
+
+			to := yml(`---
+args:
+- --verbose
+- --port=9090
+- --log=debug
+- --new
+- --once
+- --tail
+`)
+
+			It("should report insertions and deletions at their index", func() {
+				results, err := compare(from, to, dyff.ListDiffStrategy(dyff.LCS))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(4))
+				Expect(results[0]).To(BeSameDiffAs(singleDiff("/args/1", dyff.REMOVAL, list(`[ --port=8080 ]`), nil)))
+				Expect(results[1]).To(BeSameDiffAs(singleDiff("/args/1", dyff.ADDITION, nil, list(`[ --port=9090 ]`))))
+				Expect(results[2]).To(BeSameDiffAs(singleDiff("/args/3", dyff.ADDITION, nil, list(`[ --new ]`))))
+				Expect(results[3]).To(BeSameDiffAs(singleDiff("/args/5", dyff.ADDITION, nil, list(`[ --tail ]`))))
+			})
+
+			It("should report consecutive changes as one run", func() {
+				results, err := compare(
+					yml("list: [a, b, c, d, e]"),
+					yml("list: [a, x, y, e]"),
+					dyff.ListDiffStrategy(dyff.LCS),
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(2))
+				Expect(results[0]).To(BeSameDiffAs(singleDiff("/list/1", dyff.REMOVAL, list(`[ b, c, d ]`), nil)))
+				Expect(results[1]).To(BeSameDiffAs(singleDiff("/list/1", dyff.ADDITION, nil, list(`[ x, y ]`))))
+			})
+
+			It("should report moved entries as deletion and insertion", func() {
+				results, err := compare(yml("list: [a, b, c]"), yml("list: [c, a, b]"), dyff.ListDiffStrategy(dyff.LCS))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(2))
+				Expect(results[0]).To(BeSameDiffAs(singleDiff("/list/0", dyff.ADDITION, nil, list(`[ c ]`))))
+				Expect(results[1]).To(BeSameDiffAs(singleDiff("/list/2", dyff.REMOVAL, list(`[ c ]`), nil)))
+
+				results, err = compare(yml("list: [a, b, c]"), yml("list: [c, a, b]"), dyff.ListDiffStrategy(dyff.LCS), dyff.IgnoreOrderChanges(true))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(BeEmpty())
+			})
+
+			It("should only compare the entries between the common prefix and suffix of large lists", func() {
+				var fromList, toList []string
+				for i := 0; i < 5000; i++ {
+					fromList = append(fromList, fmt.Sprintf("entry-%d", i))
+					toList = append(toList, fmt.Sprintf("entry-%d", i))
+				}
+
+				toList[2500] = "changed"
+
+				results, err := compare(
+					yml("list: ["+strings.Join(fromList, ", ")+"]"),
+					yml("list: ["+strings.Join(toList, ", ")+"]"),
+					dyff.ListDiffStrategy(dyff.LCS),
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(2))
+				Expect(results[0]).To(BeSameDiffAs(singleDiff("/list/2500", dyff.REMOVAL, list(`[ entry-2500 ]`), nil)))
+				Expect(results[1]).To(BeSameDiffAs(singleDiff("/list/2500", dyff.ADDITION, nil, list(`[ changed ]`))))
+			})
+
+			It("should compare lists as sets if they are too large for the longest common subsequence", func() {
+				var fromList, toList []string
+				for i := 0; i < 3000; i++ {
+					fromList = append(fromList, fmt.Sprintf("from-%d", i))
+					toList = append(toList, fmt.Sprintf("to-%d", i))
+				}
+
+				results, err := compare(
+					yml("list: ["+strings.Join(fromList, ", ")+"]"),
+					yml("list: ["+strings.Join(toList, ", ")+"]"),
+					dyff.ListDiffStrategy(dyff.LCS),
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Path.String()).To(Equal("/list"))
+			})
+		})
+
+		Context("moved map entries", func() {
+			from := yml(`---
+spec:
+  oldName:
+    image: foo
+    replicas: 2
+  flag: true
+  other: true
+`)
+
+			to := yml(`---
+spec:
+  newName:
+    image: foo
+    replicas: 2
+  feature: true
+status:
+  other: true
+`)
+
+			It("should report removed and added identical entries as moved", func() {
+				results, err := compare(from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(2))
+
+				results, err = compare(from, to, dyff.DetectMoves(true))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(4))
+
+				Expect(results[0].Path.String()).To(Equal("/"))
+				Expect(results[0].Details[0].Kind).To(Equal(dyff.ADDITION))
+
+				Expect(results[1].Path.String()).To(Equal("/spec"))
+				Expect(results[1].Details).To(HaveLen(1))
+				Expect(results[1].Details[0].Kind).To(Equal(dyff.REMOVAL))
+				Expect(humanDiff(results[1])).To(ContainSubstring("other: true"))
+
+				Expect(results[2].Path.String()).To(Equal("/spec/newName"))
+				Expect(results[2].Details[0].Kind).To(Equal(dyff.MOVED))
+				Expect(results[2].Details[0].From.Value).To(Equal("/spec/oldName"))
+
+				Expect(results[3]).To(BeSameDiffAs(singleDiff("/spec/feature", dyff.MOVED, "/spec/flag", yml("true"))))
+				Expect(humanDiff(results[3])).To(ContainSubstring("→ moved from spec.flag"))
+			})
+		})
+
+		Context("excluded paths", func() {
+			It("should not compare excluded paths and everything below them", func() {
+				from := yml(`---
+metadata:
+  name: config
+data:
+  foo: bar
+  big: blob
+`)
+
+				to := yml(`---
+metadata:
+  name: configuration
+data:
+  foo: baz
+  new: entry
+`)
+
+				results, err := compare(from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(3))
+
+				results, err = compare(from, to, dyff.ExcludePaths("/data"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0]).To(BeSameDiffAs(singleDiff("/metadata/name", dyff.MODIFICATION, "config", "configuration")))
+
+				results, err = compare(from, to, dyff.ExcludePaths("data.foo", "/metadata"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Path.String()).To(Equal("/data"))
+			})
+
+			It("should fail for invalid paths to be excluded", func() {
+				_, err := compare(yml(`{foo: bar}`), yml(`{foo: baz}`), dyff.ExcludePaths("/a=b=c"))
+				Expect(err).To(HaveOccurred())
+
+				_, err = compare(yml(`{foo: bar}`), yml(`{foo: baz}`), dyff.IgnoreOrderChangesAt("/a=b=c"))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("suppression comments in input files", func() {
+			from := `---
+spec:
+  replicas: 1 # dyff:ignore
+  # dyff:ignore
+  annotations:
+    build: "1234"
+  image: app:1
+`
+
+			to := `---
+spec:
+  replicas: 3
+  image: app:2
+  debug: true # dyff:ignore
+`
+
+			It("should skip map entries annotated with dyff:ignore in either file", func() {
+				results, err := compare(yml(from), yml(to))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0]).To(BeSameDiffAs(singleDiff("/spec/image", dyff.MODIFICATION, "app:1", "app:2")))
+			})
+
+			It("should report annotated map entries if suppression comments are disabled", func() {
+				results, err := compare(yml(from), yml(to), dyff.SuppressionComments(false))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(3))
+			})
+		})
+
 		Context("two YAML structures with Kubernetes lists", func() {
 			It("should identify individual list entries based on the nested name field in the respective entry metadata", func() {
 				from, to := loadFiles(
@@ -1125,5 +2312,132 @@ b: bar
 				Expect(results.Diffs).To(HaveLen(0))
 			})
 		})
+
+		Context("comparing input files repeatedly", func() {
+			var versions = []string{`---
+script: |
+  # install
+  make install
+list:
+- [a, b]
+- {foo: bar, version: 1}
+containers:
+- name: app
+  image: app:1
+`, `---
+script: |
+  make install
+
+list:
+- {foo: bar, version: 1}
+- [a, b]
+containers:
+- name: app
+  image: app:2
+- name: sidecar
+  image: proxy:1
+`, `---
+script: |
+  make test
+list:
+- {foo: bar, version: 2}
+containers:
+- name: sidecar
+  image: proxy:1
+`}
+
+			It("should report the same differences as a single comparison when only one input changes", func() {
+				var files = make([]ytbx.InputFile, len(versions))
+				for i, version := range versions {
+					files[i] = ytbx.InputFile{Location: fmt.Sprintf("version-%d.yml", i), Documents: multiDoc(version)}
+				}
+
+				options := []dyff.CompareOption{dyff.NormalizeScripts(true), dyff.IgnoreOrderChanges(true)}
+				comparator := dyff.NewComparator(options...)
+
+				for _, pair := range [][2]int{{0, 1}, {1, 2}, {0, 2}, {0, 2}, {2, 0}} {
+					expected, err := dyff.CompareInputFiles(files[pair[0]], files[pair[1]], options...)
+					Expect(err).ToNot(HaveOccurred())
+
+					report, err := comparator.Compare(files[pair[0]], files[pair[1]])
+					Expect(err).ToNot(HaveOccurred())
+					Expect(report.Diffs).To(HaveLen(len(expected.Diffs)), "%v", pair)
+					for i := range expected.Diffs {
+						Expect(report.Diffs[i]).To(BeSameDiffAs(expected.Diffs[i]), "%v", pair)
+					}
+				}
+			})
+
+			It("should keep the state of the previous comparison when an input cannot be compared", func() {
+				from := ytbx.InputFile{Location: "from.yml", Documents: multiDoc(versions[0])}
+				to := ytbx.InputFile{Location: "to.yml", Documents: multiDoc(versions[1])}
+				tagged := ytbx.InputFile{Location: "tagged.yml", Documents: multiDoc(`{"password": !vault "secret/a"}`)}
+
+				comparator := dyff.NewComparator(dyff.CustomTags(dyff.CustomTagsStrict))
+
+				expected, err := comparator.Compare(from, to)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = comparator.Compare(from, tagged)
+				Expect(err).To(HaveOccurred())
+
+				report, err := comparator.Compare(from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.Diffs).To(Equal(expected.Diffs))
+			})
+		})
+
+		Context("input files containing lists where only a combination of fields is unique", func() {
+			from := yml(`---
+endpoints:
+- host: example.org
+  port: 80
+  weight: 1
+- host: example.org
+  port: 443
+  weight: 1
+- host: example.com
+  port: 443
+  weight: 1
+`)
+
+			to := yml(`---
+endpoints:
+- host: example.com
+  port: 443
+  weight: 1
+- host: example.org
+  port: 443
+  weight: 2
+- host: example.org
+  port: 80
+  weight: 1
+`)
+
+			It("should use the composite identifier to match the list entries", func() {
+				results, err := compare(from, to, dyff.IgnoreOrderChanges(true), dyff.CompositeIdentifier("host", "port"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0]).To(BeSameDiffAs(singleDiff("/endpoints/host+port=example.org+443/weight", dyff.MODIFICATION, 1, 2)))
+			})
+
+			It("should not use composite identifiers that are not unique", func() {
+				results, err := compare(from, to, dyff.IgnoreOrderChanges(true), dyff.CompositeIdentifier("host", "weight"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Path.String()).To(Equal("/endpoints"))
+			})
+
+			It("should compare the values of the composite identifier field by field", func() {
+				results, err := compare(
+					yml(`{list: [{a: "x+y", b: z, value: 1}, {a: x, b: "y+z", value: 1}]}`),
+					yml(`{list: [{a: "x+y", b: z, value: 1}, {a: x, b: "y+z", value: 2}]}`),
+					dyff.CompositeIdentifier("a", "b"),
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Path.String()).To(Equal(`/list/a+b=x+y\+z/value`))
+			})
+		})
 	})
 })
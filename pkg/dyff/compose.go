@@ -0,0 +1,283 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// composeOrderInsensitive are the lists of a Docker Compose service, where the
+// order of the entries has no meaning
+var composeOrderInsensitive = []string{
+	"ports", "expose", "depends_on", "networks", "volumes", "volumes_from",
+	"secrets", "configs", "cap_add", "cap_drop", "dns", "dns_search",
+	"extra_hosts", "env_file", "devices", "links", "profiles", "security_opt",
+}
+
+// ComposeNormalization normalizes the different syntaxes of Docker Compose
+// services before comparing them: ports in the short syntax (`"8080:80"`) are
+// converted into the long syntax (target, published, host_ip, protocol),
+// environment variables and labels in the list syntax (`KEY=value`) are
+// converted into maps, and the list syntax of depends_on is converted into
+// the long syntax with the default condition.
+func ComposeNormalization(value bool) CompareOption {
+	return func(settings *compareSettings) {
+		settings.ComposeNormalization = value
+	}
+}
+
+func composePresetOptions() []CompareOption {
+	options := []CompareOption{ComposeNormalization(true)}
+	for _, field := range composeOrderInsensitive {
+		options = append(options, ScopedOptions("/services/*/"+field, IgnoreOrderChanges(true)))
+	}
+
+	return options
+}
+
+// normalizeCompose normalizes the services of all documents, the documents
+// are copied so that the input files keep their original content
+func (compare *compare) normalizeCompose(inputFiles ...*ytbx.InputFile) {
+	if !compare.settings.ComposeNormalization {
+		return
+	}
+
+	for _, inputFile := range inputFiles {
+		documents := make([]*yamlv3.Node, len(inputFile.Documents))
+		for i, document := range inputFile.Documents {
+			documents[i] = copyNode(document)
+
+			services, ok := mappingValue(documentRoot(documents[i]), "services")
+			if !ok || services.Kind != yamlv3.MappingNode {
+				continue
+			}
+
+			for j := 1; j < len(services.Content); j += 2 {
+				normalizeComposeService(services.Content[j])
+			}
+		}
+
+		inputFile.Documents = documents
+	}
+}
+
+func normalizeComposeService(service *yamlv3.Node) {
+	if service.Kind != yamlv3.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(service.Content); i += 2 {
+		value := service.Content[i+1]
+
+		switch service.Content[i].Value {
+		case "ports":
+			if value.Kind == yamlv3.SequenceNode {
+				for j, port := range value.Content {
+					value.Content[j] = composePort(port)
+				}
+			}
+
+		case "environment", "labels":
+			service.Content[i+1] = composeKeyValues(value)
+
+		case "depends_on":
+			service.Content[i+1] = composeDependencies(value)
+		}
+	}
+}
+
+// composePort returns the long syntax of a port definition, with the default
+// protocol (tcp) in case none is set. Port ranges are kept as they are.
+func composePort(port *yamlv3.Node) *yamlv3.Node {
+	var fields = map[string]string{}
+	var others []*yamlv3.Node
+
+	switch port.Kind {
+	case yamlv3.ScalarNode:
+		spec := port.Value
+		if idx := strings.LastIndex(spec, "/"); idx >= 0 {
+			spec, fields["protocol"] = spec[:idx], spec[idx+1:]
+		}
+
+		// an IPv6 host address is written in brackets, e.g. [::1]:8080:80
+		if strings.HasPrefix(spec, "[") {
+			if idx := strings.Index(spec, "]:"); idx > 0 {
+				fields["host_ip"], spec = spec[1:idx], spec[idx+2:]
+			}
+		}
+
+		parts := strings.Split(spec, ":")
+		switch len(parts) {
+		case 1:
+			fields["target"] = parts[0]
+
+		case 2:
+			fields["published"], fields["target"] = parts[0], parts[1]
+
+		case 3:
+			fields["host_ip"], fields["published"], fields["target"] = parts[0], parts[1], parts[2]
+
+		default:
+			return port
+		}
+
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(port.Content); i += 2 {
+			switch key, value := port.Content[i].Value, followAlias(port.Content[i+1]); key {
+			case "target", "published", "host_ip", "protocol":
+				fields[key] = value.Value
+
+			default:
+				others = append(others, port.Content[i], port.Content[i+1])
+			}
+		}
+
+	default:
+		return port
+	}
+
+	if !isDigitsOnly(fields["target"]) {
+		return port
+	}
+
+	if fields["protocol"] == "" {
+		fields["protocol"] = "tcp"
+	}
+
+	result := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+	for _, key := range []string{"target", "published", "host_ip", "protocol"} {
+		if value := fields[key]; value != "" {
+			tag := "!!str"
+			if key == "target" {
+				tag = "!!int"
+			}
+
+			result.Content = append(result.Content,
+				&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: key},
+				&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: tag, Value: value},
+			)
+		}
+	}
+
+	result.Content = append(result.Content, others...)
+	return result
+}
+
+// composeKeyValues returns the map syntax of environment variables or labels,
+// where all values are strings (a variable without value is null)
+func composeKeyValues(node *yamlv3.Node) *yamlv3.Node {
+	result := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+
+	var add = func(key string, value *yamlv3.Node) {
+		if !isNullNode(value) {
+			value = &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value.Value}
+		}
+
+		result.Content = append(result.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: key}, value)
+	}
+
+	switch node.Kind {
+	case yamlv3.SequenceNode:
+		for _, entry := range node.Content {
+			if entry = followAlias(entry); entry.Kind != yamlv3.ScalarNode {
+				return node
+			}
+
+			key, value, found := strings.Cut(entry.Value, "=")
+			if found {
+				add(key, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value})
+			} else {
+				add(key, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!null", Value: "null"})
+			}
+		}
+
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value := followAlias(node.Content[i+1])
+			if value.Kind != yamlv3.ScalarNode {
+				return node
+			}
+
+			add(node.Content[i].Value, value)
+		}
+
+	default:
+		return node
+	}
+
+	return result
+}
+
+// composeDependencies returns the long syntax of depends_on, where services
+// without a condition use the default condition (service_started)
+func composeDependencies(node *yamlv3.Node) *yamlv3.Node {
+	var condition = func() []*yamlv3.Node {
+		return []*yamlv3.Node{
+			{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: "condition"},
+			{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: "service_started"},
+		}
+	}
+
+	switch node.Kind {
+	case yamlv3.SequenceNode:
+		result := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		for _, entry := range node.Content {
+			if entry = followAlias(entry); entry.Kind != yamlv3.ScalarNode {
+				return node
+			}
+
+			result.Content = append(result.Content,
+				&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: entry.Value},
+				&yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map", Content: condition()},
+			)
+		}
+
+		return result
+
+	case yamlv3.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			if dependency := node.Content[i]; dependency.Kind == yamlv3.MappingNode {
+				if _, ok := findValueByKey(dependency, "condition"); !ok {
+					dependency.Content = append(condition(), dependency.Content...)
+				}
+			}
+		}
+	}
+
+	return node
+}
+
+func isDigitsOnly(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
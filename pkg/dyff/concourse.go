@@ -0,0 +1,102 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// concourseStepTypes are the fields that define the type of a step in the
+// plan of a Concourse job, the value is the name of the resource, task, or
+// pipeline the step refers to
+var concourseStepTypes = []string{"get", "put", "task", "set_pipeline", "load_var"}
+
+// ConcourseSteps enables the identification of the steps in the plans of
+// Concourse jobs by their type and name (e.g. `get: source`), so that added,
+// removed, reordered, or changed steps are reported individually instead of
+// as a replacement of the whole plan. Lists with steps that have no such name
+// (e.g. `in_parallel`), or with ambiguous names are compared as usual.
+func ConcourseSteps(value bool) CompareOption {
+	return func(settings *compareSettings) {
+		settings.ConcourseSteps = value
+	}
+}
+
+// concourseStep is a list item identifier for steps of Concourse plans, the
+// name of a step is its type and name, for example `get:source`
+type concourseStep struct{}
+
+var _ listItemIdentifier = &concourseStep{}
+
+func (*concourseStep) Name(node *yamlv3.Node) (string, error) {
+	node = followAlias(node)
+	if node.Kind != yamlv3.MappingNode {
+		return "", fmt.Errorf("provided node is not a mapping node")
+	}
+
+	for _, stepType := range concourseStepTypes {
+		if value, ok := findValueByKey(node, stepType); ok && value.Kind == yamlv3.ScalarNode {
+			return stepType + ":" + value.Value, nil
+		}
+	}
+
+	return "", fmt.Errorf("provided node is not a named Concourse step")
+}
+
+func (cs *concourseStep) FindNodeByName(sequenceNode *yamlv3.Node, name string) (*yamlv3.Node, error) {
+	for _, entry := range sequenceNode.Content {
+		if nameOfNode, err := cs.Name(entry); err == nil && nameOfNode == name {
+			return entry, nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to find step with name %q", name)
+}
+
+func (*concourseStep) String() string {
+	return "step"
+}
+
+// PathElement returns the path element of the step, which refers to the
+// field of the step type, for example `get=source`
+func (*concourseStep) PathElement(name string) ytbx.PathElement {
+	stepType, stepName, _ := strings.Cut(name, ":")
+	return ytbx.PathElement{Idx: -1, Key: stepType, Name: stepName}
+}
+
+// getConcourseStepIdentifier returns the step identifier, if all entries of
+// both lists are steps with a unique name
+func (compare *compare) getConcourseStepIdentifier(listA, listB *yamlv3.Node) listItemIdentifier {
+	if !compare.settings.ConcourseSteps {
+		return nil
+	}
+
+	identifier := &concourseStep{}
+	if !hasUniqueNames(identifier, listA, listB) {
+		return nil
+	}
+
+	return identifier
+}
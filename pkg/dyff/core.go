@@ -28,7 +28,6 @@ import (
 	"github.com/gonvenience/bunt"
 	"github.com/gonvenience/text"
 	"github.com/gonvenience/ytbx"
-	"github.com/mitchellh/hashstructure"
 	yamlv3 "gopkg.in/yaml.v3"
 )
 
@@ -38,13 +37,52 @@ type CompareOption func(*compareSettings)
 type compareSettings struct {
 	NonStandardIdentifierGuessCountThreshold int
 	IgnoreOrderChanges                       bool
+	IgnoreOrderChangesAt                     []string
+	ExcludePaths                             []string
 	IgnoreWhitespaceChanges                  bool
+	IgnoreNumberFormatChanges                bool
+	IgnoreBlockScalarStyleChanges            bool
 	KubernetesEntityDetection                bool
 	AdditionalIdentifiers                    []string
+	CompositeIdentifiers                     [][]string
+	NullEquivalents                          []string
+	CustomTags                               CustomTagMode
+	ListDiffStrategy                         ListDiffMode
+	NodeHashing                              NodeHashMode
+	DetectMoves                              bool
+	NormalizeLineEndings                     bool
+	CompareDirectives                        bool
+	ConcourseSteps                           bool
+	ComposeNormalization                     bool
+	AnsibleTasks                             bool
+	CloudInitModules                         bool
+	PrometheusRules                          bool
+	NormalizeScripts                         bool
+	ValuesDefaults                           *yamlv3.Node
+	ValuesSchema                             *yamlv3.Node
+	SuppressionComments                      bool
+	Scopes                                   []scopedOptions
+	Jobs                                     int
+	FileMetadata                             bool
+	FollowRefs                               RefMode
+	FileCompared                             func(path string, report Report)
+
+	// pairDocumentsByPosition disables the pairing of Kubernetes resources by
+	// name, which is used to compare one template against many documents
+	pairDocumentsByPosition bool
+
+	// err is the first error of an invalid compare option, which is returned
+	// when the comparison starts
+	err error
 }
 
 type compare struct {
 	settings compareSettings
+
+	// caches of the current comparison, and the ones retained from the
+	// previous comparison of a Comparator (entries move over on first use)
+	caches   compareCaches
+	retained compareCaches
 }
 
 // AdditionalIdentifiers specifies additional identifiers that will be
@@ -55,6 +93,18 @@ func AdditionalIdentifiers(fieldNames ...string) CompareOption {
 	}
 }
 
+// CompositeIdentifier specifies a combination of fields that is used as the
+// key for matching list entries from source to target, for lists where no
+// single field is unique, e.g. host and port. Composite identifiers take
+// precedence over the single field identifiers.
+func CompositeIdentifier(fieldNames ...string) CompareOption {
+	return func(settings *compareSettings) {
+		if len(fieldNames) > 0 {
+			settings.CompositeIdentifiers = append(settings.CompositeIdentifiers, fieldNames)
+		}
+	}
+}
+
 // NonStandardIdentifierGuessCountThreshold specifies how many list entries are
 // needed for the guess-the-identifier function to actually consider the key
 // name. Or in short, if the lists only contain two entries each, there are more
@@ -72,6 +122,54 @@ func IgnoreOrderChanges(value bool) CompareOption {
 	}
 }
 
+// IgnoreOrderChangesAt disables the detection for changes of the order in
+// lists at or below the provided paths (Go-Patch or Dot-Style), for example
+// to ignore the order of environment variables, while the order of lists
+// elsewhere still matters
+func IgnoreOrderChangesAt(paths ...string) CompareOption {
+	scopes, err := parseScopes(paths)
+	return func(settings *compareSettings) {
+		settings.IgnoreOrderChangesAt = append(settings.IgnoreOrderChangesAt, scopes...)
+		settings.setError(err)
+	}
+}
+
+// ExcludePaths skips the comparison of the provided paths (Go-Patch or
+// Dot-Style) and everything below them, so that excluded parts of the input,
+// for example the data of a huge ConfigMap, are never compared at all
+func ExcludePaths(paths ...string) CompareOption {
+	scopes, err := parseScopes(paths)
+	return func(settings *compareSettings) {
+		settings.ExcludePaths = append(settings.ExcludePaths, scopes...)
+		settings.setError(err)
+	}
+}
+
+// parseScopes parses the provided paths (Go-Patch or Dot-Style) and returns
+// them in their Go-Patch style string representation, so that they do not
+// need to be parsed again for each compared path
+func parseScopes(paths []string) ([]string, error) {
+	scopes := make([]string, 0, len(paths))
+	for _, path := range paths {
+		scopePath, err := ytbx.ParsePathStringUnsafe(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %w", path, err)
+		}
+
+		scopes = append(scopes, scopePath.String())
+	}
+
+	return scopes, nil
+}
+
+// setError records the error of an invalid compare option, only the first
+// one is kept
+func (settings *compareSettings) setError(err error) {
+	if settings.err == nil {
+		settings.err = err
+	}
+}
+
 // IgnoreWhitespaceChanges disables the detection for whitespace only changes
 func IgnoreWhitespaceChanges(value bool) CompareOption {
 	return func(settings *compareSettings) {
@@ -79,6 +177,15 @@ func IgnoreWhitespaceChanges(value bool) CompareOption {
 	}
 }
 
+// IgnoreBlockScalarStyleChanges disables the detection of changes of block
+// scalars (literal or folded style) that only differ in their chomping
+// indicator, i.e. the trailing line breaks, while the content is the same
+func IgnoreBlockScalarStyleChanges(value bool) CompareOption {
+	return func(settings *compareSettings) {
+		settings.IgnoreBlockScalarStyleChanges = value
+	}
+}
+
 // KubernetesEntityDetection enabled detecting entity identifiers from Kubernetes "kind:" and "metadata:" fields.
 func KubernetesEntityDetection(value bool) CompareOption {
 	return func(settings *compareSettings) {
@@ -86,16 +193,42 @@ func KubernetesEntityDetection(value bool) CompareOption {
 	}
 }
 
+// NullEquivalents specifies additional scalar values that are considered to be
+// equal to null, for example the empty string, or tool specific sentinels like
+// `None`. Values that are all null (or null equivalent) are not reported as a
+// difference.
+func NullEquivalents(values ...string) CompareOption {
+	return func(settings *compareSettings) {
+		settings.NullEquivalents = append(settings.NullEquivalents, values...)
+	}
+}
+
 // CompareInputFiles is one of the convenience main entry points for comparing
 // objects. In this case the representation of an input file, which might
 // contain multiple documents. It returns a report with the list of differences.
 func CompareInputFiles(from ytbx.InputFile, to ytbx.InputFile, compareOptions ...CompareOption) (Report, error) {
+	cmpr := newCompare(compareOptions...)
+
+	if err := cmpr.prepare(&from, &to); err != nil {
+		return Report{}, err
+	}
+
+	return cmpr.inputFiles(from, to)
+}
+
+// newCompare returns a comparator with the tool defaults and the provided
+// compare options applied
+func newCompare(compareOptions ...CompareOption) *compare {
 	// initialize the comparator with the tool defaults
 	cmpr := compare{
 		settings: compareSettings{
 			NonStandardIdentifierGuessCountThreshold: 3,
 			IgnoreOrderChanges:                       false,
 			KubernetesEntityDetection:                true,
+			CustomTags:                               CustomTagsOpaque,
+			ListDiffStrategy:                         HashSet,
+			NodeHashing:                              HashStructure,
+			SuppressionComments:                      true,
 		},
 	}
 
@@ -104,9 +237,63 @@ func CompareInputFiles(from ytbx.InputFile, to ytbx.InputFile, compareOptions ..
 		compareOption(&cmpr.settings)
 	}
 
+	return &cmpr
+}
+
+// prepare replaces the documents of the input files with the ones that are
+// actually compared, i.e. with custom tags handled, references resolved, and
+// the configured defaults and normalizations applied
+func (compare *compare) prepare(inputFiles ...*ytbx.InputFile) error {
+	// invalid compare options are reported before anything is compared
+	if compare.settings.err != nil {
+		return compare.settings.err
+	}
+
+	// custom tags need to be checked, or removed before the comparison
+	if err := compare.handleCustomTags(inputFiles...); err != nil {
+		return err
+	}
+
+	// references ($ref) are replaced with the referenced content, so that only
+	// changes of the effective documents are reported
+	compare.resolveRefs(inputFiles...)
+
+	// line endings of strings are normalized according to the configuration of
+	// the input files (e.g. .gitattributes)
+	compare.normalizeLineEndings(inputFiles...)
+
+	// default values and schema (e.g. of a Helm chart) are applied, so that
+	// only the effective values are compared
+	compare.applyValuesDefaults(inputFiles...)
+
+	// different syntaxes of Docker Compose services are normalized, so that
+	// only actual changes of the services are reported
+	compare.normalizeCompose(inputFiles...)
+
+	// conditions of Ansible tasks are compared regardless of their format
+	compare.normalizeAnsibleConditions(inputFiles...)
+
+	// expressions of Prometheus rules are compared regardless of whitespace
+	compare.normalizePrometheusExpressions(inputFiles...)
+
+	// comments and blank lines of shell scripts are ignored (only if configured)
+	compare.normalizeScripts(inputFiles...)
+
+	return nil
+}
+
+// inputFiles compares the prepared input files
+func (compare *compare) inputFiles(from ytbx.InputFile, to ytbx.InputFile) (Report, error) {
+	// an empty input (no documents, or only empty documents) is compared on the
+	// document level, i.e. all documents of the other input are reported as
+	// added, or removed respectively
+	if fromEmpty, toEmpty := isEmptyInput(from), isEmptyInput(to); fromEmpty || toEmpty {
+		return Report{from, to, emptyInputDiffs(from, to, fromEmpty, toEmpty)}, nil
+	}
+
 	// in case Kubernetes mode is enabled, try to compare documents in the YAML
 	// file by their names rather than just by the order of the documents
-	if cmpr.settings.KubernetesEntityDetection {
+	if compare.settings.KubernetesEntityDetection && !compare.settings.pairDocumentsByPosition {
 		var fromDocs, toDocs []*yamlv3.Node
 		var fromNames, toNames []string
 
@@ -138,19 +325,19 @@ func CompareInputFiles(from ytbx.InputFile, to ytbx.InputFile, compareOptions ..
 
 			// Compare the document nodes, in case of an error it will fall back to the default
 			// implementation and continue to compare the files without any special semantics
-			if result, err := cmpr.documentNodes(from, to); err == nil {
-				return Report{from, to, result}, nil
+			if result, err := compare.documentNodes(from, to); err == nil {
+				return compare.report(from, to, result), nil
 			}
 		}
 	}
 
 	if len(from.Documents) != len(to.Documents) {
-		return Report{}, fmt.Errorf("comparing YAMLs with a different number of documents is currently not supported")
+		return Report{}, newDocumentCountMismatchError(from, to)
 	}
 
 	var result []Diff
 	for idx := range from.Documents {
-		diffs, err := cmpr.objects(
+		diffs, err := compare.objects(
 			ytbx.Path{
 				Root:        &from,
 				DocumentIdx: idx,
@@ -166,18 +353,43 @@ func CompareInputFiles(from ytbx.InputFile, to ytbx.InputFile, compareOptions ..
 		result = append(result, diffs...)
 	}
 
-	return Report{from, to, result}, nil
+	return compare.report(from, to, result), nil
+}
+
+// report returns the report of the differences, with moves detected if
+// configured, in the order that is described in Report.Sort
+func (compare *compare) report(from ytbx.InputFile, to ytbx.InputFile, diffs []Diff) Report {
+	var report = Report{from, to, diffs}
+	if compare.settings.DetectMoves {
+		report = detectMoves(report)
+	}
+
+	report.Sort()
+	return report
 }
 
 func (compare *compare) objects(path ytbx.Path, from *yamlv3.Node, to *yamlv3.Node) ([]Diff, error) {
+	if scoped := compare.scopedTo(path); scoped != compare {
+		return scoped.objects(path, from, to)
+	}
+
 	switch {
 	case from == nil && to == nil:
 		return []Diff{}, nil
 
+	case isInScope(path, compare.settings.ExcludePaths):
+		return []Diff{}, nil
+
+	case compare.isNullEquivalent(from) && compare.isNullEquivalent(to):
+		return []Diff{}, nil
+
+	case compare.settings.IgnoreNumberFormatChanges && numericallyEqual(followAlias(from), followAlias(to)):
+		return []Diff{}, nil
+
 	case (from == nil && to != nil) || (from != nil && to == nil):
 		return []Diff{{
-			&path,
-			[]Detail{{
+			Path: &path,
+			Details: []Detail{{
 				Kind: MODIFICATION,
 				From: from,
 				To:   to,
@@ -186,8 +398,8 @@ func (compare *compare) objects(path ytbx.Path, from *yamlv3.Node, to *yamlv3.No
 
 	case (from.Kind != to.Kind) || (from.Tag != to.Tag):
 		return []Diff{{
-			&path,
-			[]Detail{{
+			Path: &path,
+			Details: []Detail{{
 				Kind: MODIFICATION,
 				From: from,
 				To:   to,
@@ -205,6 +417,7 @@ func (compare *compare) nonNilSameKindNodes(path ytbx.Path, from *yamlv3.Node, t
 	switch from.Kind {
 	case yamlv3.DocumentNode:
 		diffs, err = compare.objects(path, from.Content[0], to.Content[0])
+		diffs = append(compare.directives(path, from, to), diffs...)
 
 	case yamlv3.MappingNode:
 		diffs, err = compare.mappingNodes(path, from, to)
@@ -223,11 +436,14 @@ func (compare *compare) nonNilSameKindNodes(path ytbx.Path, from *yamlv3.Node, t
 		case "!!bool":
 			diffs, err = compare.boolValues(path, from, to)
 
+		case "!!binary":
+			diffs, err = compare.binaryValues(path, from, to)
+
 		default:
 			if from.Value != to.Value {
 				diffs, err = []Diff{{
-					&path,
-					[]Detail{{
+					Path: &path,
+					Details: []Detail{{
 						Kind: MODIFICATION,
 						From: from,
 						To:   to,
@@ -300,6 +516,11 @@ func (compare *compare) documentNodes(from, to ytbx.InputFile) ([]Diff, error) {
 				return nil, err
 			}
 
+			result = append(result, compare.directives(
+				ytbx.Path{Root: &from, DocumentIdx: fromItem.idx},
+				from.Documents[fromItem.idx],
+				to.Documents[toItem.idx],
+			)...)
 			result = append(result, diffs...)
 
 		} else {
@@ -371,6 +592,10 @@ func (compare *compare) mappingNodes(path ytbx.Path, from *yamlv3.Node, to *yaml
 
 	for i := 0; i < len(from.Content); i += 2 {
 		key, fromItem := from.Content[i], from.Content[i+1]
+		if compare.isSuppressed(key, fromItem) || compare.isSuppressedIn(to, key.Value) {
+			continue
+		}
+
 		if toItem, ok := findValueByKey(to, key.Value); ok {
 			// `from` and `to` contain the same `key` -> require comparison
 			diffs, err := compare.objects(
@@ -393,6 +618,10 @@ func (compare *compare) mappingNodes(path ytbx.Path, from *yamlv3.Node, to *yaml
 
 	for i := 0; i < len(to.Content); i += 2 {
 		key, toItem := to.Content[i], to.Content[i+1]
+		if compare.isSuppressed(key, toItem) {
+			continue
+		}
+
 		if _, ok := findValueByKey(from, key.Value); !ok {
 			// `to` contains a `key` that `from` does not have -> addition
 			additions = append(additions, key, toItem)
@@ -442,6 +671,31 @@ func (compare *compare) sequenceNodes(path ytbx.Path, from *yamlv3.Node, to *yam
 		return []Diff{}, nil
 	}
 
+	// check if the lists are steps of a Concourse plan (only if configured)
+	if identifier := compare.getConcourseStepIdentifier(from, to); identifier != nil {
+		return compare.namedEntryLists(path, identifier, from, to)
+	}
+
+	// check if the lists are tasks of an Ansible playbook (only if configured)
+	if identifier := compare.getAnsibleTaskIdentifier(from, to); identifier != nil {
+		return compare.namedEntryLists(path, identifier, from, to)
+	}
+
+	// check if the lists are users or groups of a cloud-config (only if configured)
+	if identifier := compare.getCloudInitIdentifier(path, from, to); identifier != nil {
+		return compare.namedEntryLists(path, identifier, from, to)
+	}
+
+	// check if the lists are rules of a Prometheus rule group (only if configured)
+	if identifier := compare.getPrometheusRuleIdentifier(path, from, to); identifier != nil {
+		return compare.namedEntryLists(path, identifier, from, to)
+	}
+
+	// check if a configured combination of fields can be used
+	if identifier := compare.getCompositeIdentifierFromNamedLists(from, to); identifier != nil {
+		return compare.namedEntryLists(path, identifier, from, to)
+	}
+
 	// check if a known identifier (e.g. name, or id) can be used
 	if identifier, err := compare.getIdentifierFromNamedLists(from, to); err == nil {
 		return compare.namedEntryLists(path, identifier, from, to)
@@ -478,6 +732,14 @@ func (compare *compare) simpleLists(path ytbx.Path, from *yamlv3.Node, to *yamlv
 		)
 	}
 
+	// Lists where the order is not relevant are compared as sets in any case,
+	// as well as lists that are too large for the longest common subsequence
+	if compare.settings.ListDiffStrategy == LCS && !compare.ignoreOrderChanges(path) {
+		if result, ok := compare.lcsLists(path, from, to); ok {
+			return result, nil
+		}
+	}
+
 	fromLookup := compare.createLookUpMap(from)
 	toLookup := compare.createLookUpMap(to)
 
@@ -532,7 +794,7 @@ func (compare *compare) simpleLists(path ytbx.Path, from *yamlv3.Node, to *yamlv
 	}
 
 	var orderChanges []Detail
-	if !compare.settings.IgnoreOrderChanges {
+	if !compare.ignoreOrderChanges(path) {
 		orderChanges = compare.findOrderChangesInSimpleList(fromCommon, toCommon)
 	}
 
@@ -561,7 +823,7 @@ func (compare *compare) namedEntryLists(path ytbx.Path, identifier listItemIdent
 		if toEntry, err := identifier.FindNodeByName(to, name); err == nil {
 			// `from` and `to` have the same entry identified by identifier and name -> require comparison
 			diffs, err := compare.objects(
-				ytbx.NewPathWithNamedListElement(path, identifier, name),
+				namedListElementPath(path, identifier, name),
 				followAlias(fromEntry),
 				followAlias(toEntry),
 			)
@@ -595,13 +857,41 @@ func (compare *compare) namedEntryLists(path ytbx.Path, identifier listItemIdent
 	}
 
 	var orderChanges []Detail
-	if !compare.settings.IgnoreOrderChanges {
+	if !compare.ignoreOrderChanges(path) {
 		orderChanges = findOrderChangesInNamedEntryLists(fromNames, toNames)
 	}
 
 	return packChangesAndAddToResult(result, path, orderChanges, additions, removals)
 }
 
+// ignoreOrderChanges returns whether order changes of the list at the given
+// path are ignored, either in general, or for the paths at or below one of the
+// configured paths
+func (compare *compare) ignoreOrderChanges(path ytbx.Path) bool {
+	if compare.settings.IgnoreOrderChanges {
+		return true
+	}
+
+	return isInScope(path, compare.settings.IgnoreOrderChangesAt)
+}
+
+// isInScope returns whether the given path is equal to, or below one of the
+// provided (already parsed) scope paths
+func isInScope(path ytbx.Path, scopes []string) bool {
+	if len(scopes) == 0 {
+		return false
+	}
+
+	var pathString = path.String()
+	for _, scope := range scopes {
+		if pathString == scope || strings.HasPrefix(pathString, strings.TrimSuffix(scope, "/")+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (compare *compare) nodeValues(path ytbx.Path, from *yamlv3.Node, to *yamlv3.Node) ([]Diff, error) {
 	if strings.Compare(from.Value, to.Value) != 0 {
 		// leave and don't report any differences if ignore whitespaces changes is
@@ -610,9 +900,14 @@ func (compare *compare) nodeValues(path ytbx.Path, from *yamlv3.Node, to *yamlv3
 			return nil, nil
 		}
 
+		// same for style only changes of block scalars, if configured
+		if compare.settings.IgnoreBlockScalarStyleChanges && isBlockScalarStyleChange(from, to) {
+			return nil, nil
+		}
+
 		return []Diff{{
-			&path,
-			[]Detail{{
+			Path: &path,
+			Details: []Detail{{
 				Kind: MODIFICATION,
 				From: from,
 				To:   to,
@@ -635,8 +930,8 @@ func (compare *compare) boolValues(path ytbx.Path, from *yamlv3.Node, to *yamlv3
 	result := make([]Diff, 0)
 	if boolFrom != boolTo {
 		result = append(result, Diff{
-			&path,
-			[]Detail{{
+			Path: &path,
+			Details: []Detail{{
 				Kind: MODIFICATION,
 				From: from,
 				To:   to,
@@ -647,6 +942,26 @@ func (compare *compare) boolValues(path ytbx.Path, from *yamlv3.Node, to *yamlv3
 	return result, nil
 }
 
+// isNullEquivalent returns whether the node is null, or one of the configured
+// values that are considered to be equal to null
+func (compare *compare) isNullEquivalent(node *yamlv3.Node) bool {
+	if node == nil || node.Kind != yamlv3.ScalarNode {
+		return false
+	}
+
+	if node.Tag == "!!null" {
+		return true
+	}
+
+	for _, value := range compare.settings.NullEquivalents {
+		if node.Value == value {
+			return true
+		}
+	}
+
+	return false
+}
+
 // this uses the various values mentioned in https://yaml.org/type/bool.html
 var trueValues = [...]string{"y", "Y", "yes", "Yes", "YES", "true", "True", "TRUE", "on", "On", "ON"}
 var falseValues = [...]string{"n", "N", "no", "No", "NO", "false", "False", "FALSE", "off", "Off", "OFF"}
@@ -824,34 +1139,13 @@ func (compare *compare) getIdentifierFromNamedLists(listA, listB *yamlv3.Node) (
 		return false
 	}
 
-	createKeyCountMap := func(sequenceNode *yamlv3.Node) map[string]map[string]struct{} {
-		result := map[string]map[string]struct{}{}
-		for _, entry := range sequenceNode.Content {
-			switch entry.Kind {
-			case yamlv3.MappingNode:
-				for i := 0; i < len(entry.Content); i += 2 {
-					k, v := followAlias(entry.Content[i]), followAlias(entry.Content[i+1])
-					if isCandidate(k) {
-						if _, found := result[k.Value]; !found {
-							result[k.Value] = map[string]struct{}{}
-						}
-
-						result[k.Value][v.Value] = struct{}{}
-					}
-				}
-			}
-		}
-
-		return result
-	}
-
-	counterA := createKeyCountMap(listA)
-	counterB := createKeyCountMap(listB)
+	counterA := compare.candidateCounts(listA, isCandidate)
+	counterB := compare.candidateCounts(listB, isCandidate)
 
 	// Check for the usual suspects: name, key, and id
 	for _, identifier := range compare.listItemIdentifierCandidates() {
-		if countA, okA := counterA[identifier]; okA && len(countA) == len(listA.Content) {
-			if countB, okB := counterB[identifier]; okB && len(countB) == len(listB.Content) {
+		if countA, okA := counterA[identifier]; okA && countA == len(listA.Content) {
+			if countB, okB := counterB[identifier]; okB && countB == len(listB.Content) {
 				return &singleField{identifier}, nil
 			}
 		}
@@ -860,39 +1154,36 @@ func (compare *compare) getIdentifierFromNamedLists(listA, listB *yamlv3.Node) (
 	return nil, fmt.Errorf("unable to find a key that can serve as an unique identifier")
 }
 
-func (compare *compare) getNonStandardIdentifierFromNamedLists(listA, listB *yamlv3.Node) listItemIdentifier {
-	createKeyCountMap := func(list *yamlv3.Node) map[string]int {
-		tmp := map[string]map[string]struct{}{}
-		for _, entry := range list.Content {
-			if entry.Kind != yamlv3.MappingNode {
-				return map[string]int{}
+func (compare *compare) getCompositeIdentifierFromNamedLists(listA, listB *yamlv3.Node) listItemIdentifier {
+	isUnique := func(identifier listItemIdentifier, sequenceNode *yamlv3.Node) bool {
+		names := map[string]struct{}{}
+		for _, entry := range sequenceNode.Content {
+			name, err := identifier.Name(followAlias(entry))
+			if err != nil {
+				return false
 			}
 
-			for i := 0; i < len(entry.Content); i += 2 {
-				k, v := followAlias(entry.Content[i]), followAlias(entry.Content[i+1])
-				if k.Kind == yamlv3.ScalarNode && k.Tag == "!!str" &&
-					v.Kind == yamlv3.ScalarNode && v.Tag == "!!str" {
-					if _, ok := tmp[k.Value]; !ok {
-						tmp[k.Value] = map[string]struct{}{}
-					}
-
-					tmp[k.Value][v.Value] = struct{}{}
-				}
-			}
+			names[name] = struct{}{}
 		}
 
-		result := map[string]int{}
-		for key, value := range tmp {
-			result[key] = len(value)
-		}
+		return len(names) == len(sequenceNode.Content)
+	}
 
-		return result
+	for _, fieldNames := range compare.settings.CompositeIdentifiers {
+		identifier := &compositeFields{fieldNames}
+		if isUnique(identifier, listA) && isUnique(identifier, listB) {
+			return identifier
+		}
 	}
 
+	return nil
+}
+
+func (compare *compare) getNonStandardIdentifierFromNamedLists(listA, listB *yamlv3.Node) listItemIdentifier {
 	listALength := len(listA.Content)
 	listBLength := len(listB.Content)
-	counterA := createKeyCountMap(listA)
-	counterB := createKeyCountMap(listB)
+	counterA := compare.fieldCounts(listA)
+	counterB := compare.fieldCounts(listB)
 
 	for keyA, countA := range counterA {
 		if countB, ok := counterB[keyA]; ok {
@@ -939,6 +1230,9 @@ func isEmptyDocument(node *yamlv3.Node) bool {
 	}
 
 	switch len(node.Content) {
+	case 0:
+		return true
+
 	case 1:
 		// special case: content is just null (scalar)
 		return node.Content[0].Kind == yamlv3.ScalarNode &&
@@ -968,7 +1262,7 @@ func (compare *compare) basicType(node *yamlv3.Node) interface{} {
 		panic("document nodes are not supported to be translated into a basic type")
 
 	case yamlv3.MappingNode:
-		result := map[interface{}]interface{}{}
+		result := basicMapPool.Get().(map[interface{}]interface{})
 		for i := 0; i < len(node.Content); i += 2 {
 			k, v := followAlias(node.Content[i]), followAlias(node.Content[i+1])
 			result[compare.basicType(k)] = compare.basicType(v)
@@ -977,7 +1271,7 @@ func (compare *compare) basicType(node *yamlv3.Node) interface{} {
 		return result
 
 	case yamlv3.SequenceNode:
-		result := []interface{}{}
+		result := (*basicListPool.Get().(*[]interface{}))[:0]
 
 		if compare.settings.IgnoreOrderChanges {
 			sortNode(node)
@@ -1005,10 +1299,32 @@ func (compare *compare) calcNodeHash(node *yamlv3.Node) (hash uint64) {
 
 	switch node.Kind {
 	case yamlv3.MappingNode, yamlv3.SequenceNode:
-		hash, err = hashstructure.Hash(compare.basicType(node), nil)
+		if cached, ok := compare.cachedHash(node); ok {
+			return cached
+		}
+
+		switch compare.settings.NodeHashing {
+		case CanonicalHash:
+			hash, err = compare.canonicalHash(node)
+
+		default:
+			value := compare.basicType(node)
+			hash, err = hashValue(value)
+			releaseBasicType(value)
+		}
+
+		if err == nil {
+			compare.cacheHash(node, hash)
+		}
 
 	case yamlv3.ScalarNode:
-		hash, err = hashstructure.Hash(node.Value, nil)
+		switch compare.settings.NodeHashing {
+		case CanonicalHash:
+			hash, err = compare.canonicalHash(node)
+
+		default:
+			hash, err = hashValue(node.Value)
+		}
 
 	case yamlv3.AliasNode:
 		hash = compare.calcNodeHash(followAlias(node))
@@ -1114,23 +1430,17 @@ func ChangeRoot(inputFile *ytbx.InputFile, path string, useGoPatchPaths bool, tr
 
 	// Parse path string and create nicely formatted output path
 	if resolvedPath, err := ytbx.ParsePathString(path, originalRoot); err == nil {
-		path = pathToString(&resolvedPath, useGoPatchPaths, multipleDocuments)
+		style, _ := pathStyleFor("", useGoPatchPaths)
+		path = pathToString(&resolvedPath, style, multipleDocuments)
 	}
 
-	inputFile.Note = fmt.Sprintf("YAML root was changed to %s", path)
+	AddNote(inputFile, fmt.Sprintf("YAML root was changed to %s", path))
 
 	return nil
 }
 
-func pathToString(path *ytbx.Path, useGoPatchPaths bool, showPathRoot bool) string {
-	var result string
-
-	if useGoPatchPaths {
-		result = styledGoPatchPath(path)
-
-	} else {
-		result = styledDotStylePath(path)
-	}
+func pathToString(path *ytbx.Path, style PathStyle, showPathRoot bool) string {
+	var result = style.RenderPath(path)
 
 	if path != nil && showPathRoot {
 		result += bunt.Sprintf("  LightSteelBlue{(%s)}", path.RootDescription())
@@ -1152,3 +1462,82 @@ func grab(node *yamlv3.Node, pathString string) (*yamlv3.Node, error) {
 func isWhitespaceOnlyChange(from string, to string) bool {
 	return strings.Trim(from, " \n") == strings.Trim(to, " \n")
 }
+
+// isBlockScalarStyleChange returns whether at least one of the strings is a
+// block scalar and the strings only differ in the final line break, which is
+// the case when only the chomping indicator was changed from clip to strip
+// (or vice versa), e.g. | to |-. Additional trailing line breaks are content
+// and therefore not considered to be a style change.
+func isBlockScalarStyleChange(from *yamlv3.Node, to *yamlv3.Node) bool {
+	isBlockScalar := func(node *yamlv3.Node) bool {
+		return node.Style&(yamlv3.LiteralStyle|yamlv3.FoldedStyle) != 0
+	}
+
+	if !isBlockScalar(from) && !isBlockScalar(to) {
+		return false
+	}
+
+	isStripped := func(value string) bool {
+		return !strings.HasSuffix(value, "\n")
+	}
+
+	return (isStripped(from.Value) && from.Value+"\n" == to.Value) ||
+		(isStripped(to.Value) && from.Value == to.Value+"\n")
+}
+
+// blockScalarIndicator returns the block scalar header that results in the
+// value of the node, e.g. |- for a literal block without a trailing line break
+func blockScalarIndicator(node *yamlv3.Node) string {
+	var indicator = "|"
+	if node.Style&yamlv3.FoldedStyle != 0 {
+		indicator = ">"
+	}
+
+	switch trailing := len(node.Value) - len(strings.TrimRight(node.Value, "\n")); {
+	case trailing == 0:
+		return indicator + "-"
+
+	case trailing > 1:
+		return indicator + "+"
+	}
+
+	return indicator
+}
+
+// isEmptyInput returns true in case the input file has no documents, or only
+// empty documents, which is for example the case for /dev/null
+func isEmptyInput(inputFile ytbx.InputFile) bool {
+	for _, document := range inputFile.Documents {
+		if document != nil && !isEmptyDocument(document) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// emptyInputDiffs returns the file level differences between two inputs of
+// which at least one is empty
+func emptyInputDiffs(from, to ytbx.InputFile, fromEmpty, toEmpty bool) []Diff {
+	var documents = func(inputFile ytbx.InputFile) *yamlv3.Node {
+		result := &yamlv3.Node{Kind: yamlv3.DocumentNode}
+		for _, document := range inputFile.Documents {
+			if document != nil && !isEmptyDocument(document) {
+				result.Content = append(result.Content, document.Content[0])
+			}
+		}
+
+		return result
+	}
+
+	switch {
+	case fromEmpty && toEmpty:
+		return nil
+
+	case fromEmpty:
+		return []Diff{{Details: []Detail{{Kind: ADDITION, To: documents(to)}}}}
+
+	default:
+		return []Diff{{Details: []Detail{{Kind: REMOVAL, From: documents(from)}}}}
+	}
+}
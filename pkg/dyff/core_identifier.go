@@ -22,8 +22,10 @@ package dyff
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/gonvenience/ytbx"
 	yamlv3 "gopkg.in/yaml.v3"
 )
 
@@ -41,6 +43,45 @@ type listItemIdentifier interface {
 	String() string
 }
 
+// listItemPathElement is implemented by list item identifiers, where the field
+// that identifies an entry differs from entry to entry (see concourseStep)
+type listItemPathElement interface {
+	PathElement(name string) ytbx.PathElement
+}
+
+// namedListElementPath returns the path of the list entry with the provided
+// name, which uses the identifier as the key unless the identifier provides
+// its own path element
+func namedListElementPath(path ytbx.Path, identifier listItemIdentifier, name string) ytbx.Path {
+	if custom, ok := identifier.(listItemPathElement); ok {
+		return ytbx.NewPathWithPathElement(path, custom.PathElement(name))
+	}
+
+	return ytbx.NewPathWithNamedListElement(path, identifier, name)
+}
+
+// hasUniqueNames returns whether the identifier provides a name for all
+// entries of the lists, which is unique within each list
+func hasUniqueNames(identifier listItemIdentifier, lists ...*yamlv3.Node) bool {
+	for _, list := range lists {
+		var names = map[string]struct{}{}
+		for _, entry := range list.Content {
+			name, err := identifier.Name(entry)
+			if err != nil {
+				return false
+			}
+
+			if _, duplicate := names[name]; duplicate {
+				return false
+			}
+
+			names[name] = struct{}{}
+		}
+	}
+
+	return true
+}
+
 // --- --- ---
 
 // singleField is an list item identifier that relies on one field to serve as
@@ -81,6 +122,99 @@ func (sf *singleField) String() string {
 
 // --- --- ---
 
+// compositeSeparator separates the field names and values of a composite
+// identifier, e.g. host+port for the fields and example.org+443 for the name,
+// separators (and escape characters) in values are escaped with a backslash
+const compositeSeparator = "+"
+
+var compositeValueEscaper = strings.NewReplacer(`\`, `\\`, compositeSeparator, `\`+compositeSeparator)
+
+// compositeFields is an list item identifier that relies on the combination
+// of multiple fields to differentiate between list items, e.g. 'host' and
+// 'port', where no single field is unique
+type compositeFields struct {
+	IdentifierFieldNames []string
+}
+
+var _ listItemIdentifier = &compositeFields{}
+
+func (cf *compositeFields) FindNodeByName(sequenceNode *yamlv3.Node, name string) (*yamlv3.Node, error) {
+	var values = splitCompositeName(name)
+	for _, mappingNode := range sequenceNode.Content {
+		valuesOfNode, err := cf.values(mappingNode)
+		if err != nil {
+			return nil, err
+		}
+
+		if slices.Equal(valuesOfNode, values) {
+			return mappingNode, nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to find mapping entry with name %q", name)
+}
+
+func (cf *compositeFields) Name(mappingNode *yamlv3.Node) (string, error) {
+	values, err := cf.values(mappingNode)
+	if err != nil {
+		return "", err
+	}
+
+	for i := range values {
+		values[i] = compositeValueEscaper.Replace(values[i])
+	}
+
+	return strings.Join(values, compositeSeparator), nil
+}
+
+// values returns the values of the identifier fields of the entry
+func (cf *compositeFields) values(mappingNode *yamlv3.Node) ([]string, error) {
+	if mappingNode.Kind != yamlv3.MappingNode {
+		return nil, fmt.Errorf("provided node is not a mapping node")
+	}
+
+	var values = make([]string, len(cf.IdentifierFieldNames))
+	for i, fieldName := range cf.IdentifierFieldNames {
+		result, err := grab(mappingNode, fieldName)
+		if err != nil {
+			return nil, err
+		}
+
+		values[i] = followAlias(result).Value
+	}
+
+	return values, nil
+}
+
+// splitCompositeName returns the values of a composite identifier name, which
+// is the reverse of Name
+func splitCompositeName(name string) []string {
+	var values []string
+	var value strings.Builder
+	for i := 0; i < len(name); i++ {
+		switch {
+		case name[i] == '\\' && i+1 < len(name):
+			i++
+			value.WriteByte(name[i])
+
+		case strings.HasPrefix(name[i:], compositeSeparator):
+			values = append(values, value.String())
+			value.Reset()
+
+		default:
+			value.WriteByte(name[i])
+		}
+	}
+
+	return append(values, value.String())
+}
+
+func (cf *compositeFields) String() string {
+	return strings.Join(cf.IdentifierFieldNames, compositeSeparator)
+}
+
+// --- --- ---
+
 // k8sItemIdentifier is an identifier aiming for Kubernetes items that have an
 // api version, kind, and name field to be used
 type k8sItemIdentifier struct{}
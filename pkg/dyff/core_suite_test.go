@@ -356,7 +356,7 @@ func nodify(obj interface{}) *yamlv3.Node {
 	return nil
 }
 
-func singleDiff(p string, change rune, from, to interface{}) dyff.Diff {
+func singleDiff(p string, change dyff.DetailKind, from, to interface{}) dyff.Diff {
 	return dyff.Diff{
 		Path: path(p),
 		Details: []dyff.Detail{
@@ -369,7 +369,7 @@ func singleDiff(p string, change rune, from, to interface{}) dyff.Diff {
 	}
 }
 
-func doubleDiff(p string, change1 rune, from1, to1 interface{}, change2 rune, from2, to2 interface{}) dyff.Diff {
+func doubleDiff(p string, change1 dyff.DetailKind, from1, to1 interface{}, change2 dyff.DetailKind, from2, to2 interface{}) dyff.Diff {
 	return dyff.Diff{
 		Path: path(p),
 		Details: []dyff.Detail{
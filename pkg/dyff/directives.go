@@ -0,0 +1,162 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"sync"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// documentDirectives are the directives of loaded documents, which cannot be
+// stored in the document node itself
+var documentDirectives = struct {
+	sync.RWMutex
+	directives map[*yamlv3.Node][]string
+}{directives: map[*yamlv3.Node][]string{}}
+
+// Directives returns the directives (e.g. `%YAML 1.2`, or `%TAG !e!
+// tag:example.com,2000:`) that precede the document in the input it was
+// loaded from, see LoadDocuments
+func Directives(document *yamlv3.Node) []string {
+	documentDirectives.RLock()
+	defer documentDirectives.RUnlock()
+
+	return documentDirectives.directives[document]
+}
+
+// SetDirectives sets the directives of the document, which are written in
+// front of the document when it is written as YAML
+func SetDirectives(document *yamlv3.Node, directives []string) {
+	documentDirectives.Lock()
+	defer documentDirectives.Unlock()
+
+	if len(directives) == 0 {
+		delete(documentDirectives.directives, document)
+		return
+	}
+
+	documentDirectives.directives[document] = directives
+}
+
+// CompareDirectives enables the comparison of the directives of documents,
+// changed directives are reported as a directive change of the document
+func CompareDirectives(value bool) CompareOption {
+	return func(settings *compareSettings) {
+		settings.CompareDirectives = value
+	}
+}
+
+// directives returns the difference of the directives of the two documents
+func (compare *compare) directives(path ytbx.Path, from *yamlv3.Node, to *yamlv3.Node) []Diff {
+	if !compare.settings.CompareDirectives {
+		return nil
+	}
+
+	fromDirectives, toDirectives := Directives(from), Directives(to)
+	if strings.Join(fromDirectives, "\n") == strings.Join(toDirectives, "\n") {
+		return nil
+	}
+
+	return []Diff{{
+		Path: &path,
+		Details: []Detail{{
+			Kind: DIRECTIVECHANGE,
+			From: directivesNode(fromDirectives),
+			To:   directivesNode(toDirectives),
+		}},
+	}}
+}
+
+func directivesNode(directives []string) *yamlv3.Node {
+	return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: strings.Join(directives, "\n")}
+}
+
+// directiveLines returns the directives of a directives node of a detail
+func directiveLines(node *yamlv3.Node) []string {
+	if node == nil || node.Value == "" {
+		return nil
+	}
+
+	return strings.Split(node.Value, "\n")
+}
+
+// hasDirectives returns whether the YAML stream contains directives
+func hasDirectives(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("%")) || bytes.Contains(data, []byte("\n%"))
+}
+
+// loadDocumentsWithDirectives loads the documents of the YAML stream and
+// keeps track of the directives of each document. Since the YAML parser only
+// supports YAML 1.1, other versions in %YAML directives are only kept as a
+// directive, but the document is parsed as YAML 1.1.
+func loadDocumentsWithDirectives(data []byte) ([]*yamlv3.Node, error) {
+	var compatible bytes.Buffer
+	var directives [][]string
+	var pending []string
+	var inDocument bool
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimRight(line, " \t\r")
+
+		switch {
+		case strings.HasPrefix(line, "%"):
+			pending = append(pending, trimmed)
+			if fields := strings.Fields(line); len(fields) > 1 && fields[0] == "%YAML" {
+				line = "%YAML 1.1"
+			}
+
+		case trimmed == "---" || strings.HasPrefix(line, "--- "):
+			directives = append(directives, pending)
+			pending, inDocument = nil, true
+
+		case trimmed == "...":
+			inDocument = false
+
+		case !inDocument && trimmed != "" && !strings.HasPrefix(trimmed, "#"):
+			directives = append(directives, pending)
+			pending, inDocument = nil, true
+		}
+
+		compatible.WriteString(line)
+		compatible.WriteString("\n")
+	}
+
+	documents, err := ytbx.LoadDocuments(compatible.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(directives) == len(documents) {
+		for i, document := range documents {
+			SetDirectives(document, directives[i])
+		}
+	}
+
+	return documents, nil
+}
@@ -0,0 +1,114 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	. "github.com/gonvenience/bunt"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("documents with directives", func() {
+	var load = func(input string) ytbx.InputFile {
+		documents, err := dyff.LoadDocuments([]byte(input))
+		Expect(err).ToNot(HaveOccurred())
+		return ytbx.InputFile{Documents: documents}
+	}
+
+	It("should keep the directives of each document", func() {
+		input := load(`%YAML 1.2
+%TAG !e! tag:example.com,2000:app/
+---
+foo: !e!bar baz
+...
+---
+bar: 1
+...
+%YAML 1.1
+---
+baz: 2
+`)
+
+		Expect(input.Documents).To(HaveLen(3))
+		Expect(dyff.Directives(input.Documents[0])).To(Equal([]string{"%YAML 1.2", "%TAG !e! tag:example.com,2000:app/"}))
+		Expect(dyff.Directives(input.Documents[1])).To(BeEmpty())
+		Expect(dyff.Directives(input.Documents[2])).To(Equal([]string{"%YAML 1.1"}))
+
+		Expect(input.Documents[0].Content[0].Content[1].Tag).To(Equal("tag:example.com,2000:app/bar"))
+	})
+
+	It("should only report directive changes if configured", func() {
+		from := load("%YAML 1.1\n---\nfoo: bar\n")
+		to := load("%YAML 1.2\n---\nfoo: bar\n")
+
+		report, err := dyff.CompareInputFiles(from, to)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Diffs).To(BeEmpty())
+
+		report, err = dyff.CompareInputFiles(from, to, dyff.CompareDirectives(true))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Diffs).To(HaveLen(1))
+		Expect(report.Diffs[0].Details).To(HaveLen(1))
+		Expect(report.Diffs[0].Details[0].Kind).To(Equal(dyff.DIRECTIVECHANGE))
+		Expect(report.Diffs[0].Details[0].From.Value).To(Equal("%YAML 1.1"))
+		Expect(report.Diffs[0].Details[0].To.Value).To(Equal("%YAML 1.2"))
+
+		documents, err := report.Apply(from.Documents)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dyff.Directives(documents[0])).To(Equal([]string{"%YAML 1.2"}))
+	})
+
+	It("should compare tags of different handles with the same prefix as equal", func() {
+		from := load("%TAG !a! tag:example.com,2000:\n---\nfoo: !a!bar baz\n")
+		to := load("%TAG !b! tag:example.com,2000:\n---\nfoo: !b!bar baz\n")
+
+		report, err := dyff.CompareInputFiles(from, to)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Diffs).To(BeEmpty())
+	})
+
+	It("should render directive changes in the human report", func() {
+		SetColorSettings(OFF, OFF)
+		defer SetColorSettings(AUTO, AUTO)
+
+		diff := dyff.Diff{
+			Path: &ytbx.Path{},
+			Details: []dyff.Detail{{
+				Kind: dyff.DIRECTIVECHANGE,
+				From: &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: ""},
+				To:   &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: "%YAML 1.2"},
+			}},
+		}
+
+		Expect(humanDiff(diff)).To(Equal(`
+(root level)
+% directive change
+  - (no directives)
+  + %YAML 1.2
+
+`))
+	})
+})
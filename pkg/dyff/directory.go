@@ -0,0 +1,151 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/gonvenience/ytbx"
+)
+
+// LoadDirectory recursively reads all files that contain structured data
+// (YAML, JSON, TOML, HCL, INI, Java properties, or dotenv) in the directory at
+// the provided location and returns them as a file set, so that two
+// directories can be compared using CompareFileSets. Files are only included if their relative path (or their
+// base name) matches one of the include glob patterns (all files in case there
+// are none), and none of the exclude glob patterns.
+func LoadDirectory(location string, include []string, exclude []string) (FileSet, error) {
+	var matches = func(patterns []string, name string) (bool, error) {
+		for _, pattern := range patterns {
+			for _, candidate := range []string{name, path.Base(name)} {
+				match, err := path.Match(pattern, candidate)
+				if err != nil {
+					return false, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+				}
+
+				if match {
+					return true, nil
+				}
+			}
+		}
+
+		return false, nil
+	}
+
+	var result = FileSet{
+		Location: location,
+		Files:    map[string]ytbx.InputFile{},
+		Metadata: map[string]FileMetadata{},
+	}
+
+	err := filepath.WalkDir(location, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !isSupportedFileSetEntry(file) {
+			return nil
+		}
+
+		// symbolic links to files are loaded with the content of the file
+		// they point to, links to directories are not followed
+		var metadata FileMetadata
+		switch {
+		case entry.Type().IsRegular():
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+
+			metadata.Mode = info.Mode().Perm()
+
+		case entry.Type()&fs.ModeSymlink != 0:
+			info, err := os.Stat(file)
+			if err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+
+			if metadata.Symlink, err = os.Readlink(file); err != nil {
+				return err
+			}
+
+			metadata.Mode = info.Mode().Perm()
+
+		default:
+			return nil
+		}
+
+		rel, err := filepath.Rel(location, file)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(rel)
+
+		if len(include) > 0 {
+			if included, err := matches(include, name); err != nil || !included {
+				return err
+			}
+		}
+
+		if excluded, err := matches(exclude, name); err != nil || excluded {
+			return err
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		result.Files[name] = loadFileSetEntry(file, data)
+		result.Metadata[name] = metadata
+		return nil
+	})
+
+	if err != nil {
+		return FileSet{}, fmt.Errorf("failed to read directory %s: %w", location, err)
+	}
+
+	return result, nil
+}
+
+// IsDirectory returns whether the provided location refers to a directory
+func IsDirectory(location string) bool {
+	info, err := os.Stat(location)
+	return err == nil && info.IsDir()
+}
+
+// Difference returns the sorted paths of the files that exist in the file set,
+// but not in the other file set
+func (set FileSet) Difference(other FileSet) []string {
+	var result []string
+	for _, path := range set.Paths() {
+		if _, ok := other.Files[path]; !ok {
+			result = append(result, path)
+		}
+	}
+
+	return result
+}
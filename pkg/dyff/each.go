@@ -0,0 +1,93 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// CompareEach compares a template input file, which has to consist of exactly
+// one document, against each document of the other input file separately, for
+// example to verify that many generated resources conform to a golden
+// template. The documents are always compared with the template by position,
+// i.e. Kubernetes resources are not paired by name. The resulting report
+// combines the differences of all documents, the from input file contains the
+// template once for each document of the other input file.
+func CompareEach(template ytbx.InputFile, inputFile ytbx.InputFile, compareOptions ...CompareOption) (Report, error) {
+	var templateDocs []*yamlv3.Node
+	for _, document := range template.Documents {
+		if !isEmptyDocument(document) {
+			templateDocs = append(templateDocs, document)
+		}
+	}
+
+	if len(templateDocs) != 1 {
+		return Report{}, fmt.Errorf("template %s has to contain exactly one document, but has %d", template.Location, len(templateDocs))
+	}
+
+	var result = Report{
+		From: ytbx.InputFile{Location: template.Location, Note: template.Note},
+		To:   ytbx.InputFile{Location: inputFile.Location, Note: inputFile.Note},
+	}
+
+	compareOptions = append(compareOptions, func(settings *compareSettings) {
+		settings.pairDocumentsByPosition = true
+	})
+
+	for i, document := range inputFile.Documents {
+		if isEmptyDocument(document) {
+			continue
+		}
+
+		report, err := CompareInputFiles(
+			ytbx.InputFile{Location: template.Location, Documents: []*yamlv3.Node{templateDocs[0]}},
+			ytbx.InputFile{Location: inputFile.Location, Documents: []*yamlv3.Node{document}},
+			compareOptions...,
+		)
+
+		if err != nil {
+			return Report{}, fmt.Errorf("failed to compare document #%d of %s: %w", i+1, inputFile.Location, err)
+		}
+
+		var name = fmt.Sprintf("document #%d", i+1)
+		if i < len(inputFile.Names) {
+			name = inputFile.Names[i]
+		} else if k8sName, err := k8sItem.Name(documentRoot(document)); err == nil {
+			name = k8sName
+		}
+
+		offset := len(result.To.Documents)
+		result.From.Documents = append(result.From.Documents, report.From.Documents...)
+		result.From.Names = append(result.From.Names, name)
+		result.To.Documents = append(result.To.Documents, report.To.Documents...)
+		result.To.Names = append(result.To.Names, name)
+
+		for _, diff := range report.Diffs {
+			diff.Path = rebasePath(diff.Path, &result.From, offset)
+			result.Diffs = append(result.Diffs, diff)
+		}
+	}
+
+	return result, nil
+}
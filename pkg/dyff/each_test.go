@@ -0,0 +1,71 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gonvenience/ytbx"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("compare each document against a template", func() {
+	var template = ytbx.InputFile{
+		Location:  "template.yml",
+		Documents: multiDoc("{apiVersion: v1, kind: ConfigMap, metadata: {name: golden}, data: {level: info}}"),
+	}
+
+	It("should compare each document separately by position", func() {
+		inputFile := ytbx.InputFile{
+			Location: "generated.yml",
+			Documents: multiDoc(
+				"{apiVersion: v1, kind: ConfigMap, metadata: {name: golden}, data: {level: info}}",
+				"{apiVersion: v1, kind: ConfigMap, metadata: {name: golden}, data: {level: debug}}",
+				"{apiVersion: v1, kind: ConfigMap, metadata: {name: other}, data: {level: info}}",
+			),
+		}
+
+		report, err := dyff.CompareEach(template, inputFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.From.Documents).To(HaveLen(3))
+		Expect(report.To.Documents).To(HaveLen(3))
+		Expect(report.Diffs).To(HaveLen(2))
+
+		Expect(report.Diffs[0].Path.String()).To(Equal("/data/level"))
+		Expect(report.Diffs[0].Path.DocumentIdx).To(Equal(1))
+		Expect(report.Diffs[0].Path.RootDescription()).To(Equal("v1/ConfigMap/golden"))
+
+		Expect(report.Diffs[1].Path.String()).To(Equal("/metadata/name"))
+		Expect(report.Diffs[1].Path.DocumentIdx).To(Equal(2))
+		Expect(report.Diffs[1].Path.RootDescription()).To(Equal("v1/ConfigMap/other"))
+	})
+
+	It("should fail if the template does not consist of exactly one document", func() {
+		_, err := dyff.CompareEach(
+			ytbx.InputFile{Location: "template.yml", Documents: multiDoc("{foo: bar}", "{foo: bar}")},
+			ytbx.InputFile{Location: "generated.yml", Documents: multiDoc("{foo: bar}")},
+		)
+
+		Expect(err).To(MatchError(ContainSubstring("has to contain exactly one document")))
+	})
+})
@@ -0,0 +1,251 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Line endings that are configured for a file
+const (
+	eolLF   = "lf"
+	eolCRLF = "crlf"
+	eolNone = "none"
+)
+
+// NormalizeLineEndings enables the normalization of the line endings of
+// multi-line strings according to the `.gitattributes` or `.editorconfig`
+// files that apply to the input files, so that files that were checked out
+// on different platforms do not report CRLF-induced whitespace changes
+func NormalizeLineEndings(value bool) CompareOption {
+	return func(settings *compareSettings) {
+		settings.NormalizeLineEndings = value
+	}
+}
+
+// normalizeLineEndings replaces the documents of the input files with copies
+// where the line endings of all strings are the configured ones, input files
+// without configuration (e.g. from standard input) are not changed
+func (compare *compare) normalizeLineEndings(inputFiles ...*ytbx.InputFile) {
+	if !compare.settings.NormalizeLineEndings {
+		return
+	}
+
+	for _, inputFile := range inputFiles {
+		eol := lineEndingOf(inputFile.Location)
+		if eol != eolLF && eol != eolCRLF {
+			continue
+		}
+
+		documents := make([]*yamlv3.Node, len(inputFile.Documents))
+		for i, document := range inputFile.Documents {
+			documents[i] = copyNode(document)
+			withLineEndings(documents[i], eol)
+		}
+
+		inputFile.Documents = documents
+	}
+}
+
+func withLineEndings(node *yamlv3.Node, eol string) {
+	if node.Kind == yamlv3.ScalarNode && strings.ContainsAny(node.Value, "\r\n") {
+		node.Value = strings.ReplaceAll(node.Value, "\r\n", "\n")
+		if eol == eolCRLF {
+			node.Value = strings.ReplaceAll(node.Value, "\n", "\r\n")
+		}
+	}
+
+	for _, content := range node.Content {
+		withLineEndings(content, eol)
+	}
+}
+
+// lineEndingOf returns the line ending that is configured for the file in
+// the closest `.gitattributes` file (`eol=lf`, `eol=crlf`, `text`, or
+// `-text`), or if there is none in the closest `.editorconfig` file
+// (`end_of_line`), or an empty string if there is no configuration
+func lineEndingOf(location string) string {
+	if location == "" || location == "-" {
+		return ""
+	}
+
+	if info, err := os.Stat(location); err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+
+	path, err := filepath.Abs(location)
+	if err != nil {
+		return ""
+	}
+
+	if eol := gitattributesLineEnding(path); eol != "" {
+		return eol
+	}
+
+	return editorconfigLineEnding(path)
+}
+
+// gitattributesLineEnding looks up the line ending for the file in the
+// `.gitattributes` files of the directory of the file and its parents, where
+// closer files and later lines take precedence
+func gitattributesLineEnding(path string) string {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		var result string
+		forEachLine(filepath.Join(dir, ".gitattributes"), func(line string) {
+			fields := strings.Fields(line)
+			if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || !matchesFilePattern(dir, path, fields[0]) {
+				return
+			}
+
+			for _, attribute := range fields[1:] {
+				switch attribute {
+				case "eol=lf":
+					result = eolLF
+
+				case "eol=crlf":
+					result = eolCRLF
+
+				case "text", "text=auto":
+					if result == "" || result == eolNone {
+						result = eolLF
+					}
+
+				case "-text", "binary":
+					result = eolNone
+				}
+			}
+		})
+
+		if result != "" {
+			return result
+		}
+
+		if parent := filepath.Dir(dir); parent == dir {
+			return ""
+		}
+	}
+}
+
+// editorconfigLineEnding looks up the line ending for the file in the
+// `.editorconfig` files of the directory of the file and its parents until a
+// file with `root = true`, where closer files and later sections take
+// precedence
+func editorconfigLineEnding(path string) string {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		var result string
+		var root, matching bool
+		forEachLine(filepath.Join(dir, ".editorconfig"), func(line string) {
+			switch {
+			case strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+				return
+
+			case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+				matching = matchesFilePattern(dir, path, line[1:len(line)-1])
+				return
+			}
+
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return
+			}
+
+			key, value = strings.ToLower(strings.TrimSpace(key)), strings.ToLower(strings.TrimSpace(value))
+			switch {
+			case key == "root" && value == "true":
+				root = true
+
+			case key == "end_of_line" && matching && (value == eolLF || value == eolCRLF):
+				result = value
+			}
+		})
+
+		if result != "" {
+			return result
+		}
+
+		if parent := filepath.Dir(dir); root || parent == dir {
+			return ""
+		}
+	}
+}
+
+// matchesFilePattern returns whether the file matches the pattern of a
+// configuration file in the provided directory, patterns without a slash
+// match the file name, patterns with a slash match the path relative to the
+// directory, and `{a,b}` alternatives are supported
+func matchesFilePattern(dir string, path string, pattern string) bool {
+	for _, alternative := range expandAlternatives(pattern) {
+		var name = filepath.Base(path)
+		if strings.Contains(alternative, "/") {
+			relative, err := filepath.Rel(dir, path)
+			if err != nil {
+				continue
+			}
+
+			name, alternative = filepath.ToSlash(relative), strings.TrimPrefix(strings.ReplaceAll(alternative, "**/", ""), "/")
+		}
+
+		if matched, err := filepath.Match(alternative, name); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expandAlternatives expands the first `{a,b}` group of the pattern
+func expandAlternatives(pattern string) []string {
+	start := strings.Index(pattern, "{")
+	end := strings.Index(pattern, "}")
+	if start < 0 || end < start {
+		return []string{pattern}
+	}
+
+	var result []string
+	for _, alternative := range strings.Split(pattern[start+1:end], ",") {
+		result = append(result, expandAlternatives(pattern[:start]+alternative+pattern[end+1:])...)
+	}
+
+	return result
+}
+
+// forEachLine calls the function for each trimmed, non-empty line of the
+// file, a file that cannot be read is skipped
+func forEachLine(path string, fn func(line string)) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			fn(line)
+		}
+	}
+}
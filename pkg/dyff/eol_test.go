@@ -0,0 +1,89 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("line ending normalization", func() {
+	var tmpDir string
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "dyff-eol")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	write := func(name string, content string) string {
+		location := filepath.Join(tmpDir, name)
+		Expect(os.MkdirAll(filepath.Dir(location), 0755)).To(Succeed())
+		Expect(os.WriteFile(location, []byte(content), 0644)).To(Succeed())
+		return location
+	}
+
+	compareFiles := func(from, to string, options ...dyff.CompareOption) dyff.Report {
+		fromFile, err := dyff.LoadFile(from)
+		Expect(err).ToNot(HaveOccurred())
+
+		toFile, err := dyff.LoadFile(to)
+		Expect(err).ToNot(HaveOccurred())
+
+		report, err := dyff.CompareInputFiles(fromFile, toFile, options...)
+		Expect(err).ToNot(HaveOccurred())
+		return report
+	}
+
+	It("should normalize line endings as configured in .gitattributes", func() {
+		write(".gitattributes", "* -text\n*.json text eol=lf\n")
+		from := write("windows/config.json", `{"script": "echo foo\r\necho bar\r\n"}`)
+		to := write("linux/config.json", `{"script": "echo foo\necho bar\n"}`)
+
+		Expect(compareFiles(from, to).Diffs).To(HaveLen(1))
+		Expect(compareFiles(from, to, dyff.NormalizeLineEndings(true)).Diffs).To(BeEmpty())
+	})
+
+	It("should normalize line endings as configured in .editorconfig", func() {
+		write(".editorconfig", "root = true\n\n[*.{yml,json}]\nend_of_line = lf\n")
+		from := write("a.json", `{"script": "echo foo\r\n"}`)
+		to := write("b.json", `{"script": "echo foo\n"}`)
+
+		Expect(compareFiles(from, to, dyff.NormalizeLineEndings(true)).Diffs).To(BeEmpty())
+	})
+
+	It("should not normalize files that are not configured as text", func() {
+		write(".gitattributes", "*.json binary\n")
+		from := write("a.json", `{"script": "echo foo\r\n"}`)
+		to := write("b.json", `{"script": "echo foo\n"}`)
+
+		Expect(compareFiles(from, to, dyff.NormalizeLineEndings(true)).Diffs).To(HaveLen(1))
+	})
+})
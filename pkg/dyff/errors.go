@@ -0,0 +1,122 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gonvenience/text"
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// DocumentCountMismatchError is returned if two input files with a different
+// number of documents need to be compared and there is no way to match the
+// documents by their content (e.g. by their Kubernetes resource name)
+type DocumentCountMismatchError struct {
+	From      ytbx.InputFile
+	To        ytbx.InputFile
+	Unmatched []UnmatchedDocument
+}
+
+// UnmatchedDocument describes a document that has no counterpart in the other
+// input file
+type UnmatchedDocument struct {
+	Location string
+	Index    int
+	Kind     string
+	Keys     []string
+}
+
+func newDocumentCountMismatchError(from, to ytbx.InputFile) *DocumentCountMismatchError {
+	var unmatched []UnmatchedDocument
+	var describe = func(location string, documents []*yamlv3.Node, offset int) {
+		for i := offset; i < len(documents); i++ {
+			unmatched = append(unmatched, describeDocument(location, i, documents[i]))
+		}
+	}
+
+	common := min(len(from.Documents), len(to.Documents))
+	describe(from.Location, from.Documents, common)
+	describe(to.Location, to.Documents, common)
+
+	return &DocumentCountMismatchError{
+		From:      from,
+		To:        to,
+		Unmatched: unmatched,
+	}
+}
+
+func (e *DocumentCountMismatchError) Error() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "comparing YAMLs with a different number of documents is currently not supported, %s contains %s and %s contains %s",
+		e.From.Location, text.Plural(len(e.From.Documents), "document"),
+		e.To.Location, text.Plural(len(e.To.Documents), "document"),
+	)
+
+	for _, document := range e.Unmatched {
+		fmt.Fprintf(&buf, "\n  - %s", document.String())
+	}
+
+	return buf.String()
+}
+
+// String returns a one line description of the unmatched document
+func (d UnmatchedDocument) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "document #%d of %s is unmatched (%s", d.Index+1, d.Location, d.Kind)
+
+	if len(d.Keys) > 0 {
+		fmt.Fprintf(&buf, " with keys %s", strings.Join(d.Keys, ", "))
+	}
+
+	buf.WriteString(")")
+	return buf.String()
+}
+
+func describeDocument(location string, idx int, document *yamlv3.Node) UnmatchedDocument {
+	const maxKeys = 3
+
+	result := UnmatchedDocument{Location: location, Index: idx, Kind: "empty document"}
+
+	node := document
+	if node.Kind == yamlv3.DocumentNode {
+		if len(node.Content) == 0 {
+			return result
+		}
+
+		node = node.Content[0]
+	}
+
+	result.Kind = humanReadableType(node)
+	if node.Kind == yamlv3.MappingNode {
+		for i := 0; i < len(node.Content) && len(result.Keys) < maxKeys; i += 2 {
+			result.Keys = append(result.Keys, node.Content[i].Value)
+		}
+
+		if len(node.Content)/2 > maxKeys {
+			result.Keys = append(result.Keys, "…")
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,581 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// FileSet is a collection of input files that are identified by their path
+// relative to the location of the set, for example the files of an archive
+type FileSet struct {
+	Location string
+	Files    map[string]ytbx.InputFile
+	Metadata map[string]FileMetadata
+}
+
+// FileMetadata are the details of a file of a file set besides its content,
+// which are only compared if CompareFileMetadata is enabled
+type FileMetadata struct {
+	Mode    fs.FileMode
+	Symlink string
+}
+
+// String returns a description of the metadata, for example
+// `symlink to base.yaml (-rw-r--r--)`
+func (metadata FileMetadata) String() string {
+	if metadata.Symlink != "" {
+		return fmt.Sprintf("symlink to %s (%s)", metadata.Symlink, metadata.Mode)
+	}
+
+	return fmt.Sprintf("regular file (%s)", metadata.Mode)
+}
+
+// Paths returns the sorted list of all file paths in the file set
+func (set FileSet) Paths() []string {
+	paths := make([]string, 0, len(set.Files))
+	for path := range set.Files {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+	return paths
+}
+
+// Jobs sets the number of files of file sets that are compared concurrently,
+// a value of zero or less uses one job per CPU. The resulting report does not
+// depend on the number of jobs.
+func Jobs(value int) CompareOption {
+	return func(settings *compareSettings) {
+		if value <= 0 {
+			value = runtime.NumCPU()
+		}
+
+		settings.Jobs = value
+	}
+}
+
+// CompareFileMetadata enables the comparison of the metadata of the files of
+// file sets: the file mode, whether a file is a symbolic link, and the case of
+// the file name. Files whose paths only differ in case are paired and reported
+// as renamed instead of being reported as removed and added.
+func CompareFileMetadata(value bool) CompareOption {
+	return func(settings *compareSettings) {
+		settings.FileMetadata = value
+	}
+}
+
+// FileCompared registers a function that is called with the differences of
+// each file of file sets as soon as the file and all files before it (in the
+// order of their paths) are compared, for example to stream the report file by
+// file. The paths of the differences refer to the documents of the combined
+// report, which are only complete once all files are compared.
+func FileCompared(fn func(path string, report Report)) CompareOption {
+	return func(settings *compareSettings) {
+		settings.FileCompared = fn
+	}
+}
+
+// isSupportedFileSetEntry returns whether a file with the given path should be
+// part of a file set, which are all files that can contain structured data
+func isSupportedFileSetEntry(path string) bool {
+	if isKnownExtension(strings.ToLower(filepath.Ext(path))) {
+		return true
+	}
+
+	_, ok := propertiesFormatOf(path)
+	return ok
+}
+
+// loadFileSetEntry loads the provided data as an input file, in case the data
+// cannot be parsed (e.g. a Helm chart template), the whole content is used as
+// a single text document so that changes are still reported
+func loadFileSetEntry(location string, data []byte) ytbx.InputFile {
+	if isHCLLocation(location) {
+		if documents, err := LoadHCLDocuments(data); err == nil {
+			return ytbx.InputFile{Location: location, Documents: documents}
+		}
+	}
+
+	if isINILocation(location) {
+		if documents, err := LoadINIDocuments(data); err == nil {
+			return ytbx.InputFile{Location: location, Documents: documents}
+		}
+	}
+
+	if format, ok := propertiesFormatOf(location); ok {
+		if documents, err := LoadPropertiesDocuments(data, format); err == nil {
+			return ytbx.InputFile{Location: location, Documents: documents}
+		}
+	}
+
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if documents, err := LoadDocuments(data); err == nil {
+			return ytbx.InputFile{Location: location, Documents: documents}
+		}
+	}
+
+	return ytbx.InputFile{
+		Location: location,
+		Note:     "text",
+		Documents: []*yamlv3.Node{{
+			Kind: yamlv3.DocumentNode,
+			Content: []*yamlv3.Node{{
+				Kind:  yamlv3.ScalarNode,
+				Tag:   "!!str",
+				Value: string(data),
+			}},
+		}},
+	}
+}
+
+// CompareFileSets compares two file sets by matching the files by their path.
+// Files that exist in both sets are compared using CompareInputFiles, files
+// that only exist in one of the sets are reported as a document addition or
+// removal. The resulting report combines all documents of all files, where the
+// names of the documents refer to the respective file path.
+func CompareFileSets(from FileSet, to FileSet, compareOptions ...CompareOption) (Report, error) {
+	var result = Report{
+		From: ytbx.InputFile{Location: from.Location},
+		To:   ytbx.InputFile{Location: to.Location},
+	}
+
+	var settings compareSettings
+	for _, compareOption := range compareOptions {
+		compareOption(&settings)
+	}
+
+	var (
+		paths   = map[string]struct{}{}
+		renames = map[string]string{}
+		diffs   []Diff
+	)
+
+	if settings.FileMetadata {
+		renames = from.CaseOnlyRenames(to)
+	}
+
+	var toPathOf = func(path string) string {
+		if renamed, ok := renames[path]; ok {
+			return renamed
+		}
+
+		return path
+	}
+
+	for _, path := range from.Paths() {
+		paths[path] = struct{}{}
+	}
+
+	var renamed = map[string]struct{}{}
+	for _, path := range renames {
+		renamed[path] = struct{}{}
+	}
+
+	for _, path := range to.Paths() {
+		if _, ok := renamed[path]; !ok {
+			paths[path] = struct{}{}
+		}
+	}
+
+	var sorted = make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+
+	sort.Strings(sorted)
+
+	var appendDocuments = func(target *ytbx.InputFile, path string, inputFile ytbx.InputFile) int {
+		offset := len(target.Documents)
+		for i, document := range inputFile.Documents {
+			target.Documents = append(target.Documents, document)
+			target.Names = append(target.Names, fileSetDocumentName(path, inputFile, i))
+		}
+
+		return offset
+	}
+
+	var contentOf = func(inputFile ytbx.InputFile) *yamlv3.Node {
+		node := &yamlv3.Node{Kind: yamlv3.DocumentNode}
+		for _, document := range inputFile.Documents {
+			node.Content = append(node.Content, document.Content...)
+		}
+
+		return node
+	}
+
+	// Files that exist in both sets are compared by a bounded number of
+	// workers, the results are combined in the order of the paths
+	var (
+		reports = make([]Report, len(sorted))
+		errs    = make([]error, len(sorted))
+		done    = make([]chan struct{}, len(sorted))
+		queue   = make(chan int, len(sorted))
+		stopped atomic.Bool
+		wg      sync.WaitGroup
+	)
+
+	for i, path := range sorted {
+		done[i] = make(chan struct{})
+		if _, inFrom := from.Files[path]; inFrom {
+			if _, inTo := to.Files[toPathOf(path)]; inTo {
+				queue <- i
+				continue
+			}
+		}
+
+		close(done[i])
+	}
+
+	close(queue)
+
+	for n := 0; n < max(settings.Jobs, 1); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				if !stopped.Load() {
+					reports[i], errs[i] = CompareInputFiles(from.Files[sorted[i]], to.Files[toPathOf(sorted[i])], compareOptions...)
+				}
+
+				close(done[i])
+			}
+		}()
+	}
+
+	// stop the workers in case the comparison fails early
+	defer wg.Wait()
+	defer stopped.Store(true)
+
+	for i, path := range sorted {
+		<-done[i]
+
+		fromFile, inFrom := from.Files[path]
+		toFile, inTo := to.Files[toPathOf(path)]
+		offset := len(diffs)
+
+		switch {
+		case inFrom && inTo:
+			if errs[i] != nil {
+				return Report{}, fmt.Errorf("failed to compare %s: %w", path, errs[i])
+			}
+
+			report := reports[i]
+			documentOffset := appendDocuments(&result.From, path, report.From)
+			toDocumentOffset := appendDocuments(&result.To, path, report.To)
+
+			for _, diff := range splitFileLevelDiffs(report.Diffs) {
+				diff.FromSource, diff.ToSource = diffSources(path, diff, report)
+				if documents, ok := addedDocuments(diff); ok {
+					diff.Path = rebasePath(nil, &result.To, toDocumentOffset+documentIndex(report.To.Documents, documents.Content[0]))
+				} else {
+					diff.Path = rebasePath(diff.Path, &result.From, documentOffset+removedDocumentIndex(diff, report))
+				}
+
+				diffs = append(diffs, diff)
+			}
+
+			if settings.FileMetadata {
+				if diff, ok := fileMetadataDiff(from, to, path, toPathOf(path)); ok {
+					diff.Path = rebasePath(nil, &result.From, documentOffset)
+					diffs = append(diffs, diff)
+				}
+			}
+
+		case inFrom:
+			documentOffset := appendDocuments(&result.From, path, fromFile)
+			diffs = append(diffs, Diff{
+				Path:       &ytbx.Path{Root: &result.From, DocumentIdx: documentOffset},
+				Details:    []Detail{{Kind: REMOVAL, From: contentOf(fromFile)}},
+				FromSource: &Source{File: path, Line: firstLine(contentOf(fromFile))},
+			})
+
+		case inTo:
+			documentOffset := appendDocuments(&result.To, path, toFile)
+			diffs = append(diffs, Diff{
+				Path:     &ytbx.Path{Root: &result.To, DocumentIdx: documentOffset},
+				Details:  []Detail{{Kind: ADDITION, To: contentOf(toFile)}},
+				ToSource: &Source{File: path, Line: firstLine(contentOf(toFile))},
+			})
+		}
+
+		// release the file report, it is no longer required
+		reports[i] = Report{}
+
+		if settings.FileCompared != nil {
+			settings.FileCompared(path, Report{From: result.From, To: result.To, Diffs: diffs[offset:len(diffs):len(diffs)]})
+		}
+	}
+
+	result.Diffs = diffs
+
+	// Documents that moved to another file can only be detected once all
+	// files are compared
+	if settings.DetectMoves {
+		result = detectMoves(result)
+	}
+
+	result.Sort()
+	return result, nil
+}
+
+// CaseOnlyRenames returns the files that only exist in the file set with a
+// path that only differs in case from the path of exactly one file of the
+// other file set, mapped from the path in the file set to the path in the
+// other file set
+func (set FileSet) CaseOnlyRenames(other FileSet) map[string]string {
+	var candidates = map[string][]string{}
+	for _, path := range other.Difference(set) {
+		candidates[strings.ToLower(path)] = append(candidates[strings.ToLower(path)], path)
+	}
+
+	var count = map[string]int{}
+	for _, path := range set.Difference(other) {
+		count[strings.ToLower(path)]++
+	}
+
+	var result = map[string]string{}
+	for _, path := range set.Difference(other) {
+		if matches := candidates[strings.ToLower(path)]; len(matches) == 1 && count[strings.ToLower(path)] == 1 {
+			result[path] = matches[0]
+		}
+	}
+
+	return result
+}
+
+// fileMetadataDiff returns a file level difference with one modification for
+// each changed metadata of the file (name, type, or mode)
+func fileMetadataDiff(from FileSet, to FileSet, fromPath string, toPath string) (Diff, bool) {
+	var modification = func(from, to string) Detail {
+		return Detail{
+			Kind: MODIFICATION,
+			From: &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: from},
+			To:   &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: to},
+		}
+	}
+
+	var details []Detail
+	if fromPath != toPath {
+		details = append(details, modification("name "+fromPath, "name "+toPath))
+	}
+
+	fromMetadata, fromOK := from.Metadata[fromPath]
+	toMetadata, toOK := to.Metadata[toPath]
+	if fromOK && toOK && fromMetadata != toMetadata {
+		details = append(details, modification(fromMetadata.String(), toMetadata.String()))
+	}
+
+	if len(details) == 0 {
+		return Diff{}, false
+	}
+
+	return Diff{
+		Details:    details,
+		FromSource: &Source{File: fromPath},
+		ToSource:   &Source{File: toPath},
+	}, true
+}
+
+// diffSources returns the sources of a difference of the report of the file
+// with the provided path. The lines are taken from the nodes of the details,
+// or from the node at the path of the difference otherwise. For the to side,
+// this is only possible if the documents of both files are in the same order.
+func diffSources(path string, diff Diff, report Report) (*Source, *Source) {
+	var fromLine, toLine int
+	for _, detail := range diff.Details {
+		if fromLine == 0 && detail.Kind != MOVED {
+			fromLine = firstLine(detail.From)
+		}
+
+		if toLine == 0 {
+			toLine = firstLine(detail.To)
+		}
+	}
+
+	var lineAt = func(documents []*yamlv3.Node) int {
+		if diff.Path == nil || diff.Path.DocumentIdx >= len(documents) {
+			return 0
+		}
+
+		node, err := lookupNode(documentRoot(documents[diff.Path.DocumentIdx]), diff.Path.PathElements)
+		if err != nil {
+			return 0
+		}
+
+		return node.Line
+	}
+
+	var from, to *Source
+	if hasKind(diff, REMOVAL, MODIFICATION, ORDERCHANGE) || diff.Path != nil {
+		if fromLine == 0 {
+			fromLine = lineAt(report.From.Documents)
+		}
+
+		from = &Source{File: path, Line: fromLine}
+	}
+
+	if hasKind(diff, ADDITION, MODIFICATION, ORDERCHANGE, MOVED) || diff.Path != nil {
+		if toLine == 0 && len(report.From.Documents) == len(report.To.Documents) {
+			toLine = lineAt(report.To.Documents)
+		}
+
+		to = &Source{File: path, Line: toLine}
+	}
+
+	return from, to
+}
+
+func hasKind(diff Diff, kinds ...DetailKind) bool {
+	for _, detail := range diff.Details {
+		for _, kind := range kinds {
+			if detail.Kind == kind {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// firstLine returns the first known line of the node or any of its sub nodes
+func firstLine(node *yamlv3.Node) int {
+	if node == nil {
+		return 0
+	}
+
+	if node.Line > 0 {
+		return node.Line
+	}
+
+	for _, content := range node.Content {
+		if line := firstLine(content); line > 0 {
+			return line
+		}
+	}
+
+	return 0
+}
+
+// sourceDescription returns the sources of the difference, for example
+// `templates/app.yaml:12 → templates/app.yaml:14`, or an empty string if the
+// difference has no sources
+func sourceDescription(diff Diff) string {
+	switch {
+	case diff.FromSource != nil && diff.ToSource != nil && *diff.FromSource != *diff.ToSource:
+		return diff.FromSource.String() + " → " + diff.ToSource.String()
+
+	case diff.FromSource != nil:
+		return diff.FromSource.String()
+
+	case diff.ToSource != nil:
+		return diff.ToSource.String()
+	}
+
+	return ""
+}
+
+// splitFileLevelDiffs returns the differences with each detail of a file level
+// difference (document additions, removals, and order changes) as a separate
+// difference, so that each of them can refer to the document of its side
+func splitFileLevelDiffs(diffs []Diff) []Diff {
+	var result = make([]Diff, 0, len(diffs))
+	for _, diff := range diffs {
+		if diff.Path != nil || len(diff.Details) < 2 {
+			result = append(result, diff)
+			continue
+		}
+
+		for _, detail := range diff.Details {
+			result = append(result, Diff{Details: []Detail{detail}})
+		}
+	}
+
+	return result
+}
+
+// addedDocuments returns the added documents of a file level difference that
+// only consists of document additions
+func addedDocuments(diff Diff) (*yamlv3.Node, bool) {
+	if diff.Path != nil || len(diff.Details) != 1 {
+		return nil, false
+	}
+
+	detail := diff.Details[0]
+	if detail.Kind != ADDITION || detail.To == nil || detail.To.Kind != yamlv3.DocumentNode || len(detail.To.Content) == 0 {
+		return nil, false
+	}
+
+	return detail.To, true
+}
+
+// removedDocumentIndex returns the index of the first removed document of a
+// file level difference in the from file, or zero for any other difference
+func removedDocumentIndex(diff Diff, report Report) int {
+	if diff.Path != nil || len(diff.Details) != 1 {
+		return 0
+	}
+
+	detail := diff.Details[0]
+	if detail.Kind != REMOVAL || detail.From == nil || detail.From.Kind != yamlv3.DocumentNode || len(detail.From.Content) == 0 {
+		return 0
+	}
+
+	return documentIndex(report.From.Documents, detail.From.Content[0])
+}
+
+// rebasePath returns a copy of the provided path that refers to the provided
+// root input file where the documents start at the given offset, a path-less
+// (file level) difference refers to the document at the offset
+func rebasePath(path *ytbx.Path, root *ytbx.InputFile, offset int) *ytbx.Path {
+	if path == nil {
+		return &ytbx.Path{Root: root, DocumentIdx: offset}
+	}
+
+	return &ytbx.Path{
+		Root:         root,
+		DocumentIdx:  path.DocumentIdx + offset,
+		PathElements: path.PathElements,
+	}
+}
+
+func fileSetDocumentName(path string, inputFile ytbx.InputFile, idx int) string {
+	switch {
+	case idx < len(inputFile.Names):
+		return fmt.Sprintf("%s: %s", path, inputFile.Names[idx])
+
+	case len(inputFile.Documents) > 1:
+		return fmt.Sprintf("%s: document #%d", path, idx+1)
+
+	default:
+		return path
+	}
+}
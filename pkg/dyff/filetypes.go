@@ -0,0 +1,114 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+)
+
+// FileType is a well-known type of file, which is detected by its file name,
+// with the part of the file that is relevant when comparing two versions of it
+type FileType struct {
+	Name     string
+	Patterns []string
+	Chroot   string
+	Project  []string
+}
+
+// Supported file types, see FileType
+var fileTypes = []FileType{
+	{
+		Name:     "helm-chart",
+		Patterns: []string{"Chart.yaml", "Chart.yml"},
+		Project:  []string{"/version", "/appVersion", "/kubeVersion", "/dependencies"},
+	},
+	{
+		Name:     "kustomization",
+		Patterns: []string{"kustomization.yaml", "kustomization.yml", "Kustomization"},
+		Project:  []string{"/namespace", "/resources", "/components", "/images", "/patches", "/replicas", "/helmCharts"},
+	},
+	{
+		Name:     "docker-compose",
+		Patterns: []string{"docker-compose*.yml", "docker-compose*.yaml", "compose*.yml", "compose*.yaml"},
+		Chroot:   "/services",
+	},
+}
+
+// FileTypeNames returns the names of all supported file types
+func FileTypeNames() []string {
+	names := make([]string, len(fileTypes))
+	for i, fileType := range fileTypes {
+		names[i] = fileType.Name
+	}
+
+	return names
+}
+
+// LookupFileType returns the file type with the provided name
+func LookupFileType(name string) (FileType, error) {
+	for _, fileType := range fileTypes {
+		if fileType.Name == name {
+			return fileType, nil
+		}
+	}
+
+	return FileType{}, fmt.Errorf("unknown file type %q, supported file types are %s", name, strings.Join(FileTypeNames(), ", "))
+}
+
+// DetectFileType returns the file type that matches the file name of the
+// provided location, if any
+func DetectFileType(location string) (FileType, bool) {
+	name := filepath.Base(location)
+	for _, fileType := range fileTypes {
+		for _, pattern := range fileType.Patterns {
+			if matched, _ := filepath.Match(pattern, name); matched {
+				return fileType, true
+			}
+		}
+	}
+
+	return FileType{}, false
+}
+
+// Apply changes the root of the input file, or reduces it to the relevant
+// paths as defined by the file type. A change root path that does not exist
+// in the input file is ignored, for example a compose file without services.
+func (fileType FileType) Apply(inputFile *ytbx.InputFile) error {
+	if fileType.Chroot != "" && len(inputFile.Documents) == 1 {
+		if _, err := ytbx.Grab(inputFile.Documents[0], fileType.Chroot); err == nil {
+			if err := ChangeRoot(inputFile, fileType.Chroot, true, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(fileType.Project) > 0 {
+		if err := Project(inputFile, fileType.Project...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
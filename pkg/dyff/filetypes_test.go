@@ -0,0 +1,86 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gonvenience/ytbx"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("well-known file types", func() {
+	It("should detect file types by their file name", func() {
+		for location, name := range map[string]string{
+			"charts/app/Chart.yaml":       "helm-chart",
+			"overlays/kustomization.yaml": "kustomization",
+			"docker-compose.override.yml": "docker-compose",
+			"/srv/compose.yaml":           "docker-compose",
+		} {
+			fileType, ok := dyff.DetectFileType(location)
+			Expect(ok).To(BeTrue(), location)
+			Expect(fileType.Name).To(Equal(name), location)
+		}
+
+		_, ok := dyff.DetectFileType("values.yaml")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should reduce a Helm chart file to the relevant fields", func() {
+		fileType, err := dyff.LookupFileType("helm-chart")
+		Expect(err).ToNot(HaveOccurred())
+
+		inputFile := ytbx.InputFile{Location: "Chart.yaml", Documents: multiDoc(`---
+apiVersion: v2
+name: app
+description: An application
+version: 1.2.3
+appVersion: "4.5.6"
+`)}
+
+		Expect(fileType.Apply(&inputFile)).To(Succeed())
+		Expect(inputFile.Documents).To(HaveLen(1))
+
+		root := inputFile.Documents[0].Content[0]
+		var keys []string
+		for i := 0; i < len(root.Content); i += 2 {
+			keys = append(keys, root.Content[i].Value)
+		}
+
+		Expect(keys).To(ConsistOf("apiVersion", "version", "appVersion"))
+	})
+
+	It("should leave files without the change root path as they are", func() {
+		fileType, err := dyff.LookupFileType("docker-compose")
+		Expect(err).ToNot(HaveOccurred())
+
+		inputFile := ytbx.InputFile{Location: "compose.yaml", Documents: multiDoc(`{volumes: {data: {}}}`)}
+		Expect(fileType.Apply(&inputFile)).To(Succeed())
+		Expect(inputFile.Documents[0].Content[0].Content[0].Value).To(Equal("volumes"))
+	})
+
+	It("should fail for unknown file types", func() {
+		_, err := dyff.LookupFileType("unknown")
+		Expect(err).To(MatchError(ContainSubstring("supported file types are helm-chart, kustomization, docker-compose")))
+	})
+})
@@ -0,0 +1,125 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Fingerprint returns a stable hash of the semantic changes in the report. It
+// only depends on the paths, kinds, and values of the differences, but not on
+// the way they are rendered, the order of the differences in the report, the
+// order of keys in maps, the style of YAML values (e.g. quoting), comments,
+// or the locations of the input files. Two reports with the same fingerprint
+// contain the same set of differences.
+func (r Report) Fingerprint() string {
+	entries := make([]string, len(r.Diffs))
+	for i, diff := range r.Diffs {
+		entries[i] = canonicalDiff(diff)
+	}
+
+	sort.Strings(entries)
+	return sha256Hex([]byte(strings.Join(entries, "\n\n")))
+}
+
+// Fingerprint returns a stable hash of the semantic change of the difference,
+// see Report.Fingerprint for details
+func (d Diff) Fingerprint() string {
+	return sha256Hex([]byte(canonicalDiff(d)))
+}
+
+// canonicalDiff returns a representation of the difference that only depends
+// on its path, kinds, and values
+func canonicalDiff(diff Diff) string {
+	var buf strings.Builder
+
+	switch diff.Path {
+	case nil:
+		buf.WriteString("(file level)")
+
+	default:
+		buf.WriteString(diff.Path.RootDescription())
+		buf.WriteString(" ")
+		buf.WriteString(diff.Path.String())
+	}
+
+	for _, detail := range diff.Details {
+		fmt.Fprintf(&buf, "\n%c ", detail.Kind)
+		writeCanonicalNode(&buf, detail.From)
+		buf.WriteString(" ")
+		writeCanonicalNode(&buf, detail.To)
+	}
+
+	return buf.String()
+}
+
+// writeCanonicalNode writes a representation of the node that only depends on
+// its semantic content, keys of maps are written in sorted order
+func writeCanonicalNode(buf *strings.Builder, node *yamlv3.Node) {
+	if node == nil {
+		buf.WriteString("~")
+		return
+	}
+
+	node = followAlias(node)
+
+	switch node.Kind {
+	case yamlv3.DocumentNode:
+		buf.WriteString("---[")
+		for _, content := range node.Content {
+			writeCanonicalNode(buf, content)
+			buf.WriteString(",")
+		}
+		buf.WriteString("]")
+
+	case yamlv3.SequenceNode:
+		buf.WriteString("[")
+		for _, content := range node.Content {
+			writeCanonicalNode(buf, content)
+			buf.WriteString(",")
+		}
+		buf.WriteString("]")
+
+	case yamlv3.MappingNode:
+		entries := make([]string, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			var entry strings.Builder
+			writeCanonicalNode(&entry, node.Content[i])
+			entry.WriteString(":")
+			writeCanonicalNode(&entry, node.Content[i+1])
+			entries = append(entries, entry.String())
+		}
+
+		sort.Strings(entries)
+		buf.WriteString("{")
+		buf.WriteString(strings.Join(entries, ","))
+		buf.WriteString("}")
+
+	default:
+		buf.WriteString(node.ShortTag())
+		buf.WriteString(strconv.Quote(node.Value))
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/mitchellh/hashstructure"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// NodeHashMode defines how the hash of a node is calculated, which is used to
+// match entries of lists without identifiers
+type NodeHashMode string
+
+// Supported modes to calculate node hashes
+const (
+	// HashStructure converts nodes into basic Go types and hashes them using
+	// the hashstructure package (default)
+	HashStructure NodeHashMode = "hashstructure"
+
+	// CanonicalHash hashes a canonical serialization of the node directly
+	// using FNV-1a, which skips the conversion into basic types and does not
+	// allocate for scalar values
+	CanonicalHash NodeHashMode = "canonical"
+)
+
+// NodeHashing sets how node hashes are calculated, see HashStructure and
+// CanonicalHash
+func NodeHashing(mode NodeHashMode) CompareOption {
+	return func(settings *compareSettings) {
+		settings.NodeHashing = mode
+	}
+}
+
+// FNV-1a 64-bit offset basis and prime
+const (
+	fnvOffset64 uint64 = 14695981039346656037
+	fnvPrime64  uint64 = 1099511628211
+)
+
+// Markers that separate the node kinds in the canonical serialization, so
+// that for example the scalar `a` and the list `[a]` do not collide
+const (
+	canonicalScalar   byte = 's'
+	canonicalMapping  byte = 'm'
+	canonicalSequence byte = 'l'
+)
+
+func fnvByte(hash uint64, b byte) uint64 {
+	return (hash ^ uint64(b)) * fnvPrime64
+}
+
+func fnvString(hash uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		hash = fnvByte(hash, s[i])
+	}
+
+	return hash
+}
+
+func fnvUint64(hash uint64, value uint64) uint64 {
+	for i := 0; i < 8; i++ {
+		hash = fnvByte(hash, byte(value>>(8*i)))
+	}
+
+	return hash
+}
+
+// mixHash scrambles the bits of a hash so that the order-independent sum of
+// several hashes does not cancel out similar entries
+func mixHash(hash uint64) uint64 {
+	hash ^= hash >> 33
+	hash *= 0xff51afd7ed558ccd
+	hash ^= hash >> 33
+	hash *= 0xc4ceb9fe1a85ec53
+	hash ^= hash >> 33
+	return hash
+}
+
+// canonicalHash calculates the hash of the canonical serialization of a node:
+// scalars are serialized by their value (like the basic type conversion, the
+// tag is not considered), mapping entries are combined independent of their
+// order, and sequence entries are combined in order unless order changes are
+// ignored. Nested mappings and sequences go through calcNodeHash so that their
+// hashes are cached, too.
+func (compare *compare) canonicalHash(node *yamlv3.Node) (uint64, error) {
+	switch node.Kind {
+	case yamlv3.ScalarNode:
+		hash := fnvByte(fnvOffset64, canonicalScalar)
+		hash = fnvUint64(hash, uint64(len(node.Value)))
+		return fnvString(hash, node.Value), nil
+
+	case yamlv3.MappingNode:
+		var sum uint64
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			entry := fnvUint64(fnvOffset64, compare.calcNodeHash(node.Content[i]))
+			entry = fnvUint64(entry, compare.calcNodeHash(node.Content[i+1]))
+			sum += mixHash(entry)
+		}
+
+		hash := fnvByte(fnvOffset64, canonicalMapping)
+		hash = fnvUint64(hash, uint64(len(node.Content)/2))
+		return fnvUint64(hash, sum), nil
+
+	case yamlv3.SequenceNode:
+		hash := fnvByte(fnvOffset64, canonicalSequence)
+		hash = fnvUint64(hash, uint64(len(node.Content)))
+
+		if compare.settings.IgnoreOrderChanges {
+			// keep the side effect of the basic type conversion, which sorts
+			// the list, so that both hash modes report the same result
+			sortNode(node)
+
+			var sum uint64
+			for _, entry := range node.Content {
+				sum += mixHash(compare.calcNodeHash(entry))
+			}
+
+			return fnvUint64(hash, sum), nil
+		}
+
+		for _, entry := range node.Content {
+			hash = fnvUint64(hash, compare.calcNodeHash(entry))
+		}
+
+		return hash, nil
+
+	case yamlv3.AliasNode:
+		return compare.canonicalHash(followAlias(node))
+	}
+
+	return 0, fmt.Errorf("kind %v is not supported", node.Kind)
+}
+
+// Pools for the maps and lists of the basic type conversion, as well as the
+// options (including the hasher) of the hashstructure package, which would
+// otherwise be allocated for every node of every hash calculation
+var (
+	basicMapPool = sync.Pool{New: func() interface{} {
+		return map[interface{}]interface{}{}
+	}}
+
+	basicListPool = sync.Pool{New: func() interface{} {
+		list := make([]interface{}, 0, 8)
+		return &list
+	}}
+
+	hashOptionsPool = sync.Pool{New: func() interface{} {
+		return &hashstructure.HashOptions{Hasher: fnv.New64()}
+	}}
+)
+
+// hashValue hashes a basic type value using the hashstructure package with
+// its default options, but a pooled hasher
+func hashValue(value interface{}) (uint64, error) {
+	options := hashOptionsPool.Get().(*hashstructure.HashOptions)
+	defer hashOptionsPool.Put(options)
+
+	return hashstructure.Hash(value, options)
+}
+
+// releaseBasicType returns the maps and lists of a value created by the basic
+// type conversion to their pools, the value must not be used afterwards
+func releaseBasicType(value interface{}) {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		for _, entry := range value {
+			releaseBasicType(entry)
+		}
+
+		clear(value)
+		basicMapPool.Put(value)
+
+	case []interface{}:
+		for _, entry := range value {
+			releaseBasicType(entry)
+		}
+
+		clear(value)
+		value = value[:0]
+		basicListPool.Put(&value)
+	}
+}
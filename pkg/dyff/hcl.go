@@ -0,0 +1,649 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+var hclNumber = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// isHCLLocation returns whether the location refers to a HCL file, like the
+// Terraform configuration (.tf) and variable (.tfvars) files
+func isHCLLocation(location string) bool {
+	switch strings.ToLower(filepath.Ext(location)) {
+	case ".tf", ".tfvars", ".hcl":
+		return true
+	}
+
+	return false
+}
+
+// LoadHCLDocuments loads the provided data as a HCL (native syntax) document,
+// for example a Terraform configuration file. Attributes become map entries,
+// blocks become maps nested by their type and labels, for example the block
+// `resource "aws_instance" "web" {}` is located at the path
+// /resource/aws_instance/web, so that blocks are matched by their type and
+// name. Blocks without labels become a list if the block type is used more
+// than once. Literal values (strings, numbers, bools, null), tuples, and
+// objects are loaded as such, all other expressions (e.g. references, function
+// calls, or conditionals) are loaded as a string of the expression.
+func LoadHCLDocuments(data []byte) ([]*yamlv3.Node, error) {
+	parser := hclParser{src: []rune(string(data)), line: 1, blocks: map[*yamlv3.Node]hclBlockNode{}}
+
+	body, err := parser.body(false)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*yamlv3.Node{{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{body}}}, nil
+}
+
+type hclParser struct {
+	src  []rune
+	pos  int
+	line int
+
+	// blocks are the nodes that were created for blocks, so that blocks of
+	// the same type are added to them and conflicts with attributes are found
+	blocks map[*yamlv3.Node]hclBlockNode
+}
+
+type hclBlockNode int
+
+const (
+	hclBlockBody hclBlockNode = iota + 1
+	hclBlockLabels
+	hclBlockList
+)
+
+func (p *hclParser) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("failed to parse HCL in line %d: %s", p.line, fmt.Sprintf(format, a...))
+}
+
+func (p *hclParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *hclParser) peek(offset int) rune {
+	if p.pos+offset < len(p.src) {
+		return p.src[p.pos+offset]
+	}
+
+	return 0
+}
+
+func (p *hclParser) next() rune {
+	r := p.src[p.pos]
+	if r == '\n' {
+		p.line++
+	}
+
+	p.pos++
+	return r
+}
+
+// skip skips whitespace and comments, newlines are only skipped if requested
+func (p *hclParser) skip(newlines bool) {
+	for !p.eof() {
+		switch r := p.peek(0); {
+		case r == '\n' && !newlines:
+			return
+
+		case unicode.IsSpace(r):
+			p.next()
+
+		case r == '#', r == '/' && p.peek(1) == '/':
+			for !p.eof() && p.peek(0) != '\n' {
+				p.next()
+			}
+
+		case r == '/' && p.peek(1) == '*':
+			p.next()
+			p.next()
+			for !p.eof() && !(p.peek(0) == '*' && p.peek(1) == '/') {
+				p.next()
+			}
+
+			if !p.eof() {
+				p.next()
+				p.next()
+			}
+
+		default:
+			return
+		}
+	}
+}
+
+func isHCLIdentifier(r rune, first bool) bool {
+	return unicode.IsLetter(r) || r == '_' || (!first && (unicode.IsDigit(r) || r == '-'))
+}
+
+func (p *hclParser) identifier() string {
+	start := p.pos
+	for !p.eof() && isHCLIdentifier(p.peek(0), p.pos == start) {
+		p.next()
+	}
+
+	return string(p.src[start:p.pos])
+}
+
+// body parses attributes and blocks until the end of the input, or until the
+// closing brace of a block
+func (p *hclParser) body(block bool) (*yamlv3.Node, error) {
+	node := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+
+	for {
+		p.skip(true)
+
+		switch {
+		case p.eof() && block:
+			return nil, p.errorf("missing closing brace of block")
+
+		case p.eof():
+			return node, nil
+
+		case p.peek(0) == '}' && block:
+			p.next()
+			return node, nil
+		}
+
+		name := p.identifier()
+		if name == "" {
+			return nil, p.errorf("unexpected character %q", p.peek(0))
+		}
+
+		p.skip(false)
+		if p.peek(0) == '=' && p.peek(1) != '=' {
+			p.next()
+
+			value, err := p.expression("\n}")
+			if err != nil {
+				return nil, err
+			}
+
+			if _, exists := findValueByKey(node, name); exists {
+				return nil, p.errorf("duplicate attribute %s", name)
+			}
+
+			node.Content = append(node.Content, hclString(name), value)
+			continue
+		}
+
+		var labels []string
+		for p.peek(0) != '{' {
+			switch {
+			case p.peek(0) == '"':
+				label, err := p.quotedString()
+				if err != nil {
+					return nil, err
+				}
+
+				labels = append(labels, label)
+
+			case isHCLIdentifier(p.peek(0), true):
+				labels = append(labels, p.identifier())
+
+			default:
+				return nil, p.errorf("expected block labels or opening brace of block %s", name)
+			}
+
+			p.skip(false)
+		}
+
+		p.next()
+		body, err := p.body(true)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.addBlock(node, append([]string{name}, labels...), body); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// addBlock adds the body of a block to the map of the enclosing body, nested
+// by the block type and labels. A block that already exists becomes a list.
+func (p *hclParser) addBlock(node *yamlv3.Node, keys []string, body *yamlv3.Node) error {
+	p.blocks[body] = hclBlockBody
+
+	for _, key := range keys[:len(keys)-1] {
+		next, exists := findValueByKey(node, key)
+		if !exists {
+			next = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, hclString(key), next)
+			p.blocks[next] = hclBlockLabels
+		}
+
+		if p.blocks[next] != hclBlockLabels {
+			return p.errorf("block %s conflicts with an existing definition of %s", strings.Join(keys, " "), key)
+		}
+
+		node = next
+	}
+
+	key := keys[len(keys)-1]
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != key {
+			continue
+		}
+
+		switch existing := node.Content[i+1]; p.blocks[existing] {
+		case hclBlockList:
+			existing.Content = append(existing.Content, body)
+
+		case hclBlockBody:
+			list := &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq", Content: []*yamlv3.Node{existing, body}}
+			p.blocks[list] = hclBlockList
+			node.Content[i+1] = list
+
+		default:
+			return p.errorf("block %s conflicts with an existing definition of %s", strings.Join(keys, " "), key)
+		}
+
+		return nil
+	}
+
+	node.Content = append(node.Content, hclString(key), body)
+	return nil
+}
+
+// expression parses the expression of an attribute, a tuple entry, or an
+// object value, which ends with one of the terminators (at nesting level zero)
+func (p *hclParser) expression(terminators string) (*yamlv3.Node, error) {
+	p.skip(false)
+	start, line := p.pos, p.line
+
+	var node *yamlv3.Node
+	var err error
+	switch {
+	case p.peek(0) == '<' && p.peek(1) == '<':
+		value, err := p.heredoc()
+		if err != nil {
+			return nil, err
+		}
+
+		return hclString(value), nil
+
+	case p.peek(0) == '"':
+		var value string
+		if value, err = p.quotedString(); err == nil {
+			node = hclString(value)
+		}
+
+	case p.peek(0) == '[' && !p.isForExpression():
+		node, err = p.tuple()
+
+	case p.peek(0) == '{' && !p.isForExpression():
+		node, err = p.object()
+	}
+
+	// literals that are followed by more (e.g. an operator) are part of a
+	// larger expression, which is loaded as it is written
+	if node != nil && err == nil {
+		p.skip(false)
+		if p.eof() || strings.ContainsRune(terminators, p.peek(0)) {
+			return node, nil
+		}
+	}
+
+	p.pos, p.line = start, line
+	text, err := p.raw(terminators)
+	if err != nil {
+		return nil, err
+	}
+
+	return hclLiteral(text), nil
+}
+
+// raw reads an expression as it is written
+func (p *hclParser) raw(terminators string) (string, error) {
+	start, depth := p.pos, 0
+
+loop:
+	for !p.eof() {
+		switch r := p.peek(0); {
+		case depth == 0 && strings.ContainsRune(terminators, r):
+			break loop
+
+		case r == '#', r == '/' && p.peek(1) == '/':
+			if depth == 0 {
+				break loop
+			}
+
+			p.skip(false)
+
+		case r == '/' && p.peek(1) == '*':
+			p.skip(false)
+
+		case r == '"':
+			if _, err := p.quotedString(); err != nil {
+				return "", err
+			}
+
+		case strings.ContainsRune("([{", r):
+			depth++
+			p.next()
+
+		case strings.ContainsRune(")]}", r):
+			if depth == 0 {
+				return "", p.errorf("unexpected character %q", r)
+			}
+
+			depth--
+			p.next()
+
+		default:
+			p.next()
+		}
+	}
+
+	text := strings.TrimSpace(string(p.src[start:p.pos]))
+	if text == "" {
+		return "", p.errorf("missing expression")
+	}
+
+	return text, nil
+}
+
+// isForExpression returns whether the bracket or brace at the current
+// position starts a for expression, e.g. [for s in var.list : upper(s)]
+func (p *hclParser) isForExpression() bool {
+	i := p.pos + 1
+	for i < len(p.src) && unicode.IsSpace(p.src[i]) {
+		i++
+	}
+
+	return i+3 < len(p.src) && string(p.src[i:i+3]) == "for" && unicode.IsSpace(p.src[i+3])
+}
+
+func (p *hclParser) tuple() (*yamlv3.Node, error) {
+	node := &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq"}
+
+	p.next()
+	for {
+		p.skip(true)
+		if p.peek(0) == ']' {
+			p.next()
+			return node, nil
+		}
+
+		entry, err := p.expression(",]\n")
+		if err != nil {
+			return nil, err
+		}
+
+		node.Content = append(node.Content, entry)
+
+		p.skip(true)
+		switch p.peek(0) {
+		case ',':
+			p.next()
+
+		case ']':
+			// closed in the next iteration
+
+		default:
+			return nil, p.errorf("expected comma or closing bracket of tuple")
+		}
+	}
+}
+
+func (p *hclParser) object() (*yamlv3.Node, error) {
+	node := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+
+	p.next()
+	for {
+		p.skip(true)
+		if p.peek(0) == '}' {
+			p.next()
+			return node, nil
+		}
+
+		var key string
+		var err error
+		switch {
+		case p.peek(0) == '"':
+			key, err = p.quotedString()
+
+		case isHCLIdentifier(p.peek(0), true):
+			key = p.identifier()
+
+		default:
+			key, err = p.raw("=:\n")
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		p.skip(false)
+		if p.peek(0) != '=' && p.peek(0) != ':' {
+			return nil, p.errorf("expected equals sign or colon after object key %s", key)
+		}
+
+		p.next()
+		value, err := p.expression(",}\n")
+		if err != nil {
+			return nil, err
+		}
+
+		node.Content = append(node.Content, hclString(key), value)
+
+		p.skip(true)
+		if p.peek(0) == ',' {
+			p.next()
+		}
+	}
+}
+
+// quotedString reads a quoted string, escape sequences are resolved, but
+// template sequences (e.g. ${var.name}) are kept as they are written
+func (p *hclParser) quotedString() (string, error) {
+	var buf strings.Builder
+
+	p.next()
+	for {
+		if p.eof() || p.peek(0) == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+
+		switch r := p.next(); {
+		case r == '"':
+			return buf.String(), nil
+
+		case r == '\\':
+			if p.eof() {
+				return "", p.errorf("unterminated string")
+			}
+
+			switch escape := p.next(); escape {
+			case 'n':
+				buf.WriteRune('\n')
+
+			case 'r':
+				buf.WriteRune('\r')
+
+			case 't':
+				buf.WriteRune('\t')
+
+			case '"', '\\':
+				buf.WriteRune(escape)
+
+			case 'u', 'U':
+				length := 4
+				if escape == 'U' {
+					length = 8
+				}
+
+				if p.pos+length > len(p.src) {
+					return "", p.errorf("invalid unicode escape sequence")
+				}
+
+				code, err := strconv.ParseUint(string(p.src[p.pos:p.pos+length]), 16, 32)
+				if err != nil {
+					return "", p.errorf("invalid unicode escape sequence")
+				}
+
+				p.pos += length
+				buf.WriteRune(rune(code))
+
+			default:
+				return "", p.errorf("invalid escape sequence \\%c", escape)
+			}
+
+		case (r == '$' || r == '%') && p.peek(0) == r && p.peek(1) == '{':
+			// escaped template sequence, i.e. $${ or %%{
+			buf.WriteRune(r)
+			buf.WriteRune(p.next())
+			buf.WriteRune(p.next())
+
+		case (r == '$' || r == '%') && p.peek(0) == '{':
+			start := p.pos - 1
+			p.next()
+			for depth := 1; depth > 0; {
+				if p.eof() {
+					return "", p.errorf("unterminated template sequence")
+				}
+
+				switch p.peek(0) {
+				case '"':
+					if _, err := p.quotedString(); err != nil {
+						return "", err
+					}
+
+					continue
+
+				case '{':
+					depth++
+
+				case '}':
+					depth--
+				}
+
+				p.next()
+			}
+
+			buf.WriteString(string(p.src[start:p.pos]))
+
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
+
+// heredoc reads a heredoc string, which in the indented form (<<-) has the
+// common leading whitespace of all lines removed
+func (p *hclParser) heredoc() (string, error) {
+	p.next()
+	p.next()
+
+	indented := p.peek(0) == '-'
+	if indented {
+		p.next()
+	}
+
+	marker := p.identifier()
+	if marker == "" {
+		return "", p.errorf("missing heredoc marker")
+	}
+
+	p.skip(false)
+	if !p.eof() && p.peek(0) != '\n' {
+		return "", p.errorf("unexpected content after heredoc marker %s", marker)
+	}
+
+	var lines []string
+	for {
+		if p.eof() {
+			return "", p.errorf("missing end of heredoc %s", marker)
+		}
+
+		p.next()
+		start := p.pos
+		for !p.eof() && p.peek(0) != '\n' {
+			p.next()
+		}
+
+		line := string(p.src[start:p.pos])
+		if strings.TrimSpace(line) == marker {
+			break
+		}
+
+		lines = append(lines, line)
+	}
+
+	if indented {
+		var common = -1
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+
+			if indent := len(line) - len(strings.TrimLeft(line, " \t")); common < 0 || indent < common {
+				common = indent
+			}
+		}
+
+		for i, line := range lines {
+			if len(line) >= common && common > 0 {
+				lines[i] = line[common:]
+			}
+		}
+	}
+
+	if len(lines) == 0 {
+		return "", nil
+	}
+
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+func hclString(value string) *yamlv3.Node {
+	return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func hclLiteral(text string) *yamlv3.Node {
+	switch {
+	case text == "true", text == "false":
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!bool", Value: text}
+
+	case text == "null":
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!null", Value: text}
+
+	case hclNumber.MatchString(text) && strings.ContainsAny(text, ".eE"):
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!float", Value: text}
+
+	case hclNumber.MatchString(text):
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!int", Value: text}
+	}
+
+	return hclString(text)
+}
@@ -0,0 +1,127 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("HCL input", func() {
+	var load = func(input string) *yamlv3.Node {
+		documents, err := dyff.LoadHCLDocuments([]byte(input))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(documents).To(HaveLen(1))
+		return documents[0].Content[0]
+	}
+
+	var expectSame = func(actual, expected *yamlv3.Node) {
+		diffs, err := compare(actual, expected)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diffs).To(BeEmpty())
+	}
+
+	It("should load blocks nested by their type and labels", func() {
+		expectSame(load(`
+# provider configuration
+terraform {
+  required_version = ">= 1.0"
+}
+
+resource "aws_instance" "web" {
+  ami   = data.aws_ami.ubuntu.id // latest
+  count = 2
+
+  ingress {
+    port = 80
+  }
+
+  ingress { port = 443 }
+}
+
+resource "aws_instance" "db" {
+  ami = "ami-123"
+}
+`), yml(`
+terraform:
+  required_version: ">= 1.0"
+resource:
+  aws_instance:
+    web:
+      ami: data.aws_ami.ubuntu.id
+      count: 2
+      ingress:
+      - port: 80
+      - port: 443
+    db:
+      ami: ami-123
+`))
+	})
+
+	It("should load literal values, tuples, objects, and heredocs", func() {
+		expectSame(load(`
+enabled  = true
+ratio    = 0.5
+nothing  = null
+name     = "web-${var.env}\t\"x\""
+zones    = ["a", "b",
+  "c", # last
+]
+tags     = { Name = "web", "kubernetes.io/role": "node" }
+script   = <<-EOT
+    #!/bin/sh
+      echo hi
+  EOT
+size     = var.large ? "large" : "small"
+upper    = [for s in var.list : upper(s)]
+`), yml(`
+enabled: true
+ratio: 0.5
+nothing: null
+name: "web-${var.env}\t\"x\""
+zones: [a, b, c]
+tags: {Name: web, kubernetes.io/role: node}
+script: |
+  #!/bin/sh
+    echo hi
+size: 'var.large ? "large" : "small"'
+upper: '[for s in var.list : upper(s)]'
+`))
+	})
+
+	It("should fail for invalid input", func() {
+		for _, input := range []string{
+			`resource "a" "b" {`,
+			`name = "unterminated`,
+			`name = 1
+name = 2`,
+			`script = <<EOT
+no end`,
+		} {
+			_, err := dyff.LoadHCLDocuments([]byte(input))
+			Expect(err).To(HaveOccurred(), input)
+		}
+	})
+})
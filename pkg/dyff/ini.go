@@ -0,0 +1,261 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// isINILocation returns whether the location refers to an INI file
+func isINILocation(location string) bool {
+	switch strings.ToLower(filepath.Ext(location)) {
+	case ".ini", ".cfg":
+		return true
+	}
+
+	return false
+}
+
+// LoadINIDocuments loads the provided data as an INI document. Sections
+// become maps (sections that are used more than once are merged), and keys
+// before the first section are top-level entries. Since INI has no types, all
+// values are strings, keys without a value are null, and keys ending with `[]`
+// (e.g. `hosts[] = a`) become lists. Comments start with `;` or `#`, either at
+// the start of a line or after whitespace, and lines without a `=` or `:`
+// separator, that are indented deeper than the previous key, continue its
+// value.
+func LoadINIDocuments(data []byte) ([]*yamlv3.Node, error) {
+	var (
+		root    = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		section = root
+		last    *yamlv3.Node
+		indent  int
+	)
+
+	for i, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		var trimmed = strings.TrimSpace(iniStripComment(line))
+		var lineIndent = len(line) - len(strings.TrimLeft(line, " \t"))
+
+		switch {
+		case trimmed == "":
+			last = nil
+
+		case lineIndent > indent && !strings.ContainsAny(trimmed, "=:") && last != nil && last.Tag == "!!str":
+			// lines that are indented deeper than the previous key and have
+			// no separator continue its value
+			last.Value += "\n" + trimmed
+
+		case strings.HasPrefix(trimmed, "["):
+			if !strings.HasSuffix(trimmed, "]") {
+				return nil, fmt.Errorf("failed to parse INI in line %d: missing closing bracket of section %s", i+1, trimmed)
+			}
+
+			var name = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			if value, ok := findValueByKey(root, name); ok {
+				if value.Kind != yamlv3.MappingNode {
+					return nil, fmt.Errorf("failed to parse INI in line %d: section %s conflicts with key %s", i+1, name, name)
+				}
+
+				section = value
+
+			} else {
+				section = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+				root.Content = append(root.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: name}, section)
+			}
+
+			last = nil
+
+		default:
+			key, value, found := strings.Cut(trimmed, "=")
+			if idx := strings.Index(trimmed, ":"); idx >= 0 && (!found || idx < len(key)) {
+				key, value, found = trimmed[:idx], trimmed[idx+1:], true
+			}
+
+			key, value = strings.TrimSpace(key), iniUnquote(strings.TrimSpace(value))
+			indent = lineIndent
+
+			last = &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value}
+			if !found {
+				last = &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!null", Value: "null"}
+			}
+
+			if name, isList := strings.CutSuffix(key, "[]"); isList {
+				list, ok := findValueByKey(section, name)
+				if !ok {
+					list = &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq"}
+					section.Content = append(section.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: name}, list)
+				}
+
+				if list.Kind != yamlv3.SequenceNode {
+					return nil, fmt.Errorf("failed to parse INI in line %d: list %s conflicts with key %s", i+1, key, name)
+				}
+
+				list.Content = append(list.Content, last)
+				continue
+			}
+
+			// keys that are defined more than once keep the last value
+			for j := 0; j+1 < len(section.Content); j += 2 {
+				if section.Content[j].Value == key {
+					section.Content[j+1] = last
+					key = ""
+					break
+				}
+			}
+
+			if key != "" {
+				section.Content = append(section.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: key}, last)
+			}
+		}
+	}
+
+	return []*yamlv3.Node{{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{root}}}, nil
+}
+
+// iniStripComment removes a comment from the line, which either starts the
+// line, or follows whitespace, but is not part of a quoted value
+func iniStripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+
+		case r == '"' || r == '\'':
+			quote = r
+
+		case (r == ';' || r == '#') && (strings.TrimSpace(line[:i]) == "" || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+
+	return line
+}
+
+func iniUnquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
+
+// INI returns the INI representation of the provided node, which has to be a
+// map. Scalar values of the top-level map come first, nested maps are written
+// as sections, and lists of scalars as repeated keys ending with `[]`. Since
+// INI does not support deeper structures, they result in an error.
+func INI(node *yamlv3.Node) (string, error) {
+	node = documentRoot(followAlias(node))
+	if node.Kind != yamlv3.MappingNode {
+		return "", fmt.Errorf("only a map can be written as INI, but found %s", humanReadableType(node))
+	}
+
+	var buf strings.Builder
+	if err := writeINIEntries(&buf, "", node); err != nil {
+		return "", err
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, followAlias(node.Content[i+1])
+		if value.Kind != yamlv3.MappingNode {
+			continue
+		}
+
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+
+		fmt.Fprintf(&buf, "[%s]\n", key)
+		if err := writeINIEntries(&buf, key, value); err != nil {
+			return "", err
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// writeINIEntries writes the key/value pairs of the map, maps are skipped on
+// the top-level since they are written as sections
+func writeINIEntries(buf *strings.Builder, section string, node *yamlv3.Node) error {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, followAlias(node.Content[i+1])
+
+		var path = key
+		if section != "" {
+			path = section + "." + key
+		}
+
+		switch value.Kind {
+		case yamlv3.MappingNode:
+			if section != "" {
+				return fmt.Errorf("failed to write %s: INI does not support nested sections", path)
+			}
+
+		case yamlv3.SequenceNode:
+			for _, entry := range value.Content {
+				if entry = followAlias(entry); entry.Kind != yamlv3.ScalarNode || isNullNode(entry) {
+					return fmt.Errorf("failed to write %s: INI only supports lists of values", path)
+				}
+
+				fmt.Fprintf(buf, "%s[] = %s\n", key, iniValue(entry.Value))
+			}
+
+		default:
+			if isNullNode(value) {
+				fmt.Fprintln(buf, key)
+				continue
+			}
+
+			if lines := strings.Split(value.Value, "\n"); len(lines) > 1 && strings.ContainsAny(strings.Join(lines[1:], "\n"), "=:") {
+				return fmt.Errorf("failed to write %s: INI does not support multi-line values with = or : in continuation lines", path)
+			}
+
+			if value.Value == "" {
+				fmt.Fprintf(buf, "%s =\n", key)
+				continue
+			}
+
+			fmt.Fprintf(buf, "%s = %s\n", key, iniValue(value.Value))
+		}
+	}
+
+	return nil
+}
+
+// iniValue returns the value, quoted if it would otherwise not be loaded as
+// the same value, multi-line values use indented continuation lines
+func iniValue(value string) string {
+	if strings.Contains(value, "\n") {
+		return strings.ReplaceAll(value, "\n", "\n  ")
+	}
+
+	if value != strings.TrimSpace(value) || iniStripComment(" "+value) != " "+value || iniUnquote(value) != value {
+		return `"` + value + `"`
+	}
+
+	return value
+}
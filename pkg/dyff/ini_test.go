@@ -0,0 +1,109 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("INI input and output", func() {
+	var input = `; global settings
+name = legacy
+
+[database]
+  host = db.example.org   ; primary
+  port: 5432
+  password = "s3cr;et #1"
+  readonly
+description = first line
+   second line
+
+[servers]
+hosts[] = alpha
+hosts[] = beta
+
+[database]
+port = 5433
+`
+
+	It("should load sections as maps", func() {
+		documents, err := dyff.LoadINIDocuments([]byte(input))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(documents).To(HaveLen(1))
+
+		diffs, err := compare(documents[0].Content[0], yml(`---
+name: legacy
+database:
+  host: db.example.org
+  port: "5433"
+  password: "s3cr;et #1"
+  readonly: null
+  description: "first line\nsecond line"
+servers:
+  hosts: [alpha, beta]
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diffs).To(BeEmpty())
+	})
+
+	It("should write INI that loads as the same document", func() {
+		documents, err := dyff.LoadINIDocuments([]byte(input))
+		Expect(err).ToNot(HaveOccurred())
+
+		output, err := dyff.INI(documents[0])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(Equal(`name = legacy
+
+[database]
+host = db.example.org
+port = 5433
+password = "s3cr;et #1"
+readonly
+description = first line
+  second line
+
+[servers]
+hosts[] = alpha
+hosts[] = beta
+`))
+
+		reloaded, err := dyff.LoadINIDocuments([]byte(output))
+		Expect(err).ToNot(HaveOccurred())
+
+		diffs, err := compare(documents[0].Content[0], reloaded[0].Content[0])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diffs).To(BeEmpty())
+	})
+
+	It("should fail for structures that INI does not support", func() {
+		_, err := dyff.INI(yml(`{section: {nested: {key: value}}}`))
+		Expect(err).To(MatchError(ContainSubstring("failed to write section.nested: INI does not support nested sections")))
+
+		_, err = dyff.INI(yml(`[a, b]`))
+		Expect(err).To(MatchError(ContainSubstring("only a map can be written as INI")))
+
+		_, err = dyff.LoadINIDocuments([]byte("[section\nkey = value\n"))
+		Expect(err).To(MatchError(ContainSubstring("missing closing bracket")))
+	})
+})
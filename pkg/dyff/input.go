@@ -0,0 +1,275 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// LoadFiles loads the two input files from the provided locations, see
+// LoadFile for details
+func LoadFiles(fromLocation string, toLocation string) (ytbx.InputFile, ytbx.InputFile, error) {
+	from, err := LoadFile(fromLocation)
+	if err != nil {
+		return ytbx.InputFile{}, ytbx.InputFile{}, err
+	}
+
+	to, err := LoadFile(toLocation)
+	if err != nil {
+		return ytbx.InputFile{}, ytbx.InputFile{}, err
+	}
+
+	return from, to, nil
+}
+
+// LoadFile loads the input file from the provided location the same way as
+// ytbx.LoadFile does, except that numbers in local JSON files are loaded
+// without any loss of precision (e.g. 64-bit IDs), and that the directives of
+// documents in local YAML files are kept, see LoadDocuments. The keys of local
+// TOML files keep their order, see LoadTOMLDocuments, and HCL files (e.g.
+// Terraform) are supported, see LoadHCLDocuments. Java properties and dotenv
+// files are loaded as flat maps, see LoadPropertiesDocuments, and INI files
+// are loaded with their sections as maps, see LoadINIDocuments.
+func LoadFile(location string) (ytbx.InputFile, error) {
+	if info, err := os.Stat(location); err == nil && info.Mode().IsRegular() {
+		data, err := os.ReadFile(location)
+		if err != nil {
+			return ytbx.InputFile{}, fmt.Errorf("unable to load data from %s: %w", ytbx.HumanReadableLocation(location), err)
+		}
+
+		return LoadFileContent(location, data)
+	}
+
+	return ytbx.LoadFile(location)
+}
+
+// LoadFileContent loads the provided data as the content of the file at the
+// location the same way as LoadFile does, that is the file type is detected
+// using the location. This is useful for content that is not read from the
+// file system, for example the file of another Git revision.
+func LoadFileContent(location string, data []byte) (ytbx.InputFile, error) {
+	if isHCLLocation(location) {
+		documents, err := LoadHCLDocuments(data)
+		if err != nil {
+			return ytbx.InputFile{}, fmt.Errorf("unable to parse data from %s: %w", ytbx.HumanReadableLocation(location), err)
+		}
+
+		return ytbx.InputFile{Location: location, Documents: documents}, nil
+	}
+
+	if isINILocation(location) {
+		documents, err := LoadINIDocuments(data)
+		if err != nil {
+			return ytbx.InputFile{}, fmt.Errorf("unable to parse data from %s: %w", ytbx.HumanReadableLocation(location), err)
+		}
+
+		return ytbx.InputFile{Location: location, Documents: documents}, nil
+	}
+
+	if format, ok := propertiesFormatOf(location); ok {
+		documents, err := LoadPropertiesDocuments(data, format)
+		if err != nil {
+			return ytbx.InputFile{}, fmt.Errorf("unable to parse data from %s: %w", ytbx.HumanReadableLocation(location), err)
+		}
+
+		return ytbx.InputFile{Location: location, Documents: documents}, nil
+	}
+
+	if isJSONInput(data) {
+		if documents, err := loadJSONDocuments(data); err == nil {
+			return ytbx.InputFile{Location: location, Documents: documents}, nil
+		}
+	}
+
+	if hasDirectives(data) {
+		documents, err := loadDocumentsWithDirectives(data)
+		if err != nil {
+			return ytbx.InputFile{}, fmt.Errorf("unable to parse data from %s: %w", ytbx.HumanReadableLocation(location), err)
+		}
+
+		return ytbx.InputFile{Location: location, Documents: documents}, nil
+	}
+
+	if isTOMLInput(data) {
+		if documents, err := LoadTOMLDocuments(data); err == nil {
+			return ytbx.InputFile{Location: location, Documents: documents}, nil
+		}
+	}
+	documents, err := ytbx.LoadDocuments(data)
+	if err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("unable to parse data from %s: %w", ytbx.HumanReadableLocation(location), err)
+	}
+
+	return ytbx.InputFile{Location: location, Documents: documents}, nil
+}
+
+// LoadDocuments loads the documents of the provided data the same way as
+// ytbx.LoadDocuments does, except that JSON numbers are kept as they are
+// written instead of being decoded as float64 values, which would lose the
+// precision of numbers beyond 2^53. The directives of YAML documents (e.g.
+// `%YAML` or `%TAG`) are kept, see Directives.
+func LoadDocuments(data []byte) ([]*yamlv3.Node, error) {
+	if isJSONInput(data) {
+		if documents, err := loadJSONDocuments(data); err == nil {
+			return documents, nil
+		}
+	}
+
+	if hasDirectives(data) {
+		return loadDocumentsWithDirectives(data)
+	}
+
+	if isTOMLInput(data) {
+		if documents, err := LoadTOMLDocuments(data); err == nil {
+			return documents, nil
+		}
+	}
+
+	return ytbx.LoadDocuments(data)
+}
+
+// isTOMLInput returns whether the data could be TOML, there is no easy check
+// for it other than trying to parse it, which is only worth it for data that
+// is neither empty, nor a YAML stream with document start markers
+func isTOMLInput(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && !bytes.HasPrefix(data, []byte("---"))
+}
+
+// isJSONInput returns whether the data starts like a JSON map or list
+func isJSONInput(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && (data[0] == '{' || data[0] == '[')
+}
+
+// loadJSONDocuments reads the JSON stream token by token, so that numbers
+// and (depending on ytbx.PreserveKeyOrderInJSON) the order of keys stay the
+// same as in the input
+func loadJSONDocuments(data []byte) ([]*yamlv3.Node, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var documents []*yamlv3.Node
+	for {
+		node, err := jsonValueNode(decoder)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		// without preserving the key order, keys are sorted like the keys of
+		// decoded Go maps are
+		if !ytbx.PreserveKeyOrderInJSON {
+			SortMapKeys(node)
+		}
+
+		documents = append(documents, &yamlv3.Node{
+			Kind:    yamlv3.DocumentNode,
+			Content: []*yamlv3.Node{node},
+		})
+	}
+
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("no JSON documents found")
+	}
+
+	return documents, nil
+}
+
+func jsonValueNode(decoder *json.Decoder) (*yamlv3.Node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch value := token.(type) {
+	case json.Delim:
+		switch value {
+		case '{':
+			node := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				entry, err := jsonValueNode(decoder)
+				if err != nil {
+					return nil, err
+				}
+
+				node.Content = append(node.Content,
+					&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: fmt.Sprint(key)},
+					entry,
+				)
+			}
+
+			_, err := decoder.Token()
+			return node, err
+
+		case '[':
+			node := &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq"}
+			for decoder.More() {
+				entry, err := jsonValueNode(decoder)
+				if err != nil {
+					return nil, err
+				}
+
+				node.Content = append(node.Content, entry)
+			}
+
+			_, err := decoder.Token()
+			return node, err
+		}
+
+		return nil, fmt.Errorf("unexpected JSON delimiter %v", value)
+
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(value.String(), ".eE") {
+			tag = "!!float"
+		}
+
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: tag, Value: value.String()}, nil
+
+	case string:
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value}, nil
+
+	case bool:
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(value)}, nil
+
+	case nil:
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected JSON token %v", token)
+}
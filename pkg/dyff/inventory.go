@@ -0,0 +1,153 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gonvenience/bunt"
+	"github.com/gonvenience/text"
+	"github.com/gonvenience/ytbx"
+)
+
+// DocumentInventory is the high-level delta of the documents of two input
+// files, it lists which documents were added, removed, or retained
+type DocumentInventory struct {
+	From     ytbx.InputFile
+	To       ytbx.InputFile
+	Added    []string
+	Removed  []string
+	Retained []string
+}
+
+// CompareInventory compares which documents exist in the two input files
+// without comparing the content of the documents. Documents are matched by
+// their name in case all documents are Kubernetes resources, otherwise they
+// are matched by their position in the input file.
+func CompareInventory(from ytbx.InputFile, to ytbx.InputFile) DocumentInventory {
+	var result = DocumentInventory{From: from, To: to}
+
+	fromNames, fromNamed := documentNames(from)
+	toNames, toNamed := documentNames(to)
+
+	if !fromNamed || !toNamed {
+		for i := range fromNames {
+			fromNames[i] = fmt.Sprintf("document #%d", i+1)
+		}
+
+		for i := range toNames {
+			toNames[i] = fmt.Sprintf("document #%d", i+1)
+		}
+	}
+
+	var contains = func(list []string, name string) bool {
+		for _, entry := range list {
+			if entry == name {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for _, name := range fromNames {
+		if contains(toNames, name) {
+			result.Retained = append(result.Retained, name)
+		} else {
+			result.Removed = append(result.Removed, name)
+		}
+	}
+
+	for _, name := range toNames {
+		if !contains(fromNames, name) {
+			result.Added = append(result.Added, name)
+		}
+	}
+
+	return result
+}
+
+// documentNames returns the Kubernetes resource names of the non-empty
+// documents, and whether all of them have such a name
+func documentNames(inputFile ytbx.InputFile) ([]string, bool) {
+	var names []string
+	var named = true
+	for _, document := range inputFile.Documents {
+		if document == nil || isEmptyDocument(document) {
+			continue
+		}
+
+		name, err := k8sItem.Name(documentRoot(document))
+		if err != nil {
+			named = false
+		}
+
+		names = append(names, name)
+	}
+
+	return names, named
+}
+
+// HasChanges returns whether documents were added or removed
+func (i DocumentInventory) HasChanges() bool {
+	return len(i.Added) > 0 || len(i.Removed) > 0
+}
+
+// WriteReport writes the inventory to the provided writer
+func (i DocumentInventory) WriteReport(out io.Writer) error {
+	writer := bufio.NewWriter(out)
+	defer writer.Flush()
+
+	var summary []string
+	for _, entry := range []struct {
+		count int
+		text  string
+	}{
+		{len(i.Added), "added"},
+		{len(i.Removed), "removed"},
+		{len(i.Retained), "retained"},
+	} {
+		summary = append(summary, fmt.Sprintf("%s %s", text.Plural(entry.count, "document"), entry.text))
+	}
+
+	_, _ = writer.WriteString(fmt.Sprintf("inventory of %s and %s: %s\n\n",
+		ytbx.HumanReadableLocationInformation(i.From),
+		ytbx.HumanReadableLocationInformation(i.To),
+		bunt.Style(strings.Join(summary, ", "), bunt.Bold()),
+	))
+
+	for _, name := range i.Added {
+		_, _ = writer.WriteString(green("+ %s\n", name))
+	}
+
+	for _, name := range i.Removed {
+		_, _ = writer.WriteString(red("- %s\n", name))
+	}
+
+	for _, name := range i.Retained {
+		_, _ = writer.WriteString(dimgray("  %s\n", name))
+	}
+
+	return nil
+}
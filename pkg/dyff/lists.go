@@ -0,0 +1,230 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ListDiffMode defines how lists without identifiers (simple lists) are
+// compared
+type ListDiffMode string
+
+// Supported strategies for the comparison of simple lists
+const (
+	// HashSet compares simple lists as sets of entries, all removed and added
+	// entries are reported as one removal and one addition of the list, order
+	// changes are reported separately (default)
+	HashSet ListDiffMode = "hashset"
+
+	// LCS compares simple lists using their longest common subsequence, each
+	// consecutive run of removed or added entries is reported at the index it
+	// has in the from or to list respectively (very large lists with many
+	// changes are compared like HashSet)
+	LCS ListDiffMode = "lcs"
+)
+
+// ListDiffStrategy sets how simple lists are compared, see HashSet and LCS
+func ListDiffStrategy(mode ListDiffMode) CompareOption {
+	return func(settings *compareSettings) {
+		settings.ListDiffStrategy = mode
+	}
+}
+
+// lcsMaxTableSize is the maximum number of cells of the table that is used to
+// find the longest common subsequence, larger lists are compared as sets
+const lcsMaxTableSize = 1 << 22
+
+// lcsLists compares two simple lists using the longest common subsequence of
+// their entries, which keeps the positional information of the changes, for
+// example for order-sensitive lists like container args. The common prefix
+// and suffix of the lists are skipped, since the table grows with the product
+// of the lengths of the remaining lists. If it is still too large, false is
+// returned and the lists need to be compared as sets.
+func (compare *compare) lcsLists(path ytbx.Path, from *yamlv3.Node, to *yamlv3.Node) ([]Diff, bool) {
+	fromHashes := make([]uint64, len(from.Content))
+	for i, entry := range from.Content {
+		fromHashes[i] = compare.calcNodeHash(entry)
+	}
+
+	toHashes := make([]uint64, len(to.Content))
+	for i, entry := range to.Content {
+		toHashes[i] = compare.calcNodeHash(entry)
+	}
+
+	var prefix int
+	for prefix < len(fromHashes) && prefix < len(toHashes) && fromHashes[prefix] == toHashes[prefix] {
+		prefix++
+	}
+
+	var suffix int
+	for suffix < len(fromHashes)-prefix && suffix < len(toHashes)-prefix && fromHashes[len(fromHashes)-1-suffix] == toHashes[len(toHashes)-1-suffix] {
+		suffix++
+	}
+
+	fromHashes = fromHashes[prefix : len(fromHashes)-suffix]
+	toHashes = toHashes[prefix : len(toHashes)-suffix]
+
+	if (len(fromHashes)+1)*(len(toHashes)+1) > lcsMaxTableSize {
+		return nil, false
+	}
+
+	// lengths[i][j] is the length of the longest common subsequence of the
+	// from entries starting at i and the to entries starting at j
+	lengths := make([][]int, len(fromHashes)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(toHashes)+1)
+	}
+
+	for i := len(fromHashes) - 1; i >= 0; i-- {
+		for j := len(toHashes) - 1; j >= 0; j-- {
+			switch {
+			case fromHashes[i] == toHashes[j]:
+				lengths[i][j] = lengths[i+1][j+1] + 1
+
+			case lengths[i+1][j] >= lengths[i][j+1]:
+				lengths[i][j] = lengths[i+1][j]
+
+			default:
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	var (
+		result    []Diff
+		removals  []*yamlv3.Node
+		additions []*yamlv3.Node
+
+		removalIdx, additionIdx int
+	)
+
+	var flush = func() {
+		if len(removals) > 0 {
+			removalPath := ytbx.NewPathWithIndexedListElement(path, removalIdx)
+			result = append(result, Diff{
+				Path:    &removalPath,
+				Details: []Detail{{Kind: REMOVAL, From: &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq", Content: removals}}},
+			})
+		}
+
+		if len(additions) > 0 {
+			additionPath := ytbx.NewPathWithIndexedListElement(path, additionIdx)
+			result = append(result, Diff{
+				Path:    &additionPath,
+				Details: []Detail{{Kind: ADDITION, To: &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq", Content: additions}}},
+			})
+		}
+
+		removals, additions = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < len(fromHashes) || j < len(toHashes) {
+		switch {
+		case i < len(fromHashes) && j < len(toHashes) && fromHashes[i] == toHashes[j]:
+			flush()
+			i, j = i+1, j+1
+
+		case j >= len(toHashes) || (i < len(fromHashes) && lengths[i+1][j] >= lengths[i][j+1]):
+			if len(removals) == 0 {
+				removalIdx = prefix + i
+			}
+
+			removals = append(removals, from.Content[prefix+i])
+			i++
+
+		default:
+			if len(additions) == 0 {
+				additionIdx = prefix + j
+			}
+
+			additions = append(additions, to.Content[prefix+j])
+			j++
+		}
+	}
+
+	flush()
+
+	return result, true
+}
+
+// positionalListChange returns the path of the list, if the difference is a
+// removal or addition of list entries at a specific index of a simple list as
+// reported by the LCS list diff strategy
+func (r Report) positionalListChange(diff Diff) (*ytbx.Path, bool) {
+	if diff.Path == nil || len(diff.Path.PathElements) == 0 || len(diff.Details) != 1 {
+		return nil, false
+	}
+
+	var last = diff.Path.PathElements[len(diff.Path.PathElements)-1]
+	if last.Key != "" || last.Name != "" {
+		return nil, false
+	}
+
+	var listPath = ytbx.Path{
+		Root:         diff.Path.Root,
+		DocumentIdx:  diff.Path.DocumentIdx,
+		PathElements: diff.Path.PathElements[:len(diff.Path.PathElements)-1],
+	}
+
+	var documents []*yamlv3.Node
+	var run *yamlv3.Node
+
+	switch detail := diff.Details[0]; detail.Kind {
+	case REMOVAL:
+		documents, run = r.From.Documents, detail.From
+
+	case ADDITION:
+		documents, run = r.To.Documents, detail.To
+
+	default:
+		return nil, false
+	}
+
+	if run.Kind != yamlv3.SequenceNode || listPath.DocumentIdx >= len(documents) {
+		return nil, false
+	}
+
+	list, err := lookupNode(documentRoot(documents[listPath.DocumentIdx]), listPath.PathElements)
+	if err != nil || !isRunAt(list, last.Idx, run) {
+		return nil, false
+	}
+
+	return &listPath, true
+}
+
+// isRunAt returns whether the list contains the entries of the run starting at
+// the provided index
+func isRunAt(list *yamlv3.Node, idx int, run *yamlv3.Node) bool {
+	if list.Kind != yamlv3.SequenceNode || idx < 0 || idx+len(run.Content) > len(list.Content) || len(run.Content) == 0 {
+		return false
+	}
+
+	for i, entry := range run.Content {
+		if canonicalString(list.Content[idx+i]) != canonicalString(entry) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,310 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ChangeOrigin describes on which side of a three-way comparison a change was
+// made, relative to the common base
+type ChangeOrigin string
+
+// Supported change origins of a three-way comparison
+const (
+	// ChangedInOurs is a change that was only made in our version
+	ChangedInOurs ChangeOrigin = "ours"
+
+	// ChangedInTheirs is a change that was only made in their version
+	ChangedInTheirs ChangeOrigin = "theirs"
+
+	// ChangedInBoth is a change that was made in both versions, either the
+	// same change, or changes that do not interfere with each other
+	ChangedInBoth ChangeOrigin = "both"
+
+	// ChangeConflict is a change that was made in both versions, but in a way
+	// that cannot be merged automatically
+	ChangeConflict ChangeOrigin = "conflict"
+)
+
+// ThreeWayChange is a change of a three-way comparison, depending on the
+// origin, one or both differences to the base are set
+type ThreeWayChange struct {
+	Origin ChangeOrigin
+	Ours   *Diff
+	Theirs *Diff
+}
+
+// Path returns the path of the change, which is nil for file level changes
+func (c ThreeWayChange) Path() *ytbx.Path {
+	if c.Ours != nil {
+		return c.Ours.Path
+	}
+
+	return c.Theirs.Path
+}
+
+// ThreeWayReport is the result of a three-way comparison of two modified
+// versions with their common base
+type ThreeWayReport struct {
+	Base    ytbx.InputFile
+	Ours    ytbx.InputFile
+	Theirs  ytbx.InputFile
+	Changes []ThreeWayChange
+}
+
+// MergeConflictError is returned when a three-way report with conflicting
+// changes is merged
+type MergeConflictError struct {
+	Conflicts []ThreeWayChange
+}
+
+func (e *MergeConflictError) Error() string {
+	var paths = make([]string, len(e.Conflicts))
+	for i, conflict := range e.Conflicts {
+		paths[i] = changePath(conflict)
+	}
+
+	return fmt.Sprintf("failed to merge, conflicting changes in %s", strings.Join(paths, ", "))
+}
+
+// CompareThreeWay compares two modified versions (ours and theirs) with their
+// common base and classifies each change by the side it was made on. Changes
+// made on both sides are conflicting, if they modify the same value
+// differently, add the same key with different values, or if one side
+// changes something that the other side removed or replaced as a whole.
+func CompareThreeWay(base ytbx.InputFile, ours ytbx.InputFile, theirs ytbx.InputFile, compareOptions ...CompareOption) (ThreeWayReport, error) {
+	oursReport, err := CompareInputFiles(base, ours, compareOptions...)
+	if err != nil {
+		return ThreeWayReport{}, err
+	}
+
+	theirsReport, err := CompareInputFiles(base, theirs, compareOptions...)
+	if err != nil {
+		return ThreeWayReport{}, err
+	}
+
+	var result = ThreeWayReport{
+		Base:   oursReport.From,
+		Ours:   oursReport.To,
+		Theirs: theirsReport.To,
+	}
+
+	var theirsByKey = map[string]int{}
+	for i, diff := range theirsReport.Diffs {
+		theirsByKey[changeKey(diff)] = i
+	}
+
+	var matched = map[int]struct{}{}
+	for i := range oursReport.Diffs {
+		ours := &oursReport.Diffs[i]
+
+		idx, ok := theirsByKey[changeKey(*ours)]
+		if !ok {
+			result.Changes = append(result.Changes, ThreeWayChange{Origin: ChangedInOurs, Ours: ours})
+			continue
+		}
+
+		matched[idx] = struct{}{}
+		theirs := &theirsReport.Diffs[idx]
+
+		origin := ChangedInBoth
+		if !compatibleDiffs(*ours, *theirs) {
+			origin = ChangeConflict
+		}
+
+		result.Changes = append(result.Changes, ThreeWayChange{Origin: origin, Ours: ours, Theirs: theirs})
+	}
+
+	for i := range theirsReport.Diffs {
+		if _, ok := matched[i]; !ok {
+			result.Changes = append(result.Changes, ThreeWayChange{Origin: ChangedInTheirs, Theirs: &theirsReport.Diffs[i]})
+		}
+	}
+
+	// Changes on different paths conflict, if one of them removes or replaces
+	// the part of the tree that the other one changes
+	for i := range result.Changes {
+		for j := range result.Changes {
+			if i != j && result.Changes[i].Origin != result.Changes[j].Origin && overrides(result.Changes[i], result.Changes[j]) {
+				result.Changes[i].Origin = ChangeConflict
+				result.Changes[j].Origin = ChangeConflict
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Conflicts returns the conflicting changes of the report
+func (r ThreeWayReport) Conflicts() []ThreeWayChange {
+	var result []ThreeWayChange
+	for _, change := range r.Changes {
+		if change.Origin == ChangeConflict {
+			result = append(result, change)
+		}
+	}
+
+	return result
+}
+
+// Merge applies the changes of both versions to the base and returns the
+// merged documents, it fails with a MergeConflictError in case there are
+// conflicting changes
+func (r ThreeWayReport) Merge() ([]*yamlv3.Node, error) {
+	if conflicts := r.Conflicts(); len(conflicts) > 0 {
+		return nil, &MergeConflictError{Conflicts: conflicts}
+	}
+
+	var diffs []Diff
+	for _, change := range r.Changes {
+		if change.Ours != nil {
+			diffs = append(diffs, *change.Ours)
+		}
+
+		if change.Theirs != nil {
+			diffs = append(diffs, *change.Theirs)
+		}
+	}
+
+	return applyDiffs(r.Base.Documents, diffs)
+}
+
+func changeKey(diff Diff) string {
+	if diff.Path == nil {
+		return "(file level)"
+	}
+
+	return fmt.Sprintf("%d %s", diff.Path.DocumentIdx, diff.Path.String())
+}
+
+func changePath(change ThreeWayChange) string {
+	if path := change.Path(); path != nil {
+		return path.String()
+	}
+
+	return "(file level)"
+}
+
+// compatibleDiffs returns whether the two differences of the same path can
+// both be applied
+func compatibleDiffs(ours Diff, theirs Diff) bool {
+	if (Report{Diffs: []Diff{ours}}).Fingerprint() == (Report{Diffs: []Diff{theirs}}).Fingerprint() {
+		return true
+	}
+
+	var additions = map[string]string{}
+	for _, detail := range ours.Details {
+		switch detail.Kind {
+		case MODIFICATION, ORDERCHANGE, MOVED, DIRECTIVECHANGE:
+			return false
+
+		case ADDITION:
+			if detail.To.Kind == yamlv3.MappingNode {
+				for i := 0; i+1 < len(detail.To.Content); i += 2 {
+					additions[detail.To.Content[i].Value] = canonicalString(detail.To.Content[i+1])
+				}
+			}
+		}
+	}
+
+	for _, detail := range theirs.Details {
+		switch detail.Kind {
+		case MODIFICATION, ORDERCHANGE, MOVED, DIRECTIVECHANGE:
+			return false
+
+		case ADDITION:
+			if detail.To.Kind == yamlv3.MappingNode {
+				for i := 0; i+1 < len(detail.To.Content); i += 2 {
+					if value, ok := additions[detail.To.Content[i].Value]; ok && value != canonicalString(detail.To.Content[i+1]) {
+						return false
+					}
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+// overrides returns whether the first change removes or replaces the part of
+// the tree that the second change is about
+func overrides(first ThreeWayChange, second ThreeWayChange) bool {
+	firstPath, secondPath := first.Path(), second.Path()
+	if firstPath == nil || secondPath == nil || firstPath.DocumentIdx != secondPath.DocumentIdx {
+		return false
+	}
+
+	if len(firstPath.PathElements) >= len(secondPath.PathElements) ||
+		!strings.HasPrefix(secondPath.String(), strings.TrimSuffix(firstPath.String(), "/")+"/") {
+		return false
+	}
+
+	next := secondPath.PathElements[len(firstPath.PathElements)]
+	for _, diff := range []*Diff{first.Ours, first.Theirs} {
+		if diff == nil {
+			continue
+		}
+
+		for _, detail := range diff.Details {
+			switch detail.Kind {
+			case MODIFICATION:
+				return true
+
+			case REMOVAL:
+				if removes(detail.From, next) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// removes returns whether the removed entries contain the entry of the path
+// element
+func removes(removed *yamlv3.Node, element ytbx.PathElement) bool {
+	switch removed.Kind {
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(removed.Content); i += 2 {
+			if element.Key == "" && removed.Content[i].Value == element.Name {
+				return true
+			}
+		}
+
+	case yamlv3.SequenceNode:
+		for _, entry := range removed.Content {
+			if element.Key != "" && listEntryName(followAlias(entry), element.Key) == element.Name {
+				return true
+			}
+		}
+
+		// removing entries of a simple list changes the index of the others
+		return element.Key == "" && element.Name == ""
+	}
+
+	return false
+}
@@ -0,0 +1,168 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"github.com/gonvenience/ytbx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("Three-way comparison", func() {
+	inputFile := func(input string) ytbx.InputFile {
+		return ytbx.InputFile{Documents: multiDoc(input)}
+	}
+
+	base := inputFile(`---
+spec:
+  replicas: 1
+  image: app:1
+  env:
+  - name: A
+    value: "1"
+  - name: B
+    value: "2"
+  ports: [80]
+`)
+
+	origins := func(report dyff.ThreeWayReport) map[string]dyff.ChangeOrigin {
+		result := map[string]dyff.ChangeOrigin{}
+		for _, change := range report.Changes {
+			result[change.Path().String()] = change.Origin
+		}
+
+		return result
+	}
+
+	It("should classify changes by the side they were made on", func() {
+		report, err := dyff.CompareThreeWay(base,
+			inputFile(`---
+spec:
+  replicas: 3
+  image: app:1
+  env:
+  - name: A
+    value: "1"
+  - name: B
+    value: "2"
+  ports: [80, 443]
+`),
+			inputFile(`---
+spec:
+  replicas: 1
+  image: app:2
+  env:
+  - name: A
+    value: "1"
+  - name: B
+    value: "2"
+  ports: [80, 8080]
+`),
+		)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(origins(report)).To(Equal(map[string]dyff.ChangeOrigin{
+			"/spec/replicas": dyff.ChangedInOurs,
+			"/spec/image":    dyff.ChangedInTheirs,
+			"/spec/ports":    dyff.ChangedInBoth,
+		}))
+
+		documents, err := report.Merge()
+		Expect(err).ToNot(HaveOccurred())
+
+		out, err := yamlv3.Marshal(documents[0])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(Equal(`spec:
+    replicas: 3
+    image: app:2
+    env:
+        - name: A
+          value: "1"
+        - name: B
+          value: "2"
+    ports: [80, 443, 8080]
+`))
+	})
+
+	It("should report conflicting changes and refuse to merge them", func() {
+		report, err := dyff.CompareThreeWay(base,
+			inputFile(`---
+spec:
+  replicas: 3
+  image: app:1
+  env:
+  - name: A
+    value: "1"
+  - name: B
+    value: "20"
+  ports: [80]
+`),
+			inputFile(`---
+spec:
+  replicas: 2
+  image: app:1
+  env:
+  - name: A
+    value: "1"
+  ports: [80]
+`),
+		)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(origins(report)).To(Equal(map[string]dyff.ChangeOrigin{
+			"/spec/replicas":         dyff.ChangeConflict,
+			"/spec/env":              dyff.ChangeConflict,
+			"/spec/env/name=B/value": dyff.ChangeConflict,
+		}))
+
+		_, err = report.Merge()
+		Expect(err).To(BeAssignableToTypeOf(&dyff.MergeConflictError{}))
+	})
+
+	It("should merge the same change made on both sides", func() {
+		modified := inputFile(`---
+spec:
+  replicas: 2
+  image: app:1
+  env:
+  - name: A
+    value: "1"
+  - name: B
+    value: "2"
+  ports: [80]
+  labels: {team: a}
+`)
+
+		report, err := dyff.CompareThreeWay(base, modified, modified)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Conflicts()).To(BeEmpty())
+
+		documents, err := report.Merge()
+		Expect(err).ToNot(HaveOccurred())
+
+		merged, err := dyff.CompareInputFiles(modified, ytbx.InputFile{Documents: documents})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(merged.Diffs).To(BeEmpty())
+	})
+})
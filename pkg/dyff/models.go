@@ -21,38 +21,131 @@
 package dyff
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/gonvenience/ytbx"
 	yamlv3 "gopkg.in/yaml.v3"
 )
 
+// DetailKind is the kind of a difference, the underlying value is the symbol
+// that is used in the reports
+type DetailKind rune
+
 // Constants to distinguish between the different kinds of differences
 const (
-	ADDITION     = '+'
-	REMOVAL      = '-'
-	MODIFICATION = '±'
-	ORDERCHANGE  = '⇆'
+	ADDITION     DetailKind = '+'
+	REMOVAL      DetailKind = '-'
+	MODIFICATION DetailKind = '±'
+	ORDERCHANGE  DetailKind = '⇆'
+
+	// MOVED is an identical map entry or document at another location, the
+	// From node is a string with the previous location and the To node is
+	// the value at the location of the path (see DetectMoves)
+	MOVED DetailKind = '→'
+
+	// DIRECTIVECHANGE is a change of the directives (e.g. `%YAML` or `%TAG`)
+	// of a document, the From and To nodes are strings with the directives
+	// (one per line), see CompareDirectives
+	DIRECTIVECHANGE DetailKind = '%'
 	// ILLEGAL      = '✕'
 	// ATTENTION    = '⚠'
 )
 
+var detailKindNames = map[DetailKind]string{
+	ADDITION:     "addition",
+	REMOVAL:      "removal",
+	MODIFICATION: "modification",
+	ORDERCHANGE:  "order-change",
+	MOVED:        "move",
+
+	DIRECTIVECHANGE: "directive-change",
+}
+
+// String returns the name of the detail kind, for example "addition"
+func (kind DetailKind) String() string {
+	if name, ok := detailKindNames[kind]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("DetailKind(%q)", rune(kind))
+}
+
+// Symbol returns the symbol of the detail kind, for example "+"
+func (kind DetailKind) Symbol() string {
+	return string(rune(kind))
+}
+
+// MarshalText returns the name of the detail kind
+func (kind DetailKind) MarshalText() ([]byte, error) {
+	if _, ok := detailKindNames[kind]; !ok {
+		return nil, fmt.Errorf("unsupported detail kind %q", rune(kind))
+	}
+
+	return []byte(kind.String()), nil
+}
+
+// UnmarshalText parses the name or the symbol of a detail kind
+func (kind *DetailKind) UnmarshalText(text []byte) error {
+	parsed, err := ParseDetailKind(string(text))
+	if err != nil {
+		return err
+	}
+
+	*kind = parsed
+	return nil
+}
+
+// ParseDetailKind returns the detail kind for the provided name (for example
+// "addition"), or symbol (for example "+")
+func ParseDetailKind(str string) (DetailKind, error) {
+	for kind, name := range detailKindNames {
+		if str == name || str == kind.Symbol() {
+			return kind, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unsupported detail kind %q", str)
+}
+
 // Detail encapsulate the actual details of a change, mainly the kind of
 // difference and the values
 type Detail struct {
 	From *yamlv3.Node
 	To   *yamlv3.Node
-	Kind rune
+	Kind DetailKind
 }
 
 // Diff encapsulates everything noteworthy about a difference
 type Diff struct {
 	Path    *ytbx.Path
 	Details []Detail
+
+	// FromSource and ToSource refer to the file and line of the difference
+	// in the from and to file set, they are only set by CompareFileSets and
+	// are nil if the difference does not exist on that side (e.g. new file)
+	FromSource *Source
+	ToSource   *Source
+}
+
+// Source refers to a line in a file of a file set, a line of zero means that
+// the line is unknown (e.g. for input formats without line information)
+type Source struct {
+	File string `json:"file" yaml:"file"`
+	Line int    `json:"line,omitempty" yaml:"line,omitempty"`
+}
+
+// String returns the file and line, for example `templates/app.yaml:12`
+func (source Source) String() string {
+	if source.Line > 0 {
+		return fmt.Sprintf("%s:%d", source.File, source.Line)
+	}
+
+	return source.File
 }
 
 // Report encapsulates the actual end-result of the comparison: The input data
-// and the list of differences
+// and the list of differences, which are in the order described in Sort
 type Report struct {
 	From  ytbx.InputFile
 	To    ytbx.InputFile
@@ -63,3 +156,9 @@ type Report struct {
 type ReportWriter interface {
 	WriteReport(out io.Writer) error
 }
+
+// DiffRenderer defines the interface for types that can render a single
+// difference on its own, e.g. to show it inline in another tool
+type DiffRenderer interface {
+	RenderDiff(diff Diff) (string, error)
+}
@@ -0,0 +1,273 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// DetectMoves enables the detection of identical subtrees that were removed
+// at one path and added at another, for example renamed keys or Kubernetes
+// resources that were moved to another file. These are reported as one move
+// instead of an unrelated removal and addition.
+func DetectMoves(value bool) CompareOption {
+	return func(settings *compareSettings) {
+		settings.DetectMoves = value
+	}
+}
+
+// moveCandidate is a map entry or a document that was removed or added
+type moveCandidate struct {
+	diffIdx   int
+	detailIdx int
+	entryIdx  int
+
+	path  ytbx.Path
+	value *yamlv3.Node
+	hash  string
+
+	document bool
+	matched  bool
+}
+
+// moveCandidates returns all map entries and documents of the removals or
+// additions of the provided differences
+func moveCandidates(diffs []Diff, kind DetailKind, documents []*yamlv3.Node, root *ytbx.InputFile) []*moveCandidate {
+	var result []*moveCandidate
+	for diffIdx, diff := range diffs {
+		for detailIdx, detail := range diff.Details {
+			if detail.Kind != kind {
+				continue
+			}
+
+			var node = detail.From
+			if kind == ADDITION {
+				node = detail.To
+			}
+
+			switch {
+			case node.Kind == yamlv3.MappingNode && diff.Path != nil:
+				for i := 0; i+1 < len(node.Content); i += 2 {
+					result = append(result, &moveCandidate{
+						diffIdx:   diffIdx,
+						detailIdx: detailIdx,
+						entryIdx:  i,
+						path:      ytbx.NewPathWithNamedElement(*diff.Path, node.Content[i].Value),
+						value:     node.Content[i+1],
+						hash:      canonicalString(node.Content[i+1]),
+					})
+				}
+
+			case node.Kind == yamlv3.DocumentNode:
+				for i, entry := range node.Content {
+					result = append(result, &moveCandidate{
+						diffIdx:   diffIdx,
+						detailIdx: detailIdx,
+						entryIdx:  i,
+						path:      ytbx.Path{Root: root, DocumentIdx: documentIndex(documents, entry)},
+						value:     entry,
+						hash:      canonicalString(entry),
+						document:  true,
+					})
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// documentIndex returns the index of the document with the provided content
+func documentIndex(documents []*yamlv3.Node, content *yamlv3.Node) int {
+	for i, document := range documents {
+		if document == content || documentRoot(document) == content {
+			return i
+		}
+	}
+
+	return 0
+}
+
+// isMove returns whether the added candidate is the removed candidate at
+// another location. Scalar values are only considered as moved within the
+// same map (renamed keys), since identical scalars elsewhere are most likely
+// unrelated.
+func isMove(removed, added *moveCandidate) bool {
+	if removed.document != added.document || removed.hash != added.hash {
+		return false
+	}
+
+	if removed.document {
+		return true
+	}
+
+	if removed.path.DocumentIdx != added.path.DocumentIdx || removed.path.String() == added.path.String() {
+		return false
+	}
+
+	switch followAlias(added.value).Kind {
+	case yamlv3.MappingNode, yamlv3.SequenceNode:
+		return len(followAlias(added.value).Content) > 0
+
+	default:
+		var removedParent = removed.path.PathElements[:len(removed.path.PathElements)-1]
+		var addedParent = added.path.PathElements[:len(added.path.PathElements)-1]
+		return (&ytbx.Path{PathElements: removedParent}).String() == (&ytbx.Path{PathElements: addedParent}).String()
+	}
+}
+
+// detectMoves replaces matching pairs of removed and added map entries or
+// documents with a move at the location of the addition
+func detectMoves(report Report) Report {
+	var removals = moveCandidates(report.Diffs, REMOVAL, report.From.Documents, &report.From)
+	var additions = moveCandidates(report.Diffs, ADDITION, report.To.Documents, &report.To)
+
+	var moves = map[int][]Diff{}
+	for _, removed := range removals {
+		for _, added := range additions {
+			if added.matched || !isMove(removed, added) {
+				continue
+			}
+
+			removed.matched, added.matched = true, true
+
+			var location = removed.path.String()
+			if removed.document {
+				location = removed.path.RootDescription()
+			}
+
+			var path = added.path
+			moves[added.diffIdx] = append(moves[added.diffIdx], Diff{
+				Path: &path,
+				Details: []Detail{{
+					Kind: MOVED,
+					From: &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: location},
+					To:   added.value,
+				}},
+				FromSource: report.Diffs[removed.diffIdx].FromSource,
+				ToSource:   report.Diffs[added.diffIdx].ToSource,
+			})
+
+			break
+		}
+	}
+
+	if len(moves) == 0 {
+		return report
+	}
+
+	var isMatched = func(candidates []*moveCandidate, diffIdx, detailIdx, entryIdx int) bool {
+		for _, candidate := range candidates {
+			if candidate.matched && candidate.diffIdx == diffIdx && candidate.detailIdx == detailIdx && candidate.entryIdx == entryIdx {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var result []Diff
+	for diffIdx, diff := range report.Diffs {
+		var details []Detail
+		for detailIdx, detail := range diff.Details {
+			var candidates, node = removals, detail.From
+			switch detail.Kind {
+			case ADDITION:
+				candidates, node = additions, detail.To
+
+			case REMOVAL:
+
+			default:
+				details = append(details, detail)
+				continue
+			}
+
+			var step = 1
+			if node.Kind == yamlv3.MappingNode {
+				step = 2
+			}
+
+			var remaining = *node
+			remaining.Content = nil
+			for i := 0; i < len(node.Content); i += step {
+				if !isMatched(candidates, diffIdx, detailIdx, i) {
+					remaining.Content = append(remaining.Content, node.Content[i:i+step]...)
+				}
+			}
+
+			switch {
+			case len(remaining.Content) == 0:
+				continue
+
+			case detail.Kind == ADDITION:
+				details = append(details, Detail{Kind: ADDITION, To: &remaining})
+
+			default:
+				details = append(details, Detail{Kind: REMOVAL, From: &remaining})
+			}
+		}
+
+		if len(details) > 0 {
+			result = append(result, Diff{Path: diff.Path, Details: details, FromSource: diff.FromSource, ToSource: diff.ToSource})
+		}
+
+		result = append(result, moves[diffIdx]...)
+	}
+
+	report.Diffs = result
+	return report
+}
+
+// applyMove moves the map entry from the previous path to the path of the
+// difference, moves of documents are not supported
+func applyMove(root *yamlv3.Node, diff Diff, detail Detail) error {
+	if len(diff.Path.PathElements) == 0 {
+		return fmt.Errorf("moves of documents cannot be applied")
+	}
+
+	previous, err := ytbx.ParseGoPatchStylePathString(detail.From.Value)
+	if err != nil || len(previous.PathElements) == 0 {
+		return fmt.Errorf("invalid previous path %q", detail.From.Value)
+	}
+
+	var previousKey = previous.PathElements[len(previous.PathElements)-1].Name
+	if parent, err := lookupNode(root, previous.PathElements[:len(previous.PathElements)-1]); err == nil && parent.Kind == yamlv3.MappingNode {
+		if err := applyDetail(parent, Detail{Kind: REMOVAL, From: &yamlv3.Node{
+			Kind:    yamlv3.MappingNode,
+			Content: []*yamlv3.Node{{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: previousKey}, {Kind: yamlv3.ScalarNode}},
+		}}); err != nil {
+			return err
+		}
+	}
+
+	parent, err := lookupNode(root, diff.Path.PathElements[:len(diff.Path.PathElements)-1])
+	if err != nil {
+		return err
+	}
+
+	return applyDetail(parent, Detail{Kind: ADDITION, To: &yamlv3.Node{
+		Kind:    yamlv3.MappingNode,
+		Content: []*yamlv3.Node{{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: diff.Path.PathElements[len(diff.Path.PathElements)-1].Name}, detail.To},
+	}})
+}
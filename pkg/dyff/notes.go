@@ -0,0 +1,62 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+
+	"github.com/gonvenience/ytbx"
+)
+
+// AddNote adds a note to the input file, which is rendered next to the input
+// file location by all reporters. Since an input file only has one note, all
+// notes are combined in the order they were added.
+func AddNote(inputFile *ytbx.InputFile, note string) {
+	switch {
+	case note == "":
+		return
+
+	case inputFile.Note == "":
+		inputFile.Note = note
+
+	default:
+		inputFile.Note = fmt.Sprintf("%s, %s", inputFile.Note, note)
+	}
+}
+
+// notes returns the notes of both input files of the report, prefixed with
+// the respective side, to be used by reporters without a header
+func (r Report) notes() []string {
+	var result []string
+	for _, input := range []struct {
+		side      string
+		inputFile ytbx.InputFile
+	}{
+		{"from", r.From},
+		{"to", r.To},
+	} {
+		if input.inputFile.Note != "" {
+			result = append(result, fmt.Sprintf("%s: %s", input.side, input.inputFile.Note))
+		}
+	}
+
+	return result
+}
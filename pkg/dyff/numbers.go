@@ -0,0 +1,176 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/gonvenience/neat"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+var jsonNumberRegexp = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// IgnoreNumberFormatChanges disables the detection of changes between numbers
+// that are numerically equal, but written differently, e.g. 100 and 1e2, or a
+// 128-bit decimal as a string and as a number. Numbers are compared without
+// any loss of precision.
+func IgnoreNumberFormatChanges(value bool) CompareOption {
+	return func(settings *compareSettings) {
+		settings.IgnoreNumberFormatChanges = value
+	}
+}
+
+// numericValue returns the exact value of a number scalar, strings are only
+// considered if they contain a decimal number
+func numericValue(node *yamlv3.Node) (*big.Rat, bool) {
+	if node == nil || node.Kind != yamlv3.ScalarNode {
+		return nil, false
+	}
+
+	var value = strings.ReplaceAll(node.Value, "_", "")
+	switch node.Tag {
+	case "!!int":
+		var base = 10
+		if lower := strings.ToLower(strings.TrimLeft(value, "+-")); strings.HasPrefix(lower, "0x") || strings.HasPrefix(lower, "0o") || strings.HasPrefix(lower, "0b") {
+			base = 0
+		}
+
+		if integer, ok := new(big.Int).SetString(value, base); ok {
+			return new(big.Rat).SetInt(integer), true
+		}
+
+	case "!!float", "!!str":
+		if node.Tag == "!!str" && !jsonNumberRegexp.MatchString(strings.TrimPrefix(value, "+")) {
+			return nil, false
+		}
+
+		if rat, ok := new(big.Rat).SetString(value); ok {
+			return rat, true
+		}
+	}
+
+	return nil, false
+}
+
+// numericallyEqual returns whether both nodes are numbers (or strings with
+// numbers) with the exact same value
+func numericallyEqual(from *yamlv3.Node, to *yamlv3.Node) bool {
+	fromValue, ok := numericValue(from)
+	if !ok {
+		return false
+	}
+
+	toValue, ok := numericValue(to)
+	if !ok {
+		return false
+	}
+
+	return fromValue.Cmp(toValue) == 0
+}
+
+// jsonNumber returns the JSON representation of the number scalar, where the
+// number is used as it is written if possible, so that numbers beyond the
+// precision of float64 are not changed or written in scientific notation
+func jsonNumber(node *yamlv3.Node) (string, bool) {
+	if node.Tag != "!!int" && node.Tag != "!!float" {
+		return "", false
+	}
+
+	if jsonNumberRegexp.MatchString(node.Value) {
+		return node.Value, true
+	}
+
+	value, ok := numericValue(node)
+	if !ok {
+		return "", false
+	}
+
+	if value.IsInt() {
+		return value.Num().String(), true
+	}
+
+	return strings.TrimRight(strings.TrimRight(value.FloatString(64), "0"), "."), true
+}
+
+// CompactJSON renders the node as compact JSON, in the same format as the
+// neat package does, but with numbers being written without loss of precision
+func CompactJSON(node *yamlv3.Node) (string, error) {
+	node = followAlias(node)
+
+	switch node.Kind {
+	case yamlv3.DocumentNode:
+		return CompactJSON(node.Content[0])
+
+	case yamlv3.MappingNode:
+		entries := make([]string, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, err := CompactJSON(node.Content[i])
+			if err != nil {
+				return "", err
+			}
+
+			value, err := CompactJSON(node.Content[i+1])
+			if err != nil {
+				return "", err
+			}
+
+			entries = append(entries, fmt.Sprintf("%s: %s", key, value))
+		}
+
+		return fmt.Sprintf("{%s}", strings.Join(entries, ", ")), nil
+
+	case yamlv3.SequenceNode:
+		entries := make([]string, 0, len(node.Content))
+		for _, entry := range node.Content {
+			value, err := CompactJSON(entry)
+			if err != nil {
+				return "", err
+			}
+
+			entries = append(entries, value)
+		}
+
+		return fmt.Sprintf("[%s]", strings.Join(entries, ", ")), nil
+	}
+
+	if number, ok := jsonNumber(node); ok {
+		return number, nil
+	}
+
+	output, err := neat.NewOutputProcessor(false, false, &neat.DefaultColorSchema).ToCompactJSON(node)
+	if err != nil {
+		// values without a JSON representation, e.g. infinity, are
+		// written as strings instead
+		data, marshalErr := json.Marshal(node.Value)
+		if marshalErr != nil {
+			return "", err
+		}
+
+		return string(data), nil
+	}
+
+	return output, nil
+}
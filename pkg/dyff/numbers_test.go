@@ -0,0 +1,88 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"bytes"
+
+	"github.com/gonvenience/ytbx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("big numbers", func() {
+	loadJSON := func(data string) ytbx.InputFile {
+		documents, err := dyff.LoadDocuments([]byte(data))
+		Expect(err).ToNot(HaveOccurred())
+		return ytbx.InputFile{Documents: documents}
+	}
+
+	It("should load JSON numbers without loss of precision", func() {
+		report, err := dyff.CompareInputFiles(
+			loadJSON(`{"id": 12345678901234567891, "ratio": 0.1000000000000000000001}`),
+			loadJSON(`{"id": 12345678901234567892, "ratio": 0.1000000000000000000002}`),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Diffs).To(HaveLen(2))
+		Expect(report.Diffs[0].Path.String()).To(Equal("/id"))
+		Expect(report.Diffs[0].Details[0].From.Value).To(Equal("12345678901234567891"))
+		Expect(report.Diffs[0].Details[0].To.Value).To(Equal("12345678901234567892"))
+	})
+
+	It("should keep the documents and the sorted key order of JSON streams", func() {
+		inputFile := loadJSON(`{"b": 1, "a": [true, null, "x"]} {"c": 2}`)
+		Expect(inputFile.Documents).To(HaveLen(2))
+
+		output, err := dyff.CompactJSON(inputFile.Documents[0])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(Equal(`{"a": [true, null, "x"], "b": 1}`))
+	})
+
+	It("should render big numbers without scientific notation", func() {
+		report, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Documents: multiDoc("id: 1")},
+			ytbx.InputFile{Documents: multiDoc("id: 12345678901234567891\nhex: 0xFF\nlimit: .inf")},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		var buf bytes.Buffer
+		Expect((&dyff.YQReport{Report: report}).WriteReport(&buf)).To(Succeed())
+		Expect(buf.String()).To(ContainSubstring(".id = 12345678901234567891"))
+		Expect(buf.String()).To(ContainSubstring(".hex = 255"))
+		Expect(buf.String()).To(ContainSubstring(`.limit = ".inf"`))
+	})
+
+	It("should only ignore numerically equal values in different formats if requested", func() {
+		from := yml("{a: 100, b: 12345678901234567890123, c: \"0.5\", d: 0x10, e: 1}")
+		to := yml("{a: 1e2, b: 12345678901234567890123.0, c: 0.50, d: 16, e: 2}")
+
+		results, err := compare(from, to)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(HaveLen(5))
+
+		results, err = compare(from, to, dyff.IgnoreNumberFormatChanges(true))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(HaveLen(1))
+		Expect(results[0]).To(BeSameDiffAs(singleDiff("/e", dyff.MODIFICATION, 1, 2)))
+	})
+})
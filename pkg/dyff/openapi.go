@@ -0,0 +1,219 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// openAPIOperations are the HTTP methods that are operations of a path item
+var openAPIOperations = map[string]struct{}{
+	"get": {}, "put": {}, "post": {}, "delete": {},
+	"options": {}, "head": {}, "patch": {}, "trace": {},
+}
+
+// openAPILimits are the schema keywords that limit the valid values, a
+// value of true means that an increase narrows the valid values, false
+// means that a decrease does
+var openAPILimits = map[string]bool{
+	"minimum": true, "exclusiveMinimum": true, "minLength": true, "minItems": true, "minProperties": true,
+	"maximum": false, "exclusiveMaximum": false, "maxLength": false, "maxItems": false, "maxProperties": false,
+}
+
+// BreakingChange is a change of an OpenAPI (or Swagger) document that breaks
+// existing clients of the API, for example a removed endpoint
+type BreakingChange struct {
+	Path   *ytbx.Path
+	Detail Detail
+	Reason string
+}
+
+func openAPIPresetOptions() []CompareOption {
+	return []CompareOption{
+		// the order of lists like parameters, tags, enum or required values
+		// has no meaning, the servers are the only exception, since the first
+		// one is the default server
+		IgnoreOrderChanges(true),
+		ScopedOptions("/servers", IgnoreOrderChanges(false)),
+		AdditionalIdentifiers("url"),
+	}
+}
+
+// OpenAPIBreakingChanges returns the changes of the report that break
+// existing clients, when the report is the comparison of two versions of an
+// OpenAPI (or Swagger) document: removed paths, operations, schemas,
+// properties, media types, or enum values, parameters or properties that
+// became required, changed types or formats, and narrowed value limits (e.g.
+// a lower maxLength). All other changes are considered to be non-breaking.
+func (r Report) OpenAPIBreakingChanges() []BreakingChange {
+	var result []BreakingChange
+	for _, diff := range r.Diffs {
+		if diff.Path == nil {
+			continue
+		}
+
+		var segments = make([]string, len(diff.Path.PathElements))
+		for i, element := range diff.Path.PathElements {
+			if element.Name != "" {
+				segments[i] = element.Name
+			} else {
+				segments[i] = strconv.Itoa(element.Idx)
+			}
+		}
+
+		for _, detail := range diff.Details {
+			for _, reason := range openAPIBreakingReasons(segments, detail) {
+				result = append(result, BreakingChange{Path: diff.Path, Detail: detail, Reason: reason})
+			}
+		}
+	}
+
+	return result
+}
+
+// CountOpenAPIBreakingChanges returns the number of breaking changes (see
+// OpenAPIBreakingChanges) that belong to a path matching the path filter, see
+// CountBy for the syntax of the path filter
+func (r Report) CountOpenAPIBreakingChanges(pathFilter string) int {
+	var count int
+	for _, change := range r.OpenAPIBreakingChanges() {
+		if pathFilter == "" || matchesPathFilter(change.Path, pathFilter) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func openAPIBreakingReasons(segments []string, detail Detail) []string {
+	var last string
+	if len(segments) > 0 {
+		last = segments[len(segments)-1]
+	}
+
+	var reasons []string
+	switch detail.Kind {
+	case REMOVAL:
+		switch {
+		case detail.From.Kind == yamlv3.MappingNode:
+			for i := 0; i+1 < len(detail.From.Content); i += 2 {
+				if reason, ok := openAPIRemovedEntry(append(segments[:len(segments):len(segments)], detail.From.Content[i].Value)); ok {
+					reasons = append(reasons, reason)
+				}
+			}
+
+		case detail.From.Kind == yamlv3.SequenceNode && last == "enum":
+			for _, entry := range detail.From.Content {
+				reasons = append(reasons, fmt.Sprintf("enum value %s removed", entry.Value))
+			}
+		}
+
+	case ADDITION:
+		switch {
+		case detail.To.Kind == yamlv3.MappingNode:
+			if value, ok := findValueByKey(detail.To, "required"); ok && value.Value == "true" && isOpenAPIParameter(segments) {
+				reasons = append(reasons, fmt.Sprintf("parameter %s became required", last))
+			}
+
+		case detail.To.Kind == yamlv3.SequenceNode && last == "required":
+			for _, entry := range detail.To.Content {
+				reasons = append(reasons, fmt.Sprintf("property %s became required", entry.Value))
+			}
+
+		case detail.To.Kind == yamlv3.SequenceNode && last == "parameters":
+			for _, entry := range detail.To.Content {
+				if value, ok := findValueByKey(entry, "required"); ok && value.Value == "true" {
+					name, _ := findValueByKey(entry, "name")
+					reasons = append(reasons, fmt.Sprintf("required parameter %s added", name.Value))
+				}
+			}
+		}
+
+	case MODIFICATION:
+		if detail.From.Kind != yamlv3.ScalarNode || detail.To.Kind != yamlv3.ScalarNode {
+			break
+		}
+
+		from, to := detail.From.Value, detail.To.Value
+		switch narrowsWhenIncreased, isLimit := openAPILimits[last]; {
+		case last == "type" && !(from == "integer" && to == "number"):
+			reasons = append(reasons, fmt.Sprintf("type changed from %s to %s", from, to))
+
+		case last == "format":
+			reasons = append(reasons, fmt.Sprintf("format changed from %s to %s", from, to))
+
+		case last == "$ref":
+			reasons = append(reasons, fmt.Sprintf("reference changed from %s to %s", from, to))
+
+		case last == "required" && from == "false" && to == "true":
+			reasons = append(reasons, "became required")
+
+		case last == "nullable" && from == "true" && to == "false":
+			reasons = append(reasons, "is no longer nullable")
+
+		case isLimit:
+			fromValue, fromErr := strconv.ParseFloat(from, 64)
+			toValue, toErr := strconv.ParseFloat(to, 64)
+			if fromErr == nil && toErr == nil && (toValue > fromValue) == narrowsWhenIncreased && toValue != fromValue {
+				reasons = append(reasons, fmt.Sprintf("%s narrowed from %s to %s", last, from, to))
+			}
+		}
+	}
+
+	return reasons
+}
+
+// openAPIRemovedEntry returns the reason why the removal of the map entry at
+// the provided path is a breaking change, if it is one
+func openAPIRemovedEntry(segments []string) (string, bool) {
+	var n = len(segments)
+	var name = segments[n-1]
+
+	switch {
+	case n == 2 && segments[0] == "paths":
+		return fmt.Sprintf("path %s removed", name), true
+
+	case n == 3 && segments[0] == "paths":
+		if _, ok := openAPIOperations[name]; ok {
+			return fmt.Sprintf("operation %s %s removed", name, segments[1]), true
+		}
+
+	case n == 3 && segments[0] == "components" && segments[1] == "schemas",
+		n == 2 && segments[0] == "definitions":
+		return fmt.Sprintf("schema %s removed", name), true
+
+	case n >= 2 && segments[n-2] == "properties":
+		return fmt.Sprintf("property %s removed", name), true
+
+	case n >= 2 && segments[n-2] == "content":
+		return fmt.Sprintf("media type %s removed", name), true
+	}
+
+	return "", false
+}
+
+func isOpenAPIParameter(segments []string) bool {
+	return len(segments) >= 2 && segments[len(segments)-2] == "parameters"
+}
@@ -0,0 +1,259 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("OpenAPI documents", func() {
+	from := yml(`---
+openapi: 3.0.3
+info:
+  title: Pet Store
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      parameters:
+      - name: limit
+        in: query
+        schema:
+          type: integer
+          maximum: 100
+      - name: tag
+        in: query
+      responses:
+        "200":
+          content:
+            application/json: {}
+            application/xml: {}
+    post:
+      responses:
+        "201": {}
+  /stores:
+    get:
+      responses:
+        "200": {}
+components:
+  schemas:
+    Pet:
+      required: [name]
+      properties:
+        name:
+          type: string
+        age:
+          type: integer
+        status:
+          type: string
+          enum: [available, pending, sold]
+    Error:
+      properties:
+        code:
+          type: integer
+`)
+
+	var report = func(to string, options ...dyff.CompareOption) dyff.Report {
+		diffs, err := compare(from, yml(to), options...)
+		Expect(err).ToNot(HaveOccurred())
+		return dyff.Report{Diffs: diffs}
+	}
+
+	var reasons = func(report dyff.Report) []string {
+		var result []string
+		for _, change := range report.OpenAPIBreakingChanges() {
+			result = append(result, change.Reason)
+		}
+
+		return result
+	}
+
+	It("should not report breaking changes for compatible changes", func() {
+		result := report(`---
+openapi: 3.0.3
+info:
+  title: Pet Store
+  version: 1.1.0
+paths:
+  /pets:
+    get:
+      parameters:
+      - name: tag
+        in: query
+      - name: limit
+        in: query
+        schema:
+          type: number
+          maximum: 500
+      - name: sort
+        in: query
+      responses:
+        "200":
+          content:
+            application/json: {}
+            application/xml: {}
+    post:
+      responses:
+        "201": {}
+  /stores:
+    get:
+      responses:
+        "200": {}
+  /owners:
+    get:
+      responses:
+        "200": {}
+components:
+  schemas:
+    Pet:
+      required: [name]
+      properties:
+        name:
+          type: string
+        age:
+          type: integer
+        status:
+          type: string
+          enum: [sold, pending, available, adopted]
+    Error:
+      properties:
+        code:
+          type: integer
+        message:
+          type: string
+`, dyff.IgnoreOrderChanges(true))
+
+		Expect(result.Diffs).ToNot(BeEmpty())
+		Expect(reasons(result)).To(BeEmpty())
+		Expect(result.CountOpenAPIBreakingChanges("")).To(BeZero())
+	})
+
+	It("should classify removed endpoints and narrowed types as breaking changes", func() {
+		result := report(`---
+openapi: 3.0.3
+info:
+  title: Pet Store
+  version: 2.0.0
+paths:
+  /pets:
+    get:
+      parameters:
+      - name: limit
+        in: query
+        required: true
+        schema:
+          type: integer
+          maximum: 50
+      - name: tag
+        in: query
+      - name: owner
+        in: query
+        required: true
+      responses:
+        "200":
+          content:
+            application/json: {}
+components:
+  schemas:
+    Pet:
+      required: [name, age]
+      properties:
+        name:
+          type: string
+        age:
+          type: string
+        status:
+          type: string
+          enum: [available, sold]
+`)
+
+		Expect(reasons(result)).To(ConsistOf(
+			"path /stores removed",
+			"operation post /pets removed",
+			"parameter limit became required",
+			"maximum narrowed from 100 to 50",
+			"required parameter owner added",
+			"media type application/xml removed",
+			"schema Error removed",
+			"property age became required",
+			"type changed from integer to string",
+			"enum value pending removed",
+		))
+
+		Expect(result.CountOpenAPIBreakingChanges("/components")).To(Equal(4))
+	})
+
+	It("should provide a preset that ignores the order of lists", func() {
+		preset, err := dyff.Preset(dyff.OpenAPIPreset)
+		Expect(err).ToNot(HaveOccurred())
+
+		result := report(`---
+openapi: 3.0.3
+info:
+  title: Pet Store
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      parameters:
+      - name: tag
+        in: query
+      - name: limit
+        in: query
+        schema:
+          type: integer
+          maximum: 100
+      responses:
+        "200":
+          content:
+            application/json: {}
+            application/xml: {}
+    post:
+      responses:
+        "201": {}
+  /stores:
+    get:
+      responses:
+        "200": {}
+components:
+  schemas:
+    Pet:
+      required: [name]
+      properties:
+        name:
+          type: string
+        age:
+          type: integer
+        status:
+          type: string
+          enum: [sold, available, pending]
+    Error:
+      properties:
+        code:
+          type: integer
+`, preset)
+
+		Expect(result.Diffs).To(BeEmpty())
+	})
+})
@@ -0,0 +1,268 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// ReportOption modifies a report after the comparison, for example to only
+// keep differences of specific paths
+type ReportOption func(Report) Report
+
+// ApplyReportOptions returns the report with all report options applied in
+// the provided order
+func ApplyReportOptions(report Report, reportOptions ...ReportOption) Report {
+	for _, reportOption := range reportOptions {
+		report = reportOption(report)
+	}
+
+	return report
+}
+
+// ParseOptions parses flag-like strings (e.g. `--ignore-order-changes` or
+// `--exclude=/metadata/labels`) that use the same names and syntax as the
+// flags of the dyff command line tool, and returns the respective compare
+// and report options. Only flags that affect the comparison or the content of
+// the report are supported, flags that control the output are rejected.
+func ParseOptions(args []string) ([]CompareOption, []ReportOption, error) {
+	return parseOptions(args, false)
+}
+
+// parseOptions parses the flag-like strings, flags that do not affect the
+// comparison or the content of the report are skipped if ignoreUnknown is set,
+// in this case the values have to be provided in the same argument (--a=b)
+func parseOptions(args []string, ignoreUnknown bool) ([]CompareOption, []ReportOption, error) {
+	var (
+		flags = pflag.NewFlagSet("dyff", pflag.ContinueOnError)
+
+		ignoreOrderChanges        = flags.BoolP("ignore-order-changes", "i", false, "")
+		ignoreOrderChangesAt      = flags.StringSlice("ignore-order-changes-at", nil, "")
+		ignoreWhitespaceChanges   = flags.Bool("ignore-whitespace-changes", false, "")
+		ignoreNumberFormatChanges = flags.Bool("ignore-number-format-changes", false, "")
+		ignoreBlockStyleChanges   = flags.Bool("ignore-block-scalar-style-changes", false, "")
+		kubernetesEntityDetection = flags.Bool("detect-kubernetes", true, "")
+		additionalIdentifiers     = flags.StringArray("additional-identifier", nil, "")
+		compositeIdentifiers      = flags.StringArray("composite-identifier", nil, "")
+		nullEquivalents           = flags.StringArray("null-equivalent", nil, "")
+		customTags                = flags.String("custom-tags", string(CustomTagsOpaque), "")
+		listDiffStrategy          = flags.String("list-diff-strategy", string(HashSet), "")
+		nodeHashing               = flags.String("node-hashing", string(HashStructure), "")
+		detectMoves               = flags.Bool("detect-moves", false, "")
+		normalizeLineEndings      = flags.Bool("normalize-line-endings", false, "")
+		normalizeScripts          = flags.Bool("normalize-scripts", false, "")
+		compareDirectives         = flags.Bool("compare-directives", false, "")
+		presets                   = flags.StringSlice("preset", nil, "")
+		suppressionComments       = flags.Bool("suppression-comments", true, "")
+		followRefs                = flags.Bool("follow-refs", false, "")
+		followExternalRefs        = flags.Bool("follow-external-refs", false, "")
+		scopes                    = flags.StringArray("scope", nil, "")
+		excludeSubtrees           = flags.StringSlice("exclude-subtree", nil, "")
+
+		filters                = flags.StringSlice("filter", nil, "")
+		excludes               = flags.StringSlice("exclude", nil, "")
+		filterRegexps          = flags.StringSlice("filter-regexp", nil, "")
+		excludeRegexps         = flags.StringSlice("exclude-regexp", nil, "")
+		excludeDocuments       = flags.StringSlice("exclude-document", nil, "")
+		ignoreValueChanges     = flags.BoolP("ignore-value-changes", "v", false, "")
+		ignoreNewDocuments     = flags.Bool("ignore-new-documents", false, "")
+		ignoreRemovedDocuments = flags.Bool("ignore-removed-documents", false, "")
+	)
+
+	flags.SetOutput(io.Discard)
+	flags.ParseErrorsWhitelist.UnknownFlags = ignoreUnknown
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse options: %w", err)
+	}
+
+	if flags.NArg() > 0 {
+		return nil, nil, fmt.Errorf("failed to parse options: unexpected argument %q", flags.Arg(0))
+	}
+
+	for _, paths := range [][]string{*ignoreOrderChangesAt, *excludeSubtrees} {
+		if _, err := parseScopes(paths); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse options: %w", err)
+		}
+	}
+
+	switch CustomTagMode(*customTags) {
+	case CustomTagsOpaque, CustomTagsStrict, CustomTagsStrip:
+	default:
+		return nil, nil, fmt.Errorf("failed to parse options: unsupported custom tag mode %q", *customTags)
+	}
+
+	switch ListDiffMode(*listDiffStrategy) {
+	case HashSet, LCS:
+	default:
+		return nil, nil, fmt.Errorf("failed to parse options: unsupported list diff strategy %q", *listDiffStrategy)
+	}
+
+	switch NodeHashMode(*nodeHashing) {
+	case HashStructure, CanonicalHash:
+	default:
+		return nil, nil, fmt.Errorf("failed to parse options: unsupported node hashing %q", *nodeHashing)
+	}
+
+	// Only options that were explicitly set are returned, so that the defaults
+	// of the comparison stay in place
+	var changed = flags.Changed
+
+	// Presets come first, so that explicitly set options take precedence
+	var compareOptions []CompareOption
+	for _, name := range *presets {
+		preset, err := Preset(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse options: %w", err)
+		}
+
+		compareOptions = append(compareOptions, preset)
+	}
+
+	if changed("ignore-order-changes") {
+		compareOptions = append(compareOptions, IgnoreOrderChanges(*ignoreOrderChanges))
+	}
+
+	if changed("ignore-order-changes-at") {
+		compareOptions = append(compareOptions, IgnoreOrderChangesAt(*ignoreOrderChangesAt...))
+	}
+
+	if changed("ignore-whitespace-changes") {
+		compareOptions = append(compareOptions, IgnoreWhitespaceChanges(*ignoreWhitespaceChanges))
+	}
+
+	if changed("ignore-number-format-changes") {
+		compareOptions = append(compareOptions, IgnoreNumberFormatChanges(*ignoreNumberFormatChanges))
+	}
+
+	if changed("ignore-block-scalar-style-changes") {
+		compareOptions = append(compareOptions, IgnoreBlockScalarStyleChanges(*ignoreBlockStyleChanges))
+	}
+
+	if changed("detect-kubernetes") {
+		compareOptions = append(compareOptions, KubernetesEntityDetection(*kubernetesEntityDetection))
+	}
+
+	if changed("additional-identifier") {
+		compareOptions = append(compareOptions, AdditionalIdentifiers(*additionalIdentifiers...))
+	}
+
+	for _, compositeIdentifier := range *compositeIdentifiers {
+		compareOptions = append(compareOptions, CompositeIdentifier(strings.Split(compositeIdentifier, compositeSeparator)...))
+	}
+
+	if changed("null-equivalent") {
+		compareOptions = append(compareOptions, NullEquivalents(*nullEquivalents...))
+	}
+
+	if changed("custom-tags") {
+		compareOptions = append(compareOptions, CustomTags(CustomTagMode(*customTags)))
+	}
+
+	if changed("list-diff-strategy") {
+		compareOptions = append(compareOptions, ListDiffStrategy(ListDiffMode(*listDiffStrategy)))
+	}
+
+	if changed("node-hashing") {
+		compareOptions = append(compareOptions, NodeHashing(NodeHashMode(*nodeHashing)))
+	}
+
+	if changed("detect-moves") {
+		compareOptions = append(compareOptions, DetectMoves(*detectMoves))
+	}
+
+	if changed("normalize-line-endings") {
+		compareOptions = append(compareOptions, NormalizeLineEndings(*normalizeLineEndings))
+	}
+
+	if changed("normalize-scripts") {
+		compareOptions = append(compareOptions, NormalizeScripts(*normalizeScripts))
+	}
+
+	if changed("compare-directives") {
+		compareOptions = append(compareOptions, CompareDirectives(*compareDirectives))
+	}
+
+	if changed("exclude-subtree") {
+		compareOptions = append(compareOptions, ExcludePaths(*excludeSubtrees...))
+	}
+
+	if changed("suppression-comments") {
+		compareOptions = append(compareOptions, SuppressionComments(*suppressionComments))
+	}
+
+	switch {
+	case *followExternalRefs:
+		compareOptions = append(compareOptions, FollowRefs(AllRefs))
+
+	case *followRefs:
+		compareOptions = append(compareOptions, FollowRefs(InternalRefs))
+	}
+
+	for _, scope := range *scopes {
+		scopedOption, err := ParseScopedOptions(scope)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse options: %w", err)
+		}
+
+		compareOptions = append(compareOptions, scopedOption)
+	}
+
+	// Report options are returned in the same order the command line tool
+	// applies them
+	var reportOptions []ReportOption
+	if changed("filter") {
+		reportOptions = append(reportOptions, func(r Report) Report { return r.Filter(*filters...) })
+	}
+
+	if changed("filter-regexp") {
+		reportOptions = append(reportOptions, func(r Report) Report { return r.FilterRegexp(*filterRegexps...) })
+	}
+
+	if changed("exclude") {
+		reportOptions = append(reportOptions, func(r Report) Report { return r.Exclude(*excludes...) })
+	}
+
+	if changed("exclude-regexp") {
+		reportOptions = append(reportOptions, func(r Report) Report { return r.ExcludeRegexp(*excludeRegexps...) })
+	}
+
+	if changed("exclude-document") {
+		reportOptions = append(reportOptions, func(r Report) Report { return r.ExcludeDocuments(*excludeDocuments...) })
+	}
+
+	if *ignoreValueChanges {
+		reportOptions = append(reportOptions, Report.IgnoreValueChanges)
+	}
+
+	if *ignoreNewDocuments {
+		reportOptions = append(reportOptions, Report.IgnoreDocumentAdditions)
+	}
+
+	if *ignoreRemovedDocuments {
+		reportOptions = append(reportOptions, Report.IgnoreDocumentRemovals)
+	}
+
+	return compareOptions, reportOptions, nil
+}
@@ -0,0 +1,113 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("parsing options", func() {
+	var from, to ytbx.InputFile
+
+	BeforeEach(func() {
+		from = ytbx.InputFile{Documents: []*yamlv3.Node{yml(`---
+list: [a, b]
+metadata: {labels: {foo: bar}}
+spec: {replicas: 1}
+`)}}
+
+		to = ytbx.InputFile{Documents: []*yamlv3.Node{yml(`---
+list: [b, a]
+metadata: {labels: {foo: baz}}
+spec: {replicas: 2}
+`)}}
+	})
+
+	It("should parse the same flags as the command line tool", func() {
+		compareOptions, reportOptions, err := dyff.ParseOptions([]string{
+			"-i",
+			"--exclude=/metadata/labels/foo",
+			"--custom-tags", "strip",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(compareOptions).To(HaveLen(2))
+		Expect(reportOptions).To(HaveLen(1))
+
+		report, err := dyff.CompareInputFiles(from, to, compareOptions...)
+		Expect(err).ToNot(HaveOccurred())
+
+		report = dyff.ApplyReportOptions(report, reportOptions...)
+		Expect(report.Diffs).To(HaveLen(1))
+		Expect(report.Diffs[0]).To(BeSameDiffAs(singleDiff("/spec/replicas", dyff.MODIFICATION, 1, 2)))
+	})
+
+	It("should fail for invalid paths", func() {
+		_, _, err := dyff.ParseOptions([]string{"--exclude-subtree=/a=b=c"})
+		Expect(err).To(HaveOccurred())
+
+		_, _, err = dyff.ParseOptions([]string{"--ignore-order-changes-at=/a=b=c"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should parse scoped compare options", func() {
+		compareOptions, _, err := dyff.ParseOptions([]string{
+			"--scope", "/spec --ignore-order-changes",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(compareOptions).To(HaveLen(1))
+	})
+
+	It("should parse the reference resolution flags", func() {
+		compareOptions, _, err := dyff.ParseOptions([]string{"--follow-refs"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(compareOptions).To(HaveLen(1))
+	})
+
+	It("should return no options for no input", func() {
+		compareOptions, reportOptions, err := dyff.ParseOptions(nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(compareOptions).To(BeEmpty())
+		Expect(reportOptions).To(BeEmpty())
+	})
+
+	It("should fail for unknown flags, arguments, or invalid values", func() {
+		for _, args := range [][]string{
+			{"--output", "human"},
+			{"from.yml"},
+			{"--custom-tags", "unknown"},
+			{"--list-diff-strategy", "myers"},
+			{"--node-hashing", "md5"},
+			{"--preset", "unknown"},
+			{"--ignore-order-changes=maybe"},
+			{"--scope", "/spec --exclude=/spec/foo"},
+			{"--scope", " "},
+		} {
+			_, _, err := dyff.ParseOptions(args)
+			Expect(err).To(HaveOccurred(), "%v", args)
+		}
+	})
+})
@@ -0,0 +1,211 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Sort sorts the differences of the report in place, so that they are in the
+// order of the reports of CompareInputFiles and CompareFileSets. This can be
+// used to restore the order after transformations, for example after merging
+// the reports of GroupBy. The order is:
+//
+//   - differences of file sets by the path of their file (the file of the from
+//     side, or the to side for files that only exist there)
+//   - differences without a path (added or removed documents) first
+//   - differences by the index of their document
+//   - differences by the position of their path in the document in the order
+//     of a depth-first traversal, i.e. a path comes before the paths below it,
+//     and map entries or list entries are ordered by their position in the from
+//     document, entries that only exist in the to document (e.g. moved entries)
+//     come after them in the order of the to document
+//
+// Differences that are equal in all of these are kept in their current order.
+func (r Report) Sort() {
+	type sortKey struct {
+		file      string
+		fileLevel bool
+		document  int
+		positions []int
+	}
+
+	keys := make([]sortKey, len(r.Diffs))
+	for i, diff := range r.Diffs {
+		switch {
+		case diff.FromSource != nil:
+			keys[i].file = diff.FromSource.File
+
+		case diff.ToSource != nil:
+			keys[i].file = diff.ToSource.File
+		}
+
+		if diff.Path == nil {
+			keys[i].fileLevel = true
+			continue
+		}
+
+		keys[i].document = diff.Path.DocumentIdx
+		keys[i].positions = r.pathPositions(diff.Path)
+	}
+
+	indices := make([]int, len(r.Diffs))
+	for i := range indices {
+		indices[i] = i
+	}
+
+	sort.SliceStable(indices, func(i, j int) bool {
+		a, b := keys[indices[i]], keys[indices[j]]
+		switch {
+		case a.file != b.file:
+			return a.file < b.file
+
+		case a.fileLevel != b.fileLevel:
+			return a.fileLevel
+
+		case a.document != b.document:
+			return a.document < b.document
+		}
+
+		for k := 0; k < len(a.positions) && k < len(b.positions); k++ {
+			if a.positions[k] != b.positions[k] {
+				return a.positions[k] < b.positions[k]
+			}
+		}
+
+		return len(a.positions) < len(b.positions)
+	})
+
+	sorted := make([]Diff, len(r.Diffs))
+	for i, idx := range indices {
+		sorted[i] = r.Diffs[idx]
+	}
+
+	copy(r.Diffs, sorted)
+}
+
+// pathPositions returns the position of each element of the path, which is
+// the position in the from document, or for entries that only exist in the
+// to document, the number of entries in the from document plus the position
+// in the to document
+func (r Report) pathPositions(path *ytbx.Path) []int {
+	from, to := r.documentsAt(path.DocumentIdx)
+
+	positions := make([]int, len(path.PathElements))
+	for i, element := range path.PathElements {
+		fromIdx, fromNext := elementPosition(from, element)
+		toIdx, toNext := elementPosition(to, element)
+
+		switch {
+		case fromIdx >= 0:
+			positions[i] = fromIdx
+
+		case toIdx >= 0:
+			positions[i] = entryCount(from) + toIdx
+			fromNext = nil
+
+		default:
+			positions[i] = math.MaxInt
+		}
+
+		from, to = fromNext, toNext
+	}
+
+	return positions
+}
+
+// documentsAt returns the root nodes of the from document with the provided
+// index, and of the to document it was compared with, which is the document
+// with the same name (Kubernetes resources), or with the same index
+func (r Report) documentsAt(idx int) (*yamlv3.Node, *yamlv3.Node) {
+	var root = func(documents []*yamlv3.Node, idx int) *yamlv3.Node {
+		if idx < 0 || idx >= len(documents) || documents[idx] == nil {
+			return nil
+		}
+
+		return documentRoot(documents[idx])
+	}
+
+	var toIdx = idx
+	if idx < len(r.From.Names) {
+		for i, name := range r.To.Names {
+			if name == r.From.Names[idx] {
+				toIdx = i
+				break
+			}
+		}
+	}
+
+	return root(r.From.Documents, idx), root(r.To.Documents, toIdx)
+}
+
+// elementPosition returns the position of the path element in the node and
+// the node it refers to, or -1 if there is no such entry
+func elementPosition(node *yamlv3.Node, element ytbx.PathElement) (int, *yamlv3.Node) {
+	if node = followAlias(node); node == nil {
+		return -1, nil
+	}
+
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		if element.Key == "" {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == element.Name {
+					return i / 2, node.Content[i+1]
+				}
+			}
+		}
+
+	case yamlv3.SequenceNode:
+		if element.Key == "" && element.Name == "" {
+			if element.Idx >= 0 && element.Idx < len(node.Content) {
+				return element.Idx, node.Content[element.Idx]
+			}
+
+			return -1, nil
+		}
+
+		for i, entry := range node.Content {
+			entry = followAlias(entry)
+			if listEntryName(entry, element.Key) == element.Name || (entry.Kind == yamlv3.ScalarNode && entry.Value == element.Name) {
+				return i, entry
+			}
+		}
+	}
+
+	return -1, nil
+}
+
+func entryCount(node *yamlv3.Node) int {
+	switch node = followAlias(node); {
+	case node == nil:
+		return 0
+
+	case node.Kind == yamlv3.MappingNode:
+		return len(node.Content) / 2
+	}
+
+	return len(node.Content)
+}
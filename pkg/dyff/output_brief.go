@@ -24,8 +24,10 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/gonvenience/bunt"
+	"github.com/gonvenience/neat"
 	"github.com/gonvenience/term"
 	"github.com/gonvenience/text"
 	"github.com/gonvenience/ytbx"
@@ -39,10 +41,24 @@ const (
 // BriefReport is a reporter that only prints a summary
 type BriefReport struct {
 	Report
+
+	// Detailed lists the changed documents and paths with the number of
+	// changes below the summary, but without the values
+	Detailed bool
+
+	// UseGoPatchPaths and PathStyle define how the paths of the detailed
+	// breakdown are rendered, see HumanReport
+	UseGoPatchPaths bool
+	PathStyle       string
 }
 
 // WriteReport writes a brief summary to the provided writer
 func (report *BriefReport) WriteReport(out io.Writer) error {
+	style, err := pathStyleFor(report.PathStyle, report.UseGoPatchPaths)
+	if err != nil {
+		return err
+	}
+
 	writer := bufio.NewWriter(out)
 	defer writer.Flush()
 
@@ -65,7 +81,106 @@ func (report *BriefReport) WriteReport(out io.Writer) error {
 		niceTo,
 	))
 
+	if report.Detailed {
+		if err := report.writeBreakdown(writer, style); err != nil {
+			return err
+		}
+	}
+
 	// Finish with one last newline so that we do not end next to the prompt
 	_, _ = writer.WriteString("\n")
 	return nil
 }
+
+// briefDocument is a document of the breakdown with its changed paths
+type briefDocument struct {
+	name    string
+	changes int
+	rows    [][]string
+}
+
+// writeBreakdown writes each changed document with its number of changes,
+// followed by its changed paths and the kinds of changes of each path
+func (report *BriefReport) writeBreakdown(writer stringWriter, style PathStyle) error {
+	var documents []*briefDocument
+	var lookup = map[string]*briefDocument{}
+
+	for _, diff := range report.Diffs {
+		name := "(file level)"
+		if diff.Path != nil {
+			name = diff.Path.RootDescription()
+		}
+
+		document, ok := lookup[name]
+		if !ok {
+			document = &briefDocument{name: name}
+			lookup[name] = document
+			documents = append(documents, document)
+		}
+
+		document.changes++
+		for _, row := range briefRows(diff, style) {
+			document.rows = append(document.rows, []string{"  " + row[0], row[1]})
+		}
+	}
+
+	for _, document := range documents {
+		table, err := neat.Table(document.rows, neat.CustomSeparator("  "))
+		if err != nil {
+			return err
+		}
+
+		_, _ = writer.WriteString(fmt.Sprintf("\n%s (%s)\n", bunt.Style(document.name, bunt.Bold()), text.Plural(document.changes, "change")))
+		_, _ = writer.WriteString(table)
+	}
+
+	return nil
+}
+
+// RenderDiff renders the provided difference the same way it is listed in the
+// detailed breakdown, that is the changed path (or documents) with the kinds
+// of changes, but without the document heading and indentation
+func (report *BriefReport) RenderDiff(diff Diff) (string, error) {
+	style, err := pathStyleFor(report.PathStyle, report.UseGoPatchPaths)
+	if err != nil {
+		return "", err
+	}
+
+	return neat.Table(briefRows(diff, style), neat.CustomSeparator("  "))
+}
+
+var _ DiffRenderer = &BriefReport{}
+
+// briefRows returns the rows of the difference in the detailed breakdown,
+// which is the path and kinds of changes, or each changed document in case of
+// a file level difference
+func briefRows(diff Diff, style PathStyle) [][]string {
+	if diff.Path != nil {
+		return [][]string{{style.RenderPath(diff.Path), kindCounts(diff.Details)}}
+	}
+
+	var rows [][]string
+	for _, detail := range diff.Details {
+		for _, node := range changedDocuments(detail) {
+			name := documentName(node)
+			if name == "" {
+				name = "document"
+			}
+
+			rows = append(rows, []string{name, text.Plural(1, detail.Kind.String())})
+		}
+	}
+
+	return rows
+}
+
+// kindCounts returns the number of details per change kind, for example "one
+// addition, one removal"
+func kindCounts(details []Detail) string {
+	var counts = map[DetailKind]int{}
+	for _, detail := range details {
+		counts[detail.Kind]++
+	}
+
+	return strings.Join(kindCountParts(counts), ", ")
+}
@@ -38,15 +38,25 @@ type DiffSyntaxReport struct {
 
 // WriteReport writes a human readable report to the provided writer
 func (report *DiffSyntaxReport) WriteReport(out io.Writer) error {
+	style, err := pathStyleFor(report.PathStyle, report.UseGoPatchPaths)
+	if err != nil {
+		return err
+	}
+
 	writer := bufio.NewWriter(out)
 	defer writer.Flush()
 
 	// Only show the document index if there is more than one document to show
 	showPathRoot := len(report.From.Documents) > 1
 
+	// Notes of the input files are shown like root descriptions
+	for _, note := range report.notes() {
+		_, _ = writer.WriteString(fmt.Sprintf("%s %s\n", report.RootDescriptionPrefix, note))
+	}
+
 	// Loop over the diff and generate each report into the buffer
 	for _, diff := range report.Diffs {
-		if err := report.generateDiffSyntaxDiffOutput(writer, diff, report.UseGoPatchPaths, showPathRoot); err != nil {
+		if err := report.generateDiffSyntaxDiffOutput(writer, diff, style, showPathRoot); err != nil {
 			return err
 		}
 	}
@@ -56,15 +66,28 @@ func (report *DiffSyntaxReport) WriteReport(out io.Writer) error {
 	return nil
 }
 
-// generatedyffSyntaxDiffOutput creates a human readable report of the provided diff and writes this into the given bytes buffer. There is an optional flag to indicate whether the document index (which documents of the input file) should be included in the report of the path of the difference.
-func (report *DiffSyntaxReport) generateDiffSyntaxDiffOutput(output stringWriter, diff Diff, useGoPatchPaths bool, showPathRoot bool) error {
-	_, _ = output.WriteString(fmt.Sprintf("\n%s ", report.PathPrefix))
-	if useGoPatchPaths {
-		_, _ = output.WriteString(styledGoPatchPath(diff.Path))
+// RenderDiff renders the provided difference the same way it is shown in the
+// report, but without the notes of the input files and surrounding empty lines
+func (report *DiffSyntaxReport) RenderDiff(diff Diff) (string, error) {
+	style, err := pathStyleFor(report.PathStyle, report.UseGoPatchPaths)
+	if err != nil {
+		return "", err
+	}
 
-	} else {
-		_, _ = output.WriteString(styledDotStylePath(diff.Path))
+	var buf strings.Builder
+	if err := report.generateDiffSyntaxDiffOutput(&buf, diff, style, len(report.From.Documents) > 1); err != nil {
+		return "", err
 	}
+
+	return strings.Trim(buf.String(), "\n") + "\n", nil
+}
+
+var _ DiffRenderer = &DiffSyntaxReport{}
+
+// generatedyffSyntaxDiffOutput creates a human readable report of the provided diff and writes this into the given bytes buffer. There is an optional flag to indicate whether the document index (which documents of the input file) should be included in the report of the path of the difference.
+func (report *DiffSyntaxReport) generateDiffSyntaxDiffOutput(output stringWriter, diff Diff, style PathStyle, showPathRoot bool) error {
+	_, _ = output.WriteString(fmt.Sprintf("\n%s ", report.PathPrefix))
+	_, _ = output.WriteString(style.RenderPath(diff.Path))
 	// Only @@ also needs a postfix
 	if report.PathPrefix == "@@" {
 		_, _ = output.WriteString(" @@")
@@ -76,6 +99,11 @@ func (report *DiffSyntaxReport) generateDiffSyntaxDiffOutput(output stringWriter
 		_, _ = output.WriteString(fmt.Sprintf("%s %s\n", report.RootDescriptionPrefix, diff.Path.RootDescription()))
 	}
 
+	// Write the source files and lines onto their own line
+	if source := sourceDescription(diff); source != "" {
+		_, _ = output.WriteString(fmt.Sprintf("%s %s\n", report.RootDescriptionPrefix, source))
+	}
+
 	blocks := make([]string, len(diff.Details))
 	for i, detail := range diff.Details {
 		generatedOutput, err := report.generateDiffSyntaxDetailOutput(detail)
@@ -131,6 +159,20 @@ func (report *DiffSyntaxReport) generateDiffSyntaxDetailOutput(detail Detail) (s
 			return "", err
 		}
 		return report.prefixChangeType(detailOutput), nil
+
+	case MOVED:
+		detailOutput, err := report.generateHumanDetailOutputMove(detail)
+		if err != nil {
+			return "", err
+		}
+		return report.prefixChangeType(detailOutput), nil
+
+	case DIRECTIVECHANGE:
+		detailOutput, err := report.generateHumanDetailOutputDirectives(detail)
+		if err != nil {
+			return "", err
+		}
+		return report.prefixChangeType(detailOutput), nil
 	}
 
 	return "", fmt.Errorf("unsupported detail type %c", detail.Kind)
@@ -143,7 +185,7 @@ func (report *DiffSyntaxReport) prefixChangeType(detailOutput string) string {
 	return strings.TrimSpace(strings.Join(lines, "\n"))
 }
 
-func (report *DiffSyntaxReport) prefixChangeBlock(detailOutput string, blockPrefix rune) string {
+func (report *DiffSyntaxReport) prefixChangeBlock(detailOutput string, blockPrefix DetailKind) string {
 	// trim newline from the end
 	detailOutput = strings.TrimSpace(detailOutput)
 
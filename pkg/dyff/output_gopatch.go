@@ -0,0 +1,301 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// GoPatchReport is a reporter that writes a go-patch
+// (https://github.com/cppforlife/go-patch) operations file, which transforms
+// the from input file into the to input file, for example using bosh
+// interpolate --ops-file.
+type GoPatchReport struct {
+	Report
+}
+
+type goPatchOperation struct {
+	Type  string       `yaml:"type"`
+	Path  string       `yaml:"path"`
+	Value *yamlv3.Node `yaml:"value,omitempty"`
+}
+
+// WriteReport writes the go-patch operations to the provided writer
+func (report *GoPatchReport) WriteReport(out io.Writer) error {
+	data, err := report.AsGoPatch()
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(out)
+	defer writer.Flush()
+
+	for _, note := range report.notes() {
+		_, _ = writer.WriteString(fmt.Sprintf("# %s\n", note))
+	}
+
+	_, _ = writer.Write(data)
+	return nil
+}
+
+// RenderDiff renders the go-patch operations of the provided difference, the
+// report input files are used to look up the values of replaced lists
+func (report *GoPatchReport) RenderDiff(diff Diff) (string, error) {
+	data, err := Report{From: report.From, To: report.To, Diffs: []Diff{diff}}.AsGoPatch()
+	return string(data), err
+}
+
+var _ DiffRenderer = &GoPatchReport{}
+
+// AsGoPatch returns a go-patch operations file, which transforms the from
+// input file into the to input file. Since go-patch works on one document,
+// this is only supported for reports of single document files.
+func (r Report) AsGoPatch() ([]byte, error) {
+	if len(r.From.Documents) > 1 || len(r.To.Documents) > 1 {
+		return nil, fmt.Errorf("go-patch operations can only be created for single document files")
+	}
+
+	// Lists that are replaced as a whole already contain all changes of their
+	// entries, so differences below these lists must be skipped
+	var replaced []string
+	for _, diff := range r.Diffs {
+		if diff.Path != nil && isListReplacement(diff) {
+			replaced = append(replaced, goPatchPath(diff.Path.PathElements))
+		}
+
+		if listPath, ok := r.positionalListChange(diff); ok {
+			replaced = append(replaced, goPatchPath(listPath.PathElements))
+		}
+	}
+
+	var isBelowReplacedList = func(diff Diff) bool {
+		for _, path := range replaced {
+			if diff.Path != nil && strings.HasPrefix(goPatchPath(diff.Path.PathElements), goPatchJoin(path, "")) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var operations = []goPatchOperation{}
+	var replacedLists = map[string]bool{}
+	for _, diff := range r.Diffs {
+		// Positional list entry removals and additions are expressed by
+		// replacing the whole list once
+		if listPath, ok := r.positionalListChange(diff); ok {
+			path, err := r.goPatchPathOf(listPath)
+			if err != nil {
+				return nil, err
+			}
+
+			if !replacedLists[path] {
+				list, err := r.toValue(listPath)
+				if err != nil {
+					return nil, err
+				}
+
+				replacedLists[path] = true
+				operations = append(operations, goPatchOperation{Type: "replace", Path: path, Value: list})
+			}
+
+			continue
+		}
+
+		if isBelowReplacedList(diff) {
+			continue
+		}
+
+		ops, err := r.goPatchOperations(diff)
+		if err != nil {
+			return nil, err
+		}
+
+		operations = append(operations, ops...)
+	}
+
+	var buf bytes.Buffer
+	encoder := yamlv3.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(operations); err != nil {
+		return nil, fmt.Errorf("failed to create go-patch operations: %w", err)
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to create go-patch operations: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (r Report) goPatchOperations(diff Diff) ([]goPatchOperation, error) {
+	if diff.Path == nil {
+		return nil, fmt.Errorf("document additions or removals cannot be expressed using go-patch")
+	}
+
+	path, err := r.goPatchPathOf(diff.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	// List entry removals and order changes are expressed by replacing the
+	// whole list, since go-patch cannot address simple list entries by value
+	if isListReplacement(diff) {
+		list, err := r.toValue(diff.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		return []goPatchOperation{{Type: "replace", Path: path, Value: list}}, nil
+	}
+
+	var result []goPatchOperation
+	for _, detail := range diff.Details {
+		switch detail.Kind {
+		case MODIFICATION:
+			result = append(result, goPatchOperation{Type: "replace", Path: path, Value: followAlias(detail.To)})
+
+		case MOVED:
+			previous, err := ytbx.ParseGoPatchStylePathString(detail.From.Value)
+			if err != nil || len(diff.Path.PathElements) == 0 {
+				return nil, fmt.Errorf("%s: move cannot be expressed using go-patch", diff.Path.String())
+			}
+
+			result = append(result,
+				goPatchOperation{Type: "remove", Path: goPatchPath(previous.PathElements)},
+				goPatchOperation{Type: "replace", Path: path + "?", Value: followAlias(detail.To)},
+			)
+
+		case ADDITION:
+			switch detail.To.Kind {
+			case yamlv3.MappingNode:
+				for i := 0; i+1 < len(detail.To.Content); i += 2 {
+					result = append(result, goPatchOperation{
+						Type:  "replace",
+						Path:  goPatchJoin(path, goPatchEscape(detail.To.Content[i].Value)+"?"),
+						Value: followAlias(detail.To.Content[i+1]),
+					})
+				}
+
+			case yamlv3.SequenceNode:
+				for _, entry := range detail.To.Content {
+					result = append(result, goPatchOperation{
+						Type:  "replace",
+						Path:  goPatchJoin(path, "-"),
+						Value: followAlias(entry),
+					})
+				}
+
+			default:
+				return nil, fmt.Errorf("%s: addition cannot be expressed using go-patch", diff.Path.String())
+			}
+
+		case REMOVAL:
+			switch detail.From.Kind {
+			case yamlv3.MappingNode:
+				for i := 0; i+1 < len(detail.From.Content); i += 2 {
+					result = append(result, goPatchOperation{
+						Type: "remove",
+						Path: goPatchJoin(path, goPatchEscape(detail.From.Content[i].Value)),
+					})
+				}
+
+			default:
+				return nil, fmt.Errorf("%s: removal cannot be expressed using go-patch", diff.Path.String())
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// toValue looks up the value of the given path in the to input file
+func (r Report) toValue(path *ytbx.Path) (*yamlv3.Node, error) {
+	if path.DocumentIdx >= len(r.To.Documents) {
+		return nil, fmt.Errorf("failed to look up %s, there is no document #%d", path.String(), path.DocumentIdx)
+	}
+
+	document := r.To.Documents[path.DocumentIdx]
+	if document.Kind != yamlv3.DocumentNode {
+		document = &yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{document}}
+	}
+
+	node, err := ytbx.Grab(document, path.ToGoPatchStyle())
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s: %w", path.String(), err)
+	}
+
+	return followAlias(node), nil
+}
+
+// goPatchPathOf returns the go-patch path of the given path, entries of lists
+// with a composite identifier are addressed by their index in the from input
+// file instead, since go-patch only supports one field to identify entries
+func (r Report) goPatchPathOf(path *ytbx.Path) (string, error) {
+	for _, element := range path.PathElements {
+		if strings.Contains(element.Key, compositeSeparator) {
+			return r.jsonPointer(path)
+		}
+	}
+
+	return goPatchPath(path.PathElements), nil
+}
+
+// goPatchPath translates the path elements into a go-patch path, with map
+// keys escaped the same way as in JSON pointers
+func goPatchPath(elements []ytbx.PathElement) string {
+	if len(elements) == 0 {
+		return "/"
+	}
+
+	var result strings.Builder
+	for _, element := range elements {
+		result.WriteString("/")
+
+		switch {
+		case element.Key != "":
+			fmt.Fprintf(&result, "%s=%s", goPatchEscape(element.Key), goPatchEscape(element.Name))
+
+		case element.Name != "":
+			result.WriteString(goPatchEscape(element.Name))
+
+		default:
+			fmt.Fprintf(&result, "%d", element.Idx)
+		}
+	}
+
+	return result.String()
+}
+
+func goPatchJoin(path string, token string) string {
+	return strings.TrimSuffix(path, "/") + "/" + token
+}
+
+func goPatchEscape(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
@@ -0,0 +1,314 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("go-patch report", func() {
+	goPatch := func(from, to string) string {
+		report, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Documents: []*yamlv3.Node{yml(from)}},
+			ytbx.InputFile{Documents: []*yamlv3.Node{yml(to)}},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		data, err := report.AsGoPatch()
+		Expect(err).ToNot(HaveOccurred())
+		return string(data)
+	}
+
+	It("should create replace operations for value changes and additions", func() {
+		Expect(goPatch(`---
+spec:
+  replicas: 1
+  image/name: foo
+`, `---
+spec:
+  replicas: 2
+  image/name: bar
+  labels:
+    app: foo
+`)).To(Equal(`- type: replace
+  path: /spec/labels?
+  value:
+    app: foo
+- type: replace
+  path: /spec/replicas
+  value: 2
+- type: replace
+  path: /spec/image~1name
+  value: bar
+`))
+	})
+
+	It("should create remove operations for map entries and replace lists with removed entries", func() {
+		Expect(goPatch(`---
+list:
+- one
+- two
+foo: bar
+`, `---
+list:
+- one
+`)).To(Equal(`- type: remove
+  path: /foo
+- type: replace
+  path: /list
+  value:
+    - one
+`))
+	})
+
+	It("should address entries of named lists and append new entries", func() {
+		Expect(goPatch(`---
+instance_groups:
+- name: web
+  instances: 1
+`, `---
+instance_groups:
+- name: web
+  instances: 2
+- name: worker
+  instances: 1
+`)).To(Equal(`- type: replace
+  path: /instance_groups/-
+  value:
+    name: worker
+    instances: 1
+- type: replace
+  path: /instance_groups/name=web/instances
+  value: 2
+`))
+	})
+
+	It("should skip changes below lists that are replaced as a whole", func() {
+		from, to := `---
+list:
+- name: a
+  old: 1
+  value: 1
+- name: b
+`, `---
+list:
+- name: a
+  value: 2
+`
+
+		report, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Documents: multiDoc(from)},
+			ytbx.InputFile{Documents: multiDoc(to)},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		data, err := report.AsGoPatch()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(`- type: replace
+  path: /list
+  value:
+    - name: a
+      value: 2
+`))
+
+		patched := applyGoPatch(multiDoc(from)[0], data)
+		result, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Documents: []*yamlv3.Node{patched}},
+			ytbx.InputFile{Documents: multiDoc(to)},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Diffs).To(BeEmpty())
+	})
+
+	It("should address entries of lists with a composite identifier by their index", func() {
+		from, to := `---
+endpoints:
+- host: example.org
+  port: 80
+  weight: 1
+- host: example.org
+  port: 443
+  weight: 1
+`, `---
+endpoints:
+- host: example.org
+  port: 80
+  weight: 1
+- host: example.org
+  port: 443
+  weight: 2
+`
+
+		report, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Documents: multiDoc(from)},
+			ytbx.InputFile{Documents: multiDoc(to)},
+			dyff.CompositeIdentifier("host", "port"),
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		data, err := report.AsGoPatch()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(`- type: replace
+  path: /endpoints/1/weight
+  value: 2
+`))
+
+		patched := applyGoPatch(multiDoc(from)[0], data)
+		result, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Documents: []*yamlv3.Node{patched}},
+			ytbx.InputFile{Documents: multiDoc(to)},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Diffs).To(BeEmpty())
+	})
+
+	It("should create an empty list of operations if there are no differences", func() {
+		Expect(goPatch("foo: bar", "foo: bar")).To(Equal("[]\n"))
+	})
+
+	It("should fail for files with multiple documents", func() {
+		report := dyff.Report{
+			From: ytbx.InputFile{Documents: multiDoc("foo: bar", "bar: foo")},
+			To:   ytbx.InputFile{Documents: multiDoc("foo: bar")},
+		}
+
+		_, err := report.AsGoPatch()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should render the operations of a single difference", func() {
+		report, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Documents: multiDoc(`{spec: {replicas: 1, name: foo}}`)},
+			ytbx.InputFile{Documents: multiDoc(`{spec: {replicas: 2, name: foo}}`)},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Diffs).To(HaveLen(1))
+
+		var renderer dyff.DiffRenderer = &dyff.GoPatchReport{Report: report}
+		Expect(renderer.RenderDiff(report.Diffs[0])).To(Equal(`- type: replace
+  path: /spec/replicas
+  value: 2
+`))
+	})
+})
+
+// applyGoPatch applies the replace and remove operations of a go-patch
+// operations file to the document, it supports the path syntax that is used
+// by the go-patch report
+func applyGoPatch(document *yamlv3.Node, data []byte) *yamlv3.Node {
+	var operations yamlv3.Node
+	Expect(yamlv3.Unmarshal(data, &operations)).To(Succeed())
+
+	for _, entry := range operations.Content[0].Content {
+		var operation struct{ Type, Path string }
+		Expect(entry.Decode(&operation)).To(Succeed())
+
+		var value *yamlv3.Node
+		if idx := goPatchIndex(entry, "value"); idx >= 0 {
+			value = entry.Content[idx]
+		}
+
+		tokens := strings.Split(strings.TrimPrefix(operation.Path, "/"), "/")
+		parent := document.Content[0]
+		for _, token := range tokens[:len(tokens)-1] {
+			idx := goPatchIndex(parent, token)
+			Expect(idx).To(BeNumerically(">=", 0), "path %s not found", operation.Path)
+			parent = parent.Content[idx]
+		}
+
+		last := tokens[len(tokens)-1]
+		idx := goPatchIndex(parent, last)
+		switch operation.Type {
+		case "replace":
+			switch {
+			case idx >= 0:
+				parent.Content[idx] = value
+
+			case last == "-":
+				parent.Content = append(parent.Content, value)
+
+			case strings.HasSuffix(last, "?") && parent.Kind == yamlv3.MappingNode:
+				key := goPatchUnescape(strings.TrimSuffix(last, "?"))
+				parent.Content = append(parent.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: key}, value)
+
+			default:
+				Fail("path " + operation.Path + " not found")
+			}
+
+		case "remove":
+			Expect(idx).To(BeNumerically(">=", 0), "path %s not found", operation.Path)
+			if parent.Kind == yamlv3.MappingNode {
+				parent.Content = append(parent.Content[:idx-1], parent.Content[idx+1:]...)
+			} else {
+				parent.Content = append(parent.Content[:idx], parent.Content[idx+1:]...)
+			}
+
+		default:
+			Fail("unsupported operation type " + operation.Type)
+		}
+	}
+
+	return document
+}
+
+// goPatchIndex returns the index of the node the path token refers to in the
+// content of the parent node, or -1 if there is none
+func goPatchIndex(parent *yamlv3.Node, token string) int {
+	token = strings.TrimSuffix(token, "?")
+
+	switch parent.Kind {
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(parent.Content); i += 2 {
+			if parent.Content[i].Value == goPatchUnescape(token) {
+				return i + 1
+			}
+		}
+
+	case yamlv3.SequenceNode:
+		if key, value, ok := strings.Cut(token, "="); ok {
+			for i, entry := range parent.Content {
+				if idx := goPatchIndex(entry, key); idx >= 0 && entry.Kind == yamlv3.MappingNode && entry.Content[idx].Value == goPatchUnescape(value) {
+					return i
+				}
+			}
+
+			return -1
+		}
+
+		if idx, err := strconv.Atoi(token); err == nil && idx < len(parent.Content) {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+func goPatchUnescape(token string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+}
@@ -24,7 +24,6 @@ import (
 	"bufio"
 	"bytes"
 	"crypto/x509"
-	"encoding/base64"
 	"encoding/hex"
 	"encoding/pem"
 	"fmt"
@@ -49,6 +48,23 @@ type stringWriter interface {
 	WriteString(s string) (int, error)
 }
 
+// HeaderStyle is the style of the header of the human readable report
+type HeaderStyle string
+
+// Supported header styles
+const (
+	// HeaderBanner is the dyff ASCII art banner with the input files and the
+	// number of differences (default)
+	HeaderBanner HeaderStyle = "banner"
+
+	// HeaderCompact is a single line with the input files and the number of
+	// differences
+	HeaderCompact HeaderStyle = "compact"
+
+	// HeaderNone omits the header
+	HeaderNone HeaderStyle = "none"
+)
+
 // HumanReport is a reporter with human readable output in mind
 type HumanReport struct {
 	Report
@@ -58,8 +74,18 @@ type HumanReport struct {
 	NoTableStyle          bool
 	DoNotInspectCerts     bool
 	OmitHeader            bool
+	HeaderStyle           HeaderStyle
 	UseGoPatchPaths       bool
+	PathStyle             string
 	PrefixMultiline       bool
+	OmitBinaryHexDump     bool
+	ContextKeys           int
+	GroupByKind           bool
+	VersionSummary        bool
+	RelativeTo            string
+	DocumentValues        DocumentValuesStyle
+	Summary               bool
+	Bidirectional         bool
 }
 
 // WriteReport writes a human readable report to the provided writer
@@ -67,11 +93,22 @@ func (report *HumanReport) WriteReport(out io.Writer) error {
 	writer := bufio.NewWriter(out)
 	defer writer.Flush()
 
+	style, err := pathStyleFor(report.PathStyle, report.UseGoPatchPaths)
+	if err != nil {
+		return err
+	}
+
 	// Only show the document index if there is more than one document to show
 	showPathRoot := len(report.From.Documents) > 1
 
-	// Show banner if enabled
-	if !report.OmitHeader {
+	// Show header if enabled
+	var headerStyle = report.HeaderStyle
+	if report.OmitHeader {
+		headerStyle = HeaderNone
+	}
+
+	switch headerStyle {
+	case HeaderBanner, "":
 		var header = fmt.Sprintf(`     _        __  __
    _| |_   _ / _|/ _|  between %s
  / _' | | | | |_| |_       and %s
@@ -100,26 +137,208 @@ func (report *HumanReport) WriteReport(out io.Writer) error {
 				return nil
 			}),
 		))
+
+	case HeaderCompact:
+		_, _ = writer.WriteString(fmt.Sprintf("dyff between %s and %s returned %s\n",
+			ytbx.HumanReadableLocationInformation(report.From),
+			ytbx.HumanReadableLocationInformation(report.To),
+			bunt.Style(text.Plural(len(report.Diffs), "difference"), bunt.Bold()),
+		))
+
+	case HeaderNone:
+		// nothing to write
+
+	default:
+		return fmt.Errorf("unknown header style %q, supported styles are %s, %s, and %s", headerStyle, HeaderBanner, HeaderCompact, HeaderNone)
 	}
 
-	// Loop over the diff and generate each report into the buffer
-	for _, diff := range report.Diffs {
-		if err := report.generateHumanDiffOutput(writer, diff, report.UseGoPatchPaths, showPathRoot); err != nil {
+	if !isDocumentValuesStyle(report.DocumentValues) {
+		return fmt.Errorf("unknown document values style %q, supported styles are %s", report.DocumentValues, strings.Join(DocumentValuesStyleNames(), ", "))
+	}
+
+	// Mention the common path prefix, if any of the paths is shown without it
+	if report.RelativeTo != "" {
+		for _, diff := range report.Diffs {
+			if report.relativePath(diff.Path) != diff.Path {
+				_, _ = writer.WriteString("\n")
+				_, _ = writer.WriteString(dimgray("paths below %s are shown relative to it", report.RelativeTo))
+				_, _ = writer.WriteString("\n")
+				break
+			}
+		}
+	}
+
+	// Show the statistics summary block if enabled
+	if report.Summary {
+		if err := report.writeSummary(writer); err != nil {
+			return err
+		}
+	}
+
+	// Show the table of image and version changes if enabled
+	if report.VersionSummary {
+		if err := report.writeVersionBumps(writer, style); err != nil {
 			return err
 		}
 	}
 
+	var groups = []kindGroup{{diffs: report.Diffs}}
+	if report.GroupByKind {
+		groups = groupDiffsByKind(report.Report)
+	}
+
+	// Loop over the diff and generate each report into the buffer
+	for _, group := range groups {
+		if report.GroupByKind {
+			heading := fmt.Sprintf("%s (%s)", group.kind, text.Plural(len(group.diffs), "difference"))
+			_, _ = writer.WriteString("\n")
+			_, _ = writer.WriteString(bunt.Style(heading, bunt.Bold()))
+			_, _ = writer.WriteString("\n")
+			_, _ = writer.WriteString(strings.Repeat("═", len([]rune(heading))))
+			_, _ = writer.WriteString("\n")
+		}
+
+		for _, diff := range group.diffs {
+			if err := report.generateHumanDiffOutput(writer, diff, style, showPathRoot); err != nil {
+				return err
+			}
+		}
+	}
+
 	// Finish with one last newline so that we do not end next to the prompt
 	_, _ = writer.WriteString("\n")
 	return nil
 }
 
+// RenderDiff renders the provided difference the same way it is shown in the
+// report, but without header and surrounding empty lines. The report input
+// files are only used to show the document of the path and context keys.
+func (report *HumanReport) RenderDiff(diff Diff) (string, error) {
+	style, err := pathStyleFor(report.PathStyle, report.UseGoPatchPaths)
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	if err := report.generateHumanDiffOutput(&buf, diff, style, len(report.From.Documents) > 1); err != nil {
+		return "", err
+	}
+
+	return strings.Trim(buf.String(), "\n") + "\n", nil
+}
+
+var _ DiffRenderer = &HumanReport{}
+
+// writeVersionBumps writes a table of all image tag and version changes, which
+// is usually the most relevant information of a deployment change
+func (report *HumanReport) writeVersionBumps(output stringWriter, style PathStyle) error {
+	bumps := report.VersionBumps()
+	if len(bumps) == 0 {
+		return nil
+	}
+
+	var rows = make([][]string, len(bumps))
+	for i, bump := range bumps {
+		rows[i] = []string{
+			bump.Resource(),
+			style.RenderPath(report.relativePath(bump.Path)),
+			bunt.Sprintf("Red{%s} → Green{%s}", bump.From, bump.To),
+		}
+	}
+
+	table, err := neat.Table(rows, neat.CustomSeparator("  "))
+	if err != nil {
+		return err
+	}
+
+	heading := fmt.Sprintf("Version changes (%d)", len(bumps))
+	_, _ = output.WriteString("\n")
+	_, _ = output.WriteString(bunt.Style(heading, bunt.Bold()))
+	_, _ = output.WriteString("\n")
+	_, _ = output.WriteString(strings.Repeat("═", len([]rune(heading))))
+	_, _ = output.WriteString("\n")
+	_, _ = output.WriteString(table)
+
+	return nil
+}
+
+// writeSummary writes the number of changes per change kind, per document,
+// and per top-level path, so that large reports can be skimmed quickly
+func (report *HumanReport) writeSummary(output stringWriter) error {
+	statistics := report.Statistics()
+
+	heading := "Summary"
+	_, _ = output.WriteString("\n")
+	_, _ = output.WriteString(bunt.Style(heading, bunt.Bold()))
+	_, _ = output.WriteString("\n")
+	_, _ = output.WriteString(strings.Repeat("═", len([]rune(heading))))
+	_, _ = output.WriteString("\n")
+	_, _ = output.WriteString(statistics.String())
+	_, _ = output.WriteString("\n")
+
+	// The section titles are written outside of the tables, since table rows
+	// are padded to the width of the table
+	for _, section := range []struct {
+		title   string
+		entries []StatisticsEntry
+	}{
+		{"per document:", statistics.SortedDocuments()},
+		{"per top-level path:", statistics.SortedPaths()},
+	} {
+		if len(section.entries) == 0 {
+			continue
+		}
+
+		var rows = make([][]string, len(section.entries))
+		for i, entry := range section.entries {
+			rows[i] = []string{"  " + entry.Name, text.Plural(entry.Count, "change")}
+		}
+
+		table, err := neat.Table(rows, neat.CustomSeparator("  "))
+		if err != nil {
+			return err
+		}
+
+		_, _ = output.WriteString(section.title)
+		_, _ = output.WriteString("\n")
+		_, _ = output.WriteString(table)
+	}
+
+	return nil
+}
+
+// relativePath returns the path without the common prefix configured in
+// RelativeTo, or the path itself in case it is not located below the prefix
+func (report *HumanReport) relativePath(path *ytbx.Path) *ytbx.Path {
+	var segments = 0
+	for _, segment := range strings.Split(report.RelativeTo, "/") {
+		if segment != "" {
+			segments++
+		}
+	}
+
+	if segments == 0 || path == nil || len(path.PathElements) <= segments || !matchesPathFilter(path, report.RelativeTo) {
+		return path
+	}
+
+	return &ytbx.Path{
+		Root:         path.Root,
+		DocumentIdx:  path.DocumentIdx,
+		PathElements: path.PathElements[segments:],
+	}
+}
+
 // generateHumanDiffOutput creates a human readable report of the provided diff and writes this into the given bytes buffer. There is an optional flag to indicate whether the document index (which documents of the input file) should be included in the report of the path of the difference.
-func (report *HumanReport) generateHumanDiffOutput(output stringWriter, diff Diff, useGoPatchPaths bool, showPathRoot bool) error {
+func (report *HumanReport) generateHumanDiffOutput(output stringWriter, diff Diff, style PathStyle, showPathRoot bool) error {
 	_, _ = output.WriteString("\n")
-	_, _ = output.WriteString(pathToString(diff.Path, useGoPatchPaths, showPathRoot))
+	_, _ = output.WriteString(pathToString(report.relativePath(diff.Path), style, showPathRoot))
 	_, _ = output.WriteString("\n")
 
+	if source := sourceDescription(diff); source != "" {
+		_, _ = output.WriteString(dimgray("%s", source))
+		_, _ = output.WriteString("\n")
+	}
+
 	blocks := make([]string, len(diff.Details))
 	for i, detail := range diff.Details {
 		generatedOutput, err := report.generateHumanDetailOutput(detail)
@@ -137,10 +356,102 @@ func (report *HumanReport) generateHumanDiffOutput(output stringWriter, diff Dif
 		indent = 0
 	}
 
+	if context := report.contextKeys(diff); context != "" && len(blocks) > 0 {
+		blocks[len(blocks)-1] += context + "\n"
+	}
+
+	if report.Bidirectional {
+		return report.writeBidirectional(output, diff, indent, blocks)
+	}
+
 	report.writeTextBlocks(output, indent, blocks...)
 	return nil
 }
 
+// contextKeys returns up to the configured number of unchanged sibling keys
+// of a modified map entry as a dimmed text block, so that the surrounding
+// structure is visible in the report
+func (report *HumanReport) contextKeys(diff Diff) string {
+	if report.ContextKeys <= 0 || diff.Path == nil || len(diff.Path.PathElements) == 0 {
+		return ""
+	}
+
+	var modified bool
+	for _, detail := range diff.Details {
+		modified = modified || detail.Kind == MODIFICATION
+	}
+
+	var elements = diff.Path.PathElements
+	var last = elements[len(elements)-1]
+	if !modified || last.Key != "" || last.Name == "" {
+		return ""
+	}
+
+	var idx = diff.Path.DocumentIdx
+	if idx >= len(report.From.Documents) || idx >= len(report.To.Documents) {
+		return ""
+	}
+
+	fromParent, err := lookupNode(documentRoot(report.From.Documents[idx]), elements[:len(elements)-1])
+	if err != nil || fromParent.Kind != yamlv3.MappingNode {
+		return ""
+	}
+
+	toParent, err := lookupNode(documentRoot(report.To.Documents[idx]), elements[:len(elements)-1])
+	if err != nil || toParent.Kind != yamlv3.MappingNode {
+		return ""
+	}
+
+	// collect the unchanged siblings, and where the modified key is located
+	type sibling struct{ key, value *yamlv3.Node }
+	var siblings []sibling
+	var position = -1
+	for i := 0; i+1 < len(toParent.Content); i += 2 {
+		key, value := toParent.Content[i], toParent.Content[i+1]
+		if key.Value == last.Name {
+			position = len(siblings)
+			continue
+		}
+
+		if fromValue, ok := findValueByKey(fromParent, key.Value); ok && canonicalString(fromValue) == canonicalString(value) {
+			siblings = append(siblings, sibling{key, value})
+		}
+	}
+
+	if len(siblings) == 0 {
+		return ""
+	}
+
+	// prefer the siblings that are closest to the modified key
+	if position < 0 {
+		position = len(siblings)
+	}
+
+	var start = max(position-(report.ContextKeys+1)/2, 0)
+	var end = min(start+report.ContextKeys, len(siblings))
+	start = max(end-report.ContextKeys, 0)
+
+	var lines []string
+	for _, entry := range siblings[start:end] {
+		value := followAlias(entry.value)
+		switch {
+		case value.Kind == yamlv3.MappingNode:
+			lines = append(lines, fmt.Sprintf("%s: {…}", entry.key.Value))
+
+		case value.Kind == yamlv3.SequenceNode:
+			lines = append(lines, fmt.Sprintf("%s: […]", entry.key.Value))
+
+		case strings.Contains(value.Value, "\n"):
+			lines = append(lines, fmt.Sprintf("%s: …", entry.key.Value))
+
+		default:
+			lines = append(lines, fmt.Sprintf("%s: %s", entry.key.Value, value.Value))
+		}
+	}
+
+	return dimgray("%s", strings.Join(lines, "\n"))
+}
+
 // generateHumanDetailOutput only serves as a dispatcher to call the correct sub function for the respective type of change
 func (report *HumanReport) generateHumanDetailOutput(detail Detail) (string, error) {
 	switch detail.Kind {
@@ -155,6 +466,12 @@ func (report *HumanReport) generateHumanDetailOutput(detail Detail) (string, err
 
 	case ORDERCHANGE:
 		return report.generateHumanDetailOutputOrderchange(detail)
+
+	case MOVED:
+		return report.generateHumanDetailOutputMove(detail)
+
+	case DIRECTIVECHANGE:
+		return report.generateHumanDetailOutputDirectives(detail)
 	}
 
 	return "", fmt.Errorf("unsupported detail type %c", detail.Kind)
@@ -164,6 +481,12 @@ func (report *HumanReport) generateHumanDetailOutputAddition(detail Detail) (str
 	var output bytes.Buffer
 
 	switch detail.To.Kind {
+	case yamlv3.DocumentNode:
+		_, _ = fmt.Fprint(&output, yellow("%c %s added:\n",
+			ADDITION,
+			text.Plural(len(detail.To.Content), "document"),
+		))
+
 	case yamlv3.SequenceNode:
 		_, _ = output.WriteString(yellow("%c %s added:\n",
 			ADDITION,
@@ -177,8 +500,23 @@ func (report *HumanReport) generateHumanDetailOutputAddition(detail Detail) (str
 		))
 	}
 
-	ytbx.RestructureObject(detail.To)
-	yamlOutput, err := yamlStringInGreenishColors(detail.To)
+	if detail.To.Kind == yamlv3.DocumentNode && report.summarizesDocuments() {
+		overview, err := report.documentsOverview(detail.To, green)
+		if err != nil {
+			return "", err
+		}
+
+		report.writeTextBlocks(&output, 2, overview)
+		return output.String(), nil
+	}
+
+	to, err := withRenderedTags(detail.To)
+	if err != nil {
+		return "", err
+	}
+
+	ytbx.RestructureObject(to)
+	yamlOutput, err := documentsString(to, yamlStringInGreenishColors)
 	if err != nil {
 		return "", err
 	}
@@ -207,8 +545,23 @@ func (report *HumanReport) generateHumanDetailOutputRemoval(detail Detail) (stri
 		_, _ = output.WriteString(yellow("%c %s removed:\n", REMOVAL, text))
 	}
 
-	ytbx.RestructureObject(detail.From)
-	yamlOutput, err := yamlStringInRedishColors(detail.From)
+	if detail.From.Kind == yamlv3.DocumentNode && report.summarizesDocuments() {
+		overview, err := report.documentsOverview(detail.From, red)
+		if err != nil {
+			return "", err
+		}
+
+		report.writeTextBlocks(&output, report.Indent, overview)
+		return output.String(), nil
+	}
+
+	from, err := withRenderedTags(detail.From)
+	if err != nil {
+		return "", err
+	}
+
+	ytbx.RestructureObject(from)
+	yamlOutput, err := documentsString(from, yamlStringInRedishColors)
 	if err != nil {
 		return "", err
 	}
@@ -223,7 +576,42 @@ func (report *HumanReport) generateHumanDetailOutputModification(detail Detail)
 	fromType := humanReadableType(detail.From)
 	toType := humanReadableType(detail.To)
 
+	// scalars with registered tag renderers are compared using their rendered
+	// values, which get the same treatment as strings
+	renderedFrom, renderedTo, rendered, err := renderedScalars(detail.From, detail.To)
+	if err != nil {
+		return "", err
+	}
+
 	switch {
+	case rendered && fromType == toType:
+		report.writeStringDiff(&output, renderedFrom, renderedTo)
+
+	case rendered:
+		_, _ = output.WriteString(yellow("%c type change from %s to %s\n",
+			MODIFICATION,
+			italic(fromType),
+			italic(toType),
+		))
+
+		_, _ = output.WriteString(red("%s", createStringWithPrefix("- ", renderedFrom, report.Indent)))
+		_, _ = output.WriteString(green("%s", createStringWithPrefix("+ ", renderedTo, report.Indent)))
+
+	case fromType == "string" && toType == "string" && isBlockScalarStyleChange(detail.From, detail.To):
+		// only the chomping indicator changed, show the block scalar headers
+		// instead of the (identical) content
+		scalarStyle := func(node *yamlv3.Node) string {
+			if node.Style&(yamlv3.LiteralStyle|yamlv3.FoldedStyle) == 0 {
+				return "flow scalar"
+			}
+
+			return "block scalar " + blockScalarIndicator(node)
+		}
+
+		_, _ = output.WriteString(yellow("%c block scalar style change, only trailing line breaks differ\n", MODIFICATION))
+		_, _ = output.WriteString(red("%s", createStringWithPrefix("- ", scalarStyle(detail.From), report.Indent)))
+		_, _ = output.WriteString(green("%s", createStringWithPrefix("+ ", scalarStyle(detail.To), report.Indent)))
+
 	case fromType == "string" && toType == "string":
 		// delegate to special string output
 		report.writeStringDiff(
@@ -233,26 +621,40 @@ func (report *HumanReport) generateHumanDetailOutputModification(detail Detail)
 		)
 
 	case fromType == "binary" && toType == "binary":
-		from, err := base64.StdEncoding.DecodeString(detail.From.Value)
+		from, err := decodeBinary(detail.From.Value)
 		if err != nil {
 			return "", err
 		}
 
-		to, err := base64.StdEncoding.DecodeString(detail.To.Value)
+		to, err := decodeBinary(detail.To.Value)
 		if err != nil {
 			return "", err
 		}
 
+		// start with a summary of the binary data, optionally followed by a
+		// hex dump of the content
+		fromText, toText := binarySummary(from), binarySummary(to)
+		if !report.OmitBinaryHexDump {
+			fromText = fmt.Sprintf("%s\n%s", fromText, hex.Dump(from))
+			toText = fmt.Sprintf("%s\n%s", toText, hex.Dump(to))
+		}
+
 		_, _ = output.WriteString(yellow("%c content change\n", MODIFICATION))
-		if report.PrefixMultiline {
+		switch {
+		case report.OmitBinaryHexDump:
+			_, _ = output.WriteString(red("%s", createStringWithPrefix("- ", fromText, report.Indent)))
+			_, _ = output.WriteString(green("%s", createStringWithPrefix("+ ", toText, report.Indent)))
+
+		case report.PrefixMultiline:
 			report.writeTextBlocks(&output, 0,
-				red("%s", createStringWithContinuousPrefix("- ", hex.Dump(from), report.Indent)),
-				green("%s", createStringWithContinuousPrefix("+ ", hex.Dump(to), report.Indent)),
+				red("%s", createStringWithContinuousPrefix("- ", fromText, report.Indent)),
+				green("%s", createStringWithContinuousPrefix("+ ", toText, report.Indent)),
 			)
-		} else {
+
+		default:
 			report.writeTextBlocks(&output, 0,
-				red("%s", createStringWithPrefix("- ", hex.Dump(from), report.Indent)),
-				green("%s", createStringWithPrefix("+ ", hex.Dump(to), report.Indent)),
+				red("%s", createStringWithPrefix("- ", fromText, report.Indent)),
+				green("%s", createStringWithPrefix("+ ", toText, report.Indent)),
 			)
 		}
 
@@ -270,12 +672,32 @@ func (report *HumanReport) generateHumanDetailOutputModification(detail Detail)
 			))
 		}
 
-		from, err := yamlString(detail.From)
+		// short scalar values (i.e. numbers, or booleans) with only a small
+		// difference get the same character highlighting as strings
+		if isSingleLineScalar(detail.From) && isSingleLineScalar(detail.To) &&
+			isMinorChange(detail.From.Value, detail.To.Value, report.MinorChangeThreshold) {
+			diffs := diffmatchpatch.New().DiffMain(detail.From.Value, detail.To.Value, false)
+			_, _ = output.WriteString(highlightRemovals(diffs, report.Indent))
+			_, _ = output.WriteString(highlightAdditions(diffs, report.Indent))
+			break
+		}
+
+		fromNode, err := withRenderedTags(detail.From)
 		if err != nil {
 			return "", err
 		}
 
-		to, err := yamlString(detail.To)
+		toNode, err := withRenderedTags(detail.To)
+		if err != nil {
+			return "", err
+		}
+
+		from, err := yamlString(fromNode)
+		if err != nil {
+			return "", err
+		}
+
+		to, err := yamlString(toNode)
 		if err != nil {
 			return "", err
 		}
@@ -287,6 +709,50 @@ func (report *HumanReport) generateHumanDetailOutputModification(detail Detail)
 	return output.String(), nil
 }
 
+func (report *HumanReport) generateHumanDetailOutputMove(detail Detail) (string, error) {
+	return report.moveOutput(detail, "from"), nil
+}
+
+// moveOutput renders a move with the provided direction of the previous
+// location, for example "from" or "back to"
+func (report *HumanReport) moveOutput(detail Detail, direction string) string {
+	// Documents are referred to by their name, map entries by their path
+	if !strings.HasPrefix(detail.From.Value, "/") {
+		return yellow("%c document moved %s %s\n", MOVED, direction, detail.From.Value)
+	}
+
+	var location = detail.From.Value
+	if path, err := ytbx.ParseGoPatchStylePathString(location); err == nil {
+		if style, err := pathStyleFor(report.PathStyle, report.UseGoPatchPaths); err == nil {
+			location = style.RenderPath(&path)
+		}
+	}
+
+	return yellow("%c moved %s %s\n", MOVED, direction, location)
+}
+
+func (report *HumanReport) generateHumanDetailOutputDirectives(detail Detail) (string, error) {
+	var output bytes.Buffer
+	var lines = func(node *yamlv3.Node) []string {
+		if directives := directiveLines(node); len(directives) > 0 {
+			return directives
+		}
+
+		return []string{"(no directives)"}
+	}
+
+	_, _ = output.WriteString(yellow("%c directive change\n", DIRECTIVECHANGE))
+	for _, line := range lines(detail.From) {
+		_, _ = output.WriteString(red("%s", createStringWithPrefix("- ", line, report.Indent)))
+	}
+
+	for _, line := range lines(detail.To) {
+		_, _ = output.WriteString(green("%s", createStringWithPrefix("+ ", line, report.Indent)))
+	}
+
+	return output.String(), nil
+}
+
 func (report *HumanReport) generateHumanDetailOutputOrderchange(detail Detail) (string, error) {
 	var output bytes.Buffer
 
@@ -599,6 +1065,27 @@ Serial Number: %d (%#x)
 	)
 }
 
+// documentsString renders the node using the provided function, a document
+// node with multiple documents is rendered document by document so that each
+// document starts with its own document start marker
+func documentsString(node *yamlv3.Node, render func(interface{}) (string, error)) (string, error) {
+	if node == nil || node.Kind != yamlv3.DocumentNode || len(node.Content) < 2 {
+		return render(node)
+	}
+
+	var buf strings.Builder
+	for _, content := range node.Content {
+		str, err := render(&yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{content}})
+		if err != nil {
+			return "", err
+		}
+
+		buf.WriteString(str)
+	}
+
+	return buf.String(), nil
+}
+
 func yamlString(input interface{}) (string, error) {
 	if input == nil {
 		return "<nil>", nil
@@ -628,6 +1115,14 @@ func isMinorChange(from string, to string, minorChangeThreshold float64) bool {
 	return float64(levenshteinDistance)/float64(referenceLength) < minorChangeThreshold
 }
 
+func isSingleLineScalar(node *yamlv3.Node) bool {
+	return node != nil &&
+		node.Kind == yamlv3.ScalarNode &&
+		node.Tag != "!!null" &&
+		node.Value != "" &&
+		!strings.Contains(node.Value, "\n")
+}
+
 func isMultiLine(from string, to string) bool {
 	return strings.Contains(from, "\n") || strings.Contains(to, "\n")
 }
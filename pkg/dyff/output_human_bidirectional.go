@@ -0,0 +1,88 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"strings"
+)
+
+// invertedDetail returns the detail as it would be reported when comparing
+// the inputs the other way around, i.e. additions become removals and the
+// other way around, and the from and to values are swapped
+func invertedDetail(detail Detail) Detail {
+	switch detail.Kind {
+	case ADDITION:
+		return Detail{Kind: REMOVAL, From: detail.To, To: detail.From}
+
+	case REMOVAL:
+		return Detail{Kind: ADDITION, From: detail.To, To: detail.From}
+
+	default:
+		return Detail{Kind: detail.Kind, From: detail.To, To: detail.From}
+	}
+}
+
+// generateHumanDetailOutputInverted renders the detail the other way around,
+// a move is rendered as a move back to the previous location, since the path
+// of the difference is the location after the move
+func (report *HumanReport) generateHumanDetailOutputInverted(detail Detail) (string, error) {
+	if detail.Kind == MOVED {
+		return report.moveOutput(detail, "back to"), nil
+	}
+
+	return report.generateHumanDetailOutput(invertedDetail(detail))
+}
+
+// writeBidirectional writes the details of a difference twice: from the from
+// to the to input (e.g. the upgrade), and the other way around (e.g. the
+// rollback), each with a label line
+func (report *HumanReport) writeBidirectional(output stringWriter, diff Diff, indent int, blocks []string) error {
+	inverted := make([]string, len(diff.Details))
+	for i, detail := range diff.Details {
+		generatedOutput, err := report.generateHumanDetailOutputInverted(detail)
+		if err != nil {
+			return err
+		}
+
+		inverted[i] = generatedOutput
+	}
+
+	for _, section := range []struct {
+		label  string
+		blocks []string
+	}{
+		{"from → to", blocks},
+		{"to → from", inverted},
+	} {
+		// the empty line after the blocks is only needed after both sections
+		blocks := make([]string, len(section.blocks))
+		for i, block := range section.blocks {
+			blocks[i] = strings.TrimRight(block, " \n")
+		}
+
+		_, _ = output.WriteString(strings.Repeat(" ", indent))
+		_, _ = output.WriteString(dimgray("%s", section.label))
+		_, _ = output.WriteString("\n")
+		report.writeTextBlocks(output, indent+report.Indent, blocks...)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gonvenience/text"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// DocumentValuesStyle defines how the content of added or removed documents
+// is shown in the human readable report
+type DocumentValuesStyle string
+
+// Supported styles of the content of added or removed documents
+const (
+	// DocumentValuesFull shows the complete content of the documents (default)
+	DocumentValuesFull DocumentValuesStyle = "full"
+
+	// DocumentValuesSummary only shows one line per document with its name
+	// (e.g. the kind and name of a Kubernetes resource) and size
+	DocumentValuesSummary DocumentValuesStyle = "summary"
+
+	// DocumentValuesKeys shows the summary line and the top-level keys of
+	// each document
+	DocumentValuesKeys DocumentValuesStyle = "keys"
+)
+
+// DocumentValuesStyleNames returns the names of all supported styles
+func DocumentValuesStyleNames() []string {
+	return []string{string(DocumentValuesFull), string(DocumentValuesSummary), string(DocumentValuesKeys)}
+}
+
+func isDocumentValuesStyle(style DocumentValuesStyle) bool {
+	switch style {
+	case DocumentValuesFull, DocumentValuesSummary, DocumentValuesKeys, "":
+		return true
+	}
+
+	return false
+}
+
+// summarizesDocuments returns whether added or removed documents are shown as
+// an overview rather than with their full content
+func (report *HumanReport) summarizesDocuments() bool {
+	return report.DocumentValues == DocumentValuesSummary || report.DocumentValues == DocumentValuesKeys
+}
+
+// documentsOverview returns the summary of the added or removed documents, one
+// line per document, and the top-level keys of each document depending on
+// the configured style
+func (report *HumanReport) documentsOverview(node *yamlv3.Node, colorize func(string, ...interface{}) string) (string, error) {
+	var buf strings.Builder
+	for i, document := range node.Content {
+		name, err := k8sItem.Name(document)
+		if err != nil {
+			name = fmt.Sprintf("document #%d", i+1)
+		}
+
+		content, err := yamlString(&yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{document}})
+		if err != nil {
+			return "", err
+		}
+
+		lines := strings.Count(strings.TrimRight(content, "\n"), "\n") + 1
+		buf.WriteString(colorize("%s (%s)", name, text.Plural(lines, "line")))
+		buf.WriteString("\n")
+
+		if report.DocumentValues != DocumentValuesKeys {
+			continue
+		}
+
+		if document = followAlias(document); document.Kind == yamlv3.MappingNode {
+			for j := 0; j < len(document.Content); j += 2 {
+				buf.WriteString(strings.Repeat(" ", report.Indent))
+				buf.WriteString(colorize("%s", document.Content[j].Value))
+				buf.WriteString("\n")
+			}
+		}
+	}
+
+	return buf.String(), nil
+}
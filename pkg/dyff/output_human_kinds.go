@@ -0,0 +1,141 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"sort"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// noKind is the group of differences of documents without a kind, and file
+// level differences that do not refer to specific documents
+const noKind = "Other"
+
+// kindGroup is the list of differences of documents of one resource kind
+type kindGroup struct {
+	kind  string
+	diffs []Diff
+}
+
+// groupDiffsByKind groups the differences by the kind of the documents they
+// belong to (e.g. all Deployments, then all Services), the groups are sorted
+// alphabetically with documents without a kind last. Document additions or
+// removals are split up so that each document is listed with its kind.
+func groupDiffsByKind(report Report) []kindGroup {
+	var byKind = map[string][]Diff{}
+	var add = func(kind string, diff Diff) {
+		byKind[kind] = append(byKind[kind], diff)
+	}
+
+	for _, diff := range report.Diffs {
+		if diff.Path != nil && len(diff.Path.PathElements) > 0 {
+			add(documentKind(report, diff.Path), diff)
+			continue
+		}
+
+		for _, detail := range diff.Details {
+			var node = detail.From
+			if detail.Kind == ADDITION {
+				node = detail.To
+			}
+
+			if node == nil || node.Kind != yamlv3.DocumentNode || (detail.Kind != ADDITION && detail.Kind != REMOVAL) {
+				var kind = noKind
+				if diff.Path != nil {
+					kind = documentKind(report, diff.Path)
+				}
+
+				add(kind, Diff{Path: diff.Path, Details: []Detail{detail}, FromSource: diff.FromSource, ToSource: diff.ToSource})
+				continue
+			}
+
+			var documents = map[string]*yamlv3.Node{}
+			var order []string
+			for _, document := range node.Content {
+				kind := resourceKind(document)
+				if _, ok := documents[kind]; !ok {
+					documents[kind] = &yamlv3.Node{Kind: yamlv3.DocumentNode}
+					order = append(order, kind)
+				}
+
+				documents[kind].Content = append(documents[kind].Content, document)
+			}
+
+			for _, kind := range order {
+				split := Detail{Kind: detail.Kind}
+				if detail.Kind == ADDITION {
+					split.To = documents[kind]
+				} else {
+					split.From = documents[kind]
+				}
+
+				add(kind, Diff{Path: diff.Path, Details: []Detail{split}, FromSource: diff.FromSource, ToSource: diff.ToSource})
+			}
+		}
+	}
+
+	var kinds = make([]string, 0, len(byKind))
+	for kind := range byKind {
+		kinds = append(kinds, kind)
+	}
+
+	sort.Slice(kinds, func(i, j int) bool {
+		if (kinds[i] == noKind) != (kinds[j] == noKind) {
+			return kinds[j] == noKind
+		}
+
+		return kinds[i] < kinds[j]
+	})
+
+	var result = make([]kindGroup, len(kinds))
+	for i, kind := range kinds {
+		result[i] = kindGroup{kind: kind, diffs: byKind[kind]}
+	}
+
+	return result
+}
+
+// documentKind returns the kind of the document the path refers to
+func documentKind(report Report, path *ytbx.Path) string {
+	var documents = report.From.Documents
+	if path.Root != nil {
+		documents = path.Root.Documents
+	}
+
+	if path.DocumentIdx >= len(documents) {
+		return noKind
+	}
+
+	return resourceKind(documents[path.DocumentIdx])
+}
+
+// resourceKind returns the value of the kind field of the document
+func resourceKind(document *yamlv3.Node) string {
+	if root := followAlias(documentRoot(document)); root.Kind == yamlv3.MappingNode {
+		if kind, ok := findValueByKey(root, "kind"); ok && kind.Kind == yamlv3.ScalarNode && kind.Value != "" {
+			return kind.Value
+		}
+	}
+
+	return noKind
+}
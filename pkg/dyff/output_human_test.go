@@ -21,7 +21,9 @@
 package dyff_test
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -29,6 +31,7 @@ import (
 	. "github.com/gonvenience/bunt"
 
 	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
 
 	"github.com/homeport/dyff/pkg/dyff"
 )
@@ -51,6 +54,49 @@ some.yaml.structure.string
     - fOObar?
     + Foobar!
 
+`))
+		})
+
+		It("should show each difference in both directions if configured", func() {
+			reporter := dyff.HumanReport{
+				Report: dyff.Report{Diffs: []dyff.Diff{
+					singleDiff("/spec/replicas", dyff.MODIFICATION, 1, 2),
+					singleDiff("/spec/feature", dyff.MOVED, "/spec/flag", yml("true")),
+					singleDiff("/spec/list", dyff.ADDITION, nil, list("[foo]")),
+				}},
+				Indent:        2,
+				OmitHeader:    true,
+				NoTableStyle:  true,
+				Bidirectional: true,
+			}
+
+			var buf bytes.Buffer
+			Expect(reporter.WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(BeEquivalentTo(`
+spec.replicas
+  from → to
+    ± value change
+      - 1
+      + 2
+  to → from
+    ± value change
+      - 2
+      + 1
+
+spec.feature
+  from → to
+    → moved from spec.flag
+  to → from
+    → moved back to spec.flag
+
+spec.list
+  from → to
+    + one list entry added:
+      - foo
+  to → from
+    - one list entry removed:
+      - foo
+
 `))
 		})
 
@@ -150,6 +196,18 @@ input: |+
 				false,
 			)
 		})
+
+		It("should highlight the changed characters of a minor number change", func() {
+			out := humanDiff(singleDiff("/resources/cpu", dyff.MODIFICATION, 100, 1000))
+			Expect(RemoveAllEscapeSequences(out)).To(BeEquivalentTo(`
+resources.cpu
+  ± value change
+    - 100
+    + 1000
+
+`))
+			Expect(out).To(ContainSubstring("\x1b[1;"))
+		})
 	})
 
 	Context("human path rendering", func() {
@@ -246,4 +304,566 @@ variables.ROUTER_TLS_PEM.options
 			)
 		})
 	})
+
+	Context("reporting differences of binary data", func() {
+		BeforeEach(func() {
+			SetColorSettings(OFF, OFF)
+		})
+
+		AfterEach(func() {
+			SetColorSettings(AUTO, AUTO)
+		})
+
+		It("should ignore differences in the formatting of the base64 text", func() {
+			from := yml("data: !!binary Zm9vYmFy\n")
+			to := yml("data: !!binary |\n  Zm9v\n  YmFy\n")
+
+			result, err := compare(from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(BeEmpty())
+		})
+
+		It("should only show a summary of the binary data if requested", func() {
+			from := yml("data: !!binary Zm9vYmFy\n")
+			to := yml("data: !!binary Zm9vYmF6\n")
+
+			result, err := compare(from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(HaveLen(1))
+
+			var buf bytes.Buffer
+			reporter := dyff.HumanReport{Report: dyff.Report{Diffs: result}, Indent: 2, OmitHeader: true, OmitBinaryHexDump: true}
+			Expect(reporter.WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(BeEquivalentTo(`
+data
+  ± content change
+    - 6 bytes, sha256:c3ab8ff13720
+    + 6 bytes, sha256:798f012674b5
+
+`))
+		})
+
+		It("should show a summary of added binary data instead of the base64 text", func() {
+			from := yml("config: {}\n")
+			to := yml("config: {data: !!binary Zm9vYmFy}\n")
+
+			result, err := compare(from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(HaveLen(1))
+			Expect(humanDiff(result[0])).To(ContainSubstring("<binary data, 6 bytes, sha256:c3ab8ff13720>"))
+			Expect(humanDiff(result[0])).ToNot(ContainSubstring("Zm9vYmFy"))
+		})
+
+		It("should show added or removed binary data that cannot be decoded as it is", func() {
+			from := yml("config: {old: !!binary '%%%not-base64'}\n")
+			to := yml("config: {new: !!binary '%%%not-base64'}\n")
+
+			result, err := compare(from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(HaveLen(1))
+			Expect(humanDiff(result[0])).To(ContainSubstring("%%%not-base64"))
+		})
+	})
+
+	Context("reporting unchanged context keys", func() {
+		BeforeEach(func() {
+			SetColorSettings(OFF, OFF)
+		})
+
+		AfterEach(func() {
+			SetColorSettings(AUTO, AUTO)
+		})
+
+		It("should show the closest unchanged sibling keys of modified entries", func() {
+			from := yml("spec: {name: foo, labels: {a: b}, image: app:1, replicas: 1, other: x}")
+			to := yml("spec: {name: foo, labels: {a: b}, image: app:2, replicas: 1, other: y}")
+
+			var buf bytes.Buffer
+			reporter := dyff.HumanReport{
+				Report: dyff.Report{
+					From:  ytbx.InputFile{Documents: []*yamlv3.Node{from}},
+					To:    ytbx.InputFile{Documents: []*yamlv3.Node{to}},
+					Diffs: []dyff.Diff{singleDiff("/spec/image", dyff.MODIFICATION, "app:1", "app:2")},
+				},
+				Indent:      2,
+				OmitHeader:  true,
+				ContextKeys: 2,
+			}
+
+			Expect(reporter.WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(BeEquivalentTo(`
+spec.image
+  ± value change
+    - app:1
+    + app:2
+  labels: {…}
+  replicas: 1
+
+`))
+		})
+	})
+
+	Context("reporting paths in custom styles", func() {
+		BeforeEach(func() {
+			SetColorSettings(OFF, OFF)
+			dyff.RegisterPathStyle("jq", dyff.PathStyleFunc(func(path *ytbx.Path) string {
+				var result strings.Builder
+				for _, element := range path.PathElements {
+					switch {
+					case element.Key != "":
+						fmt.Fprintf(&result, "[] | select(.%s == %q)", element.Key, element.Name)
+					case element.Name != "":
+						fmt.Fprintf(&result, ".%s", element.Name)
+					default:
+						fmt.Fprintf(&result, "[%d]", element.Idx)
+					}
+				}
+
+				return result.String()
+			}))
+		})
+
+		AfterEach(func() {
+			SetColorSettings(AUTO, AUTO)
+			dyff.UnregisterPathStyle("jq")
+		})
+
+		It("should use the registered path style for the paths of the differences", func() {
+			from := yml("spec: {containers: [{name: app, image: app:1}]}")
+			to := yml("spec: {containers: [{name: app, image: app:2}]}")
+
+			result, err := compare(from, to)
+			Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+			reporter := dyff.HumanReport{
+				Report:     dyff.Report{Diffs: result},
+				Indent:     2,
+				OmitHeader: true,
+				PathStyle:  "jq",
+			}
+
+			Expect(reporter.WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(HavePrefix("\n.spec.containers[] | select(.name == \"app\").image\n"))
+		})
+
+		It("should fail for unknown path styles", func() {
+			reporter := dyff.HumanReport{PathStyle: "unknown"}
+			Expect(reporter.WriteReport(&bytes.Buffer{})).To(MatchError(ContainSubstring(`unknown path style "unknown"`)))
+			Expect(dyff.PathStyleNames()).To(Equal([]string{"dot", "go-patch", "jq"}))
+		})
+	})
+
+	Context("grouping differences by kind", func() {
+		BeforeEach(func() {
+			SetColorSettings(OFF, OFF)
+		})
+
+		AfterEach(func() {
+			SetColorSettings(AUTO, AUTO)
+		})
+
+		It("should list the differences per resource kind with a heading", func() {
+			from, err := dyff.LoadDocuments([]byte(`---
+apiVersion: v1
+kind: Service
+metadata: {name: web}
+spec: {port: 80}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web}
+spec: {replicas: 1}
+`))
+			Expect(err).ToNot(HaveOccurred())
+
+			to, err := dyff.LoadDocuments([]byte(`---
+apiVersion: v1
+kind: Service
+metadata: {name: web}
+spec: {port: 8080}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web}
+spec: {replicas: 2}
+`))
+			Expect(err).ToNot(HaveOccurred())
+
+			report, err := dyff.CompareInputFiles(ytbx.InputFile{Documents: from}, ytbx.InputFile{Documents: to})
+			Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+			reporter := dyff.HumanReport{Report: report, Indent: 2, OmitHeader: true, GroupByKind: true}
+			Expect(reporter.WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(BeEquivalentTo(`
+Deployment (one difference)
+═══════════════════════════
+
+spec.replicas  (apps/v1/Deployment/web)
+  ± value change
+    - 1
+    + 2
+
+Service (one difference)
+════════════════════════
+
+spec.port  (v1/Service/web)
+  ± value change
+    - 80
+    + 8080
+
+`))
+		})
+	})
+
+	Context("header styles", func() {
+		var report dyff.Report
+
+		BeforeEach(func() {
+			SetColorSettings(OFF, OFF)
+
+			var err error
+			report, err = dyff.CompareInputFiles(
+				ytbx.InputFile{Location: "from.yml", Documents: []*yamlv3.Node{yml(`{name: foo}`)}},
+				ytbx.InputFile{Location: "to.yml", Documents: []*yamlv3.Node{yml(`{name: bar}`)}},
+			)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			SetColorSettings(AUTO, AUTO)
+		})
+
+		It("should write a single line header in compact style", func() {
+			var buf bytes.Buffer
+			reporter := dyff.HumanReport{Report: report, Indent: 2, HeaderStyle: dyff.HeaderCompact}
+			Expect(reporter.WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(HavePrefix("dyff between from.yml and to.yml returned one difference\n\nname\n"))
+		})
+
+		It("should write the banner by default, and no header if omitted", func() {
+			var buf bytes.Buffer
+			reporter := dyff.HumanReport{Report: report, Indent: 2}
+			Expect(reporter.WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring("   _| |_   _ / _|/ _|  between from.yml"))
+
+			for _, reporter := range []dyff.HumanReport{
+				{Report: report, Indent: 2, HeaderStyle: dyff.HeaderNone},
+				{Report: report, Indent: 2, HeaderStyle: dyff.HeaderCompact, OmitHeader: true},
+			} {
+				buf.Reset()
+				Expect(reporter.WriteReport(&buf)).To(Succeed())
+				Expect(buf.String()).To(HavePrefix("\nname\n"))
+			}
+		})
+
+		It("should fail for unknown header styles", func() {
+			reporter := dyff.HumanReport{Report: report, Indent: 2, HeaderStyle: "fancy"}
+			Expect(reporter.WriteReport(&bytes.Buffer{})).To(MatchError(ContainSubstring(`unknown header style "fancy"`)))
+		})
+	})
+
+	Context("values of added or removed documents", func() {
+		var report dyff.Report
+
+		BeforeEach(func() {
+			SetColorSettings(OFF, OFF)
+
+			from, err := dyff.LoadDocuments([]byte(`---
+apiVersion: v1
+kind: Service
+metadata: {name: web}
+spec: {port: 80}
+`))
+			Expect(err).ToNot(HaveOccurred())
+
+			to, err := dyff.LoadDocuments([]byte(`---
+apiVersion: v1
+kind: Service
+metadata: {name: web}
+spec: {port: 80}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web}
+spec:
+  replicas: 2
+  template: {}
+`))
+			Expect(err).ToNot(HaveOccurred())
+
+			report, err = dyff.CompareInputFiles(ytbx.InputFile{Documents: from}, ytbx.InputFile{Documents: to})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			SetColorSettings(AUTO, AUTO)
+		})
+
+		It("should only show the name and size of documents in summary style", func() {
+			var buf bytes.Buffer
+			reporter := dyff.HumanReport{Report: report, Indent: 2, OmitHeader: true, DocumentValues: dyff.DocumentValuesSummary}
+			Expect(reporter.WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(BeEquivalentTo(`
+(file level)
+  + one document added:
+    apps/v1/Deployment/web (eight lines)
+
+`))
+		})
+
+		It("should also list the top-level keys of documents in keys style", func() {
+			var buf bytes.Buffer
+			reporter := dyff.HumanReport{Report: report, Indent: 2, OmitHeader: true, DocumentValues: dyff.DocumentValuesKeys}
+			Expect(reporter.WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(BeEquivalentTo(`
+(file level)
+  + one document added:
+    apps/v1/Deployment/web (eight lines)
+      apiVersion
+      kind
+      metadata
+      spec
+
+`))
+		})
+
+		It("should fail for unknown styles", func() {
+			reporter := dyff.HumanReport{Report: report, Indent: 2, DocumentValues: "none"}
+			Expect(reporter.WriteReport(&bytes.Buffer{})).To(MatchError(ContainSubstring(`unknown document values style "none"`)))
+		})
+	})
+
+	Context("rendering a single difference", func() {
+		BeforeEach(func() {
+			SetColorSettings(OFF, OFF)
+		})
+
+		AfterEach(func() {
+			SetColorSettings(AUTO, AUTO)
+		})
+
+		It("should render the difference like in the report, but without header", func() {
+			report, err := dyff.CompareInputFiles(
+				ytbx.InputFile{Location: "from.yml", Documents: []*yamlv3.Node{yml(`{name: foo, size: 1}`)}},
+				ytbx.InputFile{Location: "to.yml", Documents: []*yamlv3.Node{yml(`{name: bar, size: 1}`)}},
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Diffs).To(HaveLen(1))
+
+			reporter := dyff.HumanReport{Report: report, Indent: 2, ContextKeys: 1}
+			Expect(reporter.RenderDiff(report.Diffs[0])).To(Equal(`name
+  ± value change
+    - foo
+    + bar
+  size: 1
+`))
+		})
+
+		It("should render differences without the report input files", func() {
+			var renderer dyff.DiffRenderer = &dyff.DiffSyntaxReport{
+				PathPrefix:            "@@",
+				RootDescriptionPrefix: "#",
+				ChangeTypePrefix:      "!",
+				HumanReport:           dyff.HumanReport{Indent: 0},
+			}
+
+			content := singleDiff("/some/yaml/structure/string", dyff.MODIFICATION, "foo", "bar")
+			Expect(renderer.RenderDiff(content)).To(Equal(`@@ some.yaml.structure.string @@
+! ± value change
+- foo
++ bar
+`))
+		})
+
+		It("should fail for unknown path styles", func() {
+			reporter := dyff.HumanReport{PathStyle: "fancy"}
+			_, err := reporter.RenderDiff(singleDiff("/foo", dyff.MODIFICATION, "foo", "bar"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("paths relative to a common prefix", func() {
+		BeforeEach(func() {
+			SetColorSettings(OFF, OFF)
+		})
+
+		AfterEach(func() {
+			SetColorSettings(AUTO, AUTO)
+		})
+
+		It("should trim the prefix from paths below it and keep all other paths", func() {
+			from := yml(`---
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1
+`)
+
+			to := yml(`---
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:2
+`)
+
+			report, err := dyff.CompareInputFiles(
+				ytbx.InputFile{Location: "from.yml", Documents: []*yamlv3.Node{from}},
+				ytbx.InputFile{Location: "to.yml", Documents: []*yamlv3.Node{to}},
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+			reporter := dyff.HumanReport{Report: report, Indent: 2, OmitHeader: true, UseGoPatchPaths: true, RelativeTo: "/spec/template"}
+			Expect(reporter.WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(HavePrefix("\npaths below /spec/template are shown relative to it\n"))
+			Expect(buf.String()).To(ContainSubstring("\n/spec/replicas\n"))
+			Expect(buf.String()).To(ContainSubstring("\n/spec/containers/name=app/image\n"))
+
+			// structured data of the report is not affected
+			Expect(report.Diffs[1].Path.String()).To(Equal("/spec/template/spec/containers/name=app/image"))
+		})
+	})
+
+	Context("summarizing version changes", func() {
+		BeforeEach(func() {
+			SetColorSettings(OFF, OFF)
+		})
+
+		AfterEach(func() {
+			SetColorSettings(AUTO, AUTO)
+		})
+
+		It("should list image tag and version changes in a table before the differences", func() {
+			from := yml(`---
+version: 1.0.0
+replicas: 1
+containers:
+- name: app
+  image: registry.io/app:1.24
+- name: sidecar
+  image: envoy:v1
+`)
+
+			to := yml(`---
+version: 1.1.0
+replicas: 2
+containers:
+- name: app
+  image: registry.io/app:1.25
+- name: sidecar
+  image: proxy:v1
+`)
+
+			report, err := dyff.CompareInputFiles(
+				ytbx.InputFile{Documents: []*yamlv3.Node{from}},
+				ytbx.InputFile{Documents: []*yamlv3.Node{to}},
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			bumps := report.VersionBumps()
+			Expect(bumps).To(HaveLen(2))
+			Expect(bumps[0].Path.String()).To(Equal("/version"))
+			Expect(bumps[1].Path.String()).To(Equal("/containers/name=app/image"))
+
+			var buf bytes.Buffer
+			reporter := dyff.HumanReport{Report: report.Filter("/version"), Indent: 2, OmitHeader: true, VersionSummary: true}
+			Expect(reporter.WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(BeEquivalentTo(`
+Version changes (1)
+═══════════════════
+document #1  version  1.0.0 → 1.1.0
+
+version
+  ± value change
+    - 1.0.0
+    + 1.1.0
+
+`))
+		})
+
+		It("should show the number of changes per kind, document, and top-level path before the differences", func() {
+			report, err := dyff.CompareInputFiles(
+				ytbx.InputFile{Documents: []*yamlv3.Node{yml(`{"version": "1.0.0", "spec": {"a": 1, "b": 1}}`)}},
+				ytbx.InputFile{Documents: []*yamlv3.Node{yml(`{"version": "1.1.0", "spec": {"a": 2, "c": 1}}`)}},
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+			reporter := dyff.HumanReport{Report: report.Filter("/version"), Indent: 2, OmitHeader: true, Summary: true}
+			Expect(reporter.WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(HavePrefix(`
+Summary
+═══════
+one modification across one document
+`))
+
+			Expect(report.Statistics().SortedPaths()).To(Equal([]dyff.StatisticsEntry{{Name: "/spec", Count: 3}, {Name: "/version", Count: 1}}))
+		})
+
+		It("should not write trailing whitespace in the summary", func() {
+			report, err := dyff.CompareInputFiles(
+				ytbx.InputFile{Documents: multiDoc(`{name: one, spec: {a: 1}}`, `{name: two, version: "1.0.0"}`)},
+				ytbx.InputFile{Documents: multiDoc(`{name: one, spec: {a: 2}}`, `{name: two, version: "1.1.0"}`)},
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+			reporter := dyff.HumanReport{Report: report, Indent: 2, OmitHeader: true, Summary: true}
+			Expect(reporter.WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring("per document:\n"))
+			Expect(buf.String()).To(ContainSubstring("per top-level path:\n"))
+
+			for _, line := range strings.Split(buf.String(), "\n") {
+				Expect(line).ToNot(MatchRegexp(`\s$`))
+			}
+		})
+	})
+
+	Context("reporting differences of custom tags", func() {
+		BeforeEach(func() {
+			SetColorSettings(OFF, OFF)
+			dyff.RegisterTagRenderer("!vault", func(node *yamlv3.Node) (string, error) {
+				return fmt.Sprintf("<vault reference %s>", node.Value), nil
+			})
+		})
+
+		AfterEach(func() {
+			SetColorSettings(AUTO, AUTO)
+			dyff.UnregisterTagRenderer("!vault")
+		})
+
+		It("should use the registered renderer for modified values", func() {
+			from := yml(`{"password": !vault "secret/a"}`)
+			to := yml(`{"password": !vault "secret/b"}`)
+
+			result, err := compare(from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(HaveLen(1))
+			Expect(humanDiff(result[0])).To(BeEquivalentTo(`
+password
+  ± value change
+    - <vault reference secret/a>
+    + <vault reference secret/b>
+
+`))
+		})
+
+		It("should use the registered renderer for added values", func() {
+			from := yml(`{"config": {}}`)
+			to := yml(`{"config": {"password": !vault "secret/a"}}`)
+
+			result, err := compare(from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(HaveLen(1))
+			Expect(humanDiff(result[0])).To(ContainSubstring(`password: "<vault reference secret/a>"`))
+		})
+	})
 })
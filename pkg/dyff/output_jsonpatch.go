@@ -0,0 +1,385 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// JSONPatchReport is a reporter that writes a JSON Patch (RFC 6902) document,
+// which transforms the from input file into the to input file, for example
+// using kubectl patch --type=json.
+type JSONPatchReport struct {
+	Report
+}
+
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	From  string          `json:"from,omitempty"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// WriteReport writes the JSON Patch operations to the provided writer
+func (report *JSONPatchReport) WriteReport(out io.Writer) error {
+	data, err := report.AsJSONPatch()
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(out, "%s\n", data)
+	return err
+}
+
+// RenderDiff renders the JSON Patch operations of the provided difference, the
+// report input files are used to look up list indices and replaced lists
+func (report *JSONPatchReport) RenderDiff(diff Diff) (string, error) {
+	data, err := Report{From: report.From, To: report.To, Diffs: []Diff{diff}}.AsJSONPatch()
+	if err != nil {
+		return "", err
+	}
+
+	return string(data) + "\n", nil
+}
+
+var _ DiffRenderer = &JSONPatchReport{}
+
+// AsJSONPatch returns a JSON Patch (RFC 6902) document, which transforms the
+// from input file into the to input file. Entries of named-entry lists are
+// addressed by their index in the from input file. Lists with removed entries
+// or order changes are replaced as a whole. Since JSON Patch works on one
+// document, this is only supported for reports of single document files.
+func (r Report) AsJSONPatch() ([]byte, error) {
+	if len(r.From.Documents) > 1 || len(r.To.Documents) > 1 {
+		return nil, fmt.Errorf("JSON Patch operations can only be created for single document files")
+	}
+
+	// Lists that are replaced as a whole already contain all changes of their
+	// entries, so differences below these lists must be skipped
+	var replaced []string
+	for _, diff := range r.Diffs {
+		if diff.Path != nil && isListReplacement(diff) {
+			replaced = append(replaced, diff.Path.ToGoPatchStyle())
+		}
+	}
+
+	// Positional list entry removals and additions are expressed by replacing
+	// the whole list once
+	var positional = map[string]*ytbx.Path{}
+	for _, diff := range r.Diffs {
+		if listPath, ok := r.positionalListChange(diff); ok {
+			if _, seen := positional[listPath.ToGoPatchStyle()]; !seen {
+				positional[listPath.ToGoPatchStyle()] = listPath
+				replaced = append(replaced, listPath.ToGoPatchStyle())
+			}
+		}
+	}
+
+	var isBelowReplacedList = func(diff Diff) bool {
+		for _, path := range replaced {
+			if strings.HasPrefix(diff.Path.ToGoPatchStyle(), path+"/") {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var operations = []jsonPatchOperation{}
+	for _, diff := range r.Diffs {
+		if diff.Path == nil {
+			return nil, fmt.Errorf("document additions or removals cannot be expressed using JSON Patch")
+		}
+
+		if listPath, ok := r.positionalListChange(diff); ok && positional[listPath.ToGoPatchStyle()] != nil {
+			delete(positional, listPath.ToGoPatchStyle())
+			op, err := r.listReplacement(listPath)
+			if err != nil {
+				return nil, err
+			}
+
+			operations = append(operations, op)
+			continue
+		}
+
+		if isBelowReplacedList(diff) {
+			continue
+		}
+
+		ops, err := r.jsonPatchOperations(diff)
+		if err != nil {
+			return nil, err
+		}
+
+		operations = append(operations, ops...)
+	}
+
+	data, err := json.MarshalIndent(operations, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON Patch operations: %w", err)
+	}
+
+	return data, nil
+}
+
+func isListReplacement(diff Diff) bool {
+	for _, detail := range diff.Details {
+		if detail.Kind == ORDERCHANGE || (detail.Kind == REMOVAL && detail.From.Kind == yamlv3.SequenceNode) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jsonPatchMove returns the operation that moves the map entry from the
+// previous path to the path of the difference
+func (r Report) jsonPatchMove(diff Diff, detail Detail) ([]jsonPatchOperation, error) {
+	previous, err := ytbx.ParseGoPatchStylePathString(detail.From.Value)
+	if err != nil || len(diff.Path.PathElements) == 0 {
+		return nil, fmt.Errorf("%s: move cannot be expressed using JSON Patch", diff.Path.String())
+	}
+
+	previous.DocumentIdx = diff.Path.DocumentIdx
+	from, err := r.jsonPointer(&previous)
+	if err != nil {
+		return nil, err
+	}
+
+	var elements = diff.Path.PathElements
+	parent, err := r.jsonPointer(&ytbx.Path{DocumentIdx: diff.Path.DocumentIdx, PathElements: elements[:len(elements)-1]})
+	if err != nil {
+		return nil, err
+	}
+
+	return []jsonPatchOperation{{Op: "move", From: from, Path: parent + "/" + goPatchEscape(elements[len(elements)-1].Name)}}, nil
+}
+
+// listReplacement returns the operation that replaces the list at the path
+// with the list of the to input file
+func (r Report) listReplacement(path *ytbx.Path) (jsonPatchOperation, error) {
+	pointer, err := r.jsonPointer(path)
+	if err != nil {
+		return jsonPatchOperation{}, err
+	}
+
+	list, err := r.toValue(path)
+	if err != nil {
+		return jsonPatchOperation{}, err
+	}
+
+	value, err := yqValue(list)
+	if err != nil {
+		return jsonPatchOperation{}, err
+	}
+
+	return jsonPatchOperation{Op: "replace", Path: pointer, Value: json.RawMessage(value)}, nil
+}
+
+func (r Report) jsonPatchOperations(diff Diff) ([]jsonPatchOperation, error) {
+	if len(diff.Details) == 1 && diff.Details[0].Kind == MOVED {
+		return r.jsonPatchMove(diff, diff.Details[0])
+	}
+
+	pointer, err := r.jsonPointer(diff.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var operation = func(op string, path string, node *yamlv3.Node) (jsonPatchOperation, error) {
+		if node == nil {
+			return jsonPatchOperation{Op: op, Path: path}, nil
+		}
+
+		value, err := yqValue(node)
+		if err != nil {
+			return jsonPatchOperation{}, err
+		}
+
+		return jsonPatchOperation{Op: op, Path: path, Value: json.RawMessage(value)}, nil
+	}
+
+	if isListReplacement(diff) {
+		list, err := r.toValue(diff.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		op, err := operation("replace", pointer, list)
+		if err != nil {
+			return nil, err
+		}
+
+		return []jsonPatchOperation{op}, nil
+	}
+
+	var result []jsonPatchOperation
+	var add = func(op string, path string, node *yamlv3.Node) error {
+		operation, err := operation(op, path, node)
+		if err != nil {
+			return err
+		}
+
+		result = append(result, operation)
+		return nil
+	}
+
+	for _, detail := range diff.Details {
+		switch detail.Kind {
+		case MODIFICATION:
+			if err := add("replace", pointer, detail.To); err != nil {
+				return nil, err
+			}
+
+		case ADDITION:
+			switch detail.To.Kind {
+			case yamlv3.MappingNode:
+				for i := 0; i+1 < len(detail.To.Content); i += 2 {
+					if err := add("add", pointer+"/"+goPatchEscape(detail.To.Content[i].Value), detail.To.Content[i+1]); err != nil {
+						return nil, err
+					}
+				}
+
+			case yamlv3.SequenceNode:
+				for _, entry := range detail.To.Content {
+					if err := add("add", pointer+"/-", entry); err != nil {
+						return nil, err
+					}
+				}
+
+			default:
+				return nil, fmt.Errorf("%s: addition cannot be expressed using JSON Patch", diff.Path.String())
+			}
+
+		case REMOVAL:
+			switch detail.From.Kind {
+			case yamlv3.MappingNode:
+				for i := 0; i+1 < len(detail.From.Content); i += 2 {
+					if err := add("remove", pointer+"/"+goPatchEscape(detail.From.Content[i].Value), nil); err != nil {
+						return nil, err
+					}
+				}
+
+			default:
+				return nil, fmt.Errorf("%s: removal cannot be expressed using JSON Patch", diff.Path.String())
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// jsonPointer translates the path into a JSON Pointer (RFC 6901), entries of
+// named-entry lists are looked up in the from input file to get their index
+func (r Report) jsonPointer(path *ytbx.Path) (string, error) {
+	if path.DocumentIdx >= len(r.From.Documents) {
+		return "", fmt.Errorf("failed to look up %s, there is no document #%d", path.String(), path.DocumentIdx)
+	}
+
+	node := r.From.Documents[path.DocumentIdx]
+	if node.Kind == yamlv3.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	var result strings.Builder
+	for _, element := range path.PathElements {
+		node = followAlias(node)
+		result.WriteString("/")
+
+		switch {
+		case element.Key != "":
+			idx := -1
+			if node.Kind == yamlv3.SequenceNode {
+				for i, entry := range node.Content {
+					if listEntryName(followAlias(entry), element.Key) == element.Name {
+						idx = i
+						break
+					}
+				}
+			}
+
+			if idx < 0 {
+				return "", fmt.Errorf("failed to look up %s, there is no list entry with %s=%s", path.String(), element.Key, element.Name)
+			}
+
+			result.WriteString(strconv.Itoa(idx))
+			node = node.Content[idx]
+
+		case element.Name != "":
+			if node.Kind != yamlv3.MappingNode {
+				return "", fmt.Errorf("failed to look up %s, there is no key %s", path.String(), element.Name)
+			}
+
+			value, ok := findValueByKey(node, element.Name)
+			if !ok {
+				return "", fmt.Errorf("failed to look up %s, there is no key %s", path.String(), element.Name)
+			}
+
+			result.WriteString(goPatchEscape(element.Name))
+			node = value
+
+		default:
+			if node.Kind != yamlv3.SequenceNode || element.Idx < 0 || element.Idx >= len(node.Content) {
+				return "", fmt.Errorf("failed to look up %s, there is no list entry #%d", path.String(), element.Idx)
+			}
+
+			result.WriteString(strconv.Itoa(element.Idx))
+			node = node.Content[element.Idx]
+		}
+	}
+
+	return result.String(), nil
+}
+
+// listEntryName returns the name of the list entry using the given identifier
+// key, which can also be the identifier of Kubernetes resources, or a
+// composite identifier
+func listEntryName(entry *yamlv3.Node, key string) string {
+	if entry.Kind != yamlv3.MappingNode {
+		return ""
+	}
+
+	if value, ok := findValueByKey(entry, key); ok {
+		return value.Value
+	}
+
+	if strings.Contains(key, compositeSeparator) {
+		identifier := compositeFields{strings.Split(key, compositeSeparator)}
+		if name, err := identifier.Name(entry); err == nil {
+			return name
+		}
+	}
+
+	if key == k8sItem.String() {
+		if name, err := k8sItem.Name(entry); err == nil {
+			return name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,117 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"github.com/gonvenience/ytbx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("JSON Patch report", func() {
+	jsonPatch := func(from, to string) string {
+		report, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Documents: []*yamlv3.Node{yml(from)}},
+			ytbx.InputFile{Documents: []*yamlv3.Node{yml(to)}},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		data, err := report.AsJSONPatch()
+		Expect(err).ToNot(HaveOccurred())
+		return string(data)
+	}
+
+	It("should create add and replace operations for value changes and additions", func() {
+		Expect(jsonPatch(`---
+spec:
+  replicas: 1
+  image/name: foo
+`, `---
+spec:
+  replicas: 2
+  image/name: bar
+  labels:
+    app: foo
+`)).To(MatchJSON(`[
+  {"op": "add", "path": "/spec/labels", "value": {"app": "foo"}},
+  {"op": "replace", "path": "/spec/replicas", "value": 2},
+  {"op": "replace", "path": "/spec/image~1name", "value": "bar"}
+]`))
+	})
+
+	It("should create remove operations for map entries and replace lists with removed entries", func() {
+		Expect(jsonPatch(`---
+list:
+- name: one
+  value: 1
+- name: two
+  value: 2
+foo: bar
+`, `---
+list:
+- name: one
+  value: 42
+`)).To(MatchJSON(`[
+  {"op": "remove", "path": "/foo"},
+  {"op": "replace", "path": "/list", "value": [{"name": "one", "value": 42}]}
+]`))
+	})
+
+	It("should address entries of named lists by their index and append new entries", func() {
+		Expect(jsonPatch(`---
+containers:
+- name: sidecar
+  image: envoy
+- name: app
+  image: app:1
+`, `---
+containers:
+- name: sidecar
+  image: envoy
+- name: app
+  image: app:2
+- name: init
+  image: busybox
+`)).To(MatchJSON(`[
+  {"op": "add", "path": "/containers/-", "value": {"name": "init", "image": "busybox"}},
+  {"op": "replace", "path": "/containers/1/image", "value": "app:2"}
+]`))
+	})
+
+	It("should create an empty patch if there are no differences", func() {
+		Expect(jsonPatch("foo: bar", "foo: bar")).To(MatchJSON(`[]`))
+	})
+
+	It("should render the operations of a single difference", func() {
+		report, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Documents: multiDoc(`{spec: {replicas: 1, name: foo}}`)},
+			ytbx.InputFile{Documents: multiDoc(`{spec: {replicas: 2, name: foo}}`)},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Diffs).To(HaveLen(1))
+
+		var renderer dyff.DiffRenderer = &dyff.JSONPatchReport{Report: report}
+		Expect(renderer.RenderDiff(report.Diffs[0])).To(MatchJSON(`[{"op": "replace", "path": "/spec/replicas", "value": 2}]`))
+	})
+})
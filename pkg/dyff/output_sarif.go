@@ -0,0 +1,320 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"slices"
+
+	"github.com/gonvenience/text"
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// SARIF schema and version of the written log
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// SARIFReport is a reporter that writes a SARIF 2.1.0 log, so that the
+// differences can be ingested by security and compliance tooling using the
+// standard SARIF uploaders. Each detail of a difference is one result, with a
+// rule per change kind (for example dyff/modification), and the file, line,
+// and path of the difference as location.
+type SARIFReport struct {
+	Report
+
+	// ToolVersion is the version of the tool in the log (optional)
+	ToolVersion string
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	RuleIndex  int               `json:"ruleIndex"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifRuleDescriptions are the descriptions of the rules per change kind
+var sarifRuleDescriptions = map[DetailKind]string{
+	MODIFICATION:    "A value was modified",
+	ADDITION:        "A map entry, list entry, or document was added",
+	REMOVAL:         "A map entry, list entry, or document was removed",
+	ORDERCHANGE:     "The order of list entries changed",
+	MOVED:           "A map entry or document was moved to another location",
+	DIRECTIVECHANGE: "The directives of a document changed",
+}
+
+// WriteReport writes the SARIF log to the provided writer
+func (report *SARIFReport) WriteReport(out io.Writer) error {
+	var rules = make([]sarifRule, len(statisticsKindOrder))
+	for i, kind := range statisticsKindOrder {
+		rules[i] = sarifRule{
+			ID:                   sarifRuleID(kind),
+			Name:                 kind.String(),
+			ShortDescription:     sarifMessage{Text: sarifRuleDescriptions[kind]},
+			DefaultConfiguration: sarifConfiguration{Level: "warning"},
+		}
+	}
+
+	var results = []sarifResult{}
+	for _, diff := range report.Diffs {
+		diffResults, err := report.sarifResults(diff)
+		if err != nil {
+			return err
+		}
+
+		results = append(results, diffResults...)
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "dyff",
+				Version:        report.ToolVersion,
+				InformationURI: "https://github.com/homeport/dyff",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	})
+}
+
+// RenderDiff renders the SARIF results of the provided difference (one per
+// detail) as a JSON list, the rule indices refer to the rules of the report
+func (report *SARIFReport) RenderDiff(diff Diff) (string, error) {
+	results, err := report.sarifResults(diff)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(data) + "\n", nil
+}
+
+var _ DiffRenderer = &SARIFReport{}
+
+// sarifResults returns the results of the difference, one per detail
+func (report *SARIFReport) sarifResults(diff Diff) ([]sarifResult, error) {
+	var results = []sarifResult{}
+	for _, detail := range diff.Details {
+		idx := slices.Index(statisticsKindOrder, detail.Kind)
+		if idx < 0 {
+			return nil, fmt.Errorf("unsupported detail type %c", detail.Kind)
+		}
+
+		result := sarifResult{
+			RuleID:    sarifRuleID(detail.Kind),
+			RuleIndex: idx,
+			Message:   sarifMessage{Text: fmt.Sprintf("%s: %s", sarifPath(diff.Path), detailDescription(detail))},
+			Locations: []sarifLocation{report.sarifLocation(diff, detail)},
+		}
+
+		if diff.Path != nil {
+			result.Properties = map[string]string{"document": diff.Path.RootDescription()}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func sarifRuleID(kind DetailKind) string {
+	return "dyff/" + kind.String()
+}
+
+func sarifPath(path *ytbx.Path) string {
+	if path == nil {
+		return "(file level)"
+	}
+
+	return path.ToGoPatchStyle()
+}
+
+// sarifLocation returns the location of the detail, which is the to input
+// file, except for removals, which only exist in the from input file
+func (report *SARIFReport) sarifLocation(diff Diff, detail Detail) sarifLocation {
+	var location sarifLocation
+	if diff.Path != nil {
+		location.LogicalLocations = []sarifLogicalLocation{{
+			FullyQualifiedName: diff.Path.ToGoPatchStyle(),
+			Kind:               "member",
+		}}
+	}
+
+	var file, line = report.To.Location, firstLine(detail.To)
+	var source = diff.ToSource
+	if detail.Kind == REMOVAL {
+		file, line, source = report.From.Location, firstLine(detail.From), diff.FromSource
+	}
+
+	if source != nil {
+		file = source.File
+		if source.Line > 0 {
+			line = source.Line
+		}
+	}
+
+	if file == "" {
+		return location
+	}
+
+	location.PhysicalLocation = &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: sarifURI(file)}}
+	if line > 0 {
+		location.PhysicalLocation.Region = &sarifRegion{StartLine: line}
+	}
+
+	return location
+}
+
+// sarifURI returns the location as URI, absolute paths are file URIs, while
+// relative paths (and URLs) are used as they are
+func sarifURI(location string) string {
+	if filepath.IsAbs(location) {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(location)}).String()
+	}
+
+	return filepath.ToSlash(location)
+}
+
+// detailDescription returns a short description of the detail without the
+// complete values, for example "value changed from 1 to 2"
+func detailDescription(detail Detail) string {
+	switch detail.Kind {
+	case MODIFICATION:
+		if detail.From != nil && detail.To != nil && detail.From.Kind == yamlv3.ScalarNode && detail.To.Kind == yamlv3.ScalarNode {
+			return fmt.Sprintf("value changed from %s to %s", detail.From.Value, detail.To.Value)
+		}
+
+		return "value changed"
+
+	case ADDITION:
+		return entriesDescription(detail.To) + " added"
+
+	case REMOVAL:
+		return entriesDescription(detail.From) + " removed"
+
+	case ORDERCHANGE:
+		return "order changed"
+
+	case MOVED:
+		return "moved from " + detail.From.Value
+
+	case DIRECTIVECHANGE:
+		return "directives changed"
+	}
+
+	return detail.Kind.String()
+}
+
+func entriesDescription(node *yamlv3.Node) string {
+	if node == nil {
+		return "value"
+	}
+
+	switch node.Kind {
+	case yamlv3.DocumentNode:
+		return text.Plural(len(node.Content), "document")
+
+	case yamlv3.MappingNode:
+		return text.Plural(len(node.Content)/2, "map entry", "map entries")
+
+	case yamlv3.SequenceNode:
+		return text.Plural(len(node.Content), "list entry", "list entries")
+	}
+
+	return "value"
+}
@@ -0,0 +1,161 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/gonvenience/ytbx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("SARIF report", func() {
+	type result struct {
+		RuleID    string `json:"ruleId"`
+		RuleIndex int    `json:"ruleIndex"`
+		Message   struct {
+			Text string `json:"text"`
+		} `json:"message"`
+		Locations []struct {
+			PhysicalLocation struct {
+				ArtifactLocation struct {
+					URI string `json:"uri"`
+				} `json:"artifactLocation"`
+				Region struct {
+					StartLine int `json:"startLine"`
+				} `json:"region"`
+			} `json:"physicalLocation"`
+			LogicalLocations []struct {
+				FullyQualifiedName string `json:"fullyQualifiedName"`
+			} `json:"logicalLocations"`
+		} `json:"locations"`
+	}
+
+	type log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name    string `json:"name"`
+					Version string `json:"version"`
+					Rules   []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []result `json:"results"`
+		} `json:"runs"`
+	}
+
+	sarif := func(report dyff.Report) log {
+		var buf bytes.Buffer
+		Expect((&dyff.SARIFReport{Report: report, ToolVersion: "1.0.0"}).WriteReport(&buf)).To(Succeed())
+
+		var result log
+		Expect(json.Unmarshal(buf.Bytes(), &result)).To(Succeed())
+		return result
+	}
+
+	It("should create one result per change with the rule of the change kind", func() {
+		from := ytbx.InputFile{Location: "manifests/from.yml", Documents: multiDoc(`---
+spec:
+  replicas: 1
+  old: true
+`)}
+
+		to := ytbx.InputFile{Location: "manifests/to.yml", Documents: multiDoc(`---
+spec:
+  replicas: 2
+  new: true
+`)}
+
+		report, err := dyff.CompareInputFiles(from, to)
+		Expect(err).ToNot(HaveOccurred())
+
+		output := sarif(report)
+		Expect(output.Version).To(Equal("2.1.0"))
+		Expect(output.Runs).To(HaveLen(1))
+
+		driver := output.Runs[0].Tool.Driver
+		Expect(driver.Name).To(Equal("dyff"))
+		Expect(driver.Version).To(Equal("1.0.0"))
+
+		results := output.Runs[0].Results
+		Expect(results).To(HaveLen(3))
+		for _, result := range results {
+			Expect(driver.Rules[result.RuleIndex].ID).To(Equal(result.RuleID))
+		}
+
+		Expect(results[0].RuleID).To(Equal("dyff/removal"))
+		Expect(results[0].Message.Text).To(Equal("/spec: one map entry removed"))
+		Expect(results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI).To(Equal("manifests/from.yml"))
+		Expect(results[0].Locations[0].PhysicalLocation.Region.StartLine).To(Equal(4))
+
+		Expect(results[1].RuleID).To(Equal("dyff/addition"))
+		Expect(results[1].Locations[0].PhysicalLocation.ArtifactLocation.URI).To(Equal("manifests/to.yml"))
+
+		Expect(results[2].RuleID).To(Equal("dyff/modification"))
+		Expect(results[2].Message.Text).To(Equal("/spec/replicas: value changed from 1 to 2"))
+		Expect(results[2].Locations[0].PhysicalLocation.ArtifactLocation.URI).To(Equal("manifests/to.yml"))
+		Expect(results[2].Locations[0].PhysicalLocation.Region.StartLine).To(Equal(3))
+		Expect(results[2].Locations[0].LogicalLocations[0].FullyQualifiedName).To(Equal("/spec/replicas"))
+	})
+
+	It("should use file URIs for absolute paths and write an empty list of results without changes", func() {
+		from := ytbx.InputFile{Location: "/tmp/from.yml", Documents: multiDoc(`{"a": 1}`)}
+		to := ytbx.InputFile{Location: "/tmp/to.yml", Documents: multiDoc(`{"a": 2}`)}
+
+		report, err := dyff.CompareInputFiles(from, to)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sarif(report).Runs[0].Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI).To(Equal("file:///tmp/to.yml"))
+
+		report, err = dyff.CompareInputFiles(from, from)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sarif(report).Runs[0].Results).To(BeEmpty())
+
+		var buf bytes.Buffer
+		Expect((&dyff.SARIFReport{Report: report}).WriteReport(&buf)).To(Succeed())
+		Expect(buf.String()).To(ContainSubstring(`"results": []`))
+	})
+
+	It("should render the results of a single difference", func() {
+		report, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Documents: multiDoc(`{spec: {replicas: 1, name: foo}}`)},
+			ytbx.InputFile{Documents: multiDoc(`{spec: {replicas: 2, name: foo}}`)},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Diffs).To(HaveLen(1))
+
+		var renderer dyff.DiffRenderer = &dyff.SARIFReport{Report: report}
+		output, err := renderer.RenderDiff(report.Diffs[0])
+		Expect(err).ToNot(HaveOccurred())
+
+		var results []result
+		Expect(json.Unmarshal([]byte(output), &results)).To(Succeed())
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].RuleID).To(Equal("dyff/modification"))
+		Expect(results[0].Message.Text).To(ContainSubstring("replicas"))
+	})
+})
@@ -0,0 +1,205 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// StructuredReport is a reporter that writes the report in its serialized
+// form (see MarshalJSON and MarshalYAML) so that it can be processed by other
+// tools, or read back in later using LoadReport
+//
+// By default, all keys of maps in the documents and details of the report are
+// written in their original order. With SortKeys, maps keys are written in
+// alphabetical order instead, which results in a stable output even if the
+// input files use different key orders.
+//
+// The optional provenance of the from and to input files is included in the
+// output so that consumers can verify exactly what was compared.
+type StructuredReport struct {
+	Report
+	Format         string
+	SortKeys       bool
+	FromProvenance *InputProvenance
+	ToProvenance   *InputProvenance
+}
+
+// WriteReport writes the serialized report in the configured format (json,
+// or yaml) to the provided writer
+func (report *StructuredReport) WriteReport(out io.Writer) error {
+	var result = report.Report
+	if report.SortKeys {
+		result = sortedKeysReport(result)
+	}
+
+	schema, err := result.toSchema()
+	if err != nil {
+		return err
+	}
+
+	schema.From.Provenance = report.FromProvenance
+	schema.To.Provenance = report.ToProvenance
+
+	return report.encode(out, schema)
+}
+
+// RenderDiff renders the provided difference the same way it is serialized as
+// part of the report, in the configured format
+func (report *StructuredReport) RenderDiff(diff Diff) (string, error) {
+	if report.SortKeys {
+		diff = sortedKeysReport(Report{Diffs: []Diff{diff}}).Diffs[0]
+	}
+
+	schema, err := diffToSchema(diff, &report.Report)
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	if err := report.encode(&buf, schema); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+var _ DiffRenderer = &StructuredReport{}
+
+func (report *StructuredReport) encode(out io.Writer, value interface{}) error {
+	switch report.Format {
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+
+	case "yaml":
+		encoder := yamlv3.NewEncoder(out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+
+		return encoder.Close()
+	}
+
+	return fmt.Errorf("unsupported structured report format %q", report.Format)
+}
+
+// SortMapKeys sorts the keys of all maps in the provided node (and all of its
+// sub nodes) alphabetically, the node is changed in place
+func SortMapKeys(node *yamlv3.Node) {
+	if node == nil {
+		return
+	}
+
+	if node.Kind == yamlv3.MappingNode {
+		type entry struct{ key, value *yamlv3.Node }
+
+		entries := make([]entry, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			entries = append(entries, entry{node.Content[i], node.Content[i+1]})
+		}
+
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].key.Value < entries[j].key.Value
+		})
+
+		for i, e := range entries {
+			node.Content[2*i], node.Content[2*i+1] = e.key, e.value
+		}
+	}
+
+	for _, content := range node.Content {
+		SortMapKeys(content)
+	}
+}
+
+// sortedKeysReport returns a copy of the report where all documents and all
+// detail nodes have their map keys sorted, the original report is not changed
+func sortedKeysReport(report Report) Report {
+	sortedCopy := func(node *yamlv3.Node) *yamlv3.Node {
+		if node == nil {
+			return nil
+		}
+
+		result := resolveAliases(node)
+		SortMapKeys(result)
+		return result
+	}
+
+	sortedInputFile := func(inputFile ytbx.InputFile) ytbx.InputFile {
+		documents := make([]*yamlv3.Node, len(inputFile.Documents))
+		for i, document := range inputFile.Documents {
+			documents[i] = sortedCopy(document)
+		}
+
+		inputFile.Documents = documents
+		return inputFile
+	}
+
+	result := Report{
+		From:  sortedInputFile(report.From),
+		To:    sortedInputFile(report.To),
+		Diffs: make([]Diff, len(report.Diffs)),
+	}
+
+	// paths need to refer to the sorted input files, so that it is still
+	// known whether they belong to the from or to input file
+	sortedRoot := func(path *ytbx.Path) *ytbx.Path {
+		if path == nil || path.Root == nil {
+			return path
+		}
+
+		var root = path.Root
+		switch {
+		case isSameInputFile(root, report.From):
+			root = &result.From
+
+		case isSameInputFile(root, report.To):
+			root = &result.To
+		}
+
+		return &ytbx.Path{Root: root, DocumentIdx: path.DocumentIdx, PathElements: path.PathElements}
+	}
+
+	for i, diff := range report.Diffs {
+		details := make([]Detail, len(diff.Details))
+		for j, detail := range diff.Details {
+			details[j] = Detail{
+				Kind: detail.Kind,
+				From: sortedCopy(detail.From),
+				To:   sortedCopy(detail.To),
+			}
+		}
+
+		result.Diffs[i] = Diff{Path: sortedRoot(diff.Path), Details: details, FromSource: diff.FromSource, ToSource: diff.ToSource}
+	}
+
+	return result
+}
@@ -0,0 +1,158 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"sync"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// TagRenderer renders the value of a node with a specific tag, for example to
+// show a `!vault` reference or decoded `!!binary` content in the human report
+type TagRenderer func(node *yamlv3.Node) (string, error)
+
+var tagRenderers = struct {
+	sync.RWMutex
+	renderers map[string]TagRenderer
+}{
+	renderers: map[string]TagRenderer{},
+}
+
+// RegisterTagRenderer registers a renderer for nodes with the provided tag
+// (e.g. `!vault`), which is used by the human reporter when printing added,
+// removed, or modified nodes. A renderer that is already registered for the
+// tag is replaced.
+func RegisterTagRenderer(tag string, renderer TagRenderer) {
+	tagRenderers.Lock()
+	defer tagRenderers.Unlock()
+
+	tagRenderers.renderers[tag] = renderer
+}
+
+// UnregisterTagRenderer removes the renderer for the provided tag
+func UnregisterTagRenderer(tag string) {
+	tagRenderers.Lock()
+	defer tagRenderers.Unlock()
+
+	delete(tagRenderers.renderers, tag)
+}
+
+func lookupTagRenderer(node *yamlv3.Node) (TagRenderer, bool) {
+	if node == nil || node.Tag == "" {
+		return nil, false
+	}
+
+	tagRenderers.RLock()
+	defer tagRenderers.RUnlock()
+
+	renderer, ok := tagRenderers.renderers[node.Tag]
+	return renderer, ok
+}
+
+// renderedScalars returns the rendered values of two scalar nodes, which is
+// only the case if at least one of them has a registered tag renderer
+func renderedScalars(from, to *yamlv3.Node) (string, string, bool, error) {
+	if from == nil || to == nil || from.Kind != yamlv3.ScalarNode || to.Kind != yamlv3.ScalarNode {
+		return "", "", false, nil
+	}
+
+	fromRenderer, fromOK := lookupTagRenderer(from)
+	toRenderer, toOK := lookupTagRenderer(to)
+	if !fromOK && !toOK {
+		return "", "", false, nil
+	}
+
+	var render = func(node *yamlv3.Node, renderer TagRenderer, ok bool) (string, error) {
+		if !ok {
+			return node.Value, nil
+		}
+
+		return renderer(node)
+	}
+
+	fromValue, err := render(from, fromRenderer, fromOK)
+	if err != nil {
+		return "", "", false, err
+	}
+
+	toValue, err := render(to, toRenderer, toOK)
+	if err != nil {
+		return "", "", false, err
+	}
+
+	return fromValue, toValue, true, nil
+}
+
+// withRenderedTags returns the node with all nodes that have a registered tag
+// renderer replaced by string nodes with the rendered value, the node itself
+// is not modified (only the parts that contain rendered nodes are copied)
+func withRenderedTags(node *yamlv3.Node) (*yamlv3.Node, error) {
+	if node == nil {
+		return nil, nil
+	}
+
+	renderer, ok := lookupTagRenderer(node)
+	if !ok && node.Kind == yamlv3.ScalarNode && node.Tag == "!!binary" {
+		renderer, ok = renderBinary, true
+	}
+
+	if ok {
+		value, err := renderer(node)
+		if err != nil {
+			return nil, err
+		}
+
+		return &yamlv3.Node{
+			Kind:        yamlv3.ScalarNode,
+			Tag:         "!!str",
+			Value:       value,
+			HeadComment: node.HeadComment,
+			LineComment: node.LineComment,
+			FootComment: node.FootComment,
+		}, nil
+	}
+
+	var content []*yamlv3.Node
+	for i, entry := range node.Content {
+		rendered, err := withRenderedTags(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		if rendered != entry && content == nil {
+			content = make([]*yamlv3.Node, len(node.Content))
+			copy(content, node.Content)
+		}
+
+		if content != nil {
+			content[i] = rendered
+		}
+	}
+
+	if content == nil {
+		return node, nil
+	}
+
+	result := *node
+	result.Content = content
+	return &result, nil
+}
@@ -21,8 +21,12 @@
 package dyff_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 
+	"github.com/gonvenience/ytbx"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -92,4 +96,132 @@ Miss Foobar`
                  500000`, Sprintf("Lime{#1}"), Sprintf("Blue{#2}"), Sprintf("Aqua{~#3~}"), Sprintf("LemonChiffon{_*#4*_}"))))
 		})
 	})
+
+	Context("brief output", func() {
+		BeforeEach(func() {
+			SetColorSettings(OFF, OFF)
+		})
+
+		AfterEach(func() {
+			SetColorSettings(AUTO, AUTO)
+		})
+
+		It("should list the changed documents and paths with their number of changes", func() {
+			from := ytbx.InputFile{Location: "/ginkgo/output/test/from", Documents: multiDoc(`---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: one
+data:
+  a: 1
+  b: 1
+`)}
+
+			to := ytbx.InputFile{Location: "/ginkgo/output/test/to", Documents: multiDoc(`---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: one
+data:
+  a: 2
+  c: 1
+`, `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: two
+`)}
+
+			report, err := dyff.CompareInputFiles(from, to)
+			Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+			Expect((&dyff.BriefReport{Report: report, Detailed: true}).WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(HaveSuffix(`
+(file level) (one change)
+  v1/ConfigMap/two  one addition
+
+v1/ConfigMap/one (two changes)
+  data    one addition, one removal
+  data.a  one modification
+
+`))
+
+			buf.Reset()
+			Expect((&dyff.BriefReport{Report: report, Detailed: true, UseGoPatchPaths: true}).WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring("  /data/a  one modification\n"))
+
+			buf.Reset()
+			Expect((&dyff.BriefReport{Report: report}).WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).ToNot(ContainSubstring("v1/ConfigMap/one"))
+
+			var renderer dyff.DiffRenderer = &dyff.BriefReport{Report: report}
+			Expect(renderer.RenderDiff(report.Diffs[0])).To(Equal("v1/ConfigMap/two  one addition\n"))
+			Expect(renderer.RenderDiff(report.Diffs[2])).To(Equal("data.a  one modification\n"))
+		})
+	})
+
+	Context("structured output", func() {
+		It("should render a single difference the same way it is serialized in the report", func() {
+			report, err := dyff.CompareInputFiles(
+				ytbx.InputFile{Documents: multiDoc(`{spec: {replicas: 1, name: foo}}`)},
+				ytbx.InputFile{Documents: multiDoc(`{spec: {replicas: 2, name: foo}}`)},
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Diffs).To(HaveLen(1))
+
+			var renderer dyff.DiffRenderer = &dyff.StructuredReport{Report: report, Format: "json"}
+			output, err := renderer.RenderDiff(report.Diffs[0])
+			Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+			Expect((&dyff.StructuredReport{Report: report, Format: "json"}).WriteReport(&buf)).To(Succeed())
+
+			var serialized struct {
+				Diffs []json.RawMessage `json:"diffs"`
+			}
+			Expect(json.Unmarshal(buf.Bytes(), &serialized)).To(Succeed())
+			Expect(serialized.Diffs).To(HaveLen(1))
+			Expect(output).To(MatchJSON(serialized.Diffs[0]))
+
+			_, err = (&dyff.StructuredReport{Report: report, Format: "toml"}).RenderDiff(report.Diffs[0])
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("notes of input files", func() {
+		var report dyff.Report
+
+		BeforeEach(func() {
+			from := ytbx.InputFile{Location: "/ginkgo/output/test/from", Documents: multiDoc(`{"items": [{"name": "foo", "value": 1}]}`)}
+			to := ytbx.InputFile{Location: "/ginkgo/output/test/to", Documents: multiDoc(`{"items": [{"name": "foo", "value": 2}]}`)}
+
+			dyff.AddNote(&from, "rendered from chart version 1.0.0")
+			Expect(dyff.ChangeRoot(&from, "/items", false, true)).To(Succeed())
+			Expect(dyff.ChangeRoot(&to, "/items", false, true)).To(Succeed())
+
+			var err error
+			report, err = dyff.CompareInputFiles(from, to)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should combine notes added by library callers and the change root", func() {
+			Expect(report.From.Note).To(Equal("rendered from chart version 1.0.0, YAML root was changed to items"))
+			Expect(report.To.Note).To(Equal("YAML root was changed to items"))
+		})
+
+		It("should render the notes in all reporters", func() {
+			for _, writer := range []dyff.ReportWriter{
+				&dyff.BriefReport{Report: report},
+				&dyff.DiffSyntaxReport{PathPrefix: "@@", RootDescriptionPrefix: "#", ChangeTypePrefix: "!", HumanReport: dyff.HumanReport{Report: report}},
+				&dyff.YQReport{Report: report},
+				&dyff.StructuredReport{Report: report, Format: "yaml"},
+			} {
+				var buf bytes.Buffer
+				Expect(writer.WriteReport(&buf)).To(Succeed())
+				Expect(buf.String()).To(ContainSubstring("rendered from chart version 1.0.0, YAML root was changed to items"), "%T", writer)
+				Expect(buf.String()).To(ContainSubstring("YAML root was changed to items"), "%T", writer)
+			}
+		})
+	})
 })
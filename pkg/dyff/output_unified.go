@@ -0,0 +1,256 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// UnifiedDiffReport is a reporter that writes a unified diff for standard diff
+// viewers. Both sides are the normalized YAML rendering of the inputs (not the
+// original bytes), where the to side is the from input with the differences
+// of the report applied, so that filtered differences are not part of the
+// output. Since formatting and comments of the input files are not kept, the
+// line numbers refer to the normalized rendering and the output is not meant
+// to be applied to the original files with patch.
+type UnifiedDiffReport struct {
+	Report
+	ContextLines int
+}
+
+type unifiedLine struct {
+	op   byte
+	text string
+}
+
+// WriteReport writes the unified diff to the provided writer
+func (report *UnifiedDiffReport) WriteReport(out io.Writer) error {
+	lines, hunks, err := report.unifiedHunksOf(report.Diffs)
+	if err != nil {
+		return err
+	}
+
+	if len(hunks) == 0 {
+		return nil
+	}
+
+	writer := bufio.NewWriter(out)
+	defer writer.Flush()
+
+	// text before the file headers is ignored by diff tools, it is used to
+	// label the output as a diff of the normalized documents
+	_, _ = fmt.Fprintf(writer, "normalized YAML of %s and %s\n", report.From.Location, report.To.Location)
+	_, _ = fmt.Fprintf(writer, "--- %s\n", report.From.Location)
+	_, _ = fmt.Fprintf(writer, "+++ %s\n", report.To.Location)
+
+	writeHunks(writer, lines, hunks)
+	return nil
+}
+
+// RenderDiff renders the hunks of the unified diff that only contains the
+// provided difference, but without the label and file headers
+func (report *UnifiedDiffReport) RenderDiff(diff Diff) (string, error) {
+	lines, hunks, err := report.unifiedHunksOf([]Diff{diff})
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	writeHunks(&buf, lines, hunks)
+	return buf.String(), nil
+}
+
+var _ DiffRenderer = &UnifiedDiffReport{}
+
+// unifiedHunksOf returns the lines and hunks of the unified diff between the
+// from input file and the from input file with the provided differences applied
+func (report *UnifiedDiffReport) unifiedHunksOf(diffs []Diff) ([]unifiedLine, []unifiedHunk, error) {
+	changed, err := applyDiffs(report.From.Documents, diffs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create unified diff: %w", err)
+	}
+
+	from, err := renderDocuments(report.From.Documents)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create unified diff: %w", err)
+	}
+
+	to, err := renderDocuments(changed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create unified diff: %w", err)
+	}
+
+	lines := unifiedLines(from, to)
+	return lines, unifiedHunks(lines, max(report.ContextLines, 0)), nil
+}
+
+// writeHunks writes the hunks with their ranges and lines
+func writeHunks(writer io.Writer, lines []unifiedLine, hunks []unifiedHunk) {
+	for _, hunk := range hunks {
+		var fromStart, fromCount, toStart, toCount = hunk.fromStart, 0, hunk.toStart, 0
+		for _, line := range lines[hunk.start:hunk.end] {
+			if line.op != '+' {
+				fromCount++
+			}
+
+			if line.op != '-' {
+				toCount++
+			}
+		}
+
+		_, _ = fmt.Fprintf(writer, "@@ -%s +%s @@\n", hunkRange(fromStart, fromCount), hunkRange(toStart, toCount))
+		for _, line := range lines[hunk.start:hunk.end] {
+			_, _ = fmt.Fprintf(writer, "%c%s\n", line.op, line.text)
+		}
+	}
+}
+
+// renderDocuments renders the documents as YAML with document separators
+func renderDocuments(documents []*yamlv3.Node) (string, error) {
+	var buf bytes.Buffer
+	encoder := yamlv3.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	var encoded bool
+	for _, document := range documents {
+		if document == nil || isEmptyDocument(document) {
+			continue
+		}
+
+		if err := encoder.Encode(document); err != nil {
+			return "", err
+		}
+
+		encoded = true
+	}
+
+	// the encoder fails to close a stream without any document
+	if !encoded {
+		return "", nil
+	}
+
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// unifiedLines returns the line by line diff of the two texts, where each
+// line is prefixed by its operation (space, minus, or plus)
+func unifiedLines(from string, to string) []unifiedLine {
+	dmp := diffmatchpatch.New()
+	fromChars, toChars, lines := dmp.DiffLinesToChars(from, to)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(fromChars, toChars, false), lines)
+
+	var result []unifiedLine
+	for _, diff := range diffs {
+		var op byte
+		switch diff.Type {
+		case diffmatchpatch.DiffInsert:
+			op = '+'
+
+		case diffmatchpatch.DiffDelete:
+			op = '-'
+
+		default:
+			op = ' '
+		}
+
+		for _, line := range strings.SplitAfter(diff.Text, "\n") {
+			if line != "" {
+				result = append(result, unifiedLine{op: op, text: strings.TrimSuffix(line, "\n")})
+			}
+		}
+	}
+
+	return result
+}
+
+type unifiedHunk struct {
+	start, end         int
+	fromStart, toStart int
+}
+
+// unifiedHunks groups the changed lines into hunks with the given number of
+// context lines, hunks that are close to each other are combined
+func unifiedHunks(lines []unifiedLine, context int) []unifiedHunk {
+	var result []unifiedHunk
+	var fromLine, toLine = 1, 1
+	var fromAt, toAt = make([]int, len(lines)), make([]int, len(lines))
+
+	for i, line := range lines {
+		fromAt[i], toAt[i] = fromLine, toLine
+		if line.op != '+' {
+			fromLine++
+		}
+
+		if line.op != '-' {
+			toLine++
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		if lines[i].op == ' ' {
+			continue
+		}
+
+		start := max(i-context, 0)
+		end := i
+		for j := i; j < len(lines); j++ {
+			if lines[j].op != ' ' {
+				end = j
+				continue
+			}
+
+			if j-end > 2*context {
+				break
+			}
+		}
+
+		end = min(end+context+1, len(lines))
+		result = append(result, unifiedHunk{start: start, end: end, fromStart: fromAt[start], toStart: toAt[start]})
+		i = end - 1
+	}
+
+	return result
+}
+
+// hunkRange returns the line range of a hunk, an empty range refers to the
+// line before the hunk
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		start--
+	}
+
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+
+	return fmt.Sprintf("%d,%d", start, count)
+}
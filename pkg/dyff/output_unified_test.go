@@ -0,0 +1,164 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"bytes"
+
+	"github.com/gonvenience/ytbx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("unified diff report", func() {
+	unifiedDiff := func(from, to string, contextLines int) string {
+		report, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Location: "from.yml", Documents: []*yamlv3.Node{yml(from)}},
+			ytbx.InputFile{Location: "to.yml", Documents: []*yamlv3.Node{yml(to)}},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		var buf bytes.Buffer
+		reportWriter := &dyff.UnifiedDiffReport{Report: report, ContextLines: contextLines}
+		Expect(reportWriter.WriteReport(&buf)).To(Succeed())
+		return buf.String()
+	}
+
+	It("should create hunks with context lines for the changes", func() {
+		Expect(unifiedDiff(`---
+a: 1
+b: 2
+c: 3
+d: 4
+e: 5
+f: 6
+`, `---
+a: 1
+b: 2
+c: 3
+d: 4
+e: 5
+f: 7
+`, 2)).To(Equal(`normalized YAML of from.yml and to.yml
+--- from.yml
++++ to.yml
+@@ -4,3 +4,3 @@
+ d: 4
+ e: 5
+-f: 6
++f: 7
+`))
+	})
+
+	It("should create separate hunks for changes that are far apart", func() {
+		Expect(unifiedDiff(`---
+a: 1
+b: 2
+c: 3
+d: 4
+e: 5
+`, `---
+a: 0
+b: 2
+c: 3
+d: 4
+e: 6
+`, 1)).To(Equal(`normalized YAML of from.yml and to.yml
+--- from.yml
++++ to.yml
+@@ -1,2 +1,2 @@
+-a: 1
++a: 0
+ b: 2
+@@ -4,2 +4,2 @@
+ d: 4
+-e: 5
++e: 6
+`))
+	})
+
+	It("should apply positional list changes of the LCS list diff strategy", func() {
+		report, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Location: "from.yml", Documents: []*yamlv3.Node{yml("args:\n- a\n- b\n- c\n- d\n- e\n")}},
+			ytbx.InputFile{Location: "to.yml", Documents: []*yamlv3.Node{yml("args:\n- x\n- a\n- c\n- y\n- z\n- e\n- f\n")}},
+			dyff.ListDiffStrategy(dyff.LCS),
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		var buf bytes.Buffer
+		reportWriter := &dyff.UnifiedDiffReport{Report: report, ContextLines: 0}
+		Expect(reportWriter.WriteReport(&buf)).To(Succeed())
+		Expect(buf.String()).To(Equal(`normalized YAML of from.yml and to.yml
+--- from.yml
++++ to.yml
+@@ -1,0 +2 @@
++  - x
+@@ -3 +3,0 @@
+-  - b
+@@ -5 +5,2 @@
+-  - d
++  - y
++  - z
+@@ -6,0 +8 @@
++  - f
+`))
+	})
+
+	It("should render an input without documents as empty", func() {
+		report, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Location: "from.yml"},
+			ytbx.InputFile{Location: "to.yml", Documents: multiDoc("foo: bar")},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		var buf bytes.Buffer
+		reportWriter := &dyff.UnifiedDiffReport{Report: report, ContextLines: 3}
+		Expect(reportWriter.WriteReport(&buf)).To(Succeed())
+		Expect(buf.String()).To(Equal(`normalized YAML of from.yml and to.yml
+--- from.yml
++++ to.yml
+@@ -0,0 +1 @@
++foo: bar
+`))
+	})
+
+	It("should not write anything if there are no differences", func() {
+		Expect(unifiedDiff("foo: bar", "foo: bar", 3)).To(BeEmpty())
+	})
+
+	It("should render the hunks of a single difference", func() {
+		report, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Location: "from.yml", Documents: []*yamlv3.Node{yml("---\na: 1\nb: 2\nc: 3\n")}},
+			ytbx.InputFile{Location: "to.yml", Documents: []*yamlv3.Node{yml("---\na: 9\nb: 2\nc: 8\n")}},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Diffs).To(HaveLen(2))
+
+		var renderer dyff.DiffRenderer = &dyff.UnifiedDiffReport{Report: report}
+		Expect(renderer.RenderDiff(report.Diffs[1])).To(Equal(`@@ -3 +3 @@
+-c: 3
++c: 8
+`))
+	})
+})
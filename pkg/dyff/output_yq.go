@@ -0,0 +1,362 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+var yqIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// YQReport is a reporter that writes yq (https://github.com/mikefarah/yq)
+// expressions, one per line, which reproduce the changes when they are
+// applied to the from input file, for example using yq --inplace. Changes
+// that cannot be expressed (e.g. order changes) are written as comments.
+type YQReport struct {
+	Report
+}
+
+// WriteReport writes the yq expressions to the provided writer
+func (report *YQReport) WriteReport(out io.Writer) error {
+	writer := bufio.NewWriter(out)
+	defer writer.Flush()
+
+	for _, note := range report.notes() {
+		_, _ = writer.WriteString(fmt.Sprintf("# %s\n", note))
+	}
+
+	var replacedLists = map[string]bool{}
+	for _, diff := range report.Diffs {
+		// Positional list entry removals and additions are expressed by
+		// assigning the whole list once
+		if listPath, ok := report.positionalListChange(diff); ok {
+			if replacedLists[listPath.String()] {
+				continue
+			}
+
+			replacedLists[listPath.String()] = true
+		}
+
+		lines, err := report.RenderDiff(diff)
+		if err != nil {
+			return err
+		}
+
+		_, _ = writer.WriteString(lines)
+	}
+
+	return nil
+}
+
+// RenderDiff renders the yq expressions of the provided difference, one per
+// line. Positional list changes are rendered as an assignment of the list.
+func (report *YQReport) RenderDiff(diff Diff) (string, error) {
+	if listPath, ok := report.positionalListChange(diff); ok {
+		line, err := report.listAssignment(listPath)
+		if err != nil {
+			return "", err
+		}
+
+		return line + "\n", nil
+	}
+
+	lines, err := report.expressions(diff)
+	if err != nil {
+		return "", err
+	}
+
+	if len(lines) == 0 {
+		return "", nil
+	}
+
+	if source := sourceDescription(diff); source != "" {
+		lines = append([]string{"# " + source}, lines...)
+	}
+
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+var _ DiffRenderer = &YQReport{}
+
+func (report *YQReport) expressions(diff Diff) ([]string, error) {
+	if diff.Path == nil {
+		return []string{"# document additions, removals, or order changes cannot be expressed using yq"}, nil
+	}
+
+	var target = yqTarget{
+		multipleDocuments: len(report.From.Documents) > 1,
+		documentIdx:       diff.Path.DocumentIdx,
+	}
+
+	var result []string
+	for _, detail := range diff.Details {
+		switch detail.Kind {
+		case MODIFICATION:
+			value, err := yqValue(detail.To)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, target.assign(yqPath(diff.Path.PathElements), value))
+
+		case ADDITION:
+			switch detail.To.Kind {
+			case yamlv3.MappingNode:
+				for i := 0; i+1 < len(detail.To.Content); i += 2 {
+					value, err := yqValue(detail.To.Content[i+1])
+					if err != nil {
+						return nil, err
+					}
+
+					path := yqPath(append(copyElements(diff.Path.PathElements), ytbx.PathElement{Idx: -1, Name: detail.To.Content[i].Value}))
+					result = append(result, target.assign(path, value))
+				}
+
+			case yamlv3.SequenceNode:
+				value, err := yqValue(detail.To)
+				if err != nil {
+					return nil, err
+				}
+
+				result = append(result, target.update(yqPath(diff.Path.PathElements), "+=", value))
+
+			default:
+				result = append(result, fmt.Sprintf("# %s: addition cannot be expressed using yq", diff.Path.String()))
+			}
+
+		case REMOVAL:
+			switch detail.From.Kind {
+			case yamlv3.MappingNode:
+				for i := 0; i+1 < len(detail.From.Content); i += 2 {
+					path := yqPath(append(copyElements(diff.Path.PathElements), ytbx.PathElement{Idx: -1, Name: detail.From.Content[i].Value}))
+					result = append(result, target.delete(path))
+				}
+
+			case yamlv3.SequenceNode:
+				indices, ok := report.removedIndices(diff.Path, detail.From)
+				if !ok {
+					result = append(result, fmt.Sprintf("# %s: removal cannot be expressed using yq", diff.Path.String()))
+					continue
+				}
+
+				// entries are deleted by index (starting with the last one, so
+				// that the other indices stay the same), since a selection by
+				// value would delete all equal entries
+				for i := len(indices) - 1; i >= 0; i-- {
+					path := yqPath(append(copyElements(diff.Path.PathElements), ytbx.PathElement{Idx: indices[i]}))
+					result = append(result, target.delete(path))
+				}
+
+			default:
+				result = append(result, fmt.Sprintf("# %s: removal cannot be expressed using yq", diff.Path.String()))
+			}
+
+		case ORDERCHANGE:
+			result = append(result, fmt.Sprintf("# %s: order change cannot be expressed using yq", diff.Path.String()))
+
+		case DIRECTIVECHANGE:
+			result = append(result, fmt.Sprintf("# %s: directive change cannot be expressed using yq", diff.Path.String()))
+
+		case MOVED:
+			previous, err := ytbx.ParseGoPatchStylePathString(detail.From.Value)
+			if err != nil || len(diff.Path.PathElements) == 0 {
+				result = append(result, fmt.Sprintf("# %s: move cannot be expressed using yq", diff.Path.String()))
+				continue
+			}
+
+			result = append(result,
+				target.assign(yqPath(diff.Path.PathElements), yqPath(previous.PathElements)),
+				target.delete(yqPath(previous.PathElements)),
+			)
+		}
+	}
+
+	return result, nil
+}
+
+// removedIndices returns the indices of the removed entries in the list of the
+// from input file in ascending order, each entry of the list is only used once
+// so that duplicate entries are matched to distinct indices
+func (report *YQReport) removedIndices(path *ytbx.Path, removed *yamlv3.Node) ([]int, bool) {
+	if path.DocumentIdx >= len(report.From.Documents) {
+		return nil, false
+	}
+
+	list, err := lookupNode(documentRoot(report.From.Documents[path.DocumentIdx]), path.PathElements)
+	if err != nil || list.Kind != yamlv3.SequenceNode {
+		return nil, false
+	}
+
+	var used = make([]bool, len(list.Content))
+	var result []int
+	for _, entry := range removed.Content {
+		var found bool
+		for i, candidate := range list.Content {
+			if !used[i] && canonicalString(candidate) == canonicalString(entry) {
+				used[i], found = true, true
+				result = append(result, i)
+				break
+			}
+		}
+
+		if !found {
+			return nil, false
+		}
+	}
+
+	sort.Ints(result)
+	return result, true
+}
+
+// listAssignment returns the expression that assigns the list of the to
+// input file to the path
+func (report *YQReport) listAssignment(path *ytbx.Path) (string, error) {
+	list, err := report.toValue(path)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := yqValue(list)
+	if err != nil {
+		return "", err
+	}
+
+	var target = yqTarget{
+		multipleDocuments: len(report.From.Documents) > 1,
+		documentIdx:       path.DocumentIdx,
+	}
+
+	return target.assign(yqPath(path.PathElements), value), nil
+}
+
+// yqTarget is the document that the expressions refer to, which needs to be
+// selected explicitly in case the input file contains more than one document
+type yqTarget struct {
+	multipleDocuments bool
+	documentIdx       int
+}
+
+func (t yqTarget) path(path string) string {
+	if t.multipleDocuments {
+		return fmt.Sprintf("select(documentIndex == %d) | %s", t.documentIdx, path)
+	}
+
+	return path
+}
+
+func (t yqTarget) assign(path string, value string) string {
+	return t.update(path, "=", value)
+}
+
+func (t yqTarget) update(path string, operator string, value string) string {
+	if target := t.path(path); strings.Contains(target, "|") {
+		return fmt.Sprintf("(%s) %s %s", target, operator, value)
+	}
+
+	return fmt.Sprintf("%s %s %s", path, operator, value)
+}
+
+func (t yqTarget) delete(path string) string {
+	return fmt.Sprintf("del(%s)", t.path(path))
+}
+
+// yqPath translates the path elements into a yq path expression, for example
+// .list[] | select(.name == "one") | .key
+func yqPath(elements []ytbx.PathElement) string {
+	var result strings.Builder
+	var afterSelect bool
+
+	for _, element := range elements {
+		switch {
+		case element.Key != "":
+			if result.Len() == 0 {
+				result.WriteString(".")
+			}
+
+			fmt.Fprintf(&result, "[] | select(.%s == %s)", yqKey(element.Key), strconv.Quote(element.Name))
+			afterSelect = true
+			continue
+
+		case element.Name != "":
+			if afterSelect {
+				result.WriteString(" | ")
+			}
+
+			result.WriteString(".")
+			result.WriteString(yqKey(element.Name))
+
+		default:
+			switch {
+			case afterSelect:
+				result.WriteString(" | .")
+
+			case result.Len() == 0:
+				result.WriteString(".")
+			}
+
+			fmt.Fprintf(&result, "[%d]", element.Idx)
+		}
+
+		afterSelect = false
+	}
+
+	if result.Len() == 0 {
+		return "."
+	}
+
+	return result.String()
+}
+
+func yqKey(key string) string {
+	if yqIdentifier.MatchString(key) {
+		return key
+	}
+
+	return strconv.Quote(key)
+}
+
+func yqValue(node *yamlv3.Node) (string, error) {
+	if node == nil {
+		return "null", nil
+	}
+
+	output, err := CompactJSON(node)
+	if err != nil {
+		return "", fmt.Errorf("failed to create yq value: %w", err)
+	}
+
+	return output, nil
+}
+
+func copyElements(elements []ytbx.PathElement) []ytbx.PathElement {
+	result := make([]ytbx.PathElement, len(elements))
+	copy(result, elements)
+	return result
+}
@@ -0,0 +1,119 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"bytes"
+
+	"github.com/gonvenience/ytbx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("yq report", func() {
+	yq := func(from, to string) string {
+		report, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Documents: []*yamlv3.Node{yml(from)}},
+			ytbx.InputFile{Documents: []*yamlv3.Node{yml(to)}},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		var buf bytes.Buffer
+		Expect((&dyff.YQReport{Report: report}).WriteReport(&buf)).To(Succeed())
+		return buf.String()
+	}
+
+	It("should create assignments for value changes and additions", func() {
+		Expect(yq(`---
+spec:
+  replicas: 1
+  image-name: foo
+`, `---
+spec:
+  replicas: 2
+  image-name: bar
+  labels:
+    app: foo
+`)).To(Equal(`.spec.labels = {"app": "foo"}
+.spec.replicas = 2
+.spec."image-name" = "bar"
+`))
+	})
+
+	It("should render the expressions of a single difference", func() {
+		report, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Documents: []*yamlv3.Node{yml(`{spec: {replicas: 1, name: foo}}`)}},
+			ytbx.InputFile{Documents: []*yamlv3.Node{yml(`{spec: {replicas: 2, name: foo}}`)}},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Diffs).To(HaveLen(1))
+
+		Expect((&dyff.YQReport{Report: report}).RenderDiff(report.Diffs[0])).To(Equal(".spec.replicas = 2\n"))
+	})
+
+	It("should create deletions for removed map and list entries", func() {
+		Expect(yq(`---
+list:
+- one
+- two
+foo: bar
+`, `---
+list:
+- one
+`)).To(Equal(`del(.foo)
+del(.list[1])
+`))
+	})
+
+	It("should only delete the removed entry of a list with duplicate entries", func() {
+		Expect(yq(`---
+dup: [1, 1, 2]
+`, `---
+dup: [1, 2]
+`)).To(Equal(`del(.dup[0])
+`))
+
+		Expect(yq(`---
+dup: [1, 3, 1, 2, 3]
+`, `---
+dup: [1, 2]
+`)).To(Equal(`del(.dup[4])
+del(.dup[1])
+del(.dup[0])
+`))
+	})
+
+	It("should select entries of named lists", func() {
+		Expect(yq(`---
+containers:
+- name: app
+  image: app:1
+`, `---
+containers:
+- name: app
+  image: app:2
+`)).To(Equal(`(.containers[] | select(.name == "app") | .image) = "app:2"
+`))
+	})
+})
@@ -0,0 +1,131 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gonvenience/ytbx"
+)
+
+// Names of the built-in path styles
+const (
+	// DotPathStyle renders paths like `spec.containers.app.image`
+	DotPathStyle = "dot"
+
+	// GoPatchPathStyle renders paths like `/spec/containers/name=app/image`
+	GoPatchPathStyle = "go-patch"
+)
+
+// PathStyle renders the path of a difference in the reports, for example in
+// the style of a tool that is used to process the files. The path is nil for
+// file level differences, and has no path elements for differences at the
+// root level of a document. The result can contain bunt markup for styling,
+// e.g. `*bold*` or `_italic_`.
+type PathStyle interface {
+	RenderPath(path *ytbx.Path) string
+}
+
+// PathStyleFunc is a function that implements the PathStyle interface
+type PathStyleFunc func(path *ytbx.Path) string
+
+// RenderPath calls the function
+func (f PathStyleFunc) RenderPath(path *ytbx.Path) string {
+	return f(path)
+}
+
+var pathStyles = struct {
+	sync.RWMutex
+	styles map[string]PathStyle
+}{
+	styles: map[string]PathStyle{
+		DotPathStyle:     PathStyleFunc(styledDotStylePath),
+		GoPatchPathStyle: PathStyleFunc(styledGoPatchPath),
+	},
+}
+
+// RegisterPathStyle registers a path style under the provided name, so that
+// it can be used by the reporters (see HumanReport). A style that is already
+// registered with the name is replaced.
+func RegisterPathStyle(name string, style PathStyle) {
+	pathStyles.Lock()
+	defer pathStyles.Unlock()
+
+	pathStyles.styles[name] = style
+}
+
+// UnregisterPathStyle removes the path style with the provided name, the
+// built-in styles cannot be removed
+func UnregisterPathStyle(name string) {
+	if name == DotPathStyle || name == GoPatchPathStyle {
+		return
+	}
+
+	pathStyles.Lock()
+	defer pathStyles.Unlock()
+
+	delete(pathStyles.styles, name)
+}
+
+// LookupPathStyle returns the path style with the provided name
+func LookupPathStyle(name string) (PathStyle, bool) {
+	pathStyles.RLock()
+	defer pathStyles.RUnlock()
+
+	style, ok := pathStyles.styles[name]
+	return style, ok
+}
+
+// PathStyleNames returns the sorted names of all registered path styles
+func PathStyleNames() []string {
+	pathStyles.RLock()
+	defer pathStyles.RUnlock()
+
+	names := make([]string, 0, len(pathStyles.styles))
+	for name := range pathStyles.styles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// pathStyleFor returns the path style with the provided name, or the dot or
+// go-patch style depending on the flag if no name is provided
+func pathStyleFor(name string, useGoPatchPaths bool) (PathStyle, error) {
+	switch {
+	case name != "":
+		if style, ok := LookupPathStyle(name); ok {
+			return style, nil
+		}
+
+		return nil, fmt.Errorf("unknown path style %q, supported styles are %s", name, strings.Join(PathStyleNames(), ", "))
+
+	case useGoPatchPaths:
+		return PathStyleFunc(styledGoPatchPath), nil
+
+	default:
+		return PathStyleFunc(styledDotStylePath), nil
+	}
+}
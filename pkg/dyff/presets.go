@@ -0,0 +1,113 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Names of the built-in presets
+const (
+	// ConcoursePreset identifies the steps of Concourse pipeline plans by
+	// their get, put, task, set_pipeline, or load_var field
+	ConcoursePreset = "concourse"
+
+	// ComposePreset normalizes the syntaxes of Docker Compose services (e.g.
+	// of ports), and ignores the order of lists in services where it has no
+	// meaning
+	ComposePreset = "compose"
+
+	// OpenAPIPreset ignores the order of lists in OpenAPI (or Swagger)
+	// documents (e.g. parameters, or enum values), see also the breaking
+	// changes of a report in Report.OpenAPIBreakingChanges
+	OpenAPIPreset = "openapi"
+
+	// AnsiblePreset identifies tasks, handlers, and plays of Ansible
+	// playbooks and roles, and ignores the format of conditions
+	AnsiblePreset = "ansible"
+
+	// CloudInitPreset identifies users, groups, and files of a cloud-config,
+	// and ignores the order of lists where it has no meaning (e.g. packages)
+	CloudInitPreset = "cloud-init"
+
+	// PrometheusPreset identifies the rules of Prometheus rule groups by
+	// their alert or record field, and ignores the whitespace of expressions
+	PrometheusPreset = "prometheus"
+)
+
+var presets = struct {
+	sync.RWMutex
+	options map[string][]CompareOption
+}{
+	options: map[string][]CompareOption{
+		ConcoursePreset:  {ConcourseSteps(true)},
+		ComposePreset:    composePresetOptions(),
+		OpenAPIPreset:    openAPIPresetOptions(),
+		AnsiblePreset:    {AnsibleTasks(true)},
+		CloudInitPreset:  cloudInitPresetOptions(),
+		PrometheusPreset: {PrometheusRules(true)},
+	},
+}
+
+// RegisterPreset registers a preset, which is a named set of compare options
+// for a well-known file type. A preset that is already registered with the
+// name is replaced.
+func RegisterPreset(name string, options ...CompareOption) {
+	presets.Lock()
+	defer presets.Unlock()
+
+	presets.options[name] = options
+}
+
+// PresetNames returns the sorted names of all registered presets
+func PresetNames() []string {
+	presets.RLock()
+	defer presets.RUnlock()
+
+	names := make([]string, 0, len(presets.options))
+	for name := range presets.options {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Preset returns a compare option that applies all compare options of the
+// preset with the provided name
+func Preset(name string) (CompareOption, error) {
+	presets.RLock()
+	options, ok := presets.options[name]
+	presets.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown preset %q, supported presets are %s", name, strings.Join(PresetNames(), ", "))
+	}
+
+	return func(settings *compareSettings) {
+		for _, option := range options {
+			option(settings)
+		}
+	}, nil
+}
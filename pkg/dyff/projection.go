@@ -0,0 +1,198 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gonvenience/text"
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// projectionIdentifiers are the fields of list entries that are kept in the
+// projection, so that list entries can still be matched by their identifier
+var projectionIdentifiers = []string{"name", "key", "id"}
+
+// Project reduces all documents of the input file to the provided paths, all
+// other content is dropped. Paths use the slash separated style, for example
+// `/spec/template/spec/containers/*/image`, where `*` matches all entries of a
+// list or map, and `name=value` matches the named entries of a list. The fields
+// that identify a document (Kubernetes apiVersion, kind, and metadata name and
+// namespace) or a list entry (name, key, or id) are always kept, so that the
+// projected documents and list entries can still be matched with each other.
+func Project(inputFile *ytbx.InputFile, paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	var projections = make([][]string, len(paths))
+	for i, path := range paths {
+		segments, err := projectionSegments(path)
+		if err != nil {
+			return err
+		}
+
+		projections[i] = segments
+	}
+
+	// Kubernetes documents are matched by these fields, so keep them
+	var documentProjections = append(projections[:len(projections):len(projections)],
+		[]string{"apiVersion"},
+		[]string{"kind"},
+		[]string{"metadata", "name"},
+		[]string{"metadata", "namespace"},
+	)
+
+	for i, document := range inputFile.Documents {
+		if document == nil || len(document.Content) == 0 {
+			continue
+		}
+
+		root := followAlias(document.Content[0])
+		result := project(root, documentProjections)
+		if result == nil {
+			result = &yamlv3.Node{Kind: root.Kind, Tag: root.Tag}
+		}
+
+		inputFile.Documents[i] = &yamlv3.Node{
+			Kind:    yamlv3.DocumentNode,
+			Content: []*yamlv3.Node{result},
+		}
+	}
+
+	AddNote(inputFile, fmt.Sprintf("YAML was reduced to %s", text.Plural(len(paths), "projected path")))
+
+	return nil
+}
+
+func projectionSegments(path string) ([]string, error) {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("invalid projection path %q, expected a path starting with a slash", path)
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(path, "/")[1:] {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("invalid projection path %q, expected at least one path element", path)
+	}
+
+	return segments, nil
+}
+
+// project returns a copy of the node that only contains the content of the
+// provided paths (list of path segments), or nil if none of the paths exist
+func project(node *yamlv3.Node, paths [][]string) *yamlv3.Node {
+	node = followAlias(node)
+
+	// rests returns the remaining segments of all paths that match, and
+	// whether one of the paths ends at the current position
+	var rests = func(matches func(segment string) bool) ([][]string, bool) {
+		var result [][]string
+		for _, path := range paths {
+			if matches(path[0]) {
+				if len(path) == 1 {
+					return nil, true
+				}
+
+				result = append(result, path[1:])
+			}
+		}
+
+		return result, false
+	}
+
+	var result = &yamlv3.Node{Kind: node.Kind, Tag: node.Tag, Style: node.Style}
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			remaining, complete := rests(func(segment string) bool {
+				return segment == "*" || segment == followAlias(k).Value
+			})
+
+			switch {
+			case complete:
+				result.Content = append(result.Content, k, v)
+
+			case len(remaining) > 0:
+				if projected := project(v, remaining); projected != nil {
+					result.Content = append(result.Content, k, projected)
+				}
+			}
+		}
+
+	case yamlv3.SequenceNode:
+		for idx, entry := range node.Content {
+			remaining, complete := rests(func(segment string) bool {
+				return matchesListEntry(segment, idx, followAlias(entry))
+			})
+
+			switch {
+			case complete:
+				result.Content = append(result.Content, entry)
+
+			case len(remaining) > 0:
+				// Keep the identifying fields of list entries so that the
+				// projected entries can still be matched by their name
+				for _, identifier := range projectionIdentifiers {
+					remaining = append(remaining, []string{identifier})
+				}
+
+				if projected := project(entry, remaining); projected != nil {
+					result.Content = append(result.Content, projected)
+				}
+			}
+		}
+	}
+
+	if len(result.Content) == 0 {
+		return nil
+	}
+
+	return result
+}
+
+func matchesListEntry(segment string, idx int, entry *yamlv3.Node) bool {
+	if segment == "*" {
+		return true
+	}
+
+	if key, value, ok := strings.Cut(segment, "="); ok {
+		if entry.Kind != yamlv3.MappingNode {
+			return false
+		}
+
+		node, found := findValueByKey(entry, key)
+		return found && followAlias(node).Value == value
+	}
+
+	number, err := strconv.Atoi(segment)
+	return err == nil && number == idx
+}
@@ -0,0 +1,199 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// prometheusRuleKeys are the fields that identify a rule of a Prometheus rule
+// group, which is either an alerting or a recording rule
+var prometheusRuleKeys = []string{"alert", "record"}
+
+// PrometheusRules enables the identification of the rules of Prometheus rule
+// files (and PrometheusRule resources) by their `alert` or `record` field, so
+// that a changed rule is reported as such rather than as a change of the whole
+// list of rules of a group. Rule groups are identified by their name anyway.
+// Lists of rules with ambiguous names are compared as usual. Also, PromQL
+// expressions (`expr`) of rules are compared regardless of their whitespace.
+func PrometheusRules(value bool) CompareOption {
+	return func(settings *compareSettings) {
+		settings.PrometheusRules = value
+	}
+}
+
+// prometheusRule is a list item identifier for alerting and recording rules,
+// the name of a rule is its field and value, for example `alert:HighLatency`
+type prometheusRule struct{}
+
+var _ listItemIdentifier = &prometheusRule{}
+
+func (*prometheusRule) Name(node *yamlv3.Node) (string, error) {
+	node = followAlias(node)
+	if node.Kind != yamlv3.MappingNode {
+		return "", fmt.Errorf("provided node is not a mapping node")
+	}
+
+	for _, key := range prometheusRuleKeys {
+		if value, ok := findValueByKey(node, key); ok {
+			if value = followAlias(value); value.Kind == yamlv3.ScalarNode && value.Value != "" {
+				return key + ":" + value.Value, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("provided node is not a Prometheus rule")
+}
+
+func (pr *prometheusRule) FindNodeByName(sequenceNode *yamlv3.Node, name string) (*yamlv3.Node, error) {
+	for _, entry := range sequenceNode.Content {
+		if nameOfNode, err := pr.Name(entry); err == nil && nameOfNode == name {
+			return entry, nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to find rule with name %q", name)
+}
+
+func (*prometheusRule) String() string {
+	return "rule"
+}
+
+// PathElement returns the path element of the rule, which refers to the
+// identifying field, for example `alert=HighLatency`
+func (*prometheusRule) PathElement(name string) ytbx.PathElement {
+	key, value, _ := strings.Cut(name, ":")
+	return ytbx.PathElement{Idx: -1, Key: key, Name: value}
+}
+
+// getPrometheusRuleIdentifier returns the rule identifier for lists named
+// rules, if all entries of both lists are rules with a unique name
+func (compare *compare) getPrometheusRuleIdentifier(path ytbx.Path, listA, listB *yamlv3.Node) listItemIdentifier {
+	if !compare.settings.PrometheusRules || len(path.PathElements) == 0 {
+		return nil
+	}
+
+	if path.PathElements[len(path.PathElements)-1].Name != "rules" {
+		return nil
+	}
+
+	identifier := &prometheusRule{}
+	if !hasUniqueNames(identifier, listA, listB) {
+		return nil
+	}
+
+	return identifier
+}
+
+// normalizePrometheusExpressions normalizes the expressions of all rules of
+// the documents, the documents are copied so that the input files keep their
+// original content
+func (compare *compare) normalizePrometheusExpressions(inputFiles ...*ytbx.InputFile) {
+	if !compare.settings.PrometheusRules {
+		return
+	}
+
+	for _, inputFile := range inputFiles {
+		documents := make([]*yamlv3.Node, len(inputFile.Documents))
+		for i, document := range inputFile.Documents {
+			documents[i] = copyNode(document)
+			normalizeExpressionsIn(documents[i], "")
+		}
+
+		inputFile.Documents = documents
+	}
+}
+
+func normalizeExpressionsIn(node *yamlv3.Node, key string) {
+	switch node.Kind {
+	case yamlv3.DocumentNode:
+		for _, entry := range node.Content {
+			normalizeExpressionsIn(entry, "")
+		}
+
+	case yamlv3.SequenceNode:
+		for _, entry := range node.Content {
+			if key == "rules" && entry.Kind == yamlv3.MappingNode {
+				if expr, ok := findValueByKey(entry, "expr"); ok && expr.Kind == yamlv3.ScalarNode {
+					expr.Value = normalizePromQL(expr.Value)
+					expr.Style = 0
+				}
+			}
+
+			normalizeExpressionsIn(entry, "")
+		}
+
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			normalizeExpressionsIn(node.Content[i+1], node.Content[i].Value)
+		}
+	}
+}
+
+// normalizePromQL collapses whitespace of a PromQL expression that is not part
+// of a quoted string, and removes it next to brackets and commas entirely, so
+// that for example `sum by (job) ( rate(x[5m]) )` and `sum by(job)(rate(x[5m]))`
+// are the same
+func normalizePromQL(expression string) string {
+	var (
+		result strings.Builder
+		quote  rune
+		last   rune
+		space  bool
+	)
+
+	isTight := func(r rune) bool {
+		return strings.ContainsRune("()[]{},", r)
+	}
+
+	for _, r := range strings.TrimSpace(expression) {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			space = true
+			continue
+		}
+
+		if space {
+			if last != 0 && !isTight(last) && !isTight(r) {
+				result.WriteRune(' ')
+			}
+
+			space = false
+		}
+
+		result.WriteRune(r)
+		last = r
+	}
+
+	return result.String()
+}
@@ -0,0 +1,374 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// PropertiesFormat is the format of a flat key/value file
+type PropertiesFormat string
+
+// Supported key/value file formats
+const (
+	JavaProperties PropertiesFormat = "properties"
+	DotEnv         PropertiesFormat = "dotenv"
+)
+
+// propertiesFormatOf returns the key/value file format of the location based
+// on the file name, for example `app.properties`, `.env`, or `prod.env`. A
+// file extension that does not stand for a known format (e.g. the `.new` of
+// `app.properties.new`, or the `.local` of `.env.local`) is ignored.
+func propertiesFormatOf(location string) (PropertiesFormat, bool) {
+	var name = strings.ToLower(filepath.Base(location))
+
+	for i := 0; i < 2; i++ {
+		switch ext := filepath.Ext(name); {
+		case ext == ".properties":
+			return JavaProperties, true
+
+		case ext == ".env":
+			return DotEnv, true
+
+		case ext == "" || isKnownExtension(ext):
+			return "", false
+
+		default:
+			name = strings.TrimSuffix(name, ext)
+		}
+	}
+
+	return "", false
+}
+
+func isKnownExtension(ext string) bool {
+	switch ext {
+	case ".yml", ".yaml", ".json", ".toml", ".tf", ".tfvars", ".hcl", ".ini", ".cfg":
+		return true
+	}
+
+	return false
+}
+
+// LoadPropertiesDocuments loads the provided data of a Java properties or
+// dotenv file as a document with a flat map, so that changes are reported per
+// key. All values are strings, keys that are defined more than once keep the
+// position of their first definition and the value of the last one.
+func LoadPropertiesDocuments(data []byte, format PropertiesFormat) ([]*yamlv3.Node, error) {
+	var (
+		root  = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		index = map[string]*yamlv3.Node{}
+		lines = strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	)
+
+	var add = func(key string, value string) {
+		if node, ok := index[key]; ok {
+			node.Value = value
+			return
+		}
+
+		index[key] = &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value}
+		root.Content = append(root.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: key}, index[key])
+	}
+
+	for i := 0; i < len(lines); i++ {
+		var (
+			key, value string
+			next       int
+			err        error
+		)
+
+		switch format {
+		case JavaProperties:
+			key, value, next, err = propertiesEntry(lines, i)
+
+		case DotEnv:
+			key, value, next, err = dotEnvEntry(lines, i)
+
+		default:
+			return nil, fmt.Errorf("unsupported key/value file format %q", format)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if key != "" {
+			add(key, value)
+		}
+
+		i = next
+	}
+
+	return []*yamlv3.Node{{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{root}}}, nil
+}
+
+// propertiesEntry reads the entry starting in the given line of a Java
+// properties file, and returns it together with the index of its last line,
+// since lines ending with a backslash continue in the next line
+func propertiesEntry(lines []string, i int) (string, string, int, error) {
+	var line = strings.TrimLeft(lines[i], " \t\f")
+	if line == "" || line[0] == '#' || line[0] == '!' {
+		return "", "", i, nil
+	}
+
+	for continues(line) && i+1 < len(lines) {
+		i++
+		line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+	}
+
+	// the key ends with the first unescaped separator (`=`, `:`, or whitespace)
+	var end = len(line)
+	for j := 0; j < len(line); j++ {
+		if line[j] == '\\' {
+			j++
+			continue
+		}
+
+		if strings.ContainsRune("=: \t\f", rune(line[j])) {
+			end = j
+			break
+		}
+	}
+
+	var rest = strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	key, err := unescapeProperty(line[:end])
+	if err != nil {
+		return "", "", i, fmt.Errorf("failed to parse properties in line %d: %w", i+1, err)
+	}
+
+	value, err := unescapeProperty(rest)
+	if err != nil {
+		return "", "", i, fmt.Errorf("failed to parse properties in line %d: %w", i+1, err)
+	}
+
+	return key, value, i, nil
+}
+
+// continues returns whether the line ends with an odd number of backslashes
+func continues(line string) bool {
+	var count int
+	for j := len(line) - 1; j >= 0 && line[j] == '\\'; j-- {
+		count++
+	}
+
+	return count%2 == 1
+}
+
+func unescapeProperty(text string) (string, error) {
+	var result strings.Builder
+	for j := 0; j < len(text); j++ {
+		if text[j] != '\\' || j+1 == len(text) {
+			result.WriteByte(text[j])
+			continue
+		}
+
+		j++
+		switch text[j] {
+		case 't':
+			result.WriteByte('\t')
+
+		case 'n':
+			result.WriteByte('\n')
+
+		case 'r':
+			result.WriteByte('\r')
+
+		case 'f':
+			result.WriteByte('\f')
+
+		case 'u':
+			if j+5 > len(text) {
+				return "", fmt.Errorf("malformed unicode escape sequence")
+			}
+
+			code, err := strconv.ParseUint(text[j+1:j+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed unicode escape sequence: %w", err)
+			}
+
+			result.WriteRune(rune(code))
+			j += 4
+
+		default:
+			result.WriteByte(text[j])
+		}
+	}
+
+	return result.String(), nil
+}
+
+// dotEnvEntry reads the entry starting in the given line of a dotenv file,
+// and returns it together with the index of its last line, since double
+// quoted values can span multiple lines
+func dotEnvEntry(lines []string, i int) (string, string, int, error) {
+	var line = strings.TrimSpace(lines[i])
+	if line == "" || line[0] == '#' {
+		return "", "", i, nil
+	}
+
+	line = strings.TrimPrefix(line, "export ")
+
+	key, value, found := strings.Cut(line, "=")
+	if !found {
+		return "", "", i, fmt.Errorf("failed to parse dotenv in line %d: missing `=` after %q", i+1, key)
+	}
+
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+	switch {
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", "", i, fmt.Errorf("failed to parse dotenv in line %d: unterminated single quoted value of %s", i+1, key)
+		}
+
+		return key, value[1 : end+1], i, nil
+
+	case strings.HasPrefix(value, `"`):
+		var start = i
+		var text = value[1:]
+		for {
+			if end := closingQuote(text); end >= 0 {
+				return key, unescapeDotEnv(text[:end]), i, nil
+			}
+
+			if i+1 == len(lines) {
+				return "", "", i, fmt.Errorf("failed to parse dotenv in line %d: unterminated double quoted value of %s", start+1, key)
+			}
+
+			i++
+			text += "\n" + lines[i]
+		}
+
+	default:
+		// inline comments require whitespace in front of the hash sign
+		if idx := strings.Index(value, " #"); idx >= 0 {
+			value = strings.TrimSpace(value[:idx])
+		}
+
+		return key, value, i, nil
+	}
+}
+
+// closingQuote returns the index of the first unescaped double quote
+func closingQuote(text string) int {
+	for j := 0; j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			j++
+
+		case '"':
+			return j
+		}
+	}
+
+	return -1
+}
+
+func unescapeDotEnv(text string) string {
+	return strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(text)
+}
+
+// Properties returns the Java properties representation of the provided node,
+// which has to be a map. Nested maps and lists are flattened into keys with
+// the usual notation, for example `server.port` and `hosts[0]`. Null values
+// and empty maps or lists are written with an empty value.
+func Properties(node *yamlv3.Node) (string, error) {
+	node = documentRoot(followAlias(node))
+	if node.Kind != yamlv3.MappingNode {
+		return "", fmt.Errorf("only a map can be written as properties, but found %s", humanReadableType(node))
+	}
+
+	var buf strings.Builder
+	writeProperties(&buf, "", node)
+	return buf.String(), nil
+}
+
+func writeProperties(buf *strings.Builder, prefix string, node *yamlv3.Node) {
+	switch node = followAlias(node); {
+	case node.Kind == yamlv3.MappingNode && len(node.Content) > 0:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			var key = escapeProperty(node.Content[i].Value, true)
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+
+			writeProperties(buf, key, node.Content[i+1])
+		}
+
+	case node.Kind == yamlv3.SequenceNode && len(node.Content) > 0:
+		for i, entry := range node.Content {
+			writeProperties(buf, fmt.Sprintf("%s[%d]", prefix, i), entry)
+		}
+
+	case node.Kind == yamlv3.ScalarNode && !isNullNode(node):
+		fmt.Fprintf(buf, "%s=%s\n", prefix, escapeProperty(node.Value, false))
+
+	default:
+		fmt.Fprintf(buf, "%s=\n", prefix)
+	}
+}
+
+// escapeProperty escapes the text so that it is loaded as the same key or
+// value, for values only leading whitespace needs to be escaped
+func escapeProperty(text string, isKey bool) string {
+	var result strings.Builder
+	for i, r := range text {
+		switch {
+		case r == '\\':
+			result.WriteString(`\\`)
+
+		case r == '\n':
+			result.WriteString(`\n`)
+
+		case r == '\r':
+			result.WriteString(`\r`)
+
+		case r == '\t':
+			result.WriteString(`\t`)
+
+		case r == '\f':
+			result.WriteString(`\f`)
+
+		case r == ' ' && (isKey || strings.TrimLeft(text[:i], " ") == ""):
+			result.WriteString(`\ `)
+
+		case isKey && strings.ContainsRune("=:#!", r):
+			result.WriteRune('\\')
+			result.WriteRune(r)
+
+		default:
+			result.WriteRune(r)
+		}
+	}
+
+	return result.String()
+}
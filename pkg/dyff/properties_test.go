@@ -0,0 +1,174 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("Properties and dotenv input", func() {
+	var load = func(input string, format dyff.PropertiesFormat) *yamlv3.Node {
+		documents, err := dyff.LoadPropertiesDocuments([]byte(input), format)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(documents).To(HaveLen(1))
+		return documents[0].Content[0]
+	}
+
+	var entries = func(node *yamlv3.Node) [][2]string {
+		var result [][2]string
+		for i := 0; i < len(node.Content); i += 2 {
+			result = append(result, [2]string{node.Content[i].Value, node.Content[i+1].Value})
+		}
+
+		return result
+	}
+
+	It("should load Java properties as a flat map of strings", func() {
+		node := load(`# server settings
+! also a comment
+server.port=8080
+server.host : localhost
+greeting Hello\tWorld
+path = C:\\temp
+message = first line \
+          second line
+unicode=\u00e4
+empty=
+server.port = 9090
+`, dyff.JavaProperties)
+
+		Expect(entries(node)).To(Equal([][2]string{
+			{"server.port", "9090"},
+			{"server.host", "localhost"},
+			{"greeting", "Hello\tWorld"},
+			{"path", `C:\temp`},
+			{"message", "first line second line"},
+			{"unicode", "ä"},
+			{"empty", ""},
+		}))
+
+		for i := 1; i < len(node.Content); i += 2 {
+			Expect(node.Content[i].Tag).To(Equal("!!str"))
+		}
+	})
+
+	It("should load dotenv files as a flat map of strings", func() {
+		node := load(`# database
+DB_HOST=localhost
+export DB_PORT=5432
+DB_USER='admin # not a comment'
+DB_PASS="s3cr\"et"
+CERT="-----BEGIN-----
+abc
+-----END-----"
+DEBUG=true # enable debugging
+EMPTY=
+`, dyff.DotEnv)
+
+		Expect(entries(node)).To(Equal([][2]string{
+			{"DB_HOST", "localhost"},
+			{"DB_PORT", "5432"},
+			{"DB_USER", "admin # not a comment"},
+			{"DB_PASS", `s3cr"et`},
+			{"CERT", "-----BEGIN-----\nabc\n-----END-----"},
+			{"DEBUG", "true"},
+			{"EMPTY", ""},
+		}))
+	})
+
+	It("should fail for malformed dotenv files", func() {
+		_, err := dyff.LoadPropertiesDocuments([]byte("FOO=bar\nnot an entry\n"), dyff.DotEnv)
+		Expect(err).To(MatchError(ContainSubstring("line 2")))
+
+		_, err = dyff.LoadPropertiesDocuments([]byte("FOO=\"bar\n"), dyff.DotEnv)
+		Expect(err).To(MatchError(ContainSubstring("unterminated double quoted value of FOO")))
+	})
+
+	It("should detect the format based on the file name", func() {
+		dir, err := os.MkdirTemp("", "dyff-properties")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		for _, name := range []string{"app.properties", "app.properties.new", ".env", ".env.local", "prod.env"} {
+			location := filepath.Join(dir, name)
+			Expect(os.WriteFile(location, []byte("key=value\n"), 0644)).To(Succeed())
+
+			inputFile, err := dyff.LoadFile(location)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inputFile.Documents).To(HaveLen(1))
+			Expect(entries(inputFile.Documents[0].Content[0])).To(Equal([][2]string{{"key", "value"}}), name)
+		}
+	})
+
+	It("should write properties that load as the same flat map", func() {
+		output, err := dyff.Properties(yml(`---
+server:
+  port: 8080
+  hosts: [alpha, beta]
+greeting: "  Hello\tWorld"
+key with spaces: a=b
+empty: ~
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(Equal(`server.port=8080
+server.hosts[0]=alpha
+server.hosts[1]=beta
+greeting=\ \ Hello\tWorld
+key\ with\ spaces=a=b
+empty=
+`))
+
+		Expect(entries(load(output, dyff.JavaProperties))).To(Equal([][2]string{
+			{"server.port", "8080"},
+			{"server.hosts[0]", "alpha"},
+			{"server.hosts[1]", "beta"},
+			{"greeting", "  Hello\tWorld"},
+			{"key with spaces", "a=b"},
+			{"empty", ""},
+		}))
+
+		_, err = dyff.Properties(yml(`[a, b]`))
+		Expect(err).To(MatchError(ContainSubstring("only a map can be written as properties")))
+	})
+
+	It("should report changes per key", func() {
+		from := load("a=1\nb=2\nc=3\n", dyff.JavaProperties)
+		to := load("c=3\na=1\nb=two\nd=4\n", dyff.JavaProperties)
+
+		diffs, err := compare(from, to)
+		Expect(err).ToNot(HaveOccurred())
+
+		var paths []string
+		for _, diff := range diffs {
+			paths = append(paths, diff.Path.String())
+		}
+
+		Expect(paths).To(ConsistOf("/b", "/"))
+	})
+})
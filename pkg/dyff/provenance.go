@@ -0,0 +1,70 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// InputProvenance describes where an input of a comparison came from, so that
+// consumers of a structured report can verify exactly what was compared. Size
+// and digest are only available for inputs with known content, for example
+// local files.
+type InputProvenance struct {
+	Location         string    `json:"location" yaml:"location"`
+	ResolvedLocation string    `json:"resolvedLocation" yaml:"resolvedLocation"`
+	Size             int64     `json:"size,omitempty" yaml:"size,omitempty"`
+	SHA256           string    `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+	LoadedAt         time.Time `json:"loadedAt" yaml:"loadedAt"`
+}
+
+// NewInputProvenance creates the provenance information for the provided input
+// location that was loaded at the given point in time. The size and digest are
+// based on the provided content, which has to be the data that was actually
+// compared, since the file might have changed in the meantime. Use nil for
+// inputs without content, like directories.
+func NewInputProvenance(location string, data []byte, loadedAt time.Time) InputProvenance {
+	result := InputProvenance{
+		Location:         location,
+		ResolvedLocation: location,
+		LoadedAt:         loadedAt.UTC(),
+	}
+
+	if data != nil {
+		result.Size = int64(len(data))
+		result.SHA256 = sha256Hex(data)
+	}
+
+	if info, err := os.Stat(location); err != nil || !info.Mode().IsRegular() {
+		return result
+	}
+
+	if abs, err := filepath.Abs(location); err == nil {
+		result.ResolvedLocation = abs
+		if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+			result.ResolvedLocation = resolved
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,60 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("Input provenance", func() {
+	It("should use the compared content for size and digest, not the current file", func() {
+		tmpDir, err := os.MkdirTemp("", "dyff-provenance")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(tmpDir)
+
+		location := filepath.Join(tmpDir, "input.yml")
+		Expect(os.WriteFile(location, []byte("foo: changed afterwards\n"), 0644)).To(Succeed())
+
+		compared := []byte("foo: bar\n")
+		sum := sha256.Sum256(compared)
+
+		provenance := dyff.NewInputProvenance(location, compared, time.Now())
+		Expect(provenance.Size).To(BeEquivalentTo(len(compared)))
+		Expect(provenance.SHA256).To(Equal(hex.EncodeToString(sum[:])))
+		Expect(filepath.IsAbs(provenance.ResolvedLocation)).To(BeTrue())
+	})
+
+	It("should only contain the location for inputs without content", func() {
+		provenance := dyff.NewInputProvenance("https://example.org/input.yml", nil, time.Now())
+		Expect(provenance.ResolvedLocation).To(Equal("https://example.org/input.yml"))
+		Expect(provenance.Size).To(BeZero())
+		Expect(provenance.SHA256).To(BeEmpty())
+	})
+})
@@ -0,0 +1,166 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// redactedString is the placeholder of all redacted strings
+const redactedString = "…"
+
+// RedactValues returns a copy of the report where all scalar values of the
+// differences and documents are replaced with placeholders of the same type:
+// strings become "…", numbers 0, and booleans false. The structure of the
+// changes (paths, map keys, and document names) is kept, so that a report can
+// be shared without leaking its data.
+func (r Report) RedactValues() Report {
+	var result = Report{
+		From:  redactInputFile(r.From),
+		To:    redactInputFile(r.To),
+		Diffs: make([]Diff, len(r.Diffs)),
+	}
+
+	var roots = map[*ytbx.InputFile]*ytbx.InputFile{}
+	var redactedRoot = func(root *ytbx.InputFile) *ytbx.InputFile {
+		switch {
+		case root == nil:
+			return nil
+
+		case isSameInputFile(root, r.From):
+			return &result.From
+
+		case isSameInputFile(root, r.To):
+			return &result.To
+		}
+
+		if _, ok := roots[root]; !ok {
+			redacted := redactInputFile(*root)
+			roots[root] = &redacted
+		}
+
+		return roots[root]
+	}
+
+	for i, diff := range r.Diffs {
+		if diff.Path != nil {
+			diff.Path = &ytbx.Path{
+				Root:         redactedRoot(diff.Path.Root),
+				DocumentIdx:  diff.Path.DocumentIdx,
+				PathElements: diff.Path.PathElements,
+			}
+		}
+
+		details := make([]Detail, len(diff.Details))
+		for j, detail := range diff.Details {
+			details[j] = Detail{
+				Kind: detail.Kind,
+				From: redactNode(detail.From),
+				To:   redactNode(detail.To),
+			}
+		}
+
+		diff.Details = details
+		result.Diffs[i] = diff
+	}
+
+	return result
+}
+
+func isSameInputFile(root *ytbx.InputFile, inputFile ytbx.InputFile) bool {
+	return root.Location == inputFile.Location &&
+		len(root.Documents) == len(inputFile.Documents) &&
+		(len(root.Documents) == 0 || root.Documents[0] == inputFile.Documents[0])
+}
+
+func redactInputFile(inputFile ytbx.InputFile) ytbx.InputFile {
+	documents := make([]*yamlv3.Node, len(inputFile.Documents))
+	for i, document := range inputFile.Documents {
+		documents[i] = redactNode(document)
+	}
+
+	return ytbx.InputFile{
+		Location:  inputFile.Location,
+		Note:      inputFile.Note,
+		Names:     inputFile.Names,
+		Documents: documents,
+	}
+}
+
+// redactNode returns a copy of the node with all scalar values (but not the
+// keys of maps) replaced with placeholders
+func redactNode(node *yamlv3.Node) *yamlv3.Node {
+	if node == nil {
+		return nil
+	}
+
+	result := copyNode(node)
+	redactValues(result)
+	return result
+}
+
+func redactValues(node *yamlv3.Node) {
+	// comments can contain anything, too
+	node.LineComment, node.HeadComment, node.FootComment = "", "", ""
+
+	switch node.Kind {
+	case yamlv3.DocumentNode, yamlv3.SequenceNode:
+		for _, content := range node.Content {
+			redactValues(content)
+		}
+
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			key.LineComment, key.HeadComment, key.FootComment = "", "", ""
+			redactValues(node.Content[i+1])
+		}
+
+	case yamlv3.ScalarNode:
+		redactScalar(node)
+	}
+}
+
+func redactScalar(node *yamlv3.Node) {
+	node.Style &^= yamlv3.LiteralStyle | yamlv3.FoldedStyle
+
+	switch node.ShortTag() {
+	case "!!null":
+		return
+
+	case "!!int":
+		node.Value = "0"
+
+	case "!!float":
+		node.Value = "0.0"
+
+	case "!!bool":
+		node.Value = "false"
+
+	case "!!str", "!!binary", "!!timestamp":
+		node.Tag, node.Value = "!!str", redactedString
+
+	default:
+		// custom tags are kept, so that the type of the value is still known
+		node.Value = redactedString
+	}
+}
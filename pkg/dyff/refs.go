@@ -0,0 +1,207 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// RefMode defines which references ($ref) are resolved before comparing
+type RefMode string
+
+// Supported reference modes
+const (
+	InternalRefs RefMode = "internal"
+	AllRefs      RefMode = "all"
+)
+
+// FollowRefs enables the resolution of references ($ref) in JSON Schema or
+// OpenAPI style documents before comparing them, so that moving a definition
+// into a reference is not reported as a change. With InternalRefs, only
+// references within the same document (#/components/schemas/Pet) are
+// resolved, AllRefs resolves references to other files (pet.yaml#/Pet) as
+// well, relative to the location of the input file. References that cannot
+// be resolved, and recursive references are kept as they are.
+func FollowRefs(mode RefMode) CompareOption {
+	return func(settings *compareSettings) {
+		settings.FollowRefs = mode
+	}
+}
+
+// refContext is the document a reference is resolved in
+type refContext struct {
+	location string
+	root     *yamlv3.Node
+}
+
+type refResolver struct {
+	mode     RefMode
+	external map[string]*yamlv3.Node
+}
+
+// resolveRefs replaces all resolvable references in the documents of the
+// input files with the referenced content
+func (compare *compare) resolveRefs(inputFiles ...*ytbx.InputFile) {
+	if compare.settings.FollowRefs != InternalRefs && compare.settings.FollowRefs != AllRefs {
+		return
+	}
+
+	resolver := refResolver{
+		mode:     compare.settings.FollowRefs,
+		external: map[string]*yamlv3.Node{},
+	}
+
+	for _, inputFile := range inputFiles {
+		documents := make([]*yamlv3.Node, len(inputFile.Documents))
+		for i, document := range inputFile.Documents {
+			documents[i] = copyNode(document)
+			resolver.resolve(documents[i], refContext{location: inputFile.Location, root: documentRoot(document)}, nil)
+		}
+
+		inputFile.Documents = documents
+	}
+}
+
+func (resolver *refResolver) resolve(node *yamlv3.Node, ctx refContext, stack []string) {
+	if node.Kind == yamlv3.MappingNode {
+		if ref, ok := mappingValue(node, "$ref"); ok && ref.Kind == yamlv3.ScalarNode {
+			if target, targetCtx, key, ok := resolver.lookup(ref.Value, ctx); ok && !slices.Contains(stack, key) {
+				replacement := copyNode(target)
+				resolver.resolve(replacement, targetCtx, append(stack[:len(stack):len(stack)], key))
+
+				// keys next to the reference extend or override the referenced map
+				for i := 0; i+1 < len(node.Content); i += 2 {
+					if node.Content[i].Value == "$ref" || replacement.Kind != yamlv3.MappingNode {
+						continue
+					}
+
+					resolver.resolve(node.Content[i+1], ctx, stack)
+					if idx := mappingKeyIndex(replacement, node.Content[i].Value); idx >= 0 {
+						replacement.Content[idx+1] = node.Content[i+1]
+					} else {
+						replacement.Content = append(replacement.Content, node.Content[i], node.Content[i+1])
+					}
+				}
+
+				*node = *replacement
+				return
+			}
+		}
+	}
+
+	for _, content := range node.Content {
+		resolver.resolve(content, ctx, stack)
+	}
+}
+
+func mappingKeyIndex(node *yamlv3.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if followAlias(node.Content[i]).Value == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// lookup returns the node the reference points to, the context to resolve
+// references in it, and a key that identifies the referenced node
+func (resolver *refResolver) lookup(ref string, ctx refContext) (*yamlv3.Node, refContext, string, bool) {
+	location, pointer, _ := strings.Cut(ref, "#")
+	if location != "" {
+		if resolver.mode != AllRefs {
+			return nil, refContext{}, "", false
+		}
+
+		if !strings.Contains(location, "://") && !filepath.IsAbs(location) && ctx.location != "" {
+			location = filepath.Join(filepath.Dir(ctx.location), location)
+		}
+
+		root, ok := resolver.external[location]
+		if !ok {
+			if inputFile, err := LoadFile(location); err == nil && len(inputFile.Documents) > 0 {
+				root = documentRoot(inputFile.Documents[0])
+			}
+
+			resolver.external[location] = root
+		}
+
+		if root == nil {
+			return nil, refContext{}, "", false
+		}
+
+		ctx = refContext{location: location, root: root}
+	}
+
+	node, ok := lookupPointer(ctx.root, pointer)
+	return node, ctx, ctx.location + "#" + pointer, ok
+}
+
+// lookupPointer returns the node at the JSON pointer (RFC 6901), which can be
+// URI fragment encoded
+func lookupPointer(root *yamlv3.Node, pointer string) (*yamlv3.Node, bool) {
+	if unescaped, err := url.PathUnescape(pointer); err == nil {
+		pointer = unescaped
+	}
+
+	var node = followAlias(root)
+	if pointer == "" || pointer == "/" {
+		return node, node != nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		switch node.Kind {
+		case yamlv3.MappingNode:
+			value, ok := findValueByKey(node, token)
+			if !ok {
+				return nil, false
+			}
+
+			node = followAlias(value)
+
+		case yamlv3.SequenceNode:
+			idx, err := strconv.Atoi(token)
+			if err != nil || idx < 0 || idx >= len(node.Content) {
+				return nil, false
+			}
+
+			node = followAlias(node.Content[idx])
+
+		default:
+			return nil, false
+		}
+	}
+
+	return node, true
+}
@@ -0,0 +1,113 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gonvenience/ytbx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("Following references", func() {
+	inline := yml(`---
+paths:
+  /pets:
+    get:
+      schema:
+        type: object
+        properties:
+          name: {type: string}
+`)
+
+	referenced := yml(`---
+paths:
+  /pets:
+    get:
+      schema:
+        $ref: "#/components/schemas/Pet"
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name: {type: string}
+`)
+
+	It("should report references as changes by default", func() {
+		diffs, err := compare(inline, referenced)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diffs).To(HaveLen(2))
+	})
+
+	It("should resolve internal references before comparing", func() {
+		diffs, err := compare(inline, referenced, dyff.FollowRefs(dyff.InternalRefs))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diffs).To(HaveLen(1))
+		Expect(diffs[0].Details).To(HaveLen(1))
+		Expect(diffs[0].Details[0].Kind).To(Equal(dyff.ADDITION))
+		Expect(diffs[0].Details[0].To.Content[0].Value).To(Equal("components"))
+	})
+
+	It("should extend the referenced map with the keys next to the reference", func() {
+		diffs, err := compare(
+			yml(`{schema: {type: object, description: pet}, definitions: {Pet: {type: object, description: other}}}`),
+			yml(`{schema: {$ref: "#/definitions/Pet", description: pet}, definitions: {Pet: {type: object, description: other}}}`),
+			dyff.FollowRefs(dyff.InternalRefs),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diffs).To(BeEmpty())
+	})
+
+	It("should keep recursive and unresolvable references as they are", func() {
+		diffs, err := compare(
+			yml(`{node: {$ref: "#/definitions/Node"}, missing: {$ref: "#/definitions/Missing"}, definitions: {Node: {properties: {children: {items: {$ref: "#/definitions/Node"}}}}}}`),
+			yml(`{node: {$ref: "#/definitions/Node"}, missing: {$ref: "#/definitions/Missing"}, definitions: {Node: {properties: {children: {items: {$ref: "#/definitions/Node"}}}}}}`),
+			dyff.FollowRefs(dyff.InternalRefs),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diffs).To(BeEmpty())
+	})
+
+	It("should only resolve references to other files if enabled", func() {
+		tmpDir, err := os.MkdirTemp("", "dyff-refs")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(tmpDir)
+
+		Expect(os.WriteFile(filepath.Join(tmpDir, "pet.yaml"), []byte("Pet:\n  type: object\n  properties:\n    name: {$ref: \"#/Name\"}\nName:\n  type: string\n"), 0644)).To(Succeed())
+
+		from := ytbx.InputFile{Location: filepath.Join(tmpDir, "from.yaml"), Documents: []*yamlv3.Node{inline}}
+		to := ytbx.InputFile{Location: filepath.Join(tmpDir, "to.yaml"), Documents: []*yamlv3.Node{yml(`{paths: {/pets: {get: {schema: {$ref: "pet.yaml#/Pet"}}}}}`)}}
+
+		report, err := dyff.CompareInputFiles(from, to, dyff.FollowRefs(dyff.InternalRefs))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Diffs).ToNot(BeEmpty())
+
+		report, err = dyff.CompareInputFiles(from, to, dyff.FollowRefs(dyff.AllRefs))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Diffs).To(BeEmpty())
+	})
+})
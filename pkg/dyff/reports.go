@@ -2,8 +2,11 @@ package dyff
 
 import (
 	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
 )
 
 func (r Report) filter(hasPath func(*ytbx.Path) bool) (result Report) {
@@ -40,6 +43,10 @@ func (r Report) Filter(paths ...string) (result Report) {
 }
 
 // Exclude accepts YAML paths as input and returns a new report with differences without those paths
+//
+// A path can be restricted to one document by prefixing it with the name of
+// the document and a colon, for example `v1/ConfigMap/app:/data/key`, see
+// DocumentQualifiedPath for the format.
 func (r Report) Exclude(paths ...string) (result Report) {
 	if len(paths) == 0 {
 		return r
@@ -47,8 +54,14 @@ func (r Report) Exclude(paths ...string) (result Report) {
 
 	return r.filter(func(filterPath *ytbx.Path) bool {
 		for _, pathString := range paths {
+			document, pathString := splitDocumentQualifiedPath(pathString)
+
 			path, err := ytbx.ParsePathStringUnsafe(pathString)
-			if err == nil && filterPath != nil && path.String() == filterPath.String() {
+			if err != nil || filterPath == nil || path.String() != filterPath.String() {
+				continue
+			}
+
+			if document == "" || document == filterPath.RootDescription() {
 				return false
 			}
 		}
@@ -57,6 +70,36 @@ func (r Report) Exclude(paths ...string) (result Report) {
 	})
 }
 
+// DocumentQualifiedPath returns the path in the format that Exclude accepts
+// to only match the path in its document: the document name (for example the
+// Kubernetes resource name, or `document #2`), a colon, and the path in
+// Go-patch style. Paths of inputs with only one unnamed document are returned
+// as they are.
+func DocumentQualifiedPath(path *ytbx.Path) string {
+	if path.Root == nil || (path.DocumentIdx >= len(path.Root.Names) && len(path.Root.Documents) < 2) {
+		return path.String()
+	}
+
+	return path.RootDescription() + ":" + path.ToGoPatchStyle()
+}
+
+// splitDocumentQualifiedPath splits a path in the format of
+// DocumentQualifiedPath into document name and path, the document name is
+// empty for plain paths
+func splitDocumentQualifiedPath(pathString string) (string, string) {
+	if strings.HasPrefix(pathString, "/") {
+		return "", pathString
+	}
+
+	// document names can contain colons (e.g. `system:auth-delegator`), but
+	// no colon that is followed by a slash
+	if idx := strings.Index(pathString, ":/"); idx > 0 {
+		return pathString[:idx], pathString[idx+1:]
+	}
+
+	return "", pathString
+}
+
 // FilterRegexp accepts regular expressions as input and returns a new report with differences for matching those patterns
 func (r Report) FilterRegexp(pattern ...string) (result Report) {
 	if len(pattern) == 0 {
@@ -112,12 +155,388 @@ func (r Report) IgnoreValueChanges() (result Report) {
 				hasValChange = true
 				break
 			}
-  		}
+		}
 
 		if !hasValChange {
 			result.Diffs = append(result.Diffs, diff)
 		}
 	}
 
-	return result	
+	return result
+}
+
+// IgnoreDocumentAdditions returns a new report without differences that are
+// about documents that were added as a whole, changes in documents that exist
+// in both input files are kept
+func (r Report) IgnoreDocumentAdditions() Report {
+	return r.ignoreDocumentChanges(ADDITION)
+}
+
+// IgnoreDocumentRemovals returns a new report without differences that are
+// about documents that were removed as a whole, changes in documents that
+// exist in both input files are kept
+func (r Report) IgnoreDocumentRemovals() Report {
+	return r.ignoreDocumentChanges(REMOVAL)
+}
+
+func (r Report) ignoreDocumentChanges(kind DetailKind) Report {
+	var isDocumentNode = func(node *yamlv3.Node) bool {
+		return node != nil && node.Kind == yamlv3.DocumentNode
+	}
+
+	result := Report{
+		From: r.From,
+		To:   r.To,
+	}
+
+	for _, diff := range r.Diffs {
+		var details []Detail
+		for _, detail := range diff.Details {
+			if detail.Kind == kind && (isDocumentNode(detail.From) || isDocumentNode(detail.To)) {
+				continue
+			}
+
+			// the order change of documents needs to be re-evaluated based on
+			// the documents that exist in both input files only
+			if detail.Kind == ORDERCHANGE && diff.Path == nil {
+				var ok bool
+				if detail, ok = withoutUnmatchedNames(detail); !ok {
+					continue
+				}
+			}
+
+			details = append(details, detail)
+		}
+
+		if len(details) > 0 {
+			result.Diffs = append(result.Diffs, Diff{Path: diff.Path, Details: details, FromSource: diff.FromSource, ToSource: diff.ToSource})
+		}
+	}
+
+	return result
+}
+
+// withoutUnmatchedNames removes the names of documents that only exist on one
+// side and returns whether there is still an order change afterwards
+func withoutUnmatchedNames(detail Detail) (Detail, bool) {
+	var names = func(node *yamlv3.Node) []string {
+		var result []string
+		for _, entry := range node.Content {
+			result = append(result, entry.Value)
+		}
+
+		return result
+	}
+
+	var without = func(list []string, other []string) []string {
+		var result []string
+		for _, entry := range list {
+			for _, candidate := range other {
+				if entry == candidate {
+					result = append(result, entry)
+					break
+				}
+			}
+		}
+
+		return result
+	}
+
+	from, to := names(detail.From), names(detail.To)
+	from, to = without(from, to), without(to, from)
+
+	changed := len(from) != len(to)
+	for i := 0; !changed && i < len(from); i++ {
+		if from[i] != to[i] {
+			changed = true
+			break
+		}
+	}
+
+	if !changed {
+		return detail, false
+	}
+
+	return Detail{Kind: ORDERCHANGE, From: AsSequenceNode(from...), To: AsSequenceNode(to...)}, true
+}
+
+// ExcludeDocuments accepts document selectors as input and returns a new
+// report without any differences that belong to documents with a matching
+// name, for example `v1/Secret/*`. The name of a document is based on its
+// Kubernetes resource name (api version, kind, optional namespace, and name).
+// In a selector, `*` matches any sequence of characters including slashes.
+func (r Report) ExcludeDocuments(selectors ...string) Report {
+	if len(selectors) == 0 {
+		return r
+	}
+
+	regexps := make([]*regexp.Regexp, len(selectors))
+	for i, selector := range selectors {
+		regexps[i] = documentSelectorRegexp(selector)
+	}
+
+	var matches = func(name string) bool {
+		for _, regexp := range regexps {
+			if name != "" && regexp.MatchString(name) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var excluded = func(node *yamlv3.Node) bool {
+		return matches(documentName(node))
+	}
+
+	result := Report{
+		From: r.From,
+		To:   r.To,
+	}
+
+	for _, diff := range r.Diffs {
+		if diff.Path != nil {
+			root := r.From
+			if diff.Path.Root != nil {
+				root = *diff.Path.Root
+			}
+
+			if matches(diff.Path.RootDescription()) || excluded(documentAt(root, diff.Path.DocumentIdx)) {
+				continue
+			}
+
+			result.Diffs = append(result.Diffs, diff)
+			continue
+		}
+
+		// file level differences can contain multiple documents, or the list
+		// of document names in case of an order change
+		var details []Detail
+		for _, detail := range diff.Details {
+			switch {
+			case detail.Kind == ORDERCHANGE:
+				from, to := withoutMatchingNames(detail.From, matches), withoutMatchingNames(detail.To, matches)
+				if from != nil && to != nil {
+					if orderChange, ok := withoutUnmatchedNames(Detail{Kind: ORDERCHANGE, From: from, To: to}); ok {
+						details = append(details, orderChange)
+					}
+				}
+
+			case detail.From != nil && detail.From.Kind == yamlv3.DocumentNode:
+				if from := withoutMatchingDocuments(detail.From, excluded); len(from.Content) > 0 {
+					details = append(details, Detail{Kind: detail.Kind, From: from, To: detail.To})
+				}
+
+			case detail.To != nil && detail.To.Kind == yamlv3.DocumentNode:
+				if to := withoutMatchingDocuments(detail.To, excluded); len(to.Content) > 0 {
+					details = append(details, Detail{Kind: detail.Kind, From: detail.From, To: to})
+				}
+
+			default:
+				details = append(details, detail)
+			}
+		}
+
+		if len(details) > 0 {
+			result.Diffs = append(result.Diffs, Diff{Path: diff.Path, Details: details, FromSource: diff.FromSource, ToSource: diff.ToSource})
+		}
+	}
+
+	return result
+}
+
+func documentSelectorRegexp(selector string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, part := range strings.Split(selector, "*") {
+		expr.WriteString(regexp.QuoteMeta(part))
+		expr.WriteString(".*")
+	}
+
+	return regexp.MustCompile(strings.TrimSuffix(expr.String(), ".*") + "$")
+}
+
+// documentAt returns the content node of the document with the given index
+// and nil in case there is no such document
+func documentAt(inputFile ytbx.InputFile, idx int) *yamlv3.Node {
+	if idx < 0 || idx >= len(inputFile.Documents) {
+		return nil
+	}
+
+	document := inputFile.Documents[idx]
+	if document.Kind == yamlv3.DocumentNode {
+		if len(document.Content) == 0 {
+			return nil
+		}
+
+		return document.Content[0]
+	}
+
+	return document
+}
+
+// documentName returns the Kubernetes resource name of the node, or an empty
+// string if the node is not a Kubernetes resource
+func documentName(node *yamlv3.Node) string {
+	if node == nil {
+		return ""
+	}
+
+	if node.Kind == yamlv3.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	name, err := k8sItem.Name(node)
+	if err != nil {
+		return ""
+	}
+
+	return name
+}
+
+func withoutMatchingDocuments(node *yamlv3.Node, excluded func(*yamlv3.Node) bool) *yamlv3.Node {
+	result := &yamlv3.Node{Kind: node.Kind}
+	for _, content := range node.Content {
+		if !excluded(content) {
+			result.Content = append(result.Content, content)
+		}
+	}
+
+	return result
+}
+
+func withoutMatchingNames(node *yamlv3.Node, matches func(string) bool) *yamlv3.Node {
+	if node == nil {
+		return nil
+	}
+
+	var names []string
+	for _, content := range node.Content {
+		if !matches(content.Value) {
+			names = append(names, content.Value)
+		}
+	}
+
+	return AsSequenceNode(names...)
+}
+
+// ChangeKind is a bitmask of the kinds of changes in a report
+type ChangeKind int
+
+// Bits of the ChangeKind bitmask
+const (
+	ModificationChange ChangeKind = 1 << iota
+	AdditionChange
+	RemovalChange
+	OrderChange
+)
+
+// ChangeKinds returns the bitmask of all kinds of changes in the report, a
+// report without differences results in zero. Moved entries count as a
+// modification.
+func (r Report) ChangeKinds() ChangeKind {
+	var result ChangeKind
+	for _, diff := range r.Diffs {
+		for _, detail := range diff.Details {
+			switch detail.Kind {
+			case MODIFICATION, MOVED, DIRECTIVECHANGE:
+				result |= ModificationChange
+
+			case ADDITION:
+				result |= AdditionChange
+
+			case REMOVAL:
+				result |= RemovalChange
+
+			case ORDERCHANGE:
+				result |= OrderChange
+			}
+		}
+	}
+
+	return result
+}
+
+// CountBy returns the number of details of the given kind in the report that
+// belong to a path matching the path filter. A kind of zero matches all kinds,
+// and an empty path filter matches all paths. The path filter uses the slash
+// separated style, for example `/spec/template/spec/containers/*/image`, where
+// `*` matches any one path element. A filter also matches all paths below it,
+// so that `/spec` matches `/spec/replicas`.
+func (r Report) CountBy(kind DetailKind, pathFilter string) int {
+	var count int
+	for _, diff := range r.Diffs {
+		if pathFilter != "" && !matchesPathFilter(diff.Path, pathFilter) {
+			continue
+		}
+
+		for _, detail := range diff.Details {
+			if kind == 0 || detail.Kind == kind {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+// GroupBy groups the differences of the report by the key that the provided
+// function returns for each difference, for example the document, or the
+// top-level key it belongs to. Each group is a report with the input files of
+// this report and the differences of the group in their original order.
+func (r Report) GroupBy(key func(Diff) string) map[string]Report {
+	var result = map[string]Report{}
+	for _, diff := range r.Diffs {
+		name := key(diff)
+
+		group, ok := result[name]
+		if !ok {
+			group = Report{From: r.From, To: r.To}
+		}
+
+		group.Diffs = append(group.Diffs, diff)
+		result[name] = group
+	}
+
+	return result
+}
+
+func matchesPathFilter(path *ytbx.Path, pathFilter string) bool {
+	var segments []string
+	for _, segment := range strings.Split(pathFilter, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	if len(segments) == 0 {
+		return true
+	}
+
+	if path == nil || len(path.PathElements) < len(segments) {
+		return false
+	}
+
+	for i, segment := range segments {
+		if segment != "*" && segment != pathElementString(path.PathElements[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// pathElementString returns the Go-patch style representation of the path
+// element, for example `name=app` for a named list entry
+func pathElementString(element ytbx.PathElement) string {
+	switch {
+	case element.Name != "" && element.Key == "":
+		return element.Name
+
+	case element.Name != "" && element.Key != "":
+		return element.Key + "=" + element.Name
+
+	default:
+		return strconv.Itoa(element.Idx)
+	}
 }
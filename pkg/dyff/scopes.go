@@ -0,0 +1,145 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+)
+
+type scopedOptions struct {
+	pattern string
+	options []CompareOption
+}
+
+// ScopedOptions applies the provided compare options only to the given path
+// and everything below it. The path can be a Go-Patch or Dot-Style path, or a
+// Go-Patch style pattern with wildcards (for example /spec/containers/*/env).
+// Scopes are evaluated while comparing, so nested scopes override the
+// settings of the scopes above them, which in turn override the settings of
+// the comparison itself. Invalid options of a scope are reported by the
+// comparison, even if the scope never matches any path.
+func ScopedOptions(pathPattern string, options ...CompareOption) CompareOption {
+	// the options are only applied once a path matches, therefore they are
+	// applied to throw-away settings upfront to find invalid ones
+	var validation compareSettings
+	for _, option := range options {
+		option(&validation)
+	}
+
+	return func(settings *compareSettings) {
+		if validation.err != nil {
+			settings.setError(fmt.Errorf("invalid options for scope %s: %w", pathPattern, validation.err))
+		}
+
+		settings.Scopes = append(settings.Scopes, scopedOptions{
+			pattern: pathPattern,
+			options: options,
+		})
+	}
+}
+
+// matches returns whether the scope applies to the given path
+func (scope scopedOptions) matches(p ytbx.Path) bool {
+	var pathString = p.String()
+
+	if strings.ContainsAny(scope.pattern, "*?[") {
+		matched, err := path.Match(scope.pattern, pathString)
+		return err == nil && matched
+	}
+
+	scopePath, err := ytbx.ParsePathStringUnsafe(scope.pattern)
+	return err == nil && scopePath.String() == pathString
+}
+
+// scopedTo returns the comparator to be used for the given path, which is
+// the comparator itself unless one of the scopes starts at the path. Applied
+// scopes are removed from the derived comparator, so that they are not
+// evaluated again for the paths below.
+func (compare *compare) scopedTo(p ytbx.Path) *compare {
+	if len(compare.settings.Scopes) == 0 {
+		return compare
+	}
+
+	var matching, remaining []scopedOptions
+	for _, scope := range compare.settings.Scopes {
+		if scope.matches(p) {
+			matching = append(matching, scope)
+		} else {
+			remaining = append(remaining, scope)
+		}
+	}
+
+	if len(matching) == 0 {
+		return compare
+	}
+
+	// Slices of the settings are cloned so that options appending to them do
+	// not modify the settings of the parent scope
+	var settings = compare.settings
+	settings.IgnoreOrderChangesAt = slices.Clone(settings.IgnoreOrderChangesAt)
+	settings.ExcludePaths = slices.Clone(settings.ExcludePaths)
+	settings.AdditionalIdentifiers = slices.Clone(settings.AdditionalIdentifiers)
+	settings.CompositeIdentifiers = slices.Clone(settings.CompositeIdentifiers)
+	settings.NullEquivalents = slices.Clone(settings.NullEquivalents)
+	settings.Scopes = nil
+
+	for _, scope := range matching {
+		for _, option := range scope.options {
+			option(&settings)
+		}
+	}
+
+	settings.Scopes = append(remaining, settings.Scopes...)
+
+	// Hashes depend on the settings (e.g. ignored order changes), therefore
+	// the scoped comparator cannot reuse the hash cache of its parent
+	var scoped = *compare
+	scoped.settings = settings
+	scoped.caches, scoped.retained = compareCaches{}, compareCaches{}
+	return &scoped
+}
+
+// ParseScopedOptions parses a scope definition, which is a path (pattern)
+// followed by the flags that apply to it, separated by whitespace, for
+// example `/spec/containers/*/env --ignore-order-changes`. The flags use the
+// same syntax as ParseOptions, but only compare options are supported.
+func ParseScopedOptions(definition string) (CompareOption, error) {
+	fields := strings.Fields(definition)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("failed to parse scope: empty definition")
+	}
+
+	compareOptions, reportOptions, err := ParseOptions(fields[1:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse scope %s: %w", fields[0], err)
+	}
+
+	if len(reportOptions) > 0 {
+		return nil, fmt.Errorf("failed to parse scope %s: only compare options can be scoped", fields[0])
+	}
+
+	return ScopedOptions(fields[0], compareOptions...), nil
+}
@@ -0,0 +1,148 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// scriptKeys are the fields that contain shell scripts in CI pipeline
+// configurations, either as a multi-line string, or as a list of commands
+var scriptKeys = map[string]struct{}{
+	"script": {}, "before_script": {}, "after_script": {}, "run": {},
+}
+
+// scriptSuffixes are the suffixes of keys that contain shell scripts, for
+// example a `setup.sh` entry of a Kubernetes ConfigMap
+var scriptSuffixes = []string{".sh", ".bash"}
+
+// NormalizeScripts enables the normalization of shell scripts, which are the
+// values of CI script fields (e.g. `script`, `before_script`, or `run`), and
+// of keys ending in `.sh` (e.g. ConfigMap entries). Lines that are blank or
+// only contain a comment are ignored (except for the shebang), so that only
+// changes of the actual commands are reported.
+func NormalizeScripts(value bool) CompareOption {
+	return func(settings *compareSettings) {
+		settings.NormalizeScripts = value
+	}
+}
+
+// normalizeScripts replaces the documents of the input files with copies where
+// all scripts are normalized
+func (compare *compare) normalizeScripts(inputFiles ...*ytbx.InputFile) {
+	if !compare.settings.NormalizeScripts {
+		return
+	}
+
+	for _, inputFile := range inputFiles {
+		documents := make([]*yamlv3.Node, len(inputFile.Documents))
+		for i, document := range inputFile.Documents {
+			documents[i] = copyNode(document)
+			normalizeScriptsIn(documents[i])
+		}
+
+		inputFile.Documents = documents
+	}
+}
+
+func normalizeScriptsIn(node *yamlv3.Node) {
+	switch node.Kind {
+	case yamlv3.DocumentNode, yamlv3.SequenceNode:
+		for _, entry := range node.Content {
+			normalizeScriptsIn(entry)
+		}
+
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if isScriptKey(node.Content[i].Value) {
+				normalizeScriptNode(node.Content[i+1])
+				continue
+			}
+
+			normalizeScriptsIn(node.Content[i+1])
+		}
+	}
+}
+
+func isScriptKey(key string) bool {
+	if _, ok := scriptKeys[key]; ok {
+		return true
+	}
+
+	for _, suffix := range scriptSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeScriptNode normalizes a script, which is either a string, or a list
+// of commands where entries that only consist of comments are removed
+func normalizeScriptNode(node *yamlv3.Node) {
+	switch node.Kind {
+	case yamlv3.ScalarNode:
+		if node.Tag == "!!str" {
+			node.Value = normalizeScript(node.Value)
+		}
+
+	case yamlv3.SequenceNode:
+		var commands []*yamlv3.Node
+		for _, entry := range node.Content {
+			if entry.Kind == yamlv3.ScalarNode && entry.Tag == "!!str" {
+				if entry.Value = normalizeScript(entry.Value); strings.TrimSpace(entry.Value) == "" {
+					continue
+				}
+			}
+
+			commands = append(commands, entry)
+		}
+
+		node.Content = commands
+	}
+}
+
+// normalizeScript removes blank lines, lines that only contain a comment, and
+// trailing whitespace from the script, a trailing line break is kept
+func normalizeScript(script string) string {
+	var lines []string
+	for i, line := range strings.Split(script, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || (strings.HasPrefix(trimmed, "#") && !(i == 0 && strings.HasPrefix(trimmed, "#!"))) {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	result := strings.Join(lines, "\n")
+	if len(lines) > 0 && strings.HasSuffix(script, "\n") {
+		result += "\n"
+	}
+
+	return result
+}
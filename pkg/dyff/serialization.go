@@ -0,0 +1,588 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ReportSchemaVersion is the version of the serialization schema that is used
+// when a report is marshalled into JSON or YAML. It is written into every
+// serialized report and checked when a report is read back in.
+const ReportSchemaVersion = "v1"
+
+// The serialized form of a report looks like this (shown as YAML, the JSON
+// form uses the same field names):
+//
+//	schema: v1
+//	from:
+//	  location: from.yml
+//	  note: ""
+//	  names: []
+//	  documents:
+//	  - |
+//	    key: value
+//	to:
+//	  ...
+//	diffs:
+//	- path:
+//	    document: 0
+//	    elements:
+//	    - name: key
+//	  details:
+//	  - kind: modification
+//	    from: |
+//	      value
+//	    to: |
+//	      other value
+//
+// All YAML nodes (documents as well as the from and to of details) are stored
+// as YAML strings. A detail node that is a document node (which is used for
+// whole document additions or removals) is stored as a YAML stream where each
+// document starts with an explicit document start marker. A nil node is stored
+// as null. The kind of a detail is stored by its name, the symbol (e.g. ±) of
+// older reports is still accepted. A diff without a path (file level) has a
+// null path. The optional
+// provenance of an input file is only written by the StructuredReport and is
+// ignored when a report is read back in. Diffs of compared file sets have the
+// optional fromSource and toSource fields with the file and line, see Source.
+
+type reportSchema struct {
+	Schema string          `json:"schema" yaml:"schema"`
+	From   inputFileSchema `json:"from" yaml:"from"`
+	To     inputFileSchema `json:"to" yaml:"to"`
+	Diffs  []diffSchema    `json:"diffs" yaml:"diffs"`
+}
+
+type inputFileSchema struct {
+	Location   string           `json:"location" yaml:"location"`
+	Note       string           `json:"note,omitempty" yaml:"note,omitempty"`
+	Names      []string         `json:"names,omitempty" yaml:"names,omitempty"`
+	Provenance *InputProvenance `json:"provenance,omitempty" yaml:"provenance,omitempty"`
+	Documents  []string         `json:"documents" yaml:"documents"`
+}
+
+type diffSchema struct {
+	Path       *pathSchema    `json:"path" yaml:"path"`
+	Details    []detailSchema `json:"details" yaml:"details"`
+	FromSource *Source        `json:"fromSource,omitempty" yaml:"fromSource,omitempty"`
+	ToSource   *Source        `json:"toSource,omitempty" yaml:"toSource,omitempty"`
+}
+
+type pathSchema struct {
+	Root     string              `json:"root,omitempty" yaml:"root,omitempty"`
+	Document int                 `json:"document" yaml:"document"`
+	Elements []pathElementSchema `json:"elements" yaml:"elements"`
+}
+
+type pathElementSchema struct {
+	Idx  int    `json:"idx,omitempty" yaml:"idx,omitempty"`
+	Key  string `json:"key,omitempty" yaml:"key,omitempty"`
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+}
+
+type detailSchema struct {
+	Kind string  `json:"kind" yaml:"kind"`
+	From *string `json:"from" yaml:"from"`
+	To   *string `json:"to" yaml:"to"`
+}
+
+// MarshalJSON serializes the report into JSON using the documented report
+// schema so that it can be read back in using UnmarshalJSON
+func (r Report) MarshalJSON() ([]byte, error) {
+	schema, err := r.toSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(schema)
+}
+
+// UnmarshalJSON reads a report that was serialized using MarshalJSON
+func (r *Report) UnmarshalJSON(data []byte) error {
+	var schema reportSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return err
+	}
+
+	return r.fromSchema(schema)
+}
+
+// MarshalYAML serializes the report into YAML using the documented report
+// schema so that it can be read back in using UnmarshalYAML
+func (r Report) MarshalYAML() (interface{}, error) {
+	return r.toSchema()
+}
+
+// UnmarshalYAML reads a report that was serialized using MarshalYAML
+func (r *Report) UnmarshalYAML(value *yamlv3.Node) error {
+	var schema reportSchema
+	if err := value.Decode(&schema); err != nil {
+		return err
+	}
+
+	return r.fromSchema(schema)
+}
+
+// MarshalJSON serializes the difference into JSON using the diff part of the
+// documented report schema. Since a difference on its own has no reference to
+// the input files, only the document index of the path is written.
+func (d Diff) MarshalJSON() ([]byte, error) {
+	schema, err := diffToSchema(d, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(schema)
+}
+
+// UnmarshalJSON reads a difference that was serialized using MarshalJSON, the
+// root of the path is not set
+func (d *Diff) UnmarshalJSON(data []byte) error {
+	var schema diffSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return err
+	}
+
+	diff, err := diffFromSchema(schema, nil)
+	if err != nil {
+		return err
+	}
+
+	*d = diff
+	return nil
+}
+
+// MarshalYAML serializes the difference into YAML using the diff part of the
+// documented report schema
+func (d Diff) MarshalYAML() (interface{}, error) {
+	return diffToSchema(d, nil)
+}
+
+// UnmarshalYAML reads a difference that was serialized using MarshalYAML, the
+// root of the path is not set
+func (d *Diff) UnmarshalYAML(value *yamlv3.Node) error {
+	var schema diffSchema
+	if err := value.Decode(&schema); err != nil {
+		return err
+	}
+
+	diff, err := diffFromSchema(schema, nil)
+	if err != nil {
+		return err
+	}
+
+	*d = diff
+	return nil
+}
+
+// MarshalJSON serializes the detail into JSON using the detail part of the
+// documented report schema
+func (d Detail) MarshalJSON() ([]byte, error) {
+	schema, err := detailToSchema(d)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(schema)
+}
+
+// UnmarshalJSON reads a detail that was serialized using MarshalJSON
+func (d *Detail) UnmarshalJSON(data []byte) error {
+	var schema detailSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return err
+	}
+
+	detail, err := detailFromSchema(schema)
+	if err != nil {
+		return err
+	}
+
+	*d = detail
+	return nil
+}
+
+// MarshalYAML serializes the detail into YAML using the detail part of the
+// documented report schema
+func (d Detail) MarshalYAML() (interface{}, error) {
+	return detailToSchema(d)
+}
+
+// UnmarshalYAML reads a detail that was serialized using MarshalYAML
+func (d *Detail) UnmarshalYAML(value *yamlv3.Node) error {
+	var schema detailSchema
+	if err := value.Decode(&schema); err != nil {
+		return err
+	}
+
+	detail, err := detailFromSchema(schema)
+	if err != nil {
+		return err
+	}
+
+	*d = detail
+	return nil
+}
+
+// LoadReport reads a serialized report from the provided reader, the input
+// can either be JSON or YAML
+func LoadReport(in io.Reader) (Report, error) {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return Report{}, err
+	}
+
+	var report Report
+	switch {
+	case len(bytes.TrimSpace(data)) == 0:
+		return Report{}, fmt.Errorf("failed to read report: no input data")
+
+	case bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")):
+		err = json.Unmarshal(data, &report)
+
+	default:
+		err = yamlv3.Unmarshal(data, &report)
+	}
+
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to read report: %w", err)
+	}
+
+	return report, nil
+}
+
+func (r Report) toSchema() (reportSchema, error) {
+	from, err := inputFileToSchema(r.From)
+	if err != nil {
+		return reportSchema{}, err
+	}
+
+	to, err := inputFileToSchema(r.To)
+	if err != nil {
+		return reportSchema{}, err
+	}
+
+	diffs := make([]diffSchema, len(r.Diffs))
+	for i, diff := range r.Diffs {
+		if diffs[i], err = diffToSchema(diff, &r); err != nil {
+			return reportSchema{}, err
+		}
+	}
+
+	return reportSchema{
+		Schema: ReportSchemaVersion,
+		From:   from,
+		To:     to,
+		Diffs:  diffs,
+	}, nil
+}
+
+func (r *Report) fromSchema(schema reportSchema) error {
+	if schema.Schema != ReportSchemaVersion {
+		return fmt.Errorf("unsupported report schema %q, expected %q", schema.Schema, ReportSchemaVersion)
+	}
+
+	from, err := inputFileFromSchema(schema.From)
+	if err != nil {
+		return err
+	}
+
+	to, err := inputFileFromSchema(schema.To)
+	if err != nil {
+		return err
+	}
+
+	r.From, r.To, r.Diffs = from, to, make([]Diff, len(schema.Diffs))
+	for i, entry := range schema.Diffs {
+		if r.Diffs[i], err = diffFromSchema(entry, r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func inputFileToSchema(inputFile ytbx.InputFile) (inputFileSchema, error) {
+	documents := make([]string, len(inputFile.Documents))
+	for i, document := range inputFile.Documents {
+		str, err := encodeNode(document)
+		if err != nil {
+			return inputFileSchema{}, err
+		}
+
+		documents[i] = str
+	}
+
+	return inputFileSchema{
+		Location:  inputFile.Location,
+		Note:      inputFile.Note,
+		Names:     inputFile.Names,
+		Documents: documents,
+	}, nil
+}
+
+func inputFileFromSchema(schema inputFileSchema) (ytbx.InputFile, error) {
+	documents := make([]*yamlv3.Node, len(schema.Documents))
+	for i, str := range schema.Documents {
+		node, err := decodeNode(str)
+		if err != nil {
+			return ytbx.InputFile{}, err
+		}
+
+		documents[i] = node
+	}
+
+	return ytbx.InputFile{
+		Location:  schema.Location,
+		Note:      schema.Note,
+		Names:     schema.Names,
+		Documents: documents,
+	}, nil
+}
+
+// diffToSchema converts the difference into its schema, the report is used
+// to record whether the path refers to the from or to input file (optional)
+func diffToSchema(diff Diff, report *Report) (diffSchema, error) {
+	var result = diffSchema{
+		Details:    make([]detailSchema, len(diff.Details)),
+		FromSource: diff.FromSource,
+		ToSource:   diff.ToSource,
+	}
+
+	if diff.Path != nil {
+		result.Path = &pathSchema{
+			Document: diff.Path.DocumentIdx,
+			Elements: make([]pathElementSchema, len(diff.Path.PathElements)),
+		}
+
+		if report != nil && diff.Path.Root != nil {
+			switch {
+			case isSameInputFile(diff.Path.Root, report.From):
+				result.Path.Root = "from"
+
+			case isSameInputFile(diff.Path.Root, report.To):
+				result.Path.Root = "to"
+			}
+		}
+
+		for i, element := range diff.Path.PathElements {
+			result.Path.Elements[i] = pathElementSchema(element)
+		}
+	}
+
+	for i, detail := range diff.Details {
+		schema, err := detailToSchema(detail)
+		if err != nil {
+			return diffSchema{}, err
+		}
+
+		result.Details[i] = schema
+	}
+
+	return result, nil
+}
+
+// diffFromSchema converts the schema back into a difference, the path refers
+// to the from or to input file of the report as recorded (optional)
+func diffFromSchema(schema diffSchema, report *Report) (Diff, error) {
+	var result = Diff{
+		Details:    make([]Detail, len(schema.Details)),
+		FromSource: schema.FromSource,
+		ToSource:   schema.ToSource,
+	}
+
+	if schema.Path != nil {
+		result.Path = &ytbx.Path{DocumentIdx: schema.Path.Document}
+
+		if report != nil {
+			switch schema.Path.Root {
+			case "to":
+				result.Path.Root = &report.To
+
+			default:
+				result.Path.Root = &report.From
+			}
+		}
+
+		if len(schema.Path.Elements) > 0 {
+			result.Path.PathElements = make([]ytbx.PathElement, len(schema.Path.Elements))
+			for i, element := range schema.Path.Elements {
+				result.Path.PathElements[i] = ytbx.PathElement(element)
+			}
+		}
+	}
+
+	for i, detail := range schema.Details {
+		var err error
+		if result.Details[i], err = detailFromSchema(detail); err != nil {
+			return Diff{}, err
+		}
+	}
+
+	return result, nil
+}
+
+func detailToSchema(detail Detail) (detailSchema, error) {
+	from, err := encodeOptionalNode(detail.From)
+	if err != nil {
+		return detailSchema{}, err
+	}
+
+	to, err := encodeOptionalNode(detail.To)
+	if err != nil {
+		return detailSchema{}, err
+	}
+
+	return detailSchema{
+		Kind: detail.Kind.String(),
+		From: from,
+		To:   to,
+	}, nil
+}
+
+func detailFromSchema(schema detailSchema) (Detail, error) {
+	kind, err := ParseDetailKind(schema.Kind)
+	if err != nil {
+		return Detail{}, err
+	}
+
+	from, err := decodeOptionalNode(schema.From)
+	if err != nil {
+		return Detail{}, err
+	}
+
+	to, err := decodeOptionalNode(schema.To)
+	if err != nil {
+		return Detail{}, err
+	}
+
+	return Detail{Kind: kind, From: from, To: to}, nil
+}
+
+func encodeOptionalNode(node *yamlv3.Node) (*string, error) {
+	if node == nil {
+		return nil, nil
+	}
+
+	str, err := encodeNode(node)
+	if err != nil {
+		return nil, err
+	}
+
+	return &str, nil
+}
+
+func decodeOptionalNode(str *string) (*yamlv3.Node, error) {
+	if str == nil {
+		return nil, nil
+	}
+
+	return decodeNode(*str)
+}
+
+// encodeNode writes the node as a YAML string, document nodes are written as
+// a stream of documents with explicit document start markers
+func encodeNode(node *yamlv3.Node) (string, error) {
+	var buf bytes.Buffer
+
+	if node.Kind == yamlv3.DocumentNode {
+		for _, content := range node.Content {
+			buf.WriteString("---\n")
+
+			str, err := encodeNode(content)
+			if err != nil {
+				return "", err
+			}
+
+			buf.WriteString(str)
+		}
+
+		return buf.String(), nil
+	}
+
+	encoder := yamlv3.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(resolveAliases(node)); err != nil {
+		return "", fmt.Errorf("failed to encode node: %w", err)
+	}
+
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode node: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// decodeNode reads a YAML string created by encodeNode and returns the node
+func decodeNode(str string) (*yamlv3.Node, error) {
+	documents, err := ytbx.LoadYAMLDocuments([]byte(str))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode node: %w", err)
+	}
+
+	if strings.HasPrefix(str, "---\n") {
+		result := &yamlv3.Node{Kind: yamlv3.DocumentNode}
+		for _, document := range documents {
+			result.Content = append(result.Content, document.Content...)
+		}
+
+		return result, nil
+	}
+
+	if len(documents) != 1 || len(documents[0].Content) != 1 {
+		return nil, fmt.Errorf("failed to decode node, expected exactly one document")
+	}
+
+	return documents[0].Content[0], nil
+}
+
+// resolveAliases returns a copy of the node where all aliases are replaced
+// with the nodes they point to, so that the node can be written on its own
+// without the anchor definitions it might refer to
+func resolveAliases(node *yamlv3.Node) *yamlv3.Node {
+	if node == nil {
+		return nil
+	}
+
+	node = followAlias(node)
+
+	result := *node
+	result.Anchor = ""
+	result.Alias = nil
+
+	if len(node.Content) > 0 {
+		result.Content = make([]*yamlv3.Node, len(node.Content))
+		for i := range node.Content {
+			result.Content[i] = resolveAliases(node.Content[i])
+		}
+	}
+
+	return &result
+}
@@ -0,0 +1,303 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("Report serialization", func() {
+	render := func(report dyff.Report) string {
+		var buf bytes.Buffer
+		reporter := &dyff.HumanReport{Report: report, Indent: 2, OmitHeader: true}
+		Expect(reporter.WriteReport(&buf)).To(Succeed())
+		return buf.String()
+	}
+
+	compareFiles := func(fromPath, toPath string) dyff.Report {
+		from, to := loadFiles(fromPath, toPath)
+		report, err := dyff.CompareInputFiles(from, to)
+		Expect(err).ToNot(HaveOccurred())
+		return report
+	}
+
+	Context("JSON round-trip", func() {
+		It("should render the same report after reading it back in", func() {
+			report := compareFiles(assets("examples", "from.yml"), assets("examples", "to.yml"))
+
+			data, err := json.Marshal(report)
+			Expect(err).ToNot(HaveOccurred())
+
+			loaded, err := dyff.LoadReport(bytes.NewReader(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.Diffs).To(HaveLen(len(report.Diffs)))
+			Expect(render(loaded)).To(Equal(render(report)))
+		})
+
+		It("should keep document level additions and removals", func() {
+			report := compareFiles(assets("issues", "issue-232", "from.yml"), assets("issues", "issue-232", "to.yml"))
+
+			data, err := json.Marshal(report)
+			Expect(err).ToNot(HaveOccurred())
+
+			loaded, err := dyff.LoadReport(bytes.NewReader(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.Diffs[0].Path).To(BeNil())
+			Expect(render(loaded)).To(Equal(render(report)))
+		})
+
+		It("should keep whether a path refers to the from or to input file", func() {
+			from := ytbx.InputFile{Location: "from.yml", Documents: multiDoc(`{name: one}`, `{name: db}`)}
+			to := ytbx.InputFile{Location: "to.yml", Documents: multiDoc(`{name: one}`, `{name: two}`, `{name: db}`)}
+			report := dyff.Report{
+				From: from,
+				To:   to,
+				Diffs: []dyff.Diff{
+					{
+						Path:    &ytbx.Path{Root: &from, DocumentIdx: 1, PathElements: []ytbx.PathElement{{Name: "name"}}},
+						Details: []dyff.Detail{{Kind: dyff.MODIFICATION, From: yml(`db`), To: yml(`database`)}},
+					},
+					{
+						Path:    &ytbx.Path{Root: &to, DocumentIdx: 1},
+						Details: []dyff.Detail{{Kind: dyff.ADDITION, To: yml(`{name: two}`)}},
+					},
+				},
+			}
+
+			sortedYAML := func(any) ([]byte, error) {
+				var buf bytes.Buffer
+				err := (&dyff.StructuredReport{Report: report, Format: "yaml", SortKeys: true}).WriteReport(&buf)
+				return buf.Bytes(), err
+			}
+
+			for _, marshal := range []func(any) ([]byte, error){json.Marshal, yamlv3.Marshal, sortedYAML} {
+				data, err := marshal(report)
+				Expect(err).ToNot(HaveOccurred())
+
+				loaded, err := dyff.LoadReport(bytes.NewReader(data))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(loaded.Diffs).To(HaveLen(2))
+				Expect(loaded.Diffs[0].Path.Root.Location).To(Equal("from.yml"))
+				Expect(loaded.Diffs[1].Path.Root.Location).To(Equal("to.yml"))
+				Expect(loaded.Diffs[1].Path.Root.Documents).To(HaveLen(3))
+			}
+		})
+	})
+
+	Context("YAML round-trip", func() {
+		It("should render the same report after reading it back in", func() {
+			report := compareFiles(assets("examples", "from.yml"), assets("examples", "to.yml"))
+
+			data, err := yamlv3.Marshal(report)
+			Expect(err).ToNot(HaveOccurred())
+
+			loaded, err := dyff.LoadReport(bytes.NewReader(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(render(loaded)).To(Equal(render(report)))
+		})
+	})
+
+	Context("single differences and details", func() {
+		It("should round-trip differences in JSON and YAML", func() {
+			report := compareFiles(assets("examples", "from.yml"), assets("examples", "to.yml"))
+			Expect(report.Diffs).ToNot(BeEmpty())
+
+			for _, diff := range report.Diffs {
+				data, err := json.Marshal(diff)
+				Expect(err).ToNot(HaveOccurred())
+
+				var fromJSON dyff.Diff
+				Expect(json.Unmarshal(data, &fromJSON)).To(Succeed())
+				Expect(fromJSON).To(BeSameDiffAs(diff))
+
+				data, err = yamlv3.Marshal(diff)
+				Expect(err).ToNot(HaveOccurred())
+
+				var fromYAML dyff.Diff
+				Expect(yamlv3.Unmarshal(data, &fromYAML)).To(Succeed())
+				Expect(fromYAML).To(BeSameDiffAs(diff))
+			}
+		})
+
+		It("should serialize a detail using the report schema", func() {
+			detail := singleDiff("/some/key", dyff.MODIFICATION, "foo", "bar").Details[0]
+
+			data, err := json.Marshal(detail)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(MatchJSON(`{"kind": "modification", "from": "foo\n", "to": "bar\n"}`))
+
+			var loaded dyff.Detail
+			Expect(json.Unmarshal(data, &loaded)).To(Succeed())
+			Expect(loaded.Kind).To(Equal(dyff.MODIFICATION))
+			Expect(loaded.From.Value).To(Equal("foo"))
+			Expect(loaded.To.Value).To(Equal("bar"))
+
+			Expect(json.Unmarshal([]byte(`{"kind": "±", "from": "foo\n", "to": "bar\n"}`), &loaded)).To(Succeed())
+			Expect(loaded.Kind).To(Equal(dyff.MODIFICATION))
+
+			Expect(json.Unmarshal([]byte(`{"kind": "unknown"}`), &loaded)).ToNot(Succeed())
+		})
+	})
+
+	Context("detail kinds", func() {
+		It("should have names and parse both names and symbols", func() {
+			Expect(dyff.ADDITION.String()).To(Equal("addition"))
+			Expect(dyff.ORDERCHANGE.String()).To(Equal("order-change"))
+			Expect(dyff.MODIFICATION.Symbol()).To(Equal("±"))
+
+			for _, str := range []string{"removal", "-"} {
+				kind, err := dyff.ParseDetailKind(str)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(kind).To(Equal(dyff.REMOVAL))
+			}
+
+			_, err := dyff.ParseDetailKind("unknown")
+			Expect(err).To(HaveOccurred())
+
+			data, err := json.Marshal(map[string]dyff.DetailKind{"kind": dyff.MODIFICATION})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(MatchJSON(`{"kind": "modification"}`))
+
+			var loaded map[string]dyff.DetailKind
+			Expect(json.Unmarshal(data, &loaded)).To(Succeed())
+			Expect(loaded["kind"]).To(Equal(dyff.MODIFICATION))
+		})
+	})
+
+	Context("invalid input", func() {
+		It("should fail on an unknown schema version", func() {
+			_, err := dyff.LoadReport(bytes.NewReader([]byte(`{"schema": "v0"}`)))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail on empty input", func() {
+			_, err := dyff.LoadReport(bytes.NewReader(nil))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("structured output with sorted keys", func() {
+		It("should keep the original key order by default and sort keys if requested", func() {
+			from := yml(`---
+zulu: 1
+alpha: 2
+`)
+
+			to := yml(`---
+zulu: 1
+alpha: 3
+`)
+
+			report, err := dyff.CompareInputFiles(
+				ytbx.InputFile{Documents: []*yamlv3.Node{from}},
+				ytbx.InputFile{Documents: []*yamlv3.Node{to}},
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+			Expect((&dyff.StructuredReport{Report: report, Format: "json"}).WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring(`"zulu: 1\nalpha: 2\n"`))
+
+			buf.Reset()
+			Expect((&dyff.StructuredReport{Report: report, Format: "json", SortKeys: true}).WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring(`"alpha: 2\nzulu: 1\n"`))
+
+			// the original report must not be changed by sorting the output
+			Expect(report.From.Documents[0].Content[0].Value).To(Equal("zulu"))
+		})
+	})
+
+	Context("fingerprint", func() {
+		It("should be stable for the same set of differences", func() {
+			report := compareFiles(assets("examples", "from.yml"), assets("examples", "to.yml"))
+
+			data, err := json.Marshal(report)
+			Expect(err).ToNot(HaveOccurred())
+
+			loaded, err := dyff.LoadReport(bytes.NewReader(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.Fingerprint()).To(Equal(report.Fingerprint()))
+
+			reversed := dyff.Report{From: report.From, To: report.To}
+			for i := len(report.Diffs) - 1; i >= 0; i-- {
+				reversed.Diffs = append(reversed.Diffs, report.Diffs[i])
+			}
+
+			Expect(reversed.Fingerprint()).To(Equal(report.Fingerprint()))
+		})
+
+		It("should ignore the style of values, but not the values themselves", func() {
+			fingerprint := func(from, to string) string {
+				report, err := dyff.CompareInputFiles(
+					ytbx.InputFile{Documents: []*yamlv3.Node{yml(from)}},
+					ytbx.InputFile{Documents: []*yamlv3.Node{yml(to)}},
+				)
+				Expect(err).ToNot(HaveOccurred())
+				return report.Fingerprint()
+			}
+
+			Expect(fingerprint("foo: bar", "foo: baz")).To(Equal(fingerprint(`foo: "bar"`, `foo: 'baz' # comment`)))
+			Expect(fingerprint("foo: bar", "foo: baz")).ToNot(Equal(fingerprint("foo: bar", "foo: qux")))
+		})
+	})
+
+	Context("redacting values", func() {
+		It("should replace all values with placeholders of the same type", func() {
+			from := yml(`{name: foo, replicas: 1, ratio: 0.5, enabled: true, list: [a], empty: null} # comment`)
+			to := yml(`{name: bar, replicas: 2, ratio: 0.7, enabled: false, list: [a, b], empty: null}`)
+
+			report, err := dyff.CompareInputFiles(
+				ytbx.InputFile{Location: "from", Documents: []*yamlv3.Node{from}},
+				ytbx.InputFile{Location: "to", Documents: []*yamlv3.Node{to}},
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			redacted := report.RedactValues()
+			Expect(redacted.Diffs).To(HaveLen(len(report.Diffs)))
+			Expect(redacted.Diffs[0].Path.Root.Location).To(Equal("from"))
+			Expect(redacted.Diffs[0].Path.Root.Documents[0]).ToNot(BeIdenticalTo(report.From.Documents[0]))
+
+			out := render(redacted)
+			Expect(out).To(ContainSubstring("name"))
+			Expect(out).To(ContainSubstring("…"))
+			Expect(out).ToNot(ContainSubstring("foo"))
+			Expect(out).ToNot(ContainSubstring("bar"))
+			Expect(out).ToNot(ContainSubstring("0.7"))
+
+			var buf bytes.Buffer
+			Expect((&dyff.StructuredReport{Report: redacted, Format: "json"}).WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).ToNot(ContainSubstring("foo"))
+			Expect(buf.String()).ToNot(ContainSubstring("comment"))
+
+			// the original report is not modified
+			Expect(render(report)).To(ContainSubstring("foo"))
+		})
+	})
+})
@@ -0,0 +1,134 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// settingsFileNames are the names of project-level configuration files
+var settingsFileNames = []string{".dyff.yml", ".dyff.yaml"}
+
+// Settings are the default flags of a configuration file (.dyff.yml), which
+// uses the flag names of the command line tool as keys, for example:
+//
+//	exclude:
+//	- /metadata/annotations
+//	additional-identifier: [id]
+//	detect-kubernetes: true
+//	output: github
+//
+// The flags are in the order of the keys, with one flag for each entry of a
+// list, and the value in the same argument (--exclude=/metadata/annotations).
+type Settings struct {
+	Location string
+	Flags    []string
+}
+
+// LoadSettings loads the configuration file at the provided location
+func LoadSettings(location string) (Settings, error) {
+	data, err := os.ReadFile(location)
+	if err != nil {
+		return Settings{}, fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &document); err != nil {
+		return Settings{}, fmt.Errorf("failed to load settings from %s: %w", location, err)
+	}
+
+	var result = Settings{Location: location}
+	if len(document.Content) == 0 {
+		return result, nil
+	}
+
+	root := followAlias(document.Content[0])
+	if root.Kind != yamlv3.MappingNode {
+		return Settings{}, fmt.Errorf("failed to load settings from %s: expected a map of flag names and values", location)
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		name, value := followAlias(root.Content[i]).Value, followAlias(root.Content[i+1])
+
+		switch value.Kind {
+		case yamlv3.ScalarNode:
+			result.Flags = append(result.Flags, fmt.Sprintf("--%s=%s", name, value.Value))
+
+		case yamlv3.SequenceNode:
+			for _, entry := range value.Content {
+				if entry = followAlias(entry); entry.Kind != yamlv3.ScalarNode {
+					return Settings{}, fmt.Errorf("failed to load settings from %s: unsupported value of %s, expected a list of strings", location, name)
+				}
+
+				result.Flags = append(result.Flags, fmt.Sprintf("--%s=%s", name, entry.Value))
+			}
+
+		default:
+			return Settings{}, fmt.Errorf("failed to load settings from %s: unsupported value of %s, expected a string or a list of strings", location, name)
+		}
+	}
+
+	return result, nil
+}
+
+// FindSettingsFile looks up the configuration file (.dyff.yml, or .dyff.yaml)
+// in the provided directory and its parent directories, the search stops at
+// the root of a Git repository
+func FindSettingsFile(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		for _, name := range settingsFileNames {
+			if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.Mode().IsRegular() {
+				return filepath.Join(dir, name), true
+			}
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", false
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+
+		dir = parent
+	}
+}
+
+// Options returns the compare and report options of the settings, flags that
+// only control the output (e.g. the output style) are skipped, see ParseOptions
+func (settings Settings) Options() ([]CompareOption, []ReportOption, error) {
+	compareOptions, reportOptions, err := parseOptions(settings.Flags, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to use settings of %s: %w", settings.Location, err)
+	}
+
+	return compareOptions, reportOptions, nil
+}
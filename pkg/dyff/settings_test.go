@@ -0,0 +1,106 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gonvenience/ytbx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("Settings files", func() {
+	var tmpDir string
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "dyff-settings")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	It("should load the settings as flags and options", func() {
+		location := filepath.Join(tmpDir, ".dyff.yml")
+		Expect(os.WriteFile(location, []byte(`---
+output: github
+ignore-order-changes: true
+exclude:
+- /metadata/labels/foo
+- /spec/replicas
+`), 0644)).To(Succeed())
+
+		settings, err := dyff.LoadSettings(location)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(settings.Flags).To(Equal([]string{
+			"--output=github",
+			"--ignore-order-changes=true",
+			"--exclude=/metadata/labels/foo",
+			"--exclude=/spec/replicas",
+		}))
+
+		compareOptions, reportOptions, err := settings.Options()
+		Expect(err).ToNot(HaveOccurred())
+
+		report, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Documents: []*yamlv3.Node{yml(`{list: [a, b], metadata: {labels: {foo: bar}}, spec: {replicas: 1}}`)}},
+			ytbx.InputFile{Documents: []*yamlv3.Node{yml(`{list: [b, a], metadata: {labels: {foo: baz}}, spec: {replicas: 2}}`)}},
+			compareOptions...,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dyff.ApplyReportOptions(report, reportOptions...).Diffs).To(BeEmpty())
+	})
+
+	It("should fail for unsupported settings", func() {
+		for _, content := range []string{"[exclude]", "exclude: {foo: bar}", "exclude: [{foo: bar}]", "{"} {
+			location := filepath.Join(tmpDir, ".dyff.yml")
+			Expect(os.WriteFile(location, []byte(content), 0644)).To(Succeed())
+
+			_, err := dyff.LoadSettings(location)
+			Expect(err).To(HaveOccurred(), content)
+		}
+	})
+
+	It("should look up the settings file in the parent directories", func() {
+		nested := filepath.Join(tmpDir, "project", "sub", "dir")
+		Expect(os.MkdirAll(nested, 0755)).To(Succeed())
+		Expect(os.Mkdir(filepath.Join(tmpDir, "project", ".git"), 0755)).To(Succeed())
+
+		// outside of the repository, so it is not used
+		Expect(os.WriteFile(filepath.Join(tmpDir, ".dyff.yml"), []byte("exclude: [/a]"), 0644)).To(Succeed())
+
+		_, ok := dyff.FindSettingsFile(nested)
+		Expect(ok).To(BeFalse())
+
+		Expect(os.WriteFile(filepath.Join(tmpDir, "project", "sub", ".dyff.yaml"), []byte("exclude: [/a]"), 0644)).To(Succeed())
+
+		location, ok := dyff.FindSettingsFile(nested)
+		Expect(ok).To(BeTrue())
+		Expect(location).To(Equal(filepath.Join(tmpDir, "project", "sub", ".dyff.yaml")))
+	})
+})
@@ -0,0 +1,120 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// RunState is the result of a previous comparison, which is stored between
+// runs, for example of scheduled drift detection jobs, so that only the
+// differences that were not present in the previous run are reported
+type RunState struct {
+	// Digest is the hash of the documents of the to input file
+	Digest string `json:"digest"`
+
+	// Fingerprint is the fingerprint of the report, see Report.Fingerprint
+	Fingerprint string `json:"fingerprint"`
+
+	// Diffs contains the fingerprints of all differences, see Diff.Fingerprint
+	Diffs []string `json:"diffs"`
+}
+
+// NewRunState creates the state of the provided report
+func NewRunState(report Report) RunState {
+	var digest strings.Builder
+	for _, document := range report.To.Documents {
+		writeCanonicalNode(&digest, document)
+		digest.WriteString("\n")
+	}
+
+	var diffs = make([]string, len(report.Diffs))
+	for i, diff := range report.Diffs {
+		diffs[i] = diff.Fingerprint()
+	}
+
+	return RunState{
+		Digest:      sha256Hex([]byte(digest.String())),
+		Fingerprint: report.Fingerprint(),
+		Diffs:       diffs,
+	}
+}
+
+// LoadRunState reads the state file at the provided location, the second
+// return value is false if the file does not exist (i.e. the first run)
+func LoadRunState(location string) (RunState, bool, error) {
+	data, err := os.ReadFile(location)
+	if errors.Is(err, fs.ErrNotExist) {
+		return RunState{}, false, nil
+	}
+
+	if err != nil {
+		return RunState{}, false, fmt.Errorf("failed to read state file %s: %w", location, err)
+	}
+
+	var state RunState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return RunState{}, false, fmt.Errorf("failed to parse state file %s: %w", location, err)
+	}
+
+	return state, true, nil
+}
+
+// Save writes the state to the provided location
+func (s RunState) Save(location string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to create state file %s: %w", location, err)
+	}
+
+	if err := os.WriteFile(location, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write state file %s: %w", location, err)
+	}
+
+	return nil
+}
+
+// Since returns a report that only contains the differences that were not
+// present in the run of the provided state
+func (r Report) Since(state RunState) Report {
+	var known = make(map[string]struct{}, len(state.Diffs))
+	for _, fingerprint := range state.Diffs {
+		known[fingerprint] = struct{}{}
+	}
+
+	var result []Diff
+	for _, diff := range r.Diffs {
+		if _, ok := known[diff.Fingerprint()]; !ok {
+			result = append(result, diff)
+		}
+	}
+
+	return Report{
+		From:  r.From,
+		To:    r.To,
+		Diffs: result,
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gonvenience/ytbx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("Run state", func() {
+	var tmpDir string
+
+	var report = func(from, to string) dyff.Report {
+		result, err := dyff.CompareInputFiles(
+			ytbx.InputFile{Documents: []*yamlv3.Node{yml(from)}},
+			ytbx.InputFile{Documents: []*yamlv3.Node{yml(to)}},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		return result
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "dyff-state")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	It("should only keep the differences that were not present in the previous run", func() {
+		previous := report(`{"foo": "bar", "bar": "foo"}`, `{"foo": "BAR", "bar": "foo"}`)
+		current := report(`{"foo": "bar", "bar": "foo"}`, `{"foo": "BAR", "bar": "FOO"}`)
+
+		since := current.Since(dyff.NewRunState(previous))
+		Expect(since.Diffs).To(HaveLen(1))
+		Expect(since.Diffs[0].Path.String()).To(Equal("/bar"))
+	})
+
+	It("should report a difference again if its value changed", func() {
+		previous := report(`{"foo": "bar"}`, `{"foo": "BAR"}`)
+		current := report(`{"foo": "bar"}`, `{"foo": "Bar"}`)
+
+		Expect(current.Since(dyff.NewRunState(previous)).Diffs).To(HaveLen(1))
+	})
+
+	It("should save and load the state", func() {
+		location := filepath.Join(tmpDir, "state.json")
+
+		_, ok, err := dyff.LoadRunState(location)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeFalse())
+
+		state := dyff.NewRunState(report(`{"foo": "bar"}`, `{"foo": "BAR"}`))
+		Expect(state.Save(location)).To(Succeed())
+
+		loaded, ok, err := dyff.LoadRunState(location)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(loaded).To(Equal(state))
+		Expect(loaded.Diffs).To(HaveLen(1))
+		Expect(loaded.Digest).To(Equal(dyff.NewRunState(report(`{"foo": "baz"}`, `{"foo": "BAR"}`)).Digest))
+	})
+
+	It("should fail for invalid state files", func() {
+		location := filepath.Join(tmpDir, "state.json")
+		Expect(os.WriteFile(location, []byte("not json"), 0644)).To(Succeed())
+
+		_, _, err := dyff.LoadRunState(location)
+		Expect(err).To(MatchError(ContainSubstring("failed to parse state file")))
+	})
+})
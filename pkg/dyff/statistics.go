@@ -0,0 +1,174 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gonvenience/text"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// statisticsKindOrder is the order of the change kinds in the statistics
+var statisticsKindOrder = []DetailKind{MODIFICATION, ADDITION, REMOVAL, ORDERCHANGE, MOVED, DIRECTIVECHANGE}
+
+// Statistics are the number of changes of a report per change kind, per
+// document, and per top-level path, where each detail of a difference is one
+// change, except for added or removed documents, which count as one change
+// per document
+type Statistics struct {
+	// Kinds is the number of changes per change kind
+	Kinds map[DetailKind]int
+
+	// Documents is the number of changes per document, which are referred to
+	// by their name (e.g. the Kubernetes resource name), or their position
+	Documents map[string]int
+
+	// Paths is the number of changes per top-level path in Go-patch style,
+	// for example /spec, changes of the document itself use the path /
+	Paths map[string]int
+}
+
+// StatisticsEntry is the number of changes of one document or path
+type StatisticsEntry struct {
+	Name  string
+	Count int
+}
+
+// Statistics returns the number of changes of the report per change kind, per
+// document, and per top-level path
+func (r Report) Statistics() Statistics {
+	var result = Statistics{
+		Kinds:     map[DetailKind]int{},
+		Documents: map[string]int{},
+		Paths:     map[string]int{},
+	}
+
+	for _, diff := range r.Diffs {
+		for _, detail := range diff.Details {
+			if diff.Path == nil {
+				for _, document := range changedDocuments(detail) {
+					name := documentName(document)
+					if name == "" {
+						name = "document"
+					}
+
+					result.Kinds[detail.Kind]++
+					result.Documents[name]++
+					result.Paths["/"]++
+				}
+
+				continue
+			}
+
+			var path = "/"
+			if len(diff.Path.PathElements) > 0 {
+				path += pathElementString(diff.Path.PathElements[0])
+			}
+
+			result.Kinds[detail.Kind]++
+			result.Documents[diff.Path.RootDescription()]++
+			result.Paths[path]++
+		}
+	}
+
+	return result
+}
+
+// changedDocuments returns the documents of a detail of a file level
+// difference, which are the documents that were added or removed
+func changedDocuments(detail Detail) []*yamlv3.Node {
+	var node = detail.To
+	if detail.Kind == REMOVAL {
+		node = detail.From
+	}
+
+	if node != nil && node.Kind == yamlv3.DocumentNode {
+		return node.Content
+	}
+
+	return []*yamlv3.Node{node}
+}
+
+// kindCountParts returns the counts of the change kinds in a fixed order,
+// for example "three modifications" and "one addition"
+func kindCountParts(counts map[DetailKind]int) []string {
+	var parts []string
+	for _, kind := range statisticsKindOrder {
+		if count := counts[kind]; count > 0 {
+			parts = append(parts, text.Plural(count, kind.String()))
+		}
+	}
+
+	return parts
+}
+
+// Total returns the total number of changes
+func (s Statistics) Total() int {
+	var total int
+	for _, count := range s.Kinds {
+		total += count
+	}
+
+	return total
+}
+
+// SortedDocuments returns the number of changes per document, sorted by the
+// number of changes (most changes first), and by name
+func (s Statistics) SortedDocuments() []StatisticsEntry {
+	return sortedEntries(s.Documents)
+}
+
+// SortedPaths returns the number of changes per top-level path, sorted by the
+// number of changes (most changes first), and by path
+func (s Statistics) SortedPaths() []StatisticsEntry {
+	return sortedEntries(s.Paths)
+}
+
+func sortedEntries(counts map[string]int) []StatisticsEntry {
+	var result = make([]StatisticsEntry, 0, len(counts))
+	for name, count := range counts {
+		result = append(result, StatisticsEntry{Name: name, Count: count})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// String returns a one line summary of the statistics, for example "three
+// modifications, one addition across two documents"
+func (s Statistics) String() string {
+	parts := kindCountParts(s.Kinds)
+	if len(parts) == 0 {
+		return "no changes"
+	}
+
+	return fmt.Sprintf("%s across %s", strings.Join(parts, ", "), text.Plural(len(s.Documents), "document"))
+}
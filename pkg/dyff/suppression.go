@@ -0,0 +1,82 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// SuppressionComment is the comment that marks a map entry in an input file
+// as intentionally divergent, differences of annotated entries (including
+// the entry being added or removed) are not reported, for example:
+//
+//	replicas: 3 # dyff:ignore
+const SuppressionComment = "dyff:ignore"
+
+// SuppressionComments enables, or disables skipping map entries, which are
+// annotated with a `# dyff:ignore` comment in either input file (default)
+func SuppressionComments(value bool) CompareOption {
+	return func(settings *compareSettings) {
+		settings.SuppressionComments = value
+	}
+}
+
+// isSuppressed returns whether the map entry with the given key and value
+// nodes is annotated with a suppression comment, which can be placed in the
+// line before the key, or at the end of the line of the key
+func (compare *compare) isSuppressed(key *yamlv3.Node, value *yamlv3.Node) bool {
+	if !compare.settings.SuppressionComments {
+		return false
+	}
+
+	for _, comment := range []string{key.HeadComment, key.LineComment, value.LineComment} {
+		if hasSuppressionComment(comment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasSuppressionComment(comment string) bool {
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#"))
+		if line == SuppressionComment || strings.HasPrefix(line, SuppressionComment+" ") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSuppressedIn returns whether the map entry with the given key is
+// annotated with a suppression comment in the provided mapping node
+func (compare *compare) isSuppressedIn(mappingNode *yamlv3.Node, key string) bool {
+	for i := 0; i+1 < len(mappingNode.Content); i += 2 {
+		if mappingNode.Content[i].Value == key {
+			return compare.isSuppressed(mappingNode.Content[i], mappingNode.Content[i+1])
+		}
+	}
+
+	return false
+}
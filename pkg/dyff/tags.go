@@ -0,0 +1,170 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// CustomTagMode defines how custom tags (e.g. `!vault` or `!Ref`), which are
+// not part of the YAML core schema and cannot be resolved, are handled
+type CustomTagMode string
+
+// Supported modes for custom tag handling
+const (
+	// CustomTagsOpaque compares values with custom tags as opaque tagged
+	// values, a changed tag is reported as a modification (default)
+	CustomTagsOpaque CustomTagMode = "opaque"
+
+	// CustomTagsStrict fails the comparison if an input contains custom tags
+	CustomTagsStrict CustomTagMode = "strict"
+
+	// CustomTagsStrip removes custom tags before the comparison, so that only
+	// the values (resolved using the core schema) are compared
+	CustomTagsStrip CustomTagMode = "strip"
+)
+
+// CustomTags sets how custom tags that cannot be resolved are handled
+func CustomTags(mode CustomTagMode) CompareOption {
+	return func(settings *compareSettings) {
+		settings.CustomTags = mode
+	}
+}
+
+// UnresolvedCustomTagError is returned in strict custom tag mode, if an input
+// file contains a custom tag
+type UnresolvedCustomTagError struct {
+	Location string
+	Tag      string
+	Line     int
+	Column   int
+}
+
+func (e *UnresolvedCustomTagError) Error() string {
+	return fmt.Sprintf("unresolved custom tag %s in %s (line %d, column %d)", e.Tag, e.Location, e.Line, e.Column)
+}
+
+// handleCustomTags applies the configured custom tag mode to the input files
+func (compare *compare) handleCustomTags(inputFiles ...*ytbx.InputFile) error {
+	switch compare.settings.CustomTags {
+	case "", CustomTagsOpaque:
+		return nil
+
+	case CustomTagsStrict:
+		for _, inputFile := range inputFiles {
+			for _, document := range inputFile.Documents {
+				if node := findCustomTag(document); node != nil {
+					return &UnresolvedCustomTagError{
+						Location: inputFile.Location,
+						Tag:      node.Tag,
+						Line:     node.Line,
+						Column:   node.Column,
+					}
+				}
+			}
+		}
+
+		return nil
+
+	case CustomTagsStrip:
+		for _, inputFile := range inputFiles {
+			documents := make([]*yamlv3.Node, len(inputFile.Documents))
+			for i, document := range inputFile.Documents {
+				documents[i] = withoutCustomTags(document, map[*yamlv3.Node]*yamlv3.Node{})
+			}
+
+			inputFile.Documents = documents
+		}
+
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported custom tag mode %q, supported modes are %s, %s, and %s",
+			compare.settings.CustomTags,
+			CustomTagsOpaque,
+			CustomTagsStrict,
+			CustomTagsStrip,
+		)
+	}
+}
+
+// isCustomTag returns whether the tag is a custom tag, which is any explicit
+// tag that is not a tag of the YAML core schema
+func isCustomTag(tag string) bool {
+	return tag != "" && !strings.HasPrefix(tag, "!!")
+}
+
+// findCustomTag returns the first node with a custom tag, or nil if there is
+// none
+func findCustomTag(node *yamlv3.Node) *yamlv3.Node {
+	if node == nil {
+		return nil
+	}
+
+	if isCustomTag(node.Tag) {
+		return node
+	}
+
+	for _, content := range node.Content {
+		if result := findCustomTag(content); result != nil {
+			return result
+		}
+	}
+
+	return nil
+}
+
+// withoutCustomTags returns a copy of the node where custom tags are replaced
+// with the tags resolved from the value using the core schema
+func withoutCustomTags(node *yamlv3.Node, copies map[*yamlv3.Node]*yamlv3.Node) *yamlv3.Node {
+	if node == nil {
+		return nil
+	}
+
+	if result, ok := copies[node]; ok {
+		return result
+	}
+
+	result := *node
+	copies[node] = &result
+
+	if isCustomTag(result.Tag) {
+		result.Tag = ""
+		result.Tag = result.ShortTag()
+	}
+
+	if node.Alias != nil {
+		result.Alias = withoutCustomTags(node.Alias, copies)
+	}
+
+	if len(node.Content) > 0 {
+		result.Content = make([]*yamlv3.Node, len(node.Content))
+		for i, content := range node.Content {
+			result.Content[i] = withoutCustomTags(content, copies)
+		}
+	}
+
+	return &result
+}
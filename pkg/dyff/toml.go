@@ -0,0 +1,394 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// LoadTOMLDocuments loads the provided data as a TOML document. Other than
+// ytbx.LoadTOMLDocuments, the keys keep the order in which they appear in the
+// input. TOML only knows one document per file, the result is a list for the
+// sake of having the same signature as the other load functions.
+func LoadTOMLDocuments(data []byte) ([]*yamlv3.Node, error) {
+	var content map[string]interface{}
+	metaData, err := toml.Decode(string(data), &content)
+	if err != nil {
+		return nil, err
+	}
+
+	var order = map[string]int{}
+	for i, key := range metaData.Keys() {
+		if _, ok := order[tomlKeyPath(key)]; !ok {
+			order[tomlKeyPath(key)] = i
+		}
+	}
+
+	node, err := tomlNode(order, nil, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*yamlv3.Node{{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{node}}}, nil
+}
+
+func tomlKeyPath(key []string) string {
+	return strings.Join(key, "\x00")
+}
+
+func tomlNode(order map[string]int, path []string, value interface{}) (*yamlv3.Node, error) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		var rank = func(key string) int {
+			if idx, ok := order[tomlKeyPath(append(path, key))]; ok {
+				return idx
+			}
+
+			return math.MaxInt
+		}
+
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+
+		sort.Slice(keys, func(i, j int) bool {
+			if a, b := rank(keys[i]), rank(keys[j]); a != b {
+				return a < b
+			}
+
+			return keys[i] < keys[j]
+		})
+
+		node := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		for _, key := range keys {
+			entry, err := tomlNode(order, append(path[:len(path):len(path)], key), value[key])
+			if err != nil {
+				return nil, err
+			}
+
+			node.Content = append(node.Content,
+				&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: key},
+				entry,
+			)
+		}
+
+		return node, nil
+
+	case []map[string]interface{}:
+		node := &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq"}
+		for _, table := range value {
+			entry, err := tomlNode(order, path, table)
+			if err != nil {
+				return nil, err
+			}
+
+			node.Content = append(node.Content, entry)
+		}
+
+		return node, nil
+
+	case []interface{}:
+		node := &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq"}
+		for _, item := range value {
+			entry, err := tomlNode(order, path, item)
+			if err != nil {
+				return nil, err
+			}
+
+			node.Content = append(node.Content, entry)
+		}
+
+		return node, nil
+
+	case string:
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value}, nil
+
+	case int64:
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(value, 10)}, nil
+
+	case float64:
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!float", Value: yamlFloat(value)}, nil
+
+	case bool:
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(value)}, nil
+
+	case time.Time:
+		// local dates and times do not have a time zone, see toml.LocalDate
+		switch value.Location().String() {
+		case "datetime-local":
+			return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!timestamp", Value: value.Format("2006-01-02T15:04:05.999999999")}, nil
+
+		case "date-local":
+			return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!timestamp", Value: value.Format("2006-01-02")}, nil
+
+		case "time-local":
+			return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value.Format("15:04:05.999999999")}, nil
+		}
+
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!timestamp", Value: value.Format(time.RFC3339Nano)}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported TOML value %v (%T)", value, value)
+}
+
+func yamlFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return ".inf"
+
+	case math.IsInf(value, -1):
+		return "-.inf"
+
+	case math.IsNaN(value):
+		return ".nan"
+	}
+
+	result := strconv.FormatFloat(value, 'g', -1, 64)
+	if !strings.ContainsAny(result, ".eEn") {
+		result += ".0"
+	}
+
+	return result
+}
+
+// TOML returns the TOML representation of the provided node, which has to be
+// a map. Nested maps are written as tables, and lists of maps as arrays of
+// tables, all other values are written inline. Since TOML does not know null
+// values, they result in an error.
+func TOML(node *yamlv3.Node) (string, error) {
+	node = documentRoot(followAlias(node))
+	if node.Kind != yamlv3.MappingNode {
+		return "", fmt.Errorf("only a map can be written as TOML, but found %s", humanReadableType(node))
+	}
+
+	var buf strings.Builder
+	if err := writeTOMLTable(&buf, nil, node); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func writeTOMLTable(buf *strings.Builder, path []string, node *yamlv3.Node) error {
+	// key/value pairs have to come before any (sub) table of the table
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, followAlias(node.Content[i+1])
+		if isTOMLTable(value) || isTOMLArrayOfTables(value) {
+			continue
+		}
+
+		output, err := tomlValue(value)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", strings.Join(append(path, key), "."), err)
+		}
+
+		fmt.Fprintf(buf, "%s = %s\n", tomlKey(key), output)
+	}
+
+	var header = func(format string, keys []string) {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+
+		var parts = make([]string, len(keys))
+		for i, key := range keys {
+			parts[i] = tomlKey(key)
+		}
+
+		fmt.Fprintf(buf, format, strings.Join(parts, "."))
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, followAlias(node.Content[i+1])
+		keys := append(path[:len(path):len(path)], key)
+
+		switch {
+		case isTOMLTable(value):
+			// the header of a table that only consists of tables is implied
+			if !onlyTOMLTables(value) {
+				header("[%s]\n", keys)
+			}
+
+			if err := writeTOMLTable(buf, keys, value); err != nil {
+				return err
+			}
+
+		case isTOMLArrayOfTables(value):
+			for _, entry := range value.Content {
+				header("[[%s]]\n", keys)
+				if err := writeTOMLTable(buf, keys, followAlias(entry)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func isTOMLTable(node *yamlv3.Node) bool {
+	return node.Kind == yamlv3.MappingNode
+}
+
+func onlyTOMLTables(node *yamlv3.Node) bool {
+	for i := 1; i < len(node.Content); i += 2 {
+		if value := followAlias(node.Content[i]); !isTOMLTable(value) && !isTOMLArrayOfTables(value) {
+			return false
+		}
+	}
+
+	return len(node.Content) > 0
+}
+
+func isTOMLArrayOfTables(node *yamlv3.Node) bool {
+	if node.Kind != yamlv3.SequenceNode || len(node.Content) == 0 {
+		return false
+	}
+
+	for _, entry := range node.Content {
+		if followAlias(entry).Kind != yamlv3.MappingNode {
+			return false
+		}
+	}
+
+	return true
+}
+
+func tomlValue(node *yamlv3.Node) (string, error) {
+	node = followAlias(node)
+
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		entries := make([]string, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := tomlValue(node.Content[i+1])
+			if err != nil {
+				return "", err
+			}
+
+			entries = append(entries, fmt.Sprintf("%s = %s", tomlKey(node.Content[i].Value), value))
+		}
+
+		return "{" + strings.Join(entries, ", ") + "}", nil
+
+	case yamlv3.SequenceNode:
+		entries := make([]string, 0, len(node.Content))
+		for _, entry := range node.Content {
+			value, err := tomlValue(entry)
+			if err != nil {
+				return "", err
+			}
+
+			entries = append(entries, value)
+		}
+
+		return "[" + strings.Join(entries, ", ") + "]", nil
+
+	case yamlv3.ScalarNode:
+		switch node.ShortTag() {
+		case "!!null":
+			return "", fmt.Errorf("TOML does not support null values")
+
+		case "!!bool":
+			return strings.ToLower(node.Value), nil
+
+		case "!!int", "!!timestamp":
+			return node.Value, nil
+
+		case "!!float":
+			switch strings.ToLower(node.Value) {
+			case ".inf", "+.inf":
+				return "inf", nil
+
+			case "-.inf":
+				return "-inf", nil
+
+			case ".nan":
+				return "nan", nil
+			}
+
+			return node.Value, nil
+		}
+
+		return tomlString(node.Value), nil
+	}
+
+	return "", fmt.Errorf("kind %v is not supported", node.Kind)
+}
+
+func tomlKey(key string) string {
+	if tomlBareKey.MatchString(key) {
+		return key
+	}
+
+	return tomlString(key)
+}
+
+func tomlString(value string) string {
+	var buf strings.Builder
+	buf.WriteString(`"`)
+	for _, r := range value {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+
+		case '\\':
+			buf.WriteString(`\\`)
+
+		case '\b':
+			buf.WriteString(`\b`)
+
+		case '\t':
+			buf.WriteString(`\t`)
+
+		case '\n':
+			buf.WriteString(`\n`)
+
+		case '\f':
+			buf.WriteString(`\f`)
+
+		case '\r':
+			buf.WriteString(`\r`)
+
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&buf, `\u%04X`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+
+	buf.WriteString(`"`)
+	return buf.String()
+}
@@ -0,0 +1,93 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("TOML input and output", func() {
+	var input = `title = "example"
+
+[server]
+port = 8080
+ratio = 1.0
+started = 2021-08-21T13:37:00Z
+
+[[server.backends]]
+host = "b"
+
+[[server.backends]]
+host = "a"
+`
+
+	It("should load TOML documents keeping the order of the keys", func() {
+		documents, err := dyff.LoadTOMLDocuments([]byte(input))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(documents).To(HaveLen(1))
+
+		root := documents[0].Content[0]
+		Expect(root.Content[0].Value).To(Equal("title"))
+		Expect(root.Content[2].Value).To(Equal("server"))
+
+		server := root.Content[3]
+		Expect(server.Content[0].Value).To(Equal("port"))
+		Expect(server.Content[1].Tag).To(Equal("!!int"))
+		Expect(server.Content[3].Value).To(Equal("1.0"))
+		Expect(server.Content[3].Tag).To(Equal("!!float"))
+		Expect(server.Content[5].Tag).To(Equal("!!timestamp"))
+		Expect(server.Content[6].Value).To(Equal("backends"))
+	})
+
+	It("should write the loaded document back the same way", func() {
+		documents, err := dyff.LoadTOMLDocuments([]byte(input))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dyff.TOML(documents[0])).To(Equal(input))
+	})
+
+	It("should compare TOML files by their content", func() {
+		from, err := dyff.LoadTOMLDocuments([]byte(input))
+		Expect(err).ToNot(HaveOccurred())
+
+		to, err := dyff.LoadTOMLDocuments([]byte(`title = "example"
+
+[server]
+port = 9090
+ratio = 1.0
+started = 2021-08-21T13:37:00Z
+backends = [{host = "b"}, {host = "a"}]
+`))
+		Expect(err).ToNot(HaveOccurred())
+
+		results, err := compare(from[0].Content[0], to[0].Content[0])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(HaveLen(1))
+		Expect(results[0]).To(BeSameDiffAs(singleDiff("/server/port", dyff.MODIFICATION, 8080, 9090)))
+	})
+
+	It("should fail to write documents that are not a map", func() {
+		_, err := dyff.TOML(list(`[1, 2]`))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,226 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"strconv"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ValuesDefaults sets default values (e.g. the values.yaml of a Helm chart)
+// that are merged into the documents of both input files before they are
+// compared, so that only effective configuration differences are reported.
+// Maps are merged recursively, any other value of the input files takes
+// precedence over the default, and a null value removes the default value.
+func ValuesDefaults(defaults *yamlv3.Node) CompareOption {
+	return func(settings *compareSettings) {
+		settings.ValuesDefaults = defaults
+	}
+}
+
+// ValuesSchema sets a JSON schema (e.g. the values.schema.json of a Helm
+// chart) that is applied to the documents of both input files before they
+// are compared: missing properties with a default in the schema are added and
+// scalars are coerced to the type declared in the schema, for example the
+// string "3" becomes the integer 3 for a property of type integer.
+func ValuesSchema(schema *yamlv3.Node) CompareOption {
+	return func(settings *compareSettings) {
+		settings.ValuesSchema = schema
+	}
+}
+
+// applyValuesDefaults replaces the documents of the input files with copies
+// where the configured default values and schema are applied
+func (compare *compare) applyValuesDefaults(inputFiles ...*ytbx.InputFile) {
+	if compare.settings.ValuesDefaults == nil && compare.settings.ValuesSchema == nil {
+		return
+	}
+
+	for _, inputFile := range inputFiles {
+		documents := make([]*yamlv3.Node, len(inputFile.Documents))
+		for i, document := range inputFile.Documents {
+			// an empty document is treated like a document that only contains
+			// null, so that it ends up with the default values
+			documents[i] = copyNode(document)
+			if documents[i].Kind == yamlv3.DocumentNode && len(documents[i].Content) == 0 {
+				documents[i].Content = []*yamlv3.Node{{Kind: yamlv3.ScalarNode, Tag: "!!null", Value: "null"}}
+			}
+
+			root := documentRoot(documents[i])
+			if compare.settings.ValuesDefaults != nil {
+				root = coalesceValues(root, documentRoot(compare.settings.ValuesDefaults))
+			}
+
+			if compare.settings.ValuesSchema != nil {
+				applyValuesSchema(root, documentRoot(compare.settings.ValuesSchema))
+			}
+
+			if documents[i].Kind == yamlv3.DocumentNode {
+				documents[i].Content[0] = root
+			} else {
+				documents[i] = root
+			}
+		}
+
+		inputFile.Documents = documents
+	}
+}
+
+// coalesceValues merges the default values into the values in the same way
+// Helm does it: maps are merged recursively, and a null value removes the
+// respective default value
+func coalesceValues(values *yamlv3.Node, defaults *yamlv3.Node) *yamlv3.Node {
+	values, defaults = followAlias(values), followAlias(defaults)
+
+	if isNullNode(values) && defaults.Kind == yamlv3.MappingNode {
+		return copyNode(defaults)
+	}
+
+	if values.Kind != yamlv3.MappingNode || defaults.Kind != yamlv3.MappingNode {
+		return values
+	}
+
+	var result = *values
+	result.Content = nil
+	for i := 0; i < len(values.Content); i += 2 {
+		key, value := values.Content[i], values.Content[i+1]
+		defaultValue, hasDefault := mappingValue(defaults, key.Value)
+
+		switch {
+		case hasDefault && isNullNode(value):
+			continue
+
+		case hasDefault:
+			value = coalesceValues(value, defaultValue)
+		}
+
+		result.Content = append(result.Content, key, value)
+	}
+
+	for i := 0; i < len(defaults.Content); i += 2 {
+		key, value := defaults.Content[i], defaults.Content[i+1]
+		if _, ok := mappingValue(values, key.Value); !ok {
+			result.Content = append(result.Content, copyNode(key), copyNode(value))
+		}
+	}
+
+	return &result
+}
+
+// applyValuesSchema adds missing properties that have a default value, and
+// coerces scalars to the type declared in the schema
+func applyValuesSchema(node *yamlv3.Node, schema *yamlv3.Node) {
+	if node == nil || schema == nil || schema.Kind != yamlv3.MappingNode {
+		return
+	}
+
+	switch node.Kind {
+	case yamlv3.ScalarNode:
+		if schemaType, ok := mappingValue(schema, "type"); ok && schemaType.Kind == yamlv3.ScalarNode {
+			coerceScalar(node, schemaType.Value)
+		}
+
+	case yamlv3.MappingNode:
+		properties, _ := mappingValue(schema, "properties")
+		additional, _ := mappingValue(schema, "additionalProperties")
+
+		for i := 0; i < len(node.Content); i += 2 {
+			if property, ok := mappingValue(properties, node.Content[i].Value); ok {
+				applyValuesSchema(node.Content[i+1], property)
+			} else {
+				applyValuesSchema(node.Content[i+1], additional)
+			}
+		}
+
+		if properties == nil || properties.Kind != yamlv3.MappingNode {
+			return
+		}
+
+		for i := 0; i < len(properties.Content); i += 2 {
+			name, property := properties.Content[i].Value, properties.Content[i+1]
+			if _, ok := mappingValue(node, name); ok {
+				continue
+			}
+
+			if defaultValue, ok := mappingValue(property, "default"); ok {
+				value := copyNode(defaultValue)
+				applyValuesSchema(value, property)
+				node.Content = append(node.Content,
+					&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: name},
+					value,
+				)
+			}
+		}
+
+	case yamlv3.SequenceNode:
+		if items, ok := mappingValue(schema, "items"); ok {
+			for _, entry := range node.Content {
+				applyValuesSchema(entry, items)
+			}
+		}
+	}
+}
+
+// coerceScalar changes the tag of the scalar to the one of the schema type,
+// if the value can be represented as such, otherwise it is left unchanged
+func coerceScalar(node *yamlv3.Node, schemaType string) {
+	if isNullNode(node) {
+		return
+	}
+
+	switch schemaType {
+	case "string":
+		node.Tag = "!!str"
+
+	case "integer":
+		if _, err := strconv.ParseInt(node.Value, 10, 64); err == nil {
+			node.Tag = "!!int"
+		}
+
+	case "number":
+		if _, err := strconv.ParseInt(node.Value, 10, 64); err == nil {
+			node.Tag = "!!int"
+		} else if _, err := strconv.ParseFloat(node.Value, 64); err == nil {
+			node.Tag = "!!float"
+		}
+
+	case "boolean":
+		if value, err := strconv.ParseBool(node.Value); err == nil {
+			node.Tag, node.Value = "!!bool", strconv.FormatBool(value)
+		}
+	}
+}
+
+// mappingValue returns the value of the key, if the node is a mapping that
+// contains the key
+func mappingValue(node *yamlv3.Node, key string) (*yamlv3.Node, bool) {
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return nil, false
+	}
+
+	return findValueByKey(node, key)
+}
+
+func isNullNode(node *yamlv3.Node) bool {
+	return node.Kind == yamlv3.ScalarNode && node.Tag == "!!null"
+}
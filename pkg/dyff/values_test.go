@@ -0,0 +1,127 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("values defaults and schema", func() {
+	var defaults = yml(`---
+replicaCount: 1
+image:
+  repository: nginx
+  tag: stable
+service:
+  type: ClusterIP
+  port: 80
+`)
+
+	var schema = yml(`{
+  "type": "object",
+  "properties": {
+    "replicaCount": {"type": "integer"},
+    "debug": {"type": "boolean", "default": false},
+    "image": {
+      "type": "object",
+      "properties": {
+        "tag": {"type": "string"}
+      }
+    },
+    "ports": {
+      "type": "array",
+      "items": {"type": "integer"}
+    }
+  }
+}`)
+
+	It("should only report effective differences when default values are applied", func() {
+		from := yml(`---
+replicaCount: 1
+image:
+  tag: stable
+`)
+
+		to := yml(`---
+service:
+  port: 8080
+`)
+
+		results, err := compare(from, to)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).ToNot(BeEmpty())
+
+		results, err = compare(from, to, dyff.ValuesDefaults(defaults))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(HaveLen(1))
+		Expect(results[0]).To(BeSameDiffAs(singleDiff("/service/port", dyff.MODIFICATION, 80, 8080)))
+	})
+
+	It("should remove default values that are set to null", func() {
+		from := yml(`---
+service:
+  port: ~
+`)
+
+		to := yml(`---
+service:
+  type: ClusterIP
+`)
+
+		results, err := compare(from, to)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).ToNot(BeEmpty())
+
+		results, err = compare(from, to, dyff.ValuesDefaults(defaults))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Path.String()).To(Equal("/service"))
+		Expect(results[0].Details[0].Kind).To(Equal(dyff.ADDITION))
+	})
+
+	It("should apply schema defaults and type coercions", func() {
+		from := yml(`---
+replicaCount: "3"
+image:
+  tag: 1.25
+ports: ["80", "443"]
+`)
+
+		to := yml(`---
+replicaCount: 3
+debug: false
+image:
+  tag: "1.25"
+ports: [80, 443]
+`)
+
+		results, err := compare(from, to)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).ToNot(BeEmpty())
+
+		results, err = compare(from, to, dyff.ValuesSchema(schema))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(BeEmpty())
+	})
+})
@@ -0,0 +1,118 @@
+// Copyright © 2019 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// VersionBump is a modification of an image reference or a version field,
+// for example the tag of a container image or the version of a chart
+type VersionBump struct {
+	Path *ytbx.Path
+	From string
+	To   string
+}
+
+// Resource returns the name of the document the version bump belongs to
+func (bump VersionBump) Resource() string {
+	if bump.Path == nil {
+		return ""
+	}
+
+	return bump.Path.RootDescription()
+}
+
+// VersionBumps returns all modifications in the report that change the tag
+// (or digest) of an image reference without changing the image repository,
+// as well as all modifications of version fields (e.g. `version`,
+// `appVersion`, `tag`, or the `helm.sh/chart` label)
+func (r Report) VersionBumps() []VersionBump {
+	var result []VersionBump
+	for _, diff := range r.Diffs {
+		if diff.Path == nil || len(diff.Path.PathElements) == 0 {
+			continue
+		}
+
+		name := mapKeyName(diff.Path.PathElements[len(diff.Path.PathElements)-1])
+		for _, detail := range diff.Details {
+			if detail.Kind != MODIFICATION || !isScalar(detail.From) || !isScalar(detail.To) {
+				continue
+			}
+
+			if isVersionBump(name, detail.From.Value, detail.To.Value) {
+				result = append(result, VersionBump{Path: diff.Path, From: detail.From.Value, To: detail.To.Value})
+			}
+		}
+	}
+
+	return result
+}
+
+func isVersionBump(name string, from string, to string) bool {
+	name = strings.ToLower(name)
+	switch {
+	case name == "image":
+		fromRepository, fromVersion := splitImageReference(from)
+		toRepository, toVersion := splitImageReference(to)
+		return fromRepository == toRepository && fromVersion != toVersion
+
+	case name == "tag", name == "chart", strings.HasSuffix(name, "version"), strings.HasSuffix(name, "imagetag"), strings.HasSuffix(name, "/chart"):
+		return containsDigit(from) && containsDigit(to)
+	}
+
+	return false
+}
+
+// splitImageReference splits an image reference into the repository and the
+// tag or digest, for example `nginx:1.25` into `nginx` and `1.25`
+func splitImageReference(reference string) (repository string, version string) {
+	if idx := strings.Index(reference, "@"); idx >= 0 {
+		return reference[:idx], reference[idx+1:]
+	}
+
+	if idx := strings.LastIndex(reference, ":"); idx > strings.LastIndex(reference, "/") {
+		return reference[:idx], reference[idx+1:]
+	}
+
+	return reference, ""
+}
+
+// mapKeyName returns the name of the path element, if it refers to a map key
+func mapKeyName(element ytbx.PathElement) string {
+	if element.Key == "" {
+		return element.Name
+	}
+
+	return ""
+}
+
+func isScalar(node *yamlv3.Node) bool {
+	return node != nil && node.Kind == yamlv3.ScalarNode
+}
+
+func containsDigit(value string) bool {
+	return strings.IndexFunc(value, unicode.IsDigit) >= 0
+}